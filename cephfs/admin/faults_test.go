@@ -0,0 +1,50 @@
+//go:build ceph_preview
+
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+func TestFaultsListVolumes(t *testing.T) {
+	newFaulty := func(t *testing.T, f ccom.Fault) *FSAdmin {
+		rp, err := ccom.NewReplayCommander("testdata/replay_volumes.json")
+		require.NoError(t, err)
+		return NewFromConn(ccom.NewFaultCommander(rp, ccom.FaultRule{
+			Prefix: "fs volume ls",
+			Fault:  f,
+		}))
+	}
+
+	t.Run("errno", func(t *testing.T) {
+		fsa := newFaulty(t, ccom.Fault{Errno: -110})
+		_, err := fsa.ListVolumes()
+		assert.Error(t, err)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		fsa := newFaulty(t, ccom.Fault{Body: ccom.BodyTruncated})
+		_, err := fsa.ListVolumes()
+		assert.Error(t, err)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		fsa := newFaulty(t, ccom.Fault{Body: ccom.BodyMalformed})
+		_, err := fsa.ListVolumes()
+		assert.Error(t, err)
+	})
+	t.Run("statusOnly", func(t *testing.T) {
+		fsa := newFaulty(t, ccom.Fault{Body: ccom.BodyEmpty, Status: "oops"})
+		_, err := fsa.ListVolumes()
+		assert.ErrorIs(t, err, ErrStatusNotEmpty)
+	})
+	t.Run("unaffected", func(t *testing.T) {
+		fsa := newFaulty(t, ccom.Fault{Errno: -110})
+		fl, err := fsa.ListFileSystems()
+		assert.NoError(t, err)
+		assert.Len(t, fl, 1)
+	})
+}
@@ -0,0 +1,34 @@
+//go:build ceph_preview
+
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+func TestReplayVolumes(t *testing.T) {
+	rp, err := ccom.NewReplayCommander("testdata/replay_volumes.json")
+	require.NoError(t, err)
+	fsa := NewFromConn(rp)
+
+	vl, err := fsa.ListVolumes()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cephfs"}, vl)
+
+	fl, err := fsa.ListFileSystems()
+	assert.NoError(t, err)
+	if assert.Len(t, fl, 1) {
+		assert.Equal(t, "cephfs", fl[0].Name)
+		assert.Equal(t, "cephfs_metadata", fl[0].MetadataPool)
+		assert.Equal(t, []string{"cephfs_data"}, fl[0].DataPools)
+	}
+	assert.Len(t, rp.Unused(), 0)
+
+	_, err = fsa.ListVolumes()
+	assert.ErrorIs(t, err, ccom.ErrUnexpectedCommand)
+}
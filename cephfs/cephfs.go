@@ -18,13 +18,17 @@ import (
 // MountInfo exports ceph's ceph_mount_info from libcephfs.cc
 type MountInfo struct {
 	mount *C.struct_ceph_mount_info
+	// conn is the rados connection the mount was created from, if any.
+	// Its Hook is used for the calls on the mount.
+	conn *rados.Conn
 }
 
 func createMount(id *C.char) (*MountInfo, error) {
 	mount := &MountInfo{}
+	t := mount.startOp("ceph_create", "")
 	ret := C.ceph_create(&mount.mount, id)
-	if ret != 0 {
-		return nil, getError(ret)
+	if err := t.DoneErr(getError(ret)); err != nil {
+		return nil, err
 	}
 	return mount, nil
 }
@@ -64,10 +68,11 @@ func CreateMountWithId(id string) (*MountInfo, error) {
 //
 //	int ceph_create_from_rados(struct ceph_mount_info **cmount, rados_t cluster);
 func CreateFromRados(conn *rados.Conn) (*MountInfo, error) {
-	mount := &MountInfo{}
+	mount := &MountInfo{conn: conn}
+	t := mount.startOp("ceph_create_from_rados", "")
 	ret := C.ceph_create_from_rados(&mount.mount, C.rados_t(conn.Cluster()))
-	if ret != 0 {
-		return nil, getError(ret)
+	if err := t.DoneErr(getError(ret)); err != nil {
+		return nil, err
 	}
 	return mount, nil
 }
@@ -78,8 +83,9 @@ func CreateFromRados(conn *rados.Conn) (*MountInfo, error) {
 //
 //	int ceph_conf_read_file(struct ceph_mount_info *cmount, const char *path_list);
 func (mount *MountInfo) ReadDefaultConfigFile() error {
+	t := mount.startOp("ceph_conf_read_file", "")
 	ret := C.ceph_conf_read_file(mount.mount, nil)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // ReadConfigFile loads the ceph configuration from the specified config file.
@@ -90,8 +96,9 @@ func (mount *MountInfo) ReadDefaultConfigFile() error {
 func (mount *MountInfo) ReadConfigFile(path string) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
+	t := mount.startOp("ceph_conf_read_file", path)
 	ret := C.ceph_conf_read_file(mount.mount, cPath)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // ParseConfigArgv configures the mount using a unix style command line
@@ -113,8 +120,9 @@ func (mount *MountInfo) ParseConfigArgv(argv []string) error {
 		defer C.free(unsafe.Pointer(cargv[i]))
 	}
 
+	t := mount.startOp("ceph_conf_parse_argv", "")
 	ret := C.ceph_conf_parse_argv(mount.mount, C.int(len(cargv)), &cargv[0])
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // ParseDefaultConfigEnv configures the mount from the default Ceph
@@ -127,8 +135,9 @@ func (mount *MountInfo) ParseDefaultConfigEnv() error {
 	if err := mount.validate(); err != nil {
 		return err
 	}
+	t := mount.startOp("ceph_conf_parse_env", "")
 	ret := C.ceph_conf_parse_env(mount.mount, nil)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // SetConfigOption sets the value of the configuration option identified by
@@ -142,7 +151,8 @@ func (mount *MountInfo) SetConfigOption(option, value string) error {
 	defer C.free(unsafe.Pointer(cOption))
 	cValue := C.CString(value)
 	defer C.free(unsafe.Pointer(cValue))
-	return getError(C.ceph_conf_set(mount.mount, cOption, cValue))
+	t := mount.startOp("ceph_conf_set", "")
+	return t.DoneErr(getError(C.ceph_conf_set(mount.mount, cOption, cValue)))
 }
 
 // GetConfigOption returns the value of the Ceph configuration option
@@ -162,12 +172,13 @@ func (mount *MountInfo) GetConfigOption(option string) (string, error) {
 	// range from 4k to 256KiB
 	retry.WithSizes(4096, 1<<18, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := mount.startOp("ceph_conf_get", "")
 		ret := C.ceph_conf_get(
 			mount.mount,
 			cOption,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.size_t(len(buf)))
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.DoubleSize.If(err == errNameTooLong)
 	})
 	if err != nil {
@@ -183,7 +194,8 @@ func (mount *MountInfo) GetConfigOption(option string) (string, error) {
 //
 //	int ceph_init(struct ceph_mount_info *cmount);
 func (mount *MountInfo) Init() error {
-	return getError(C.ceph_init(mount.mount))
+	t := mount.startOp("ceph_init", "")
+	return t.DoneErr(getError(C.ceph_init(mount.mount)))
 }
 
 // Mount the file system, establishing a connection capable of I/O.
@@ -192,8 +204,9 @@ func (mount *MountInfo) Init() error {
 //
 //	int ceph_mount(struct ceph_mount_info *cmount, const char *root);
 func (mount *MountInfo) Mount() error {
+	t := mount.startOp("ceph_mount", "")
 	ret := C.ceph_mount(mount.mount, nil)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MountWithRoot mounts the file system using the path provided for the root of
@@ -205,7 +218,8 @@ func (mount *MountInfo) Mount() error {
 func (mount *MountInfo) MountWithRoot(root string) error {
 	croot := C.CString(root)
 	defer C.free(unsafe.Pointer(croot))
-	return getError(C.ceph_mount(mount.mount, croot))
+	t := mount.startOp("ceph_mount", root)
+	return t.DoneErr(getError(C.ceph_mount(mount.mount, croot)))
 }
 
 // Unmount the file system.
@@ -214,8 +228,9 @@ func (mount *MountInfo) MountWithRoot(root string) error {
 //
 //	int ceph_unmount(struct ceph_mount_info *cmount);
 func (mount *MountInfo) Unmount() error {
+	t := mount.startOp("ceph_unmount", "")
 	ret := C.ceph_unmount(mount.mount)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Release destroys the mount handle.
@@ -227,8 +242,9 @@ func (mount *MountInfo) Release() error {
 	if mount.mount == nil {
 		return nil
 	}
+	t := mount.startOp("ceph_release", "")
 	ret := C.ceph_release(mount.mount)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return err
 	}
 	mount.mount = nil
@@ -237,12 +253,15 @@ func (mount *MountInfo) Release() error {
 
 // SyncFs synchronizes all filesystem data to persistent media.
 func (mount *MountInfo) SyncFs() error {
+	t := mount.startOp("ceph_sync_fs", "")
 	ret := C.ceph_sync_fs(mount.mount)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // IsMounted checks mount status.
 func (mount *MountInfo) IsMounted() bool {
+	t := mount.startOp("ceph_is_mounted", "")
 	ret := C.ceph_is_mounted(mount.mount)
+	t.Done(0, getErrorIfNegative(ret))
 	return ret == 1
 }
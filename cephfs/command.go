@@ -48,6 +48,7 @@ func (mount *MountInfo) mdsCommand(mdsSpec string, args [][]byte, inputBuffer []
 	co := cutil.NewCommandOutput().SetFreeFunc(cephBufferFree)
 	defer co.Free()
 
+	t := mount.startCommand("ceph_mds_command", args)
 	ret := C.ceph_mds_command(
 		mount.mount, // cephfs mount ref
 		spec,        // mds spec
@@ -60,5 +61,5 @@ func (mount *MountInfo) mdsCommand(mdsSpec string, args [][]byte, inputBuffer []
 		(**C.char)(co.Outs()),
 		(*C.size_t)(co.OutsLen()))
 	buf, status := co.GoValues()
-	return buf, status, getError(ret)
+	return buf, status, t.DoneErr(getError(ret))
 }
@@ -22,7 +22,9 @@ import "C"
 //
 //	int64_t ceph_get_fs_cid(struct ceph_mount_info *cmount);
 func (mount *MountInfo) GetFsCid() (int64, error) {
+	t := mount.startOp("ceph_get_fs_cid", "")
 	ret := C.ceph_get_fs_cid(mount.mount)
+	t.Done(0, getErrorIfNegative(C.int(ret)))
 	if ret < 0 {
 		return 0, getError(C.int(ret))
 	}
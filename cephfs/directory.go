@@ -17,6 +17,9 @@ import (
 type Directory struct {
 	mount *MountInfo
 	dir   *C.struct_ceph_dir_result
+	// path is only kept to identify the target of operations reported to
+	// hooks.
+	path string
 }
 
 // OpenDir returns a new Directory handle open for I/O.
@@ -30,7 +33,9 @@ func (mount *MountInfo) OpenDir(path string) (*Directory, error) {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_opendir", path)
 	ret := C.ceph_opendir(mount.mount, cPath, &dir)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return nil, getError(ret)
 	}
@@ -38,6 +43,7 @@ func (mount *MountInfo) OpenDir(path string) (*Directory, error) {
 	return &Directory{
 		mount: mount,
 		dir:   dir,
+		path:  path,
 	}, nil
 }
 
@@ -50,7 +56,8 @@ func (dir *Directory) Close() error {
 	if dir.dir == nil {
 		return nil
 	}
-	if err := getError(C.ceph_closedir(dir.mount.mount, dir.dir)); err != nil {
+	t := dir.startOp("ceph_closedir")
+	if err := t.DoneErr(getError(C.ceph_closedir(dir.mount.mount, dir.dir))); err != nil {
 		return err
 	}
 	dir.dir = nil
@@ -152,7 +159,9 @@ func (dir *Directory) ReadDir() (*DirEntry, error) {
 		return nil, errBadFile
 	}
 	var de C.struct_dirent
+	t := dir.startOp("ceph_readdir_r")
 	ret := C.ceph_readdir_r(dir.mount.mount, dir.dir, &de)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -182,6 +191,7 @@ func (dir *Directory) ReadDirPlus(
 		de C.struct_dirent
 		s  C.struct_ceph_statx
 	)
+	t := dir.startOp("ceph_readdirplus_r")
 	ret := C.ceph_readdirplus_r(
 		dir.mount.mount,
 		dir.dir,
@@ -191,6 +201,7 @@ func (dir *Directory) ReadDirPlus(
 		C.uint(flags),
 		nil, // unused, internal Inode type not needed for high level api
 	)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -209,7 +220,9 @@ func (dir *Directory) RewindDir() {
 	if dir.dir == nil {
 		return
 	}
+	t := dir.startOp("ceph_rewinddir")
 	C.ceph_rewinddir(dir.mount.mount, dir.dir)
+	t.Done(0, nil)
 }
 
 // dirEntries provides a convenient wrapper around slices of DirEntry items.
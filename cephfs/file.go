@@ -42,6 +42,9 @@ const (
 type File struct {
 	mount *MountInfo
 	fd    C.int
+	// path is only kept to identify the target of operations reported to
+	// hooks.
+	path string
 }
 
 // Open a file at the given path. The flags are the same os flags as
@@ -56,11 +59,13 @@ func (mount *MountInfo) Open(path string, flags int, mode uint32) (*File, error)
 	}
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
+	t := mount.startOp("ceph_open", path)
 	ret := C.ceph_open(mount.mount, cPath, C.int(flags), C.mode_t(mode))
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
-	return &File{mount: mount, fd: ret}, nil
+	return &File{mount: mount, fd: ret, path: path}, nil
 }
 
 func (f *File) validate() error {
@@ -83,7 +88,8 @@ func (f *File) Close() error {
 	if err := f.validate(); err != nil {
 		return err
 	}
-	if err := getError(C.ceph_close(f.mount.mount, f.fd)); err != nil {
+	t := f.startOp("ceph_close")
+	if err := t.DoneErr(getError(C.ceph_close(f.mount.mount, f.fd))); err != nil {
 		return err
 	}
 	f.fd = -1
@@ -105,8 +111,10 @@ func (f *File) read(buf []byte, offset int64) (int, error) {
 		return 0, nil
 	}
 	bufptr := (*C.char)(unsafe.Pointer(&buf[0]))
+	t := f.startOp("ceph_read")
 	ret := C.ceph_read(
 		f.mount.mount, f.fd, bufptr, C.int64_t(len(buf)), C.int64_t(offset))
+	t.Done(int64(max(ret, 0)), getErrorIfNegative(ret))
 	switch {
 	case ret < 0:
 		return 0, getError(ret)
@@ -153,12 +161,14 @@ func (f *File) Preadv(data [][]byte, offset int64) (int, error) {
 	iov := cutil.ByteSlicesToIovec(data)
 	defer iov.Free()
 
+	t := f.startOp("ceph_preadv")
 	ret := C.ceph_preadv(
 		f.mount.mount,
 		f.fd,
 		(*C.struct_iovec)(iov.Pointer()),
 		C.int(iov.Len()),
 		C.int64_t(offset))
+	t.Done(int64(max(ret, 0)), getErrorIfNegative(ret))
 	switch {
 	case ret < 0:
 		return 0, getError(ret)
@@ -185,8 +195,10 @@ func (f *File) write(buf []byte, offset int64) (int, error) {
 		return 0, nil
 	}
 	bufptr := (*C.char)(unsafe.Pointer(&buf[0]))
+	t := f.startOp("ceph_write")
 	ret := C.ceph_write(
 		f.mount.mount, f.fd, bufptr, C.int64_t(len(buf)), C.int64_t(offset))
+	t.Done(int64(max(ret, 0)), getErrorIfNegative(ret))
 	if ret < 0 {
 		return 0, getError(ret)
 	}
@@ -223,12 +235,14 @@ func (f *File) Pwritev(data [][]byte, offset int64) (int, error) {
 	iov := cutil.ByteSlicesToIovec(data)
 	defer iov.Free()
 
+	t := f.startOp("ceph_pwritev")
 	ret := C.ceph_pwritev(
 		f.mount.mount,
 		f.fd,
 		(*C.struct_iovec)(iov.Pointer()),
 		C.int(iov.Len()),
 		C.int64_t(offset))
+	t.Done(int64(max(ret, 0)), getErrorIfNegative(ret))
 	if ret < 0 {
 		return 0, getError(ret)
 	}
@@ -253,7 +267,9 @@ func (f *File) Seek(offset int64, whence int) (int64, error) {
 		return 0, errInvalid
 	}
 
+	t := f.startOp("ceph_lseek")
 	ret := C.ceph_lseek(f.mount.mount, f.fd, C.int64_t(offset), C.int(whence))
+	t.Done(0, getErrorIfNegative(C.int(ret)))
 	if ret < 0 {
 		return 0, getError(C.int(ret))
 	}
@@ -270,8 +286,9 @@ func (f *File) Fchmod(mode uint32) error {
 		return err
 	}
 
+	t := f.startOp("ceph_fchmod")
 	ret := C.ceph_fchmod(f.mount.mount, f.fd, C.mode_t(mode))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Fchown changes the ownership of a file.
@@ -284,8 +301,9 @@ func (f *File) Fchown(user uint32, group uint32) error {
 		return err
 	}
 
+	t := f.startOp("ceph_fchown")
 	ret := C.ceph_fchown(f.mount.mount, f.fd, C.int(user), C.int(group))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Fstatx returns information about an open file.
@@ -300,6 +318,7 @@ func (f *File) Fstatx(want StatxMask, flags AtFlags) (*CephStatx, error) {
 	}
 
 	var stx C.struct_ceph_statx
+	t := f.startOp("ceph_fstatx")
 	ret := C.ceph_fstatx(
 		f.mount.mount,
 		f.fd,
@@ -307,7 +326,7 @@ func (f *File) Fstatx(want StatxMask, flags AtFlags) (*CephStatx, error) {
 		C.uint(want),
 		C.uint(flags),
 	)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return nil, err
 	}
 	return cStructToCephStatx(stx), nil
@@ -340,8 +359,9 @@ func (f *File) Fallocate(mode FallocFlags, offset, length int64) error {
 	if err := f.validate(); err != nil {
 		return err
 	}
+	t := f.startOp("ceph_fallocate")
 	ret := C.ceph_fallocate(f.mount.mount, f.fd, C.int(mode), C.int64_t(offset), C.int64_t(length))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // LockOp determines operations/type of locks which can be applied on a file.
@@ -379,8 +399,9 @@ func (f *File) Flock(operation LockOp, owner uint64) error {
 		return errInvalid
 	}
 
+	t := f.startOp("ceph_flock")
 	ret := C.ceph_flock(f.mount.mount, f.fd, C.int(operation), C.uint64_t(owner))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Fsync ensures the file content that may be cached is committed to stable
@@ -397,11 +418,13 @@ func (f *File) Fsync(sync SyncChoice) error {
 		return err
 	}
 
+	t := f.startOp("ceph_fsync")
 	ret := C.ceph_fsync(
 		f.mount.mount,
 		f.fd,
 		C.int(sync),
 	)
+	t.Done(0, getError(ret))
 	return getError(ret)
 }
 
@@ -427,10 +450,11 @@ func (f *File) Truncate(size int64) error {
 		return err
 	}
 
+	t := f.startOp("ceph_ftruncate")
 	ret := C.ceph_ftruncate(
 		f.mount.mount,
 		f.fd,
 		C.int64_t(size),
 	)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
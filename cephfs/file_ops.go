@@ -1,6 +1,3 @@
-//go:build !nautilus
-// +build !nautilus
-
 package cephfs
 
 /*
@@ -9,12 +6,57 @@ package cephfs
 #include <errno.h>
 #include <stdlib.h>
 #include <cephfs/libcephfs.h>
+
+// The functions below are resolved at runtime, so that go-ceph can be used
+// with versions of libcephfs that do not provide them. The *_dlsym functions
+// cast the function pointer fn to the matching signature and call it.
+
+typedef int(*ceph_mknod_fn)(struct ceph_mount_info *cmount, const char *path,
+                            mode_t mode, dev_t rdev);
+
+static inline int ceph_mknod_dlsym(void *fn, struct ceph_mount_info *cmount,
+                                   const char *path, mode_t mode, dev_t rdev) {
+  return ((ceph_mknod_fn) fn)(cmount, path, mode, rdev);
+}
+
+typedef int(*ceph_futime_fn)(struct ceph_mount_info *cmount, int fd,
+                             struct utimbuf *buf);
+
+static inline int ceph_futime_dlsym(void *fn, struct ceph_mount_info *cmount,
+                                    int fd, struct utimbuf *buf) {
+  return ((ceph_futime_fn) fn)(cmount, fd, buf);
+}
+
+typedef int(*ceph_futimens_fn)(struct ceph_mount_info *cmount, int fd,
+                               struct timespec times[2]);
+
+static inline int ceph_futimens_dlsym(void *fn, struct ceph_mount_info *cmount,
+                                      int fd, struct timespec times[2]) {
+  return ((ceph_futimens_fn) fn)(cmount, fd, times);
+}
+
+typedef int(*ceph_futimes_fn)(struct ceph_mount_info *cmount, int fd,
+                              struct timeval times[2]);
+
+static inline int ceph_futimes_dlsym(void *fn, struct ceph_mount_info *cmount,
+                                     int fd, struct timeval times[2]) {
+  return ((ceph_futimes_fn) fn)(cmount, fd, times);
+}
 */
 import "C"
 
 import (
-	ts "github.com/ceph/go-ceph/internal/timespec"
 	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/dlsym"
+	ts "github.com/ceph/go-ceph/internal/timespec"
+)
+
+var (
+	cephMknod    = dlsym.NewSymbol("ceph_mknod")
+	cephFutime   = dlsym.NewSymbol("ceph_futime")
+	cephFutimens = dlsym.NewSymbol("ceph_futimens")
+	cephFutimes  = dlsym.NewSymbol("ceph_futimes")
 )
 
 // Mknod creates a regular, block or character special file.
@@ -28,11 +70,17 @@ func (mount *MountInfo) Mknod(path string, mode uint16, dev uint16) error {
 		return err
 	}
 
+	fn, err := cephMknod.Pointer()
+	if err != nil {
+		return err
+	}
+
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
-	ret := C.ceph_mknod(mount.mount, cPath, C.mode_t(mode), C.dev_t(dev))
-	return getError(ret)
+	t := mount.startOp("ceph_mknod", path)
+	ret := C.ceph_mknod_dlsym(fn, mount.mount, cPath, C.mode_t(mode), C.dev_t(dev))
+	return t.DoneErr(getError(ret))
 }
 
 // Utime struct is the equivalent of C.struct_utimbuf
@@ -53,14 +101,20 @@ func (mount *MountInfo) Futime(fd int, times *Utime) error {
 		return err
 	}
 
+	fn, err := cephFutime.Pointer()
+	if err != nil {
+		return err
+	}
+
 	cFd := C.int(fd)
 	uTimeBuf := &C.struct_utimbuf{
 		actime:  C.time_t(times.AcTime),
 		modtime: C.time_t(times.ModTime),
 	}
 
-	ret := C.ceph_futime(mount.mount, cFd, uTimeBuf)
-	return getError(ret)
+	t := mount.startOp("ceph_futime", "")
+	ret := C.ceph_futime_dlsym(fn, mount.mount, cFd, uTimeBuf)
+	return t.DoneErr(getError(ret))
 }
 
 // Timeval struct is the go equivalent of C.struct_timeval type
@@ -86,6 +140,10 @@ func (mount *MountInfo) Futimens(fd int, times []Timespec) error {
 	if len(times) != 2 {
 		return getError(-C.EINVAL)
 	}
+	fn, err := cephFutimens.Pointer()
+	if err != nil {
+		return err
+	}
 
 	cFd := C.int(fd)
 	cTimes := []C.struct_timespec{}
@@ -98,8 +156,9 @@ func (mount *MountInfo) Futimens(fd int, times []Timespec) error {
 		cTimes = append(cTimes, *cTs)
 	}
 
-	ret := C.ceph_futimens(mount.mount, cFd, &cTimes[0])
-	return getError(ret)
+	t := mount.startOp("ceph_futimens", "")
+	ret := C.ceph_futimens_dlsym(fn, mount.mount, cFd, &cTimes[0])
+	return t.DoneErr(getError(ret))
 }
 
 // Futimes changes file/directory last access and modification times, here times param
@@ -117,6 +176,10 @@ func (mount *MountInfo) Futimes(fd int, times []Timeval) error {
 	if len(times) != 2 {
 		return getError(-C.EINVAL)
 	}
+	fn, err := cephFutimes.Pointer()
+	if err != nil {
+		return err
+	}
 
 	cFd := C.int(fd)
 	cTimes := []C.struct_timeval{}
@@ -127,6 +190,7 @@ func (mount *MountInfo) Futimes(fd int, times []Timeval) error {
 		})
 	}
 
-	ret := C.ceph_futimes(mount.mount, cFd, &cTimes[0])
-	return getError(ret)
+	t := mount.startOp("ceph_futimes", "")
+	ret := C.ceph_futimes_dlsym(fn, mount.mount, cFd, &cTimes[0])
+	return t.DoneErr(getError(ret))
 }
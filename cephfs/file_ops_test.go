@@ -1,6 +1,3 @@
-//go:build !nautilus
-// +build !nautilus
-
 package cephfs
 
 import (
@@ -70,7 +70,7 @@ func TestFileOpen(t *testing.T) {
 	})
 
 	t.Run("invalidFdClose", func(t *testing.T) {
-		f := &File{mount, 1980}
+		f := &File{mount: mount, fd: 1980}
 		err := f.Close()
 		assert.Error(t, err)
 	})
@@ -56,6 +56,7 @@ func (f *File) SetXattr(name string, value []byte, flags XattrFlags) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := f.startOp("ceph_fsetxattr")
 	ret := C.ceph_fsetxattr(
 		f.mount.mount,
 		f.fd,
@@ -63,7 +64,7 @@ func (f *File) SetXattr(name string, value []byte, flags XattrFlags) error {
 		vptr,
 		C.size_t(len(value)),
 		C.int(flags))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GetXattr gets an extended attribute from the open file.
@@ -90,13 +91,14 @@ func (f *File) GetXattr(name string) ([]byte, error) {
 	// range from 1k to 64KiB
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := f.startOp("ceph_fgetxattr")
 		ret = C.ceph_fgetxattr(
 			f.mount.mount,
 			f.fd,
 			cName,
 			unsafe.Pointer(&buf[0]),
 			C.size_t(size))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -124,12 +126,13 @@ func (f *File) ListXattr() ([]string, error) {
 	// range from 1k to 64KiB
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := f.startOp("ceph_flistxattr")
 		ret = C.ceph_flistxattr(
 			f.mount.mount,
 			f.fd,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.size_t(size))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -155,9 +158,10 @@ func (f *File) RemoveXattr(name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := f.startOp("ceph_fremovexattr")
 	ret := C.ceph_fremovexattr(
 		f.mount.mount,
 		f.fd,
 		cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
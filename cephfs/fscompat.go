@@ -403,6 +403,8 @@ type wrapperObject interface {
 // debugf formats info about a function and logs it.
 func debugf(o wrapperObject, fname, format string, args ...any) {
 	if o.trace() {
-		log.Debugf(fmt.Sprintf("%v.%v: %s", o.identify(), fname, format), args...)
+		log.Debug(fmt.Sprintf(format, args...),
+			"object", o.identify(),
+			"func", fname)
 	}
 }
@@ -0,0 +1,42 @@
+package cephfs
+
+import (
+	"github.com/ceph/go-ceph/internal/hooks"
+)
+
+// startOp returns a tracker for an operation on the mount, or nil if no hook
+// is set. path is the path the operation works on, if any.
+func (mount *MountInfo) startOp(name, path string) *hooks.Tracker {
+	h, _ := hooks.Lookup(mount.conn)
+	h = hooks.Select(h)
+	if h == nil {
+		return nil
+	}
+	return hooks.Start(h, hooks.Op{Name: name, Path: path})
+}
+
+// startCommand returns a tracker for a command, or nil if no hook is set.
+func (mount *MountInfo) startCommand(name string, args [][]byte) *hooks.Tracker {
+	h, _ := hooks.Lookup(mount.conn)
+	h = hooks.Select(h)
+	if h == nil {
+		return nil
+	}
+	op := hooks.Op{Name: name}
+	if len(args) > 0 {
+		op.Command = string(args[0])
+	}
+	return hooks.Start(h, op)
+}
+
+// startOp returns a tracker for an operation on the file, or nil if no hook
+// is set.
+func (f *File) startOp(name string) *hooks.Tracker {
+	return f.mount.startOp(name, f.path)
+}
+
+// startOp returns a tracker for an operation on the directory, or nil if no
+// hook is set.
+func (dir *Directory) startOp(name string) *hooks.Tracker {
+	return dir.mount.startOp(name, dir.path)
+}
@@ -0,0 +1,55 @@
+//go:build ceph_preview
+
+package cephfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/common/hooks"
+)
+
+type recordingHook struct {
+	ops []hooks.Op
+}
+
+func (r *recordingHook) OpDone(op *hooks.Op) {
+	r.ops = append(r.ops, *op)
+}
+
+func TestHooks(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	h := &recordingHook{}
+	hooks.SetGlobal(h)
+	defer hooks.SetGlobal(nil)
+
+	dname := "/hooked"
+	err := mount.MakeDir(dname, 0755)
+	require.NoError(t, err)
+	_, err = mount.Statx(dname, StatxBasicStats, AtSymlinkNofollow)
+	assert.NoError(t, err)
+	dir, err := mount.OpenDir(dname)
+	require.NoError(t, err)
+	err = dir.Close()
+	assert.NoError(t, err)
+	err = mount.RemoveDir(dname)
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, op := range h.ops {
+		names = append(names, op.Name)
+		assert.Equal(t, dname, op.Path)
+		assert.NoError(t, op.Err)
+	}
+	assert.Equal(t, []string{
+		"ceph_mkdir",
+		"ceph_statx",
+		"ceph_opendir",
+		"ceph_closedir",
+		"ceph_rmdir",
+	}, names)
+}
@@ -24,6 +24,7 @@ func (mount *MountInfo) MakeDirs(path string, mode uint32) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_mkdirs", path)
 	ret := C.ceph_mkdirs(mount.mount, cPath, C.mode_t(mode))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
@@ -18,5 +18,6 @@ import "C"
 //
 //	int ceph_mount_perms_set(struct ceph_mount_info *cmount, UserPerm *perm);
 func (mount *MountInfo) SetMountPerms(perm *UserPerm) error {
-	return getError(C.ceph_mount_perms_set(mount.mount, perm.userPerm))
+	t := mount.startOp("ceph_mount_perms_set", "")
+	return t.DoneErr(getError(C.ceph_mount_perms_set(mount.mount, perm.userPerm)))
 }
@@ -17,7 +17,9 @@ func (mount *MountInfo) CurrentDir() string {
 	if err := mount.validate(); err != nil {
 		return ""
 	}
+	t := mount.startOp("ceph_getcwd", "")
 	cDir := C.ceph_getcwd(mount.mount)
+	t.Done(0, nil)
 	return C.GoString(cDir)
 }
 
@@ -29,8 +31,9 @@ func (mount *MountInfo) ChangeDir(path string) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_chdir", path)
 	ret := C.ceph_chdir(mount.mount, cPath)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MakeDir creates a directory.
@@ -41,8 +44,9 @@ func (mount *MountInfo) MakeDir(path string, mode uint32) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_mkdir", path)
 	ret := C.ceph_mkdir(mount.mount, cPath, C.mode_t(mode))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // RemoveDir removes a directory.
@@ -53,8 +57,9 @@ func (mount *MountInfo) RemoveDir(path string) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_rmdir", path)
 	ret := C.ceph_rmdir(mount.mount, cPath)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Unlink removes a file.
@@ -69,8 +74,9 @@ func (mount *MountInfo) Unlink(path string) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_unlink", path)
 	ret := C.ceph_unlink(mount.mount, cPath)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Link creates a new link to an existing file.
@@ -88,8 +94,9 @@ func (mount *MountInfo) Link(oldname, newname string) error {
 	cNewname := C.CString(newname)
 	defer C.free(unsafe.Pointer(cNewname))
 
+	t := mount.startOp("ceph_link", newname)
 	ret := C.ceph_link(mount.mount, cOldname, cNewname)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Symlink creates a symbolic link to an existing path.
@@ -107,8 +114,9 @@ func (mount *MountInfo) Symlink(existing, newname string) error {
 	cNewname := C.CString(newname)
 	defer C.free(unsafe.Pointer(cNewname))
 
+	t := mount.startOp("ceph_symlink", newname)
 	ret := C.ceph_symlink(mount.mount, cExisting, cNewname)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Readlink returns the value of a symbolic link.
@@ -124,10 +132,12 @@ func (mount *MountInfo) Readlink(path string) (string, error) {
 	defer C.free(unsafe.Pointer(cPath))
 
 	buf := make([]byte, 4096)
+	t := mount.startOp("ceph_readlink", path)
 	ret := C.ceph_readlink(mount.mount,
 		cPath,
 		(*C.char)(unsafe.Pointer(&buf[0])),
 		C.int64_t(len(buf)))
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return "", getError(ret)
 	}
@@ -149,6 +159,7 @@ func (mount *MountInfo) Statx(path string, want StatxMask, flags AtFlags) (*Ceph
 	defer C.free(unsafe.Pointer(cPath))
 
 	var stx C.struct_ceph_statx
+	t := mount.startOp("ceph_statx", path)
 	ret := C.ceph_statx(
 		mount.mount,
 		cPath,
@@ -156,7 +167,7 @@ func (mount *MountInfo) Statx(path string, want StatxMask, flags AtFlags) (*Ceph
 		C.uint(want),
 		C.uint(flags),
 	)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return nil, err
 	}
 	return cStructToCephStatx(stx), nil
@@ -176,8 +187,9 @@ func (mount *MountInfo) Rename(from, to string) error {
 	cTo := C.CString(to)
 	defer C.free(unsafe.Pointer(cTo))
 
+	t := mount.startOp("ceph_rename", from)
 	ret := C.ceph_rename(mount.mount, cFrom, cTo)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Truncate sets the size of the specified file.
@@ -192,10 +204,11 @@ func (mount *MountInfo) Truncate(path string, size int64) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_truncate", path)
 	ret := C.ceph_truncate(
 		mount.mount,
 		cPath,
 		C.int64_t(size),
 	)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
@@ -41,6 +41,7 @@ func (mount *MountInfo) SetXattr(path, name string, value []byte, flags XattrFla
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := mount.startOp("ceph_setxattr", path)
 	ret := C.ceph_setxattr(
 		mount.mount,
 		cPath,
@@ -48,7 +49,7 @@ func (mount *MountInfo) SetXattr(path, name string, value []byte, flags XattrFla
 		vptr,
 		C.size_t(len(value)),
 		C.int(flags))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GetXattr gets an extended attribute from the file at the supplied path.
@@ -77,13 +78,14 @@ func (mount *MountInfo) GetXattr(path, name string) ([]byte, error) {
 	// range from 1k to 64KiB
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := mount.startOp("ceph_getxattr", path)
 		ret = C.ceph_getxattr(
 			mount.mount,
 			cPath,
 			cName,
 			unsafe.Pointer(&buf[0]),
 			C.size_t(size))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -113,12 +115,13 @@ func (mount *MountInfo) ListXattr(path string) ([]string, error) {
 	// range from 1k to 64KiB
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := mount.startOp("ceph_listxattr", path)
 		ret = C.ceph_listxattr(
 			mount.mount,
 			cPath,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.size_t(size))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -146,11 +149,12 @@ func (mount *MountInfo) RemoveXattr(path, name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := mount.startOp("ceph_removexattr", path)
 	ret := C.ceph_removexattr(
 		mount.mount,
 		cPath,
 		cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // LsetXattr sets an extended attribute on the file at the supplied path.
@@ -178,6 +182,7 @@ func (mount *MountInfo) LsetXattr(path, name string, value []byte, flags XattrFl
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := mount.startOp("ceph_lsetxattr", path)
 	ret := C.ceph_lsetxattr(
 		mount.mount,
 		cPath,
@@ -185,7 +190,7 @@ func (mount *MountInfo) LsetXattr(path, name string, value []byte, flags XattrFl
 		vptr,
 		C.size_t(len(value)),
 		C.int(flags))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // LgetXattr gets an extended attribute from the file at the supplied path.
@@ -214,13 +219,14 @@ func (mount *MountInfo) LgetXattr(path, name string) ([]byte, error) {
 	// range from 1k to 64KiB
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := mount.startOp("ceph_lgetxattr", path)
 		ret = C.ceph_lgetxattr(
 			mount.mount,
 			cPath,
 			cName,
 			unsafe.Pointer(&buf[0]),
 			C.size_t(size))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -250,12 +256,13 @@ func (mount *MountInfo) LlistXattr(path string) ([]string, error) {
 	// range from 1k to 64KiB
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := mount.startOp("ceph_llistxattr", path)
 		ret = C.ceph_llistxattr(
 			mount.mount,
 			cPath,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.size_t(size))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -283,9 +290,10 @@ func (mount *MountInfo) LremoveXattr(path, name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := mount.startOp("ceph_lremovexattr", path)
 	ret := C.ceph_lremovexattr(
 		mount.mount,
 		cPath,
 		cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
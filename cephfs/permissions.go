@@ -17,8 +17,9 @@ func (mount *MountInfo) Chmod(path string, mode uint32) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_chmod", path)
 	ret := C.ceph_chmod(mount.mount, cPath, C.mode_t(mode))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Chown changes the ownership of a file/directory.
@@ -26,8 +27,9 @@ func (mount *MountInfo) Chown(path string, user uint32, group uint32) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_chown", path)
 	ret := C.ceph_chown(mount.mount, cPath, C.int(user), C.int(group))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Lchown changes the ownership of a file/directory/etc without following symbolic links
@@ -35,6 +37,7 @@ func (mount *MountInfo) Lchown(path string, user uint32, group uint32) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
+	t := mount.startOp("ceph_lchown", path)
 	ret := C.ceph_lchown(mount.mount, cPath, C.int(user), C.int(group))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
@@ -26,6 +26,7 @@ func (mount *MountInfo) SelectFilesystem(name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := mount.startOp("ceph_select_filesystem", "")
 	ret := C.ceph_select_filesystem(mount.mount, cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
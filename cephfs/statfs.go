@@ -54,7 +54,9 @@ func (mount *MountInfo) StatFS(path string) (*CephStatVFS, error) {
 	defer C.free(unsafe.Pointer(cPath))
 
 	var statvfs C.struct_statvfs
+	t := mount.startOp("ceph_statfs", path)
 	ret := C.ceph_statfs(mount.mount, cPath, &statvfs)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return nil, getError(ret)
 	}
@@ -0,0 +1,42 @@
+//go:build ceph_preview
+
+package cephfs
+
+import (
+	"github.com/ceph/go-ceph/internal/dlsym"
+)
+
+// NotImplementedError is returned by functions that require a function that
+// is not provided by the version of libcephfs loaded at runtime.
+type NotImplementedError = dlsym.NotImplementedError
+
+// APIFeature identifies a part of the go-ceph cephfs API that depends on
+// functions that are not provided by all versions of libcephfs.
+type APIFeature string
+
+const (
+	// APIMknod is required by MountInfo.Mknod.
+	APIMknod = APIFeature("Mknod")
+	// APIFutime is required by MountInfo.Futime.
+	APIFutime = APIFeature("Futime")
+	// APIFutimens is required by MountInfo.Futimens.
+	APIFutimens = APIFeature("Futimens")
+	// APIFutimes is required by MountInfo.Futimes.
+	APIFutimes = APIFeature("Futimes")
+)
+
+var apiFeatureSymbols = map[APIFeature][]*dlsym.Symbol{
+	APIMknod:    {cephMknod},
+	APIFutime:   {cephFutime},
+	APIFutimens: {cephFutimens},
+	APIFutimes:  {cephFutimes},
+}
+
+// Supports returns true if the libcephfs library loaded at runtime provides
+// everything the given APIFeature requires. Functions of unsupported
+// features return a NotImplementedError. Supports returns false for unknown
+// features.
+func Supports(f APIFeature) bool {
+	symbols, ok := apiFeatureSymbols[f]
+	return ok && dlsym.Available(symbols...)
+}
@@ -0,0 +1,18 @@
+//go:build ceph_preview
+
+package cephfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupports(t *testing.T) {
+	assert.False(t, Supports(APIFeature("NoSuchFeature")))
+	// all supported ceph versions provide these functions
+	assert.True(t, Supports(APIMknod))
+	assert.True(t, Supports(APIFutime))
+	assert.True(t, Supports(APIFutimens))
+	assert.True(t, Supports(APIFutimes))
+}
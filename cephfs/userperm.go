@@ -73,7 +73,7 @@ func (p *UserPerm) Destroy() {
 
 func destroyUserPerm(p *UserPerm) {
 	if p.userPerm != nil && p.managed {
-		log.Warnf("unreachable UserPerm object has not been destroyed. Cleaning up.")
+		log.Warn("unreachable UserPerm object has not been destroyed. Cleaning up.")
 	}
 	p.Destroy()
 }
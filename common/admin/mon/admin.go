@@ -0,0 +1,26 @@
+//go:build ceph_preview
+
+package mon
+
+import (
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+// MonConn is the interface needed by MonAdmin to send commands to the mons,
+// either to the cluster as a whole or to a specific mon.
+type MonConn interface {
+	ccom.MonCommander
+	ccom.MonTargetCommander
+}
+
+// MonAdmin is used to inspect the mon quorum and administrate the mon map.
+type MonAdmin struct {
+	conn MonConn
+}
+
+// NewFromConn creates an new management object from a preexisting
+// rados connection. The existing connection can be rados.Conn or any
+// type implementing the MonConn interface.
+func NewFromConn(conn MonConn) *MonAdmin {
+	return &MonAdmin{conn}
+}
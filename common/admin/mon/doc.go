@@ -0,0 +1,5 @@
+/*
+Package mon from common/admin contains a set of APIs used to inspect the
+quorum of the Ceph monitors and to administrate the monitor map.
+*/
+package mon
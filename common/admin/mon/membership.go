@@ -0,0 +1,73 @@
+//go:build ceph_preview
+
+package mon
+
+import (
+	"sort"
+
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// AddMonOptions are the optional settings of a mon added by AddMon.
+type AddMonOptions struct {
+	// Location is the CRUSH location of the mon, such as
+	// {"datacenter": "dc1"}. It is used by stretch clusters.
+	Location map[string]string
+}
+
+// AddMon adds a mon with the given name and address to the mon map. The
+// address may include a port, otherwise the default ports are used. The mon
+// daemon itself has to be deployed separately. A nil opts is allowed.
+//
+// Similar To:
+//
+//	ceph mon add <name> <addr> [<location>...]
+func (ma *MonAdmin) AddMon(name, addr string, opts *AddMonOptions) error {
+	m := map[string]interface{}{
+		"prefix": "mon add",
+		"name":   name,
+		"addr":   addr,
+		"format": "json",
+	}
+	if opts != nil && len(opts.Location) > 0 {
+		loc := make([]string, 0, len(opts.Location))
+		for k, v := range opts.Location {
+			loc = append(loc, k+"="+v)
+		}
+		sort.Strings(loc)
+		m["location"] = loc
+	}
+	// the status reports the address the mon was added at
+	return commands.MarshalMonCommand(ma.conn, m).NoBody().End()
+}
+
+// RemoveMon removes the named mon from the mon map. Removing a mon that is
+// not in the mon map is not an error.
+//
+// Similar To:
+//
+//	ceph mon rm <name>
+func (ma *MonAdmin) RemoveMon(name string) error {
+	m := map[string]string{
+		"prefix": "mon rm",
+		"name":   name,
+		"format": "json",
+	}
+	return commands.MarshalMonCommand(ma.conn, m).NoBody().End()
+}
+
+// SetMonAddrs sets the addresses of the named mon. The addresses must be
+// given as an address vector, like "[v2:10.0.0.1:3300,v1:10.0.0.1:6789]".
+//
+// Similar To:
+//
+//	ceph mon set-addrs <name> <addrs>
+func (ma *MonAdmin) SetMonAddrs(name, addrs string) error {
+	m := map[string]string{
+		"prefix": "mon set-addrs",
+		"name":   name,
+		"addrs":  addrs,
+		"format": "json",
+	}
+	return commands.MarshalMonCommand(ma.conn, m).NoBody().End()
+}
@@ -0,0 +1,155 @@
+//go:build ceph_preview
+
+package mon
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// monTimeLayouts are the layouts of the time stamps in the mon map. Newer
+// versions of Ceph use ISO 8601 time stamps in UTC.
+var monTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999Z07:00",
+	"2006-01-02 15:04:05.999999",
+}
+
+// TimeStamp abstracts some of the details about date+time stamps returned
+// by the mons via JSON.
+type TimeStamp struct {
+	time.Time
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (ts *TimeStamp) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	var (
+		t   time.Time
+		err error
+	)
+	for _, layout := range monTimeLayouts {
+		if t, err = time.Parse(layout, raw); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	*ts = TimeStamp{t}
+	return nil
+}
+
+// Addr is a single network address of a daemon.
+type Addr struct {
+	// Type is the messenger protocol of the address, "v1" or "v2".
+	Type  string `json:"type"`
+	Addr  string `json:"addr"`
+	Nonce uint64 `json:"nonce"`
+}
+
+// AddrVec is the set of addresses a daemon can be reached at.
+type AddrVec struct {
+	Addrs []Addr `json:"addrvec"`
+}
+
+// MonInfo describes a mon in the mon map.
+type MonInfo struct {
+	Rank        int     `json:"rank"`
+	Name        string  `json:"name"`
+	PublicAddrs AddrVec `json:"public_addrs"`
+	// Addr is the legacy (v1) address of the mon.
+	Addr          string `json:"addr"`
+	PublicAddr    string `json:"public_addr"`
+	Priority      int    `json:"priority"`
+	Weight        int    `json:"weight"`
+	CrushLocation string `json:"crush_location"`
+}
+
+// MonMapFeatures lists the features of the mon map.
+type MonMapFeatures struct {
+	Persistent []string `json:"persistent"`
+	Optional   []string `json:"optional"`
+}
+
+// MonMap is the map of the mons of a cluster.
+type MonMap struct {
+	Epoch             int            `json:"epoch"`
+	FSID              string         `json:"fsid"`
+	Modified          TimeStamp      `json:"modified"`
+	Created           TimeStamp      `json:"created"`
+	MinMonRelease     int            `json:"min_mon_release"`
+	MinMonReleaseName string         `json:"min_mon_release_name"`
+	ElectionStrategy  int            `json:"election_strategy"`
+	StretchMode       bool           `json:"stretch_mode"`
+	TiebreakerMon     string         `json:"tiebreaker_mon"`
+	Features          MonMapFeatures `json:"features"`
+	Mons              []MonInfo      `json:"mons"`
+}
+
+// Mon returns the mon with the given name, or nil if the mon map does not
+// contain it.
+func (m *MonMap) Mon(name string) *MonInfo {
+	for i := range m.Mons {
+		if m.Mons[i].Name == name {
+			return &m.Mons[i]
+		}
+	}
+	return nil
+}
+
+// MonDump returns the current mon map.
+//
+// Similar To:
+//
+//	ceph mon dump
+func (ma *MonAdmin) MonDump() (*MonMap, error) {
+	m := map[string]string{
+		"prefix": "mon dump",
+		"format": "json",
+	}
+	mm := &MonMap{}
+	res := commands.MarshalMonCommand(ma.conn, m).
+		FilterPrefix("dumped monmap epoch")
+	if err := res.NoStatus().Unmarshal(mm).End(); err != nil {
+		return nil, err
+	}
+	return mm, nil
+}
+
+// MonRank identifies a mon by rank and name.
+type MonRank struct {
+	Rank int    `json:"rank"`
+	Name string `json:"name"`
+}
+
+// MonStat summarizes the state of the mons.
+type MonStat struct {
+	Epoch             int       `json:"epoch"`
+	MinMonReleaseName string    `json:"min_mon_release_name"`
+	NumMons           int       `json:"num_mons"`
+	Leader            string    `json:"leader"`
+	Quorum            []MonRank `json:"quorum"`
+}
+
+// MonStat returns a summary of the state of the mons.
+//
+// Similar To:
+//
+//	ceph mon stat
+func (ma *MonAdmin) MonStat() (*MonStat, error) {
+	m := map[string]string{
+		"prefix": "mon stat",
+		"format": "json",
+	}
+	ms := &MonStat{}
+	res := commands.MarshalMonCommand(ma.conn, m)
+	if err := res.NoStatus().Unmarshal(ms).End(); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
@@ -0,0 +1,31 @@
+//go:build ceph_preview
+
+package mon
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeStampUnmarshal(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		var ts TimeStamp
+		err := json.Unmarshal([]byte(`"2019-10-23T12:34:56.789012Z"`), &ts)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1571834096), ts.Unix())
+	})
+	t.Run("noTimeZone", func(t *testing.T) {
+		// older versions of ceph format time stamps without a time zone
+		var ts TimeStamp
+		err := json.Unmarshal([]byte(`"2019-10-23 12:34:56.789012"`), &ts)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1571834096), ts.Unix())
+	})
+	t.Run("badValue", func(t *testing.T) {
+		var ts TimeStamp
+		err := json.Unmarshal([]byte(`"yesterday"`), &ts)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,136 @@
+//go:build ceph_preview
+
+package mon
+
+import (
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// QuorumFeatures lists the features supported by all mons in the quorum.
+type QuorumFeatures struct {
+	QuorumCon string   `json:"quorum_con"`
+	QuorumMon []string `json:"quorum_mon"`
+}
+
+// QuorumStatus describes the quorum of the mons as seen by the cluster.
+type QuorumStatus struct {
+	ElectionEpoch int `json:"election_epoch"`
+	// Quorum contains the ranks of the mons in the quorum.
+	Quorum           []int    `json:"quorum"`
+	QuorumNames      []string `json:"quorum_names"`
+	QuorumLeaderName string   `json:"quorum_leader_name"`
+	// QuorumAge is the time since the quorum was formed, in seconds.
+	QuorumAge int64          `json:"quorum_age"`
+	Features  QuorumFeatures `json:"features"`
+	MonMap    MonMap         `json:"monmap"`
+}
+
+// InQuorum returns true if the named mon is part of the quorum.
+func (qs *QuorumStatus) InQuorum(name string) bool {
+	for _, n := range qs.QuorumNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// OutOfQuorum returns the names of the mons in the mon map that are not
+// part of the quorum.
+func (qs *QuorumStatus) OutOfQuorum() []string {
+	var out []string
+	for _, mon := range qs.MonMap.Mons {
+		if !qs.InQuorum(mon.Name) {
+			out = append(out, mon.Name)
+		}
+	}
+	return out
+}
+
+// QuorumStatus returns the current status of the mon quorum.
+//
+// Similar To:
+//
+//	ceph quorum_status
+func (ma *MonAdmin) QuorumStatus() (*QuorumStatus, error) {
+	m := map[string]string{
+		"prefix": "quorum_status",
+		"format": "json",
+	}
+	qs := &QuorumStatus{}
+	res := commands.MarshalMonCommand(ma.conn, m)
+	if err := res.NoStatus().Unmarshal(qs).End(); err != nil {
+		return nil, err
+	}
+	return qs, nil
+}
+
+// MonStatusFeatures lists the features required by a mon and those
+// supported by the quorum it is part of.
+type MonStatusFeatures struct {
+	RequiredCon string   `json:"required_con"`
+	RequiredMon []string `json:"required_mon"`
+	QuorumCon   string   `json:"quorum_con"`
+	QuorumMon   []string `json:"quorum_mon"`
+}
+
+// MonState is the state of a mon, such as "leader" or "probing".
+type MonState string
+
+const (
+	// MonStateProbing is the state of a mon looking for its peers.
+	MonStateProbing = MonState("probing")
+	// MonStateSynchronizing is the state of a mon catching up with the
+	// quorum.
+	MonStateSynchronizing = MonState("synchronizing")
+	// MonStateElecting is the state of a mon taking part in an election.
+	MonStateElecting = MonState("electing")
+	// MonStateLeader is the state of the leader of the quorum.
+	MonStateLeader = MonState("leader")
+	// MonStatePeon is the state of a member of the quorum that is not its
+	// leader.
+	MonStatePeon = MonState("peon")
+	// MonStateShutdown is the state of a mon that is shutting down.
+	MonStateShutdown = MonState("shutdown")
+)
+
+// MonStatus describes the state of a single mon, as seen by that mon.
+type MonStatus struct {
+	Name          string   `json:"name"`
+	Rank          int      `json:"rank"`
+	State         MonState `json:"state"`
+	ElectionEpoch int      `json:"election_epoch"`
+	// Quorum contains the ranks of the mons in the quorum.
+	Quorum []int `json:"quorum"`
+	// QuorumAge is the time since the quorum was formed, in seconds.
+	QuorumAge     int64             `json:"quorum_age"`
+	Features      MonStatusFeatures `json:"features"`
+	OutsideQuorum []string          `json:"outside_quorum"`
+	MonMap        MonMap            `json:"monmap"`
+	StretchMode   bool              `json:"stretch_mode"`
+}
+
+// InQuorum returns true if the mon is the leader or a member of the quorum.
+func (ms *MonStatus) InQuorum() bool {
+	return ms.State == MonStateLeader || ms.State == MonStatePeon
+}
+
+// MonStatus returns the state of the named mon, as reported by that mon
+// itself. Unlike QuorumStatus this can be used to inspect mons that are not
+// part of the quorum.
+//
+// Similar To:
+//
+//	ceph tell mon.<name> mon_status
+func (ma *MonAdmin) MonStatus(name string) (*MonStatus, error) {
+	m := map[string]string{
+		"prefix": "mon_status",
+		"format": "json",
+	}
+	ms := &MonStatus{}
+	res := commands.MarshalMonTargetCommand(ma.conn, name, m)
+	if err := res.NoStatus().Unmarshal(ms).End(); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
@@ -0,0 +1,102 @@
+//go:build ceph_preview
+
+package mon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/internal/admintest"
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+const goldenFile = "testdata/replay_mon.json"
+
+func TestQuorumStatus(t *testing.T) {
+	ma := NewFromConn(admintest.Golden(t, goldenFile))
+	qs, err := ma.QuorumStatus()
+	require.NoError(t, err)
+	assert.Equal(t, 14, qs.ElectionEpoch)
+	assert.Equal(t, []int{0, 1}, qs.Quorum)
+	assert.Equal(t, "a", qs.QuorumLeaderName)
+	assert.Equal(t, int64(3271), qs.QuorumAge)
+	assert.Contains(t, qs.Features.QuorumMon, "reef")
+	assert.Len(t, qs.MonMap.Mons, 3)
+	assert.True(t, qs.InQuorum("b"))
+	assert.False(t, qs.InQuorum("c"))
+	assert.Equal(t, []string{"c"}, qs.OutOfQuorum())
+}
+
+func TestMonDump(t *testing.T) {
+	ma := NewFromConn(admintest.Golden(t, goldenFile))
+	mm, err := ma.MonDump()
+	require.NoError(t, err)
+	assert.Equal(t, 3, mm.Epoch)
+	assert.Equal(t, "8f5b2c4e-3a71-4d2e-9b0c-6a1f7e2d9c31", mm.FSID)
+	assert.Equal(t, int64(1694507504), mm.Modified.Unix())
+	assert.Equal(t, 18, mm.MinMonRelease)
+	assert.Equal(t, "reef", mm.MinMonReleaseName)
+	assert.Contains(t, mm.Features.Persistent, "quincy")
+	mon := mm.Mon("b")
+	if assert.NotNil(t, mon) {
+		assert.Equal(t, 1, mon.Rank)
+		assert.Equal(t, "192.168.1.12:6789/0", mon.Addr)
+		if assert.Len(t, mon.PublicAddrs.Addrs, 2) {
+			assert.Equal(t, "v2", mon.PublicAddrs.Addrs[0].Type)
+			assert.Equal(t, "192.168.1.12:3300", mon.PublicAddrs.Addrs[0].Addr)
+		}
+	}
+	assert.Nil(t, mm.Mon("z"))
+}
+
+func TestMonStat(t *testing.T) {
+	ma := NewFromConn(admintest.Golden(t, goldenFile))
+	ms, err := ma.MonStat()
+	require.NoError(t, err)
+	assert.Equal(t, 3, ms.NumMons)
+	assert.Equal(t, "a", ms.Leader)
+	assert.Equal(t, []MonRank{{0, "a"}, {1, "b"}}, ms.Quorum)
+}
+
+func TestMonStatus(t *testing.T) {
+	ma := NewFromConn(admintest.Golden(t, goldenFile))
+	ms, err := ma.MonStatus("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", ms.Name)
+	assert.Equal(t, MonStateLeader, ms.State)
+	assert.True(t, ms.InQuorum())
+	assert.Equal(t, []int{0, 1}, ms.Quorum)
+	assert.Equal(t, 3, ms.MonMap.Epoch)
+
+	ms, err = ma.MonStatus("c")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ms.Rank)
+	assert.Equal(t, MonStateProbing, ms.State)
+	assert.False(t, ms.InQuorum())
+	assert.Equal(t, []string{"c"}, ms.OutsideQuorum)
+
+	_, err = ma.MonStatus("x")
+	assert.ErrorIs(t, err, errutil.GetError("rados", -2))
+}
+
+func TestMonMembership(t *testing.T) {
+	c := admintest.Golden(t, goldenFile)
+	ma := NewFromConn(c)
+
+	err := ma.AddMon("d", "192.168.1.14", &AddMonOptions{
+		Location: map[string]string{"host": "node4", "datacenter": "dc2"},
+	})
+	assert.NoError(t, err)
+	err = ma.SetMonAddrs("d", "[v2:192.168.1.15:3300,v1:192.168.1.15:6789]")
+	assert.NoError(t, err)
+	err = ma.SetMonAddrs("e", "[v2:192.168.1.16:3300]")
+	assert.ErrorIs(t, err, errutil.GetError("rados", -2))
+	err = ma.RemoveMon("d")
+	assert.NoError(t, err)
+
+	for _, rec := range admintest.Unused(c) {
+		assert.NotContains(t, string(rec.Request[0]), "mon add")
+	}
+}
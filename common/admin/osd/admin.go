@@ -0,0 +1,19 @@
+//go:build ceph_preview
+
+package osd
+
+import (
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+// OSDAdmin is used to query and configure individual OSD daemons.
+type OSDAdmin struct {
+	conn ccom.OSDCommander
+}
+
+// NewFromConn creates an new management object from a preexisting
+// rados connection. The existing connection can be rados.Conn or any
+// type implementing the OSDCommander interface.
+func NewFromConn(conn ccom.OSDCommander) *OSDAdmin {
+	return &OSDAdmin{conn}
+}
@@ -0,0 +1,65 @@
+//go:build ceph_preview
+
+package osd
+
+import (
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// BenchOptions control the write benchmark run by Bench. Fields that are
+// zero use the defaults of the OSD.
+type BenchOptions struct {
+	// TotalBytes is the total number of bytes to write (1 GiB by default).
+	TotalBytes uint64
+	// BlockSize is the size of every write (4 MiB by default).
+	BlockSize uint64
+	// ObjectSize is the size of the objects written to. If it is set,
+	// ObjectNum objects are prefilled and written to with small writes.
+	ObjectSize uint64
+	// ObjectNum is the number of objects written to.
+	ObjectNum uint64
+}
+
+// BenchResult is the result of a write benchmark.
+type BenchResult struct {
+	BytesWritten uint64  `json:"bytes_written"`
+	BlockSize    uint64  `json:"blocksize"`
+	ElapsedSec   float64 `json:"elapsed_sec"`
+	BytesPerSec  float64 `json:"bytes_per_sec"`
+	IOPS         float64 `json:"iops"`
+}
+
+// Bench runs a write benchmark on the object store of an OSD. A nil opts
+// runs the benchmark with the default settings.
+//
+// Similar To:
+//
+//	ceph tell osd.<id> bench [<count> <size> <object_size> <object_num>]
+func (oa *OSDAdmin) Bench(osd int, opts *BenchOptions) (*BenchResult, error) {
+	m := map[string]interface{}{
+		"prefix": "bench",
+		"format": "json",
+	}
+	if opts != nil {
+		args := []struct {
+			name  string
+			value uint64
+		}{
+			{"count", opts.TotalBytes},
+			{"size", opts.BlockSize},
+			{"object_size", opts.ObjectSize},
+			{"object_num", opts.ObjectNum},
+		}
+		for _, a := range args {
+			if a.value != 0 {
+				m[a.name] = a.value
+			}
+		}
+	}
+	r := &BenchResult{}
+	res := commands.MarshalOsdCommand(oa.conn, osd, m)
+	if err := res.NoStatus().Unmarshal(r).End(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
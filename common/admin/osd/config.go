@@ -0,0 +1,43 @@
+//go:build ceph_preview
+
+package osd
+
+import (
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// ConfigGet returns the value of a configuration option as currently used
+// by an OSD.
+//
+// Similar To:
+//
+//	ceph tell osd.<id> config get <name>
+func (oa *OSDAdmin) ConfigGet(osd int, name string) (string, error) {
+	m := map[string]string{
+		"prefix": "config get",
+		"var":    name,
+		"format": "json",
+	}
+	values := map[string]string{}
+	res := commands.MarshalOsdCommand(oa.conn, osd, m)
+	if err := res.NoStatus().Unmarshal(&values).End(); err != nil {
+		return "", err
+	}
+	return values[name], nil
+}
+
+// ConfigSet changes the value of a configuration option of a running OSD.
+// The change is not persisted and is lost when the OSD restarts.
+//
+// Similar To:
+//
+//	ceph tell osd.<id> config set <name> <value>
+func (oa *OSDAdmin) ConfigSet(osd int, name, value string) error {
+	m := map[string]interface{}{
+		"prefix": "config set",
+		"var":    name,
+		"val":    []string{value},
+		"format": "json",
+	}
+	return commands.MarshalOsdCommand(oa.conn, osd, m).NoStatus().End()
+}
@@ -0,0 +1,6 @@
+/*
+Package osd from common/admin contains a set of APIs used to interact with
+and debug individual Ceph OSD daemons, using the commands that can be sent
+to an OSD by its ID, like "ceph tell osd.<id> ..." does.
+*/
+package osd
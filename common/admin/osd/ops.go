@@ -0,0 +1,130 @@
+//go:build ceph_preview
+
+package osd
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// opTimeLayouts are the layouts of the time stamps of tracked ops. Newer
+// versions of Ceph include the time zone offset.
+var opTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999-0700",
+	"2006-01-02 15:04:05.999999",
+}
+
+// TimeStamp abstracts some of the details about date+time stamps returned
+// by the OSD op tracker via JSON.
+type TimeStamp struct {
+	time.Time
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (ts *TimeStamp) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	var (
+		t   time.Time
+		err error
+	)
+	for _, layout := range opTimeLayouts {
+		if t, err = time.Parse(layout, raw); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	*ts = TimeStamp{t}
+	return nil
+}
+
+// OpEvent is an event in the life of an op, such as "queued_for_pg" or
+// "commit_sent".
+type OpEvent struct {
+	Event string    `json:"event"`
+	Time  TimeStamp `json:"time"`
+	// Duration is the time spent since the previous event, in seconds.
+	Duration float64 `json:"duration"`
+}
+
+// OpClientInfo identifies the client that sent an op.
+type OpClientInfo struct {
+	Client     string `json:"client"`
+	ClientAddr string `json:"client_addr"`
+	Tid        uint64 `json:"tid"`
+}
+
+// OpTypeData contains the details of an op that depend on its type.
+type OpTypeData struct {
+	FlagPoint  string        `json:"flag_point"`
+	ClientInfo *OpClientInfo `json:"client_info,omitempty"`
+	Events     []OpEvent     `json:"events"`
+}
+
+// Op is an op tracked by an OSD.
+type Op struct {
+	Description string    `json:"description"`
+	InitiatedAt TimeStamp `json:"initiated_at"`
+	// Age is the time since the op was initiated, in seconds.
+	Age float64 `json:"age"`
+	// Duration is the time the op took, or has taken so far, in seconds.
+	Duration float64    `json:"duration"`
+	TypeData OpTypeData `json:"type_data"`
+}
+
+// OpsInFlight lists the ops an OSD is currently processing.
+type OpsInFlight struct {
+	Ops    []Op `json:"ops"`
+	NumOps int  `json:"num_ops"`
+}
+
+// HistoricOps lists the slowest recently completed ops of an OSD.
+type HistoricOps struct {
+	// Size is the maximum number of ops that are kept.
+	Size int `json:"size"`
+	// Duration is the time ops are kept for, in seconds.
+	Duration float64 `json:"duration"`
+	Ops      []Op    `json:"ops"`
+}
+
+// DumpOpsInFlight returns the ops an OSD is currently processing.
+//
+// Similar To:
+//
+//	ceph tell osd.<id> dump_ops_in_flight
+func (oa *OSDAdmin) DumpOpsInFlight(osd int) (*OpsInFlight, error) {
+	m := map[string]string{
+		"prefix": "dump_ops_in_flight",
+		"format": "json",
+	}
+	ops := &OpsInFlight{}
+	res := commands.MarshalOsdCommand(oa.conn, osd, m)
+	if err := res.NoStatus().Unmarshal(ops).End(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// DumpHistoricOps returns the slowest recently completed ops of an OSD.
+//
+// Similar To:
+//
+//	ceph tell osd.<id> dump_historic_ops
+func (oa *OSDAdmin) DumpHistoricOps(osd int) (*HistoricOps, error) {
+	m := map[string]string{
+		"prefix": "dump_historic_ops",
+		"format": "json",
+	}
+	ops := &HistoricOps{}
+	res := commands.MarshalOsdCommand(oa.conn, osd, m)
+	if err := res.NoStatus().Unmarshal(ops).End(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
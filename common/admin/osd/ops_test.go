@@ -0,0 +1,31 @@
+//go:build ceph_preview
+
+package osd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeStampUnmarshal(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		var ts TimeStamp
+		err := json.Unmarshal([]byte(`"2023-09-12T08:40:52.100000+0000"`), &ts)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1694508052), ts.Unix())
+	})
+	t.Run("noTimeZone", func(t *testing.T) {
+		// older versions of ceph format time stamps without a time zone
+		var ts TimeStamp
+		err := json.Unmarshal([]byte(`"2023-09-12 08:40:52.100000"`), &ts)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1694508052), ts.Unix())
+	})
+	t.Run("badValue", func(t *testing.T) {
+		var ts TimeStamp
+		err := json.Unmarshal([]byte(`"yesterday"`), &ts)
+		assert.Error(t, err)
+	})
+}
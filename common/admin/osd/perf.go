@@ -0,0 +1,113 @@
+//go:build ceph_preview
+
+package osd
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// PerfCounter is the value of a single performance counter. Counters and
+// gauges only set Value. Averages set AvgCount and Sum, and averages of
+// durations also set AvgTime. Values of any other form are kept in Raw.
+type PerfCounter struct {
+	// Value is the value of a counter or gauge.
+	Value float64
+	// IsAverage is true if the counter is an average.
+	IsAverage bool
+	// AvgCount is the number of samples of an average.
+	AvgCount uint64
+	// Sum is the sum of the samples of an average.
+	Sum float64
+	// AvgTime is the average of the samples of a duration, in seconds.
+	AvgTime float64
+	// Raw holds the JSON of values that are neither numbers nor averages.
+	Raw json.RawMessage
+}
+
+type perfAverage struct {
+	AvgCount *uint64 `json:"avgcount"`
+	Sum      float64 `json:"sum"`
+	AvgTime  float64 `json:"avgtime"`
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (c *PerfCounter) UnmarshalJSON(b []byte) error {
+	*c = PerfCounter{}
+	if err := json.Unmarshal(b, &c.Value); err == nil {
+		return nil
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		var avg perfAverage
+		if err := json.Unmarshal(b, &avg); err == nil && avg.AvgCount != nil {
+			c.IsAverage = true
+			c.AvgCount = *avg.AvgCount
+			c.Sum = avg.Sum
+			c.AvgTime = avg.AvgTime
+			return nil
+		}
+	}
+	c.Raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// PerfCounters maps the names of the counters of a collection, such as
+// "op_r" or "op_w_latency", to their values.
+type PerfCounters map[string]PerfCounter
+
+// PerfDump maps the names of the counter collections of a daemon, such as
+// "osd" or "bluestore", to their counters.
+type PerfDump map[string]PerfCounters
+
+// PerfDump returns the values of the performance counters of an OSD.
+//
+// Similar To:
+//
+//	ceph tell osd.<id> perf dump
+func (oa *OSDAdmin) PerfDump(osd int) (PerfDump, error) {
+	m := map[string]string{
+		"prefix": "perf dump",
+		"format": "json",
+	}
+	pd := PerfDump{}
+	res := commands.MarshalOsdCommand(oa.conn, osd, m)
+	if err := res.NoStatus().Unmarshal(&pd).End(); err != nil {
+		return nil, err
+	}
+	return pd, nil
+}
+
+// PerfCounterSchema describes a performance counter.
+type PerfCounterSchema struct {
+	Type        int    `json:"type"`
+	MetricType  string `json:"metric_type"`
+	ValueType   string `json:"value_type"`
+	Description string `json:"description"`
+	Nick        string `json:"nick"`
+	Priority    int    `json:"priority"`
+	Units       string `json:"units"`
+}
+
+// PerfSchema maps the names of the counter collections of a daemon to the
+// schemas of their counters.
+type PerfSchema map[string]map[string]PerfCounterSchema
+
+// PerfSchema returns the schema of the performance counters of an OSD.
+//
+// Similar To:
+//
+//	ceph tell osd.<id> perf schema
+func (oa *OSDAdmin) PerfSchema(osd int) (PerfSchema, error) {
+	m := map[string]string{
+		"prefix": "perf schema",
+		"format": "json",
+	}
+	ps := PerfSchema{}
+	res := commands.MarshalOsdCommand(oa.conn, osd, m)
+	if err := res.NoStatus().Unmarshal(&ps).End(); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
@@ -0,0 +1,135 @@
+//go:build ceph_preview
+
+package osd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/internal/admintest"
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+const goldenFile = "testdata/replay_osd.json"
+
+func TestPerfDump(t *testing.T) {
+	oa := NewFromConn(admintest.Golden(t, goldenFile))
+	pd, err := oa.PerfDump(0)
+	require.NoError(t, err)
+	assert.Len(t, pd, 6)
+
+	osd := pd["osd"]
+	assert.Equal(t, float64(1041), osd["op"].Value)
+	assert.False(t, osd["op"].IsAverage)
+	assert.Equal(t, float64(10737418240), osd["stat_bytes"].Value)
+	lat := osd["op_w_latency"]
+	assert.True(t, lat.IsAverage)
+	assert.Equal(t, uint64(946), lat.AvgCount)
+	assert.InDelta(t, 3.406148273, lat.Sum, 1e-9)
+	assert.InDelta(t, 0.003600579, lat.AvgTime, 1e-9)
+
+	wait := pd["throttle-osd_client_bytes"]["wait"]
+	assert.True(t, wait.IsAverage)
+	assert.Equal(t, uint64(0), wait.AvgCount)
+
+	raw := pd["trackedop"]["tracked_ops"]
+	assert.False(t, raw.IsAverage)
+	assert.JSONEq(t, "[]", string(raw.Raw))
+}
+
+func TestPerfSchema(t *testing.T) {
+	oa := NewFromConn(admintest.Golden(t, goldenFile))
+	ps, err := oa.PerfSchema(0)
+	require.NoError(t, err)
+	if assert.Contains(t, ps, "osd") {
+		s := ps["osd"]["op_latency"]
+		assert.Equal(t, 5, s.Type)
+		assert.Equal(t, "gauge", s.MetricType)
+		assert.Equal(t, "real-integer-pair", s.ValueType)
+		assert.Equal(t, "l", s.Nick)
+		assert.Equal(t, 9, s.Priority)
+		assert.Equal(t, "bytes", ps["osd"]["op_in_bytes"].Units)
+	}
+}
+
+func TestDumpOpsInFlight(t *testing.T) {
+	oa := NewFromConn(admintest.Golden(t, goldenFile))
+	ops, err := oa.DumpOpsInFlight(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ops.NumOps)
+	require.Len(t, ops.Ops, 1)
+	op := ops.Ops[0]
+	assert.Contains(t, op.Description, "rbd_data.10a96b8b4567")
+	assert.Equal(t, int64(1694508066), op.InitiatedAt.Unix())
+	assert.Equal(t, "waiting for sub ops", op.TypeData.FlagPoint)
+	if assert.NotNil(t, op.TypeData.ClientInfo) {
+		assert.Equal(t, "client.4231", op.TypeData.ClientInfo.Client)
+		assert.Equal(t, uint64(9), op.TypeData.ClientInfo.Tid)
+	}
+	if assert.Len(t, op.TypeData.Events, 5) {
+		assert.Equal(t, "queued_for_pg", op.TypeData.Events[2].Event)
+		assert.InDelta(t, 0.00317984, op.TypeData.Events[2].Duration, 1e-9)
+	}
+}
+
+func TestDumpHistoricOps(t *testing.T) {
+	oa := NewFromConn(admintest.Golden(t, goldenFile))
+	ops, err := oa.DumpHistoricOps(1)
+	require.NoError(t, err)
+	assert.Equal(t, 20, ops.Size)
+	assert.Equal(t, float64(600), ops.Duration)
+	require.Len(t, ops.Ops, 2)
+	assert.Equal(t, "commit sent; apply or cleanup", ops.Ops[0].TypeData.FlagPoint)
+	assert.Len(t, ops.Ops[0].TypeData.Events, 3)
+	assert.Equal(t, int64(1694508052), ops.Ops[1].InitiatedAt.Unix())
+	assert.Nil(t, ops.Ops[1].TypeData.ClientInfo)
+}
+
+func TestBench(t *testing.T) {
+	oa := NewFromConn(admintest.Golden(t, goldenFile))
+
+	r, err := oa.Bench(0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1073741824), r.BytesWritten)
+	assert.Equal(t, uint64(4194304), r.BlockSize)
+	assert.InDelta(t, 96.454, r.IOPS, 0.001)
+
+	r, err = oa.Bench(0, &BenchOptions{
+		TotalBytes: 12288000,
+		BlockSize:  4096,
+		ObjectSize: 4194304,
+		ObjectNum:  100,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12288000), r.BytesWritten)
+	assert.Equal(t, uint64(4096), r.BlockSize)
+
+	_, err = oa.Bench(0, &BenchOptions{TotalBytes: 1 << 30, BlockSize: 65536})
+	assert.ErrorIs(t, err, errutil.GetError("rados", -22))
+	assert.Contains(t, err.Error(), "osd_bench_small_size_max_iops")
+}
+
+func TestConfig(t *testing.T) {
+	c := admintest.Golden(t, goldenFile)
+	oa := NewFromConn(c)
+
+	v, err := oa.ConfigGet(2, "osd_max_backfills")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", v)
+
+	err = oa.ConfigSet(2, "osd_max_backfills", "3")
+	assert.NoError(t, err)
+
+	v, err = oa.ConfigGet(2, "osd_max_backfills")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", v)
+
+	_, err = oa.ConfigGet(2, "no_such_option")
+	assert.ErrorIs(t, err, errutil.GetError("rados", -2))
+
+	for _, rec := range admintest.Unused(c) {
+		assert.NotEqual(t, "2", rec.Target)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+// PGConn is the interface needed by PGAdmin to send commands to the
+// cluster and to individual placement groups.
+type PGConn interface {
+	ccom.MonCommander
+	ccom.PGCommander
+}
+
+// PGAdmin is used to query placement groups.
+type PGAdmin struct {
+	conn PGConn
+}
+
+// NewFromConn creates an new management object from a preexisting
+// rados connection. The existing connection can be rados.Conn or any
+// type implementing the PGConn interface.
+func NewFromConn(conn PGConn) *PGAdmin {
+	return &PGAdmin{conn}
+}
@@ -0,0 +1,6 @@
+/*
+Package pg from common/admin contains a set of APIs used to query the
+placement groups (PGs) of a Ceph cluster, their statistics and their
+recovery state, and to look up where objects are placed.
+*/
+package pg
@@ -0,0 +1,99 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// PoolStats contains the summed up statistics of the PGs of a pool.
+type PoolStats struct {
+	PoolID        int64   `json:"poolid"`
+	NumPG         int     `json:"num_pg"`
+	StatSum       StatSum `json:"stat_sum"`
+	LogSize       int64   `json:"log_size"`
+	OndiskLogSize int64   `json:"ondisk_log_size"`
+	Up            int     `json:"up"`
+	Acting        int     `json:"acting"`
+}
+
+// OSDPerfStat contains the latencies of an OSD, in milliseconds.
+type OSDPerfStat struct {
+	CommitLatencyMs float64 `json:"commit_latency_ms"`
+	ApplyLatencyMs  float64 `json:"apply_latency_ms"`
+}
+
+// OSDStats contains the statistics of an OSD as reported to the PG map.
+type OSDStats struct {
+	OSD      int         `json:"osd"`
+	UpFrom   uint64      `json:"up_from"`
+	NumPGs   int         `json:"num_pgs"`
+	KB       uint64      `json:"kb"`
+	KBUsed   uint64      `json:"kb_used"`
+	KBAvail  uint64      `json:"kb_avail"`
+	HBPeers  []int       `json:"hb_peers"`
+	PerfStat OSDPerfStat `json:"perf_stat"`
+}
+
+// PGMap is the map of all placement groups of the cluster, along with
+// statistics summed up by pool and by OSD.
+type PGMap struct {
+	Version         uint64      `json:"version"`
+	Stamp           TimeStamp   `json:"stamp"`
+	LastOSDMapEpoch uint64      `json:"last_osdmap_epoch"`
+	LastPGScan      uint64      `json:"last_pg_scan"`
+	PGStats         []PGStat    `json:"pg_stats"`
+	PoolStats       []PoolStats `json:"pool_stats"`
+	OSDStats        []OSDStats  `json:"osd_stats"`
+}
+
+// PGDump is the result of a full dump of the PG map.
+type PGDump struct {
+	// PGReady is false if the mgr has not yet received the statistics of
+	// all PGs, in which case the PG map may be incomplete.
+	PGReady bool  `json:"pg_ready"`
+	PGMap   PGMap `json:"pg_map"`
+}
+
+// PGDump returns the complete PG map. On large clusters the result can be
+// very large, use PGDumpBrief if only the states and mappings of the PGs are
+// needed.
+//
+// Similar To:
+//
+//	ceph pg dump all
+func (pa *PGAdmin) PGDump() (*PGDump, error) {
+	m := map[string]interface{}{
+		"prefix":       "pg dump",
+		"dumpcontents": []string{"all"},
+		"format":       "json",
+	}
+	d := &PGDump{}
+	res := commands.MarshalMonCommand(pa.conn, m).FilterPrefix("dumped ")
+	if err := res.NoStatus().Unmarshal(d).End(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// PGDumpBrief returns the state and the up and acting sets of all PGs.
+//
+// Similar To:
+//
+//	ceph pg dump pgs_brief
+func (pa *PGAdmin) PGDumpBrief() ([]PGBrief, error) {
+	m := map[string]interface{}{
+		"prefix":       "pg dump",
+		"dumpcontents": []string{"pgs_brief"},
+		"format":       "json",
+	}
+	res := commands.MarshalMonCommand(pa.conn, m).FilterPrefix("dumped ")
+	if err := res.NoStatus().End(); err != nil {
+		return nil, err
+	}
+	var pgs []PGBrief
+	if err := unmarshalPGStats(res.Body(), &pgs); err != nil {
+		return nil, err
+	}
+	return pgs, nil
+}
@@ -0,0 +1,71 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+var errPoolAndOSD = errors.New("pg ls: Pool and OSD can not be combined")
+
+// ListPGsOptions filter the PGs returned by ListPGs. The zero value does
+// not filter.
+type ListPGsOptions struct {
+	// Pool is the name of the pool the PGs must belong to.
+	Pool string
+	// OSD, if set, is the ID of an OSD that the PGs must be mapped to.
+	// Pool can not be combined with OSD.
+	OSD *int
+	// Primary limits the PGs mapped to OSD to those the OSD is the primary
+	// of.
+	Primary bool
+	// States are states the PGs must be in, such as "degraded" or
+	// "undersized". A PG is listed if it is in any of them.
+	States []string
+}
+
+// ListPGs returns the statistics of the PGs matching the options. A nil
+// opts lists all PGs.
+//
+// Similar To:
+//
+//	ceph pg ls [<states>...]
+//	ceph pg ls-by-pool <pool> [<states>...]
+//	ceph pg ls-by-osd <osd> [<states>...]
+//	ceph pg ls-by-primary <osd> [<states>...]
+func (pa *PGAdmin) ListPGs(opts *ListPGsOptions) ([]PGStat, error) {
+	m := map[string]interface{}{
+		"prefix": "pg ls",
+		"format": "json",
+	}
+	if opts != nil {
+		switch {
+		case opts.Pool != "" && opts.OSD != nil:
+			return nil, errPoolAndOSD
+		case opts.Pool != "":
+			m["prefix"] = "pg ls-by-pool"
+			m["poolstr"] = opts.Pool
+		case opts.OSD != nil && opts.Primary:
+			m["prefix"] = "pg ls-by-primary"
+			m["osd"] = "osd." + strconv.Itoa(*opts.OSD)
+		case opts.OSD != nil:
+			m["prefix"] = "pg ls-by-osd"
+			m["osd"] = "osd." + strconv.Itoa(*opts.OSD)
+		}
+		if len(opts.States) > 0 {
+			m["states"] = opts.States
+		}
+	}
+	res := commands.MarshalMonCommand(pa.conn, m)
+	if err := res.NoStatus().End(); err != nil {
+		return nil, err
+	}
+	var pgs []PGStat
+	if err := unmarshalPGStats(res.Body(), &pgs); err != nil {
+		return nil, err
+	}
+	return pgs, nil
+}
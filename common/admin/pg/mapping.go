@@ -0,0 +1,77 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// PGMapping contains the OSDs a PG is mapped to.
+type PGMapping struct {
+	Epoch   uint64 `json:"epoch"`
+	PGID    string `json:"pgid"`
+	RawPGID string `json:"raw_pgid"`
+	Up      []int  `json:"up"`
+	Acting  []int  `json:"acting"`
+}
+
+// PGMap returns the OSDs the given PG is mapped to.
+//
+// Similar To:
+//
+//	ceph pg map <pgid>
+func (pa *PGAdmin) PGMap(pgid string) (*PGMapping, error) {
+	m := map[string]string{
+		"prefix": "pg map",
+		"pgid":   pgid,
+		"format": "json",
+	}
+	pm := &PGMapping{}
+	res := commands.MarshalMonCommand(pa.conn, m)
+	if err := res.NoStatus().Unmarshal(pm).End(); err != nil {
+		return nil, err
+	}
+	return pm, nil
+}
+
+// ObjectMapping describes the PG and OSDs an object is mapped to.
+type ObjectMapping struct {
+	Epoch  uint64 `json:"epoch"`
+	Pool   string `json:"pool"`
+	PoolID int64  `json:"pool_id"`
+	// ObjectName is the name of the object, prefixed by "<namespace>/" if
+	// the object is in a namespace.
+	ObjectName string `json:"objname"`
+	// RawPGID is the ID of the PG derived from the hash of the object name,
+	// before it is reduced to the number of PGs of the pool.
+	RawPGID       string `json:"raw_pgid"`
+	PGID          string `json:"pgid"`
+	Up            []int  `json:"up"`
+	UpPrimary     int    `json:"up_primary"`
+	Acting        []int  `json:"acting"`
+	ActingPrimary int    `json:"acting_primary"`
+}
+
+// ObjectMap returns the PG and OSDs that an object in the given pool and
+// namespace is mapped to. The object does not need to exist.
+//
+// Similar To:
+//
+//	ceph osd map <pool> <object> [<namespace>]
+func (pa *PGAdmin) ObjectMap(pool, namespace, object string) (*ObjectMapping, error) {
+	m := map[string]string{
+		"prefix": "osd map",
+		"pool":   pool,
+		"object": object,
+		"format": "json",
+	}
+	if namespace != "" {
+		m["nspace"] = namespace
+	}
+	om := &ObjectMapping{}
+	res := commands.MarshalMonCommand(pa.conn, m)
+	if err := res.NoStatus().Unmarshal(om).End(); err != nil {
+		return nil, err
+	}
+	return om, nil
+}
@@ -0,0 +1,113 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// PGHistory contains the epochs of significant events in the history of a
+// PG.
+type PGHistory struct {
+	EpochCreated      uint64 `json:"epoch_created"`
+	LastEpochStarted  uint64 `json:"last_epoch_started"`
+	LastEpochClean    uint64 `json:"last_epoch_clean"`
+	SameUpSince       uint64 `json:"same_up_since"`
+	SameIntervalSince uint64 `json:"same_interval_since"`
+	SamePrimarySince  uint64 `json:"same_primary_since"`
+}
+
+// PGInfo contains the information a PG instance keeps about itself.
+type PGInfo struct {
+	PGID         string    `json:"pgid"`
+	LastUpdate   string    `json:"last_update"`
+	LastComplete string    `json:"last_complete"`
+	LogTail      string    `json:"log_tail"`
+	Stats        PGStat    `json:"stats"`
+	History      PGHistory `json:"history"`
+}
+
+// PGPeerInfo contains the information the primary of a PG has about a peer.
+type PGPeerInfo struct {
+	// Peer is the OSD of the peer, with the shard appended for erasure
+	// coded pools, such as "2" or "2(1)".
+	Peer         string `json:"peer"`
+	PGID         string `json:"pgid"`
+	LastUpdate   string `json:"last_update"`
+	LastComplete string `json:"last_complete"`
+	Stats        PGStat `json:"stats"`
+}
+
+// PeeringBlocker identifies an OSD that blocks the peering of a PG.
+type PeeringBlocker struct {
+	OSD           int    `json:"osd"`
+	CurrentLostAt uint64 `json:"current_lost_at"`
+	Comment       string `json:"comment"`
+}
+
+// UnfoundLocation is an OSD that may have unfound objects, along with the
+// state of the search.
+type UnfoundLocation struct {
+	OSD    string `json:"osd"`
+	Status string `json:"status"`
+}
+
+// RecoveryProgress describes the progress of the recovery and backfill of an
+// active PG.
+type RecoveryProgress struct {
+	BackfillTargets     []string `json:"backfill_targets"`
+	WaitingOnBackfill   []string `json:"waiting_on_backfill"`
+	LastBackfillStarted string   `json:"last_backfill_started"`
+	Recovering          []string `json:"recovering"`
+}
+
+// RecoveryState is one of the nested states of the peering state machine of
+// a PG, such as "Started/Primary/Peering/Down". The fields other than Name
+// and EnterTime are only set by the states they apply to.
+type RecoveryState struct {
+	Name      string    `json:"name"`
+	EnterTime TimeStamp `json:"enter_time"`
+	Comment   string    `json:"comment"`
+	// Blocked explains why peering can not proceed.
+	Blocked              string            `json:"blocked"`
+	DownOSDsWeWouldProbe []int             `json:"down_osds_we_would_probe"`
+	PeeringBlockedBy     []PeeringBlocker  `json:"peering_blocked_by"`
+	MightHaveUnfound     []UnfoundLocation `json:"might_have_unfound"`
+	RecoveryProgress     *RecoveryProgress `json:"recovery_progress"`
+}
+
+// PGQuery is the detailed state of a PG as reported by its primary OSD.
+type PGQuery struct {
+	State  string `json:"state"`
+	Epoch  uint64 `json:"epoch"`
+	Up     []int  `json:"up"`
+	Acting []int  `json:"acting"`
+	// ActingRecoveryBackfill lists the OSDs, and shards, taking part in the
+	// recovery or backfill of the PG.
+	ActingRecoveryBackfill []string        `json:"acting_recovery_backfill"`
+	SnapTrimQLen           int             `json:"snap_trimq_len"`
+	Info                   PGInfo          `json:"info"`
+	PeerInfo               []PGPeerInfo    `json:"peer_info"`
+	RecoveryState          []RecoveryState `json:"recovery_state"`
+}
+
+// Query returns the detailed state of a PG, including the state of its
+// recovery. Querying a PG that has no active primary blocks until the
+// command times out.
+//
+// Similar To:
+//
+//	ceph pg <pgid> query
+func (pa *PGAdmin) Query(pgid string) (*PGQuery, error) {
+	m := map[string]string{
+		"prefix": "query",
+		"pgid":   pgid,
+		"format": "json",
+	}
+	q := &PGQuery{}
+	res := commands.MarshalPGCommand(pa.conn, pgid, m)
+	if err := res.NoStatus().Unmarshal(q).End(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
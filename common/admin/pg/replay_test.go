@@ -0,0 +1,192 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/internal/admintest"
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+const goldenFile = "testdata/replay_pg.json"
+
+var errNoEnt = errutil.GetError("rados", -2)
+
+func TestPGDump(t *testing.T) {
+	pa := NewFromConn(admintest.Golden(t, goldenFile))
+	d, err := pa.PGDump()
+	require.NoError(t, err)
+	assert.True(t, d.PGReady)
+	assert.Equal(t, uint64(1211), d.PGMap.Version)
+	assert.Equal(t, int64(1694509331), d.PGMap.Stamp.Unix())
+	if assert.Len(t, d.PGMap.PGStats, 3) {
+		pg := d.PGMap.PGStats[1]
+		assert.Equal(t, "2.7", pg.PGID)
+		assert.Equal(t, "active+recovering+undersized+degraded", pg.State)
+		assert.Equal(t, "29'2", pg.Version)
+		assert.Equal(t, int64(2), pg.StatSum.NumObjectsDegraded)
+		assert.Equal(t, int64(8388608), pg.StatSum.NumBytes)
+		assert.Equal(t, []int{2, 1}, pg.Up)
+		assert.Equal(t, 2, pg.ActingPrimary)
+	}
+	if assert.Len(t, d.PGMap.PoolStats, 2) {
+		assert.Equal(t, int64(2), d.PGMap.PoolStats[0].PoolID)
+		assert.Equal(t, 2, d.PGMap.PoolStats[0].NumPG)
+		assert.Equal(t, int64(3), d.PGMap.PoolStats[0].StatSum.NumObjects)
+	}
+	if assert.Len(t, d.PGMap.OSDStats, 3) {
+		assert.Equal(t, 1, d.PGMap.OSDStats[1].OSD)
+		assert.Equal(t, []int{0, 2}, d.PGMap.OSDStats[1].HBPeers)
+		assert.Equal(t, uint64(9412608), d.PGMap.OSDStats[1].KBAvail)
+		assert.Equal(t, float64(3), d.PGMap.OSDStats[1].PerfStat.CommitLatencyMs)
+	}
+}
+
+func TestPGDumpBrief(t *testing.T) {
+	pa := NewFromConn(admintest.Golden(t, goldenFile))
+	expected := []PGBrief{
+		{"2.6", "active+clean", []int{1, 0, 2}, 1, []int{1, 0, 2}, 1},
+		{"2.7", "active+recovering+undersized+degraded", []int{2, 1}, 2, []int{2, 1}, 2},
+		{"1.0", "active+clean", []int{0, 2, 1}, 0, []int{0, 2, 1}, 0},
+	}
+	pgs, err := pa.PGDumpBrief()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, pgs)
+}
+
+func TestListPGs(t *testing.T) {
+	c := admintest.Golden(t, goldenFile)
+	pa := NewFromConn(c)
+	pgids := func(pgs []PGStat) []string {
+		var out []string
+		for _, pg := range pgs {
+			out = append(out, pg.PGID)
+		}
+		return out
+	}
+
+	pgs, err := pa.ListPGs(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2.6", "2.7", "1.0"}, pgids(pgs))
+
+	pgs, err = pa.ListPGs(&ListPGsOptions{
+		Pool:   "rbd",
+		States: []string{"degraded"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2.7"}, pgids(pgs))
+
+	osd := 1
+	pgs, err = pa.ListPGs(&ListPGsOptions{OSD: &osd, Primary: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2.6"}, pgids(pgs))
+
+	osd = 2
+	pgs, err = pa.ListPGs(&ListPGsOptions{
+		OSD:    &osd,
+		States: []string{"undersized", "degraded"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2.7"}, pgids(pgs))
+
+	_, err = pa.ListPGs(&ListPGsOptions{Pool: "nope"})
+	assert.ErrorIs(t, err, errNoEnt)
+
+	_, err = pa.ListPGs(&ListPGsOptions{Pool: "rbd", OSD: &osd})
+	assert.ErrorIs(t, err, errPoolAndOSD)
+
+	for _, rec := range admintest.Unused(c) {
+		assert.NotContains(t, string(rec.Request[0]), "pg ls")
+	}
+}
+
+func TestPGMap(t *testing.T) {
+	pa := NewFromConn(admintest.Golden(t, goldenFile))
+	pm, err := pa.PGMap("2.6")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(31), pm.Epoch)
+	assert.Equal(t, "2.6", pm.PGID)
+	assert.Equal(t, []int{1, 0, 2}, pm.Up)
+	assert.Equal(t, []int{1, 0, 2}, pm.Acting)
+}
+
+func TestObjectMap(t *testing.T) {
+	pa := NewFromConn(admintest.Golden(t, goldenFile))
+	om, err := pa.ObjectMap("rbd", "ns1", "obj1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), om.PoolID)
+	assert.Equal(t, "ns1/obj1", om.ObjectName)
+	assert.Equal(t, "2.b4c5fe66", om.RawPGID)
+	assert.Equal(t, "2.6", om.PGID)
+	assert.Equal(t, 1, om.UpPrimary)
+	assert.Equal(t, []int{1, 0, 2}, om.Acting)
+
+	_, err = pa.ObjectMap("nope", "", "obj1")
+	assert.ErrorIs(t, err, errNoEnt)
+}
+
+func TestQuery(t *testing.T) {
+	pa := NewFromConn(admintest.Golden(t, goldenFile))
+
+	q, err := pa.Query("2.6")
+	require.NoError(t, err)
+	assert.Equal(t, "active+clean", q.State)
+	assert.Equal(t, uint64(31), q.Epoch)
+	assert.Equal(t, []string{"0", "1", "2"}, q.ActingRecoveryBackfill)
+	assert.Equal(t, "2.6", q.Info.PGID)
+	assert.Equal(t, "29'1", q.Info.LastUpdate)
+	assert.Equal(t, uint64(27), q.Info.History.SameIntervalSince)
+	assert.Equal(t, int64(1), q.Info.Stats.StatSum.NumObjects)
+	assert.Len(t, q.PeerInfo, 2)
+	if assert.Len(t, q.RecoveryState, 2) {
+		rs := q.RecoveryState[0]
+		assert.Equal(t, "Started/Primary/Active", rs.Name)
+		assert.Equal(t, int64(1694508069), rs.EnterTime.Unix())
+		if assert.NotNil(t, rs.RecoveryProgress) {
+			assert.Equal(t, "MIN", rs.RecoveryProgress.LastBackfillStarted)
+		}
+		assert.Nil(t, q.RecoveryState[1].RecoveryProgress)
+	}
+
+	q, err = pa.Query("3.1")
+	require.NoError(t, err)
+	assert.Equal(t, "down", q.State)
+	assert.Equal(t, []int{1}, q.Info.Stats.BlockedBy)
+	if assert.Len(t, q.RecoveryState, 3) {
+		assert.Equal(t, "not enough up instances of this PG to go active",
+			q.RecoveryState[0].Comment)
+		rs := q.RecoveryState[1]
+		assert.Equal(t, "peering is blocked due to down osds", rs.Blocked)
+		assert.Equal(t, []int{1}, rs.DownOSDsWeWouldProbe)
+		if assert.Len(t, rs.PeeringBlockedBy, 1) {
+			assert.Equal(t, 1, rs.PeeringBlockedBy[0].OSD)
+		}
+	}
+
+	q, err = pa.Query("2.7")
+	require.NoError(t, err)
+	assert.Equal(t, "active+recovering+undersized+degraded", q.State)
+	assert.Equal(t, "29'1", q.Info.LastComplete)
+	assert.Equal(t, int64(1), q.Info.Stats.StatSum.NumObjectsMissingOnPrimary)
+	if assert.Len(t, q.RecoveryState, 2) {
+		rs := q.RecoveryState[0]
+		assert.Equal(t, "Started/Primary/Active", rs.Name)
+		assert.Equal(t,
+			[]UnfoundLocation{{OSD: "1", Status: "already probed"}},
+			rs.MightHaveUnfound)
+		if assert.NotNil(t, rs.RecoveryProgress) {
+			assert.Empty(t, rs.RecoveryProgress.BackfillTargets)
+			assert.Equal(t,
+				[]string{"2:e6a4b8c3:::rbd_data.10a96b8b4567.0000000000000001:head"},
+				rs.RecoveryProgress.Recovering)
+		}
+	}
+
+	// the pool does not exist
+	_, err = pa.Query("9.9")
+	assert.ErrorIs(t, err, errNoEnt)
+}
@@ -0,0 +1,119 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// pgTimeLayouts are the layouts of the time stamps of PG statistics. Newer
+// versions of Ceph include the time zone offset.
+var pgTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999-0700",
+	"2006-01-02 15:04:05.999999",
+}
+
+// TimeStamp abstracts some of the details about date+time stamps returned
+// by ceph via JSON.
+type TimeStamp struct {
+	time.Time
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (ts *TimeStamp) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	var (
+		t   time.Time
+		err error
+	)
+	for _, layout := range pgTimeLayouts {
+		if t, err = time.Parse(layout, raw); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	*ts = TimeStamp{t}
+	return nil
+}
+
+// StatSum contains the object and I/O statistics of a PG or a pool.
+type StatSum struct {
+	NumBytes                   int64 `json:"num_bytes"`
+	NumObjects                 int64 `json:"num_objects"`
+	NumObjectClones            int64 `json:"num_object_clones"`
+	NumObjectCopies            int64 `json:"num_object_copies"`
+	NumObjectsMissingOnPrimary int64 `json:"num_objects_missing_on_primary"`
+	NumObjectsMissing          int64 `json:"num_objects_missing"`
+	NumObjectsDegraded         int64 `json:"num_objects_degraded"`
+	NumObjectsMisplaced        int64 `json:"num_objects_misplaced"`
+	NumObjectsUnfound          int64 `json:"num_objects_unfound"`
+	NumRead                    int64 `json:"num_read"`
+	NumReadKB                  int64 `json:"num_read_kb"`
+	NumWrite                   int64 `json:"num_write"`
+	NumWriteKB                 int64 `json:"num_write_kb"`
+	NumScrubErrors             int64 `json:"num_scrub_errors"`
+	NumObjectsRecovered        int64 `json:"num_objects_recovered"`
+	NumBytesRecovered          int64 `json:"num_bytes_recovered"`
+}
+
+// PGStat contains the state and statistics of a placement group.
+type PGStat struct {
+	PGID string `json:"pgid"`
+	// Version is the last update of the PG, in the form "epoch'version".
+	Version            string    `json:"version"`
+	ReportedEpoch      uint64    `json:"reported_epoch"`
+	State              string    `json:"state"`
+	LastFresh          TimeStamp `json:"last_fresh"`
+	LastChange         TimeStamp `json:"last_change"`
+	LastActive         TimeStamp `json:"last_active"`
+	LastPeered         TimeStamp `json:"last_peered"`
+	LastClean          TimeStamp `json:"last_clean"`
+	LastUnstale        TimeStamp `json:"last_unstale"`
+	LastUndegraded     TimeStamp `json:"last_undegraded"`
+	LastScrub          string    `json:"last_scrub"`
+	LastScrubStamp     TimeStamp `json:"last_scrub_stamp"`
+	LastDeepScrub      string    `json:"last_deep_scrub"`
+	LastDeepScrubStamp TimeStamp `json:"last_deep_scrub_stamp"`
+	LogSize            int64     `json:"log_size"`
+	OndiskLogSize      int64     `json:"ondisk_log_size"`
+	StatSum            StatSum   `json:"stat_sum"`
+	Up                 []int     `json:"up"`
+	Acting             []int     `json:"acting"`
+	UpPrimary          int       `json:"up_primary"`
+	ActingPrimary      int       `json:"acting_primary"`
+	// BlockedBy lists the OSDs that block the peering of the PG.
+	BlockedBy    []int `json:"blocked_by"`
+	SnapTrimQLen int   `json:"snaptrimq_len"`
+}
+
+// PGBrief contains the state and mapping of a placement group.
+type PGBrief struct {
+	PGID          string `json:"pgid"`
+	State         string `json:"state"`
+	Up            []int  `json:"up"`
+	UpPrimary     int    `json:"up_primary"`
+	Acting        []int  `json:"acting"`
+	ActingPrimary int    `json:"acting_primary"`
+}
+
+// unmarshalPGStats parses a list of PG statistics into v, which must be a
+// pointer to a slice. Newer versions of ceph wrap the list in an object,
+// older versions return a plain array.
+func unmarshalPGStats(b []byte, v interface{}) error {
+	var wrapped struct {
+		PGStats json.RawMessage `json:"pg_stats"`
+	}
+	if err := json.Unmarshal(b, &wrapped); err == nil {
+		if wrapped.PGStats == nil {
+			return nil
+		}
+		return json.Unmarshal(wrapped.PGStats, v)
+	}
+	return json.Unmarshal(b, v)
+}
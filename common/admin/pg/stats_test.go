@@ -0,0 +1,40 @@
+//go:build ceph_preview
+
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalPGStats(t *testing.T) {
+	expected := []PGBrief{
+		{"2.6", "active+clean", []int{1, 0, 2}, 1, []int{1, 0, 2}, 1},
+		{"2.7", "active+undersized+degraded", []int{2, 1}, 2, []int{2, 1}, 2},
+	}
+	t.Run("wrapped", func(t *testing.T) {
+		var pgs []PGBrief
+		err := unmarshalPGStats([]byte(`{"pg_ready":true,"pg_stats":[
+			{"pgid":"2.6","state":"active+clean","up":[1,0,2],"up_primary":1,"acting":[1,0,2],"acting_primary":1},
+			{"pgid":"2.7","state":"active+undersized+degraded","up":[2,1],"up_primary":2,"acting":[2,1],"acting_primary":2}
+		]}`), &pgs)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, pgs)
+	})
+	t.Run("plainArray", func(t *testing.T) {
+		// older versions of ceph return a plain array
+		var pgs []PGBrief
+		err := unmarshalPGStats([]byte(`[
+			{"pgid":"2.6","state":"active+clean","up":[1,0,2],"up_primary":1,"acting":[1,0,2],"acting_primary":1},
+			{"pgid":"2.7","state":"active+undersized+degraded","up":[2,1],"up_primary":2,"acting":[2,1],"acting_primary":2}
+		]`), &pgs)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, pgs)
+	})
+	t.Run("noStats", func(t *testing.T) {
+		var pgs []PGBrief
+		assert.NoError(t, unmarshalPGStats([]byte(`{"pg_ready":false}`), &pgs))
+		assert.Nil(t, pgs)
+	})
+}
@@ -0,0 +1,231 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+// FaultBody selects how a Fault alters the body of a response.
+type FaultBody int
+
+const (
+	// BodyUnchanged returns the response body as-is.
+	BodyUnchanged FaultBody = iota
+	// BodyTruncated returns only the first half of the response body.
+	BodyTruncated
+	// BodyMalformed replaces the response body with invalid JSON.
+	BodyMalformed
+	// BodyEmpty returns an empty response body. Combined with a Status this
+	// produces a status-only response.
+	BodyEmpty
+)
+
+// malformedBody is returned in place of the response body by BodyMalformed.
+var malformedBody = []byte(`{"malformed": [`)
+
+// Fault describes how a FaultCommander alters the result of a command.
+type Fault struct {
+	// Delay is the time to wait before the command is executed.
+	Delay time.Duration
+	// Errno, if non-zero, causes the command to fail with the given (negative)
+	// error number without being passed on to the wrapped RadosCommander.
+	Errno int
+	// Status, if set, replaces the status string of the response.
+	Status string
+	// Body controls how the body of the response is altered.
+	Body FaultBody
+}
+
+// FaultRule selects the commands a Fault is injected into.
+// A rule matches a command if both the Kind and the Prefix match. A rule that
+// matches a command injects its Fault if the number of the match is listed in
+// Sequence or, if Sequence is empty, with the given Probability. If neither
+// Sequence nor Probability are set, the Fault is injected into every matching
+// command.
+type FaultRule struct {
+	// Kind is MonCommandKind, MgrCommandKind, OsdCommandKind or
+	// PgCommandKind. If empty, the rule matches all kinds of commands.
+	// Commands sent to a specific mon are of kind MonCommandKind.
+	Kind string
+	// Prefix matches commands whose "prefix" value starts with the given
+	// string. If empty, the rule matches all commands.
+	Prefix string
+	// Sequence is a list of 1-based match numbers the fault is injected at.
+	// For example, the value []int{2, 3} injects the fault into the second
+	// and third matching command only.
+	Sequence []int
+	// Probability is the chance, between 0 and 1, that the fault is injected
+	// into a matching command.
+	Probability float64
+	// Fault is the fault to inject.
+	Fault Fault
+}
+
+type faultRuleState struct {
+	FaultRule
+	matched  int
+	injected int
+}
+
+// FaultCommander is a RadosCommander that wraps another RadosCommander and
+// injects faults, such as errors, delays and broken responses, into the
+// commands passing through it. It is meant to test the error handling of
+// code that uses a RadosCommander. If the wrapped RadosCommander implements
+// OSDCommander, PGCommander or MonTargetCommander, faults can be injected into
+// the respective commands, too.
+type FaultCommander struct {
+	conn  RadosCommander
+	mutex sync.Mutex
+	rules []*faultRuleState
+	rand  *rand.Rand
+}
+
+// NewFaultCommander returns a FaultCommander that wraps the given
+// RadosCommander and applies the given rules, in order, to each command. The
+// first rule that fires determines the fault injected into the command.
+func NewFaultCommander(c RadosCommander, rules ...FaultRule) *FaultCommander {
+	fc := &FaultCommander{
+		conn: c,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, r := range rules {
+		fc.rules = append(fc.rules, &faultRuleState{FaultRule: r})
+	}
+	return fc
+}
+
+// Seed sets the seed of the random number generator used for rules with a
+// Probability. Use it to make fault injection reproducible.
+func (fc *FaultCommander) Seed(seed int64) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.rand = rand.New(rand.NewSource(seed))
+}
+
+// Injected returns the number of faults injected by the rule at the given
+// index.
+func (fc *FaultCommander) Injected(index int) int {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.rules[index].injected
+}
+
+// MgrCommand sends a command to the MGR using the wrapped RadosCommander,
+// possibly injecting a fault.
+func (fc *FaultCommander) MgrCommand(buf [][]byte) ([]byte, string, error) {
+	return fc.run(MgrCommandKind, firstBuf(buf), func() ([]byte, string, error) {
+		return fc.conn.MgrCommand(buf)
+	})
+}
+
+// MonCommand sends a command to the MON(s) using the wrapped RadosCommander,
+// possibly injecting a fault.
+func (fc *FaultCommander) MonCommand(buf []byte) ([]byte, string, error) {
+	return fc.run(MonCommandKind, buf, func() ([]byte, string, error) {
+		return fc.conn.MonCommand(buf)
+	})
+}
+
+// run executes cmd, unless a fault of the rules matching the command of the
+// given kind replaces or alters its result.
+func (fc *FaultCommander) run(kind string, buf []byte,
+	cmd func() ([]byte, string, error)) ([]byte, string, error) {
+
+	f := fc.selectFault(kind, buf)
+	if f == nil {
+		return cmd()
+	}
+	return f.apply(cmd)
+}
+
+// firstBuf returns the first buffer of a multi-buffer command, which holds
+// the JSON command, or nil if there is none.
+func firstBuf(buf [][]byte) []byte {
+	if len(buf) == 0 {
+		return nil
+	}
+	return buf[0]
+}
+
+// commandPrefix returns the "prefix" value of a JSON command or an empty
+// string if the buffer is not a JSON object containing a prefix.
+func commandPrefix(buf []byte) string {
+	var cmd struct {
+		Prefix string `json:"prefix"`
+	}
+	if err := json.Unmarshal(buf, &cmd); err != nil {
+		return ""
+	}
+	return cmd.Prefix
+}
+
+func (fc *FaultCommander) selectFault(kind string, buf []byte) *Fault {
+	prefix := commandPrefix(buf)
+
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	var selected *Fault
+	for _, r := range fc.rules {
+		if r.Kind != "" && r.Kind != kind {
+			continue
+		}
+		if !strings.HasPrefix(prefix, r.Prefix) {
+			continue
+		}
+		r.matched++
+		if selected == nil && fc.fires(r) {
+			r.injected++
+			selected = &r.Fault
+		}
+	}
+	return selected
+}
+
+func (fc *FaultCommander) fires(r *faultRuleState) bool {
+	switch {
+	case len(r.Sequence) > 0:
+		for _, n := range r.Sequence {
+			if n == r.matched {
+				return true
+			}
+		}
+		return false
+	case r.Probability > 0:
+		return fc.rand.Float64() < r.Probability
+	}
+	return true
+}
+
+func (f *Fault) apply(
+	cmd func() ([]byte, string, error)) ([]byte, string, error) {
+
+	if f.Delay > 0 {
+		time.Sleep(f.Delay)
+	}
+	if f.Errno != 0 {
+		return nil, f.Status, errutil.GetError("rados", f.Errno)
+	}
+	b, s, err := cmd()
+	if err != nil {
+		return b, s, err
+	}
+	switch f.Body {
+	case BodyTruncated:
+		b = b[:len(b)/2]
+	case BodyMalformed:
+		b = append([]byte(nil), malformedBody...)
+	case BodyEmpty:
+		b = nil
+	}
+	if f.Status != "" {
+		s = f.Status
+	}
+	return b, s, nil
+}
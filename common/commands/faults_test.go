@@ -0,0 +1,250 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	volumeLsCmd = `{"prefix": "fs volume ls", "format": "json"}`
+	getPathCmd  = `{"prefix": "fs subvolume getpath", "vol_name": "cephfs"}`
+)
+
+func TestFaultCommander(t *testing.T) {
+	t.Run("noRules", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander())
+		b, s, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+		assert.Equal(t, "", s)
+	})
+
+	t.Run("errno", func(t *testing.T) {
+		fake := newFakeCommander()
+		fc := NewFaultCommander(fake, FaultRule{
+			Prefix: "fs volume",
+			Fault:  Fault{Errno: -5, Status: "injected"},
+		})
+		b, s, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.Error(t, err)
+		var ec errorCoder
+		if assert.True(t, errors.As(err, &ec)) {
+			assert.Equal(t, -5, ec.ErrorCode())
+		}
+		assert.Nil(t, b)
+		assert.Equal(t, "injected", s)
+		assert.Equal(t, 0, fake.calls)
+
+		// prefix does not match
+		_, _, err = fc.MgrCommand([][]byte{[]byte(getPathCmd)})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, fake.calls)
+		assert.Equal(t, 1, fc.Injected(0))
+	})
+
+	t.Run("kind", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(), FaultRule{
+			Kind:  MonCommandKind,
+			Fault: Fault{Errno: -5},
+		})
+		_, _, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.NoError(t, err)
+		_, _, err = fc.MonCommand([]byte(volumeLsCmd))
+		assert.Error(t, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(), FaultRule{
+			Fault: Fault{Body: BodyTruncated},
+		})
+		b, _, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"name": `, string(b))
+		var v interface{}
+		assert.Error(t, json.Unmarshal(b, &v))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(), FaultRule{
+			Fault: Fault{Body: BodyMalformed},
+		})
+		b, _, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.NoError(t, err)
+		var v interface{}
+		assert.Error(t, json.Unmarshal(b, &v))
+	})
+
+	t.Run("statusOnly", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(), FaultRule{
+			Fault: Fault{Body: BodyEmpty, Status: "nothing to see"},
+		})
+		b, s, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.NoError(t, err)
+		assert.Len(t, b, 0)
+		assert.Equal(t, "nothing to see", s)
+	})
+
+	t.Run("underlyingError", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(), FaultRule{
+			Fault: Fault{Body: BodyMalformed},
+		})
+		_, _, err := fc.MonCommand([]byte(`FOOBAR!`))
+		assert.EqualError(t, err, "no way")
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(), FaultRule{
+			Fault: Fault{Delay: 20 * time.Millisecond},
+		})
+		start := time.Now()
+		_, _, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(), FaultRule{
+			Prefix:   "fs volume ls",
+			Sequence: []int{2, 4},
+			Fault:    Fault{Errno: -11},
+		})
+		var failed []int
+		for i := 1; i <= 5; i++ {
+			// non-matching commands do not count towards the sequence
+			_, _, err := fc.MgrCommand([][]byte{[]byte(getPathCmd)})
+			assert.NoError(t, err)
+			_, _, err = fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+			if err != nil {
+				failed = append(failed, i)
+			}
+		}
+		assert.Equal(t, []int{2, 4}, failed)
+		assert.Equal(t, 2, fc.Injected(0))
+	})
+
+	t.Run("firstRuleWins", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander(),
+			FaultRule{Sequence: []int{1}, Fault: Fault{Errno: -5}},
+			FaultRule{Fault: Fault{Body: BodyEmpty, Status: "second"}},
+		)
+		_, _, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.Error(t, err)
+		_, s, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+		assert.NoError(t, err)
+		assert.Equal(t, "second", s)
+		assert.Equal(t, 1, fc.Injected(0))
+		assert.Equal(t, 1, fc.Injected(1))
+	})
+
+	t.Run("probability", func(t *testing.T) {
+		run := func(seed int64) []bool {
+			fc := NewFaultCommander(newFakeCommander(), FaultRule{
+				Probability: 0.5,
+				Fault:       Fault{Errno: -5},
+			})
+			fc.Seed(seed)
+			out := make([]bool, 100)
+			for i := range out {
+				_, _, err := fc.MgrCommand([][]byte{[]byte(volumeLsCmd)})
+				out[i] = err != nil
+			}
+			return out
+		}
+		r1 := run(42)
+		assert.Equal(t, r1, run(42))
+		n := 0
+		for _, failed := range r1 {
+			if failed {
+				n++
+			}
+		}
+		assert.Greater(t, n, 10)
+		assert.Less(t, n, 90)
+	})
+}
+
+// fakeTargetCommander implements all the commander interfaces supported by
+// the FaultCommander.
+type fakeTargetCommander struct {
+	fakeOSDCommander
+}
+
+func (f *fakeTargetCommander) PGCommand(pgid []byte, buf [][]byte) ([]byte, string, error) {
+	return f.respond(buf[0])
+}
+
+func (f *fakeTargetCommander) MonCommandTarget(
+	name string, buf [][]byte) ([]byte, string, error) {
+
+	return f.respond(buf[0])
+}
+
+func TestFaultCommanderTargets(t *testing.T) {
+	req := [][]byte{[]byte(volumeLsCmd)}
+	fake := &fakeTargetCommander{
+		fakeOSDCommander: fakeOSDCommander{fakeCommander: *newFakeCommander()},
+	}
+
+	t.Run("osd", func(t *testing.T) {
+		fc := NewFaultCommander(fake, FaultRule{
+			Kind:     OsdCommandKind,
+			Sequence: []int{1},
+			Fault:    Fault{Errno: -5},
+		})
+		_, _, err := fc.OsdCommand(1, req)
+		assert.Error(t, err)
+		b, _, err := fc.OsdCommand(1, req)
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+		assert.Equal(t, 1, fc.Injected(0))
+
+		// other kinds of commands do not match
+		_, _, err = fc.PGCommand([]byte("1.a"), req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("pg", func(t *testing.T) {
+		fc := NewFaultCommander(fake, FaultRule{
+			Kind:   PgCommandKind,
+			Prefix: "fs volume",
+			Fault:  Fault{Body: BodyMalformed},
+		})
+		b, _, err := fc.PGCommand([]byte("1.a"), req)
+		assert.NoError(t, err)
+		assert.Equal(t, string(malformedBody), string(b))
+		assert.Equal(t, 1, fc.Injected(0))
+
+		_, _, err = fc.MonCommandTarget("a", req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("monTarget", func(t *testing.T) {
+		fc := NewFaultCommander(fake, FaultRule{
+			Kind:  MonCommandKind,
+			Fault: Fault{Errno: -2, Status: "injected"},
+		})
+		_, s, err := fc.MonCommandTarget("a", req)
+		assert.Error(t, err)
+		assert.Equal(t, "injected", s)
+		assert.Equal(t, 1, fc.Injected(0))
+
+		_, _, err = fc.OsdCommand(1, req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		fc := NewFaultCommander(newFakeCommander())
+		_, _, err := fc.OsdCommand(1, req)
+		assert.ErrorIs(t, err, errNoOSDCommander)
+		_, _, err = fc.PGCommand([]byte("1.a"), req)
+		assert.ErrorIs(t, err, errNoPGCommander)
+		_, _, err = fc.MonCommandTarget("a", req)
+		assert.ErrorIs(t, err, errNoMonTargetCommander)
+	})
+}
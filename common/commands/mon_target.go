@@ -0,0 +1,66 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"errors"
+)
+
+// MonTargetCommander is an interface for the API needed to execute JSON
+// formatted commands on a specific mon daemon, identified by name.
+type MonTargetCommander interface {
+	MonCommandTarget(name string, buf [][]byte) ([]byte, string, error)
+}
+
+// errNoMonTargetCommander is returned by a RecordingCommander or a
+// FaultCommander asked to send a command to a specific mon if the commander it
+// wraps does not implement MonTargetCommander.
+var errNoMonTargetCommander = errors.New(
+	"wrapped commander does not support targeted mon commands")
+
+// MonCommandTarget sends a command to the named mon using the wrapped
+// commander, which must implement MonTargetCommander, and records the request
+// and response. The record is of kind MonCommandKind, with the name of the
+// mon as its Target.
+func (r *RecordingCommander) MonCommandTarget(
+	name string, buf [][]byte) ([]byte, string, error) {
+
+	mc, ok := r.conn.(MonTargetCommander)
+	if !ok {
+		return nil, "", errNoMonTargetCommander
+	}
+	rec, err := newCommandRecord(MonCommandKind, buf)
+	if err != nil {
+		return nil, "", err
+	}
+	rec.Target = name
+	b, s, err := mc.MonCommandTarget(name, buf)
+	rec.setResponse(b, s, err)
+	if serr := r.save(rec); serr != nil && err == nil {
+		err = serr
+	}
+	return b, s, err
+}
+
+// MonCommandTarget returns the recorded response to the given command sent
+// to the named mon.
+func (r *ReplayCommander) MonCommandTarget(
+	name string, buf [][]byte) ([]byte, string, error) {
+
+	return r.replay(MonCommandKind, name, buf)
+}
+
+// MonCommandTarget sends a command to the named mon using the wrapped
+// commander, which must implement MonTargetCommander, possibly injecting a
+// fault. The command is of kind MonCommandKind.
+func (fc *FaultCommander) MonCommandTarget(
+	name string, buf [][]byte) ([]byte, string, error) {
+
+	mc, ok := fc.conn.(MonTargetCommander)
+	if !ok {
+		return nil, "", errNoMonTargetCommander
+	}
+	return fc.run(MonCommandKind, firstBuf(buf), func() ([]byte, string, error) {
+		return mc.MonCommandTarget(name, buf)
+	})
+}
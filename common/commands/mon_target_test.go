@@ -0,0 +1,61 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMonTargetCommander struct {
+	fakeCommander
+	names []string
+}
+
+func (f *fakeMonTargetCommander) MonCommandTarget(
+	name string, buf [][]byte) ([]byte, string, error) {
+
+	f.names = append(f.names, name)
+	return f.respond(buf[0])
+}
+
+func TestRecordReplayMonTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	fake := &fakeMonTargetCommander{fakeCommander: *newFakeCommander()}
+	rc := NewRecordingCommander(fake, path)
+
+	req := []byte(`{"prefix": "fs volume ls", "format": "json"}`)
+	b, _, err := rc.MonCommandTarget("a", [][]byte{req})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+	assert.Equal(t, []string{"a"}, fake.names)
+	recs := rc.Records()
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, MonCommandKind, recs[0].Kind)
+		assert.Equal(t, "a", recs[0].Target)
+	}
+
+	rp, err := NewReplayCommander(path)
+	require.NoError(t, err)
+	// untargeted commands do not match targeted records
+	_, _, err = rp.MonCommand(req)
+	assert.ErrorIs(t, err, ErrUnexpectedCommand)
+	_, _, err = rp.MonCommandTarget("b", [][]byte{req})
+	assert.ErrorIs(t, err, ErrUnexpectedCommand)
+	assert.Contains(t, err.Error(), "mon.b")
+	b, _, err = rp.MonCommandTarget("a", [][]byte{req})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+	assert.Len(t, rp.Unused(), 0)
+}
+
+func TestRecordMonTargetUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	rc := NewRecordingCommander(newFakeCommander(), path)
+	_, _, err := rc.MonCommandTarget("a", [][]byte{[]byte(`{"prefix": "mon_status"}`)})
+	assert.ErrorIs(t, err, errNoMonTargetCommander)
+	assert.Len(t, rc.Records(), 0)
+}
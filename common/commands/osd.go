@@ -0,0 +1,61 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"errors"
+	"strconv"
+)
+
+// OsdCommandKind identifies a recorded command sent to a specific OSD. The
+// Target of the record is the ID of the OSD.
+const OsdCommandKind = "osd"
+
+// OSDCommander is an interface for the API needed to execute JSON formatted
+// commands on a specific OSD daemon.
+type OSDCommander interface {
+	OsdCommand(osd int, buf [][]byte) ([]byte, string, error)
+}
+
+// errNoOSDCommander is returned by a RecordingCommander or a FaultCommander
+// asked to send an OSD command if the commander it wraps does not implement
+// OSDCommander.
+var errNoOSDCommander = errors.New(
+	"wrapped commander does not support OSD commands")
+
+// OsdCommand sends a command to an OSD using the wrapped commander, which
+// must implement OSDCommander, and records the request and response.
+func (r *RecordingCommander) OsdCommand(osd int, buf [][]byte) ([]byte, string, error) {
+	oc, ok := r.conn.(OSDCommander)
+	if !ok {
+		return nil, "", errNoOSDCommander
+	}
+	rec, err := newCommandRecord(OsdCommandKind, buf)
+	if err != nil {
+		return nil, "", err
+	}
+	rec.Target = strconv.Itoa(osd)
+	b, s, err := oc.OsdCommand(osd, buf)
+	rec.setResponse(b, s, err)
+	if serr := r.save(rec); serr != nil && err == nil {
+		err = serr
+	}
+	return b, s, err
+}
+
+// OsdCommand returns the recorded response to the given OSD command.
+func (r *ReplayCommander) OsdCommand(osd int, buf [][]byte) ([]byte, string, error) {
+	return r.replay(OsdCommandKind, strconv.Itoa(osd), buf)
+}
+
+// OsdCommand sends a command to an OSD using the wrapped commander, which
+// must implement OSDCommander, possibly injecting a fault.
+func (fc *FaultCommander) OsdCommand(osd int, buf [][]byte) ([]byte, string, error) {
+	oc, ok := fc.conn.(OSDCommander)
+	if !ok {
+		return nil, "", errNoOSDCommander
+	}
+	return fc.run(OsdCommandKind, firstBuf(buf), func() ([]byte, string, error) {
+		return oc.OsdCommand(osd, buf)
+	})
+}
@@ -0,0 +1,60 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOSDCommander struct {
+	fakeCommander
+	osds []int
+}
+
+func (f *fakeOSDCommander) OsdCommand(osd int, buf [][]byte) ([]byte, string, error) {
+	f.osds = append(f.osds, osd)
+	return f.respond(buf[0])
+}
+
+func TestRecordReplayOsd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	fake := &fakeOSDCommander{fakeCommander: *newFakeCommander()}
+	rc := NewRecordingCommander(fake, path)
+
+	req := []byte(`{"prefix": "fs volume ls", "format": "json"}`)
+	b, _, err := rc.OsdCommand(3, [][]byte{req})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+	assert.Equal(t, []int{3}, fake.osds)
+	recs := rc.Records()
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, OsdCommandKind, recs[0].Kind)
+		assert.Equal(t, "3", recs[0].Target)
+	}
+
+	rp, err := NewReplayCommander(path)
+	require.NoError(t, err)
+	// the target must match
+	_, _, err = rp.OsdCommand(4, [][]byte{req})
+	assert.ErrorIs(t, err, ErrUnexpectedCommand)
+	assert.Contains(t, err.Error(), "osd.4")
+	// as must the kind
+	_, _, err = rp.MgrCommand([][]byte{req})
+	assert.ErrorIs(t, err, ErrUnexpectedCommand)
+	b, _, err = rp.OsdCommand(3, [][]byte{req})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+	assert.Len(t, rp.Unused(), 0)
+}
+
+func TestRecordOsdUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	rc := NewRecordingCommander(newFakeCommander(), path)
+	_, _, err := rc.OsdCommand(0, [][]byte{[]byte(`{"prefix": "bench"}`)})
+	assert.ErrorIs(t, err, errNoOSDCommander)
+	assert.Len(t, rc.Records(), 0)
+}
@@ -0,0 +1,60 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"errors"
+)
+
+// PgCommandKind identifies a recorded command sent to a specific placement
+// group. The Target of the record is the ID of the PG.
+const PgCommandKind = "pg"
+
+// PGCommander is an interface for the API needed to execute JSON formatted
+// commands on a specific placement group.
+type PGCommander interface {
+	PGCommand(pgid []byte, buf [][]byte) ([]byte, string, error)
+}
+
+// errNoPGCommander is returned by a RecordingCommander or a FaultCommander
+// asked to send a PG command if the commander it wraps does not implement
+// PGCommander.
+var errNoPGCommander = errors.New(
+	"wrapped commander does not support PG commands")
+
+// PGCommand sends a command to a PG using the wrapped commander, which must
+// implement PGCommander, and records the request and response.
+func (r *RecordingCommander) PGCommand(pgid []byte, buf [][]byte) ([]byte, string, error) {
+	pc, ok := r.conn.(PGCommander)
+	if !ok {
+		return nil, "", errNoPGCommander
+	}
+	rec, err := newCommandRecord(PgCommandKind, buf)
+	if err != nil {
+		return nil, "", err
+	}
+	rec.Target = string(pgid)
+	b, s, err := pc.PGCommand(pgid, buf)
+	rec.setResponse(b, s, err)
+	if serr := r.save(rec); serr != nil && err == nil {
+		err = serr
+	}
+	return b, s, err
+}
+
+// PGCommand returns the recorded response to the given PG command.
+func (r *ReplayCommander) PGCommand(pgid []byte, buf [][]byte) ([]byte, string, error) {
+	return r.replay(PgCommandKind, string(pgid), buf)
+}
+
+// PGCommand sends a command to a PG using the wrapped commander, which must
+// implement PGCommander, possibly injecting a fault.
+func (fc *FaultCommander) PGCommand(pgid []byte, buf [][]byte) ([]byte, string, error) {
+	pc, ok := fc.conn.(PGCommander)
+	if !ok {
+		return nil, "", errNoPGCommander
+	}
+	return fc.run(PgCommandKind, firstBuf(buf), func() ([]byte, string, error) {
+		return pc.PGCommand(pgid, buf)
+	})
+}
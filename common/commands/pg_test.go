@@ -0,0 +1,46 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePGCommander struct {
+	fakeCommander
+}
+
+func (f *fakePGCommander) PGCommand(pgid []byte, buf [][]byte) ([]byte, string, error) {
+	return f.respond(buf[0])
+}
+
+func TestRecordReplayPG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	rc := NewRecordingCommander(
+		&fakePGCommander{fakeCommander: *newFakeCommander()}, path)
+
+	req := []byte(`{"prefix": "fs volume ls", "format": "json"}`)
+	_, _, err := rc.PGCommand([]byte("1.a"), [][]byte{req})
+	assert.NoError(t, err)
+	recs := rc.Records()
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, PgCommandKind, recs[0].Kind)
+		assert.Equal(t, "1.a", recs[0].Target)
+	}
+
+	rp, err := NewReplayCommander(path)
+	require.NoError(t, err)
+	_, _, err = rp.PGCommand([]byte("1.b"), [][]byte{req})
+	assert.ErrorIs(t, err, ErrUnexpectedCommand)
+	b, _, err := rp.PGCommand([]byte("1.a"), [][]byte{req})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+
+	rc = NewRecordingCommander(newFakeCommander(), path)
+	_, _, err = rc.PGCommand([]byte("1.a"), [][]byte{req})
+	assert.ErrorIs(t, err, errNoPGCommander)
+}
@@ -0,0 +1,175 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+const (
+	// MonCommandKind identifies a recorded command sent to the MON(s).
+	MonCommandKind = "mon"
+	// MgrCommandKind identifies a recorded command sent to the MGR.
+	MgrCommandKind = "mgr"
+)
+
+// CommandRecord captures a single command and the response to it. A list of
+// CommandRecord values is stored as an (indented) JSON array in the golden
+// files written by a RecordingCommander and read by a ReplayCommander.
+type CommandRecord struct {
+	// Kind is MonCommandKind, MgrCommandKind, OsdCommandKind or PgCommandKind.
+	Kind string `json:"kind"`
+	// Target identifies the daemon a targeted command was sent to, such as
+	// the ID of the OSD of an OsdCommandKind command or the name of the mon
+	// of a MonCommandKind command sent to a specific mon.
+	Target string `json:"target,omitempty"`
+	// Request contains one item for every buffer passed to the command.
+	// Buffers that are valid JSON are stored as-is, all others are stored
+	// as JSON strings.
+	Request []json.RawMessage `json:"request"`
+	// Body is the response body returned by ceph.
+	Body string `json:"body"`
+	// Status is the status string returned by ceph.
+	Status string `json:"status"`
+	// Errno is the error code of the response, if the error provided one.
+	Errno int `json:"errno,omitempty"`
+	// Error is the text of the error, if the response was an error.
+	Error string `json:"error,omitempty"`
+}
+
+type errorCoder interface {
+	ErrorCode() int
+}
+
+func newCommandRecord(kind string, buf [][]byte) (CommandRecord, error) {
+	rec := CommandRecord{
+		Kind:    kind,
+		Request: make([]json.RawMessage, len(buf)),
+	}
+	for i := range buf {
+		m, err := encodeRequest(buf[i])
+		if err != nil {
+			return rec, err
+		}
+		rec.Request[i] = m
+	}
+	return rec, nil
+}
+
+// encodeRequest converts a command buffer into a JSON value that can be
+// stored in a golden file. JSON buffers are compacted, non-JSON buffers are
+// converted to a JSON string.
+func encodeRequest(b []byte) (json.RawMessage, error) {
+	if json.Valid(b) {
+		var out bytes.Buffer
+		if err := json.Compact(&out, b); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}
+	return json.Marshal(string(b))
+}
+
+func (rec *CommandRecord) setResponse(body []byte, status string, err error) {
+	rec.Body = string(body)
+	rec.Status = status
+	if err == nil {
+		return
+	}
+	rec.Error = err.Error()
+	if ec, ok := err.(errorCoder); ok {
+		rec.Errno = ec.ErrorCode()
+	}
+}
+
+// response returns the values recorded in the record in the form returned by
+// the MonCommand and MgrCommand functions.
+func (rec *CommandRecord) response() ([]byte, string, error) {
+	var err error
+	switch {
+	case rec.Errno != 0:
+		err = errutil.GetError("rados", rec.Errno)
+	case rec.Error != "":
+		err = recordedError(rec.Error)
+	}
+	return []byte(rec.Body), rec.Status, err
+}
+
+// recordedError is returned by a ReplayCommander for recorded errors that had
+// no error code.
+type recordedError string
+
+func (e recordedError) Error() string {
+	return string(e)
+}
+
+// RecordingCommander is a RadosCommander that passes every command to the
+// RadosCommander it wraps and records the requests and responses to a golden
+// file. The golden file can later be served by a ReplayCommander, allowing
+// code that depends on a RadosCommander to be tested without a Ceph cluster.
+type RecordingCommander struct {
+	conn    RadosCommander
+	path    string
+	mutex   sync.Mutex
+	records []CommandRecord
+}
+
+// NewRecordingCommander returns a RecordingCommander that wraps the given
+// RadosCommander and writes the commands it executes to the file at path.
+// The file is rewritten after each command completes.
+func NewRecordingCommander(c RadosCommander, path string) *RecordingCommander {
+	return &RecordingCommander{conn: c, path: path}
+}
+
+// MgrCommand sends a command to the MGR using the wrapped RadosCommander and
+// records the request and response.
+func (r *RecordingCommander) MgrCommand(buf [][]byte) ([]byte, string, error) {
+	rec, err := newCommandRecord(MgrCommandKind, buf)
+	if err != nil {
+		return nil, "", err
+	}
+	b, s, err := r.conn.MgrCommand(buf)
+	rec.setResponse(b, s, err)
+	if serr := r.save(rec); serr != nil && err == nil {
+		err = serr
+	}
+	return b, s, err
+}
+
+// MonCommand sends a command to the MON(s) using the wrapped RadosCommander
+// and records the request and response.
+func (r *RecordingCommander) MonCommand(buf []byte) ([]byte, string, error) {
+	rec, err := newCommandRecord(MonCommandKind, [][]byte{buf})
+	if err != nil {
+		return nil, "", err
+	}
+	b, s, err := r.conn.MonCommand(buf)
+	rec.setResponse(b, s, err)
+	if serr := r.save(rec); serr != nil && err == nil {
+		err = serr
+	}
+	return b, s, err
+}
+
+// Records returns a copy of the commands recorded so far.
+func (r *RecordingCommander) Records() []CommandRecord {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]CommandRecord(nil), r.records...)
+}
+
+func (r *RecordingCommander) save(rec CommandRecord) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.records = append(r.records, rec)
+	data, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,171 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+var errNoEnt = errutil.GetError("test", -2)
+
+// fakeCommander returns canned responses for commands based on the raw
+// request buffer.
+type fakeCommander struct {
+	calls     int
+	responses map[string]CommandRecord
+}
+
+func (f *fakeCommander) respond(buf []byte) ([]byte, string, error) {
+	f.calls++
+	rec, ok := f.responses[string(buf)]
+	if !ok {
+		return nil, "", errors.New("no response")
+	}
+	return rec.response()
+}
+
+func (f *fakeCommander) MgrCommand(buf [][]byte) ([]byte, string, error) {
+	return f.respond(buf[0])
+}
+
+func (f *fakeCommander) MonCommand(buf []byte) ([]byte, string, error) {
+	return f.respond(buf)
+}
+
+func newFakeCommander() *fakeCommander {
+	return &fakeCommander{
+		responses: map[string]CommandRecord{
+			`{"prefix": "fs volume ls", "format": "json"}`: {
+				Body: `[{"name": "cephfs"}]`,
+			},
+			`{"prefix": "fs subvolume getpath", "vol_name": "cephfs"}`: {
+				Body:   "/volumes/_nogroup/sv1\n",
+				Status: "some status",
+			},
+			`{"prefix": "osd pool get", "pool": "nope"}`: {
+				Status: "unrecognized pool 'nope'",
+				Errno:  -2,
+			},
+			`FOOBAR!`: {
+				Error: "no way",
+			},
+		},
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	fake := newFakeCommander()
+	rc := NewRecordingCommander(fake, path)
+
+	b, s, err := rc.MgrCommand([][]byte{
+		[]byte(`{"prefix": "fs volume ls", "format": "json"}`)})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+	assert.Equal(t, "", s)
+
+	b, s, err = rc.MgrCommand([][]byte{
+		[]byte(`{"prefix": "fs subvolume getpath", "vol_name": "cephfs"}`)})
+	assert.NoError(t, err)
+	assert.Equal(t, "/volumes/_nogroup/sv1\n", string(b))
+	assert.Equal(t, "some status", s)
+
+	_, s, err = rc.MonCommand([]byte(`{"prefix": "osd pool get", "pool": "nope"}`))
+	assert.ErrorIs(t, err, errNoEnt)
+	assert.Equal(t, "unrecognized pool 'nope'", s)
+
+	_, _, err = rc.MonCommand([]byte(`FOOBAR!`))
+	assert.EqualError(t, err, "no way")
+	assert.Equal(t, 4, fake.calls)
+	assert.Len(t, rc.Records(), 4)
+
+	t.Run("replay", func(t *testing.T) {
+		rp, err := NewReplayCommander(path)
+		require.NoError(t, err)
+		assert.Len(t, rp.Unused(), 4)
+
+		// key order does not matter
+		b, s, err := rp.MgrCommand([][]byte{
+			[]byte(`{"vol_name": "cephfs", "prefix": "fs subvolume getpath"}`)})
+		assert.NoError(t, err)
+		assert.Equal(t, "/volumes/_nogroup/sv1\n", string(b))
+		assert.Equal(t, "some status", s)
+
+		b, _, err = rp.MgrCommand([][]byte{
+			[]byte(`{"format":"json","prefix":"fs volume ls"}`)})
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"name": "cephfs"}]`, string(b))
+
+		_, s, err = rp.MonCommand([]byte(`{"pool": "nope", "prefix": "osd pool get"}`))
+		assert.ErrorIs(t, err, errNoEnt)
+		var ec errorCoder
+		if assert.True(t, errors.As(err, &ec)) {
+			assert.Equal(t, -2, ec.ErrorCode())
+		}
+		assert.Equal(t, "unrecognized pool 'nope'", s)
+
+		_, _, err = rp.MonCommand([]byte(`FOOBAR!`))
+		assert.EqualError(t, err, "no way")
+		assert.Len(t, rp.Unused(), 0)
+	})
+
+	t.Run("unexpected", func(t *testing.T) {
+		rp, err := NewReplayCommander(path)
+		require.NoError(t, err)
+
+		// wrong kind of command
+		_, _, err = rp.MonCommand(
+			[]byte(`{"prefix": "fs volume ls", "format": "json"}`))
+		assert.ErrorIs(t, err, ErrUnexpectedCommand)
+
+		// different values
+		_, _, err = rp.MgrCommand([][]byte{
+			[]byte(`{"prefix": "fs volume ls", "format": "plain"}`)})
+		assert.ErrorIs(t, err, ErrUnexpectedCommand)
+
+		// replayed more often than recorded
+		_, _, err = rp.MgrCommand([][]byte{
+			[]byte(`{"prefix": "fs volume ls", "format": "json"}`)})
+		assert.NoError(t, err)
+		_, _, err = rp.MgrCommand([][]byte{
+			[]byte(`{"prefix": "fs volume ls", "format": "json"}`)})
+		assert.ErrorIs(t, err, ErrUnexpectedCommand)
+	})
+}
+
+func TestReplayRepeated(t *testing.T) {
+	req := []byte(`{"prefix": "fs subvolume info"}`)
+	m, err := encodeRequest(req)
+	require.NoError(t, err)
+	rp := NewReplayCommanderFromRecords([]CommandRecord{
+		{Kind: MgrCommandKind, Request: []json.RawMessage{m}, Body: "one"},
+		{Kind: MgrCommandKind, Request: []json.RawMessage{m}, Body: "two"},
+	})
+	b, _, err := rp.MgrCommand([][]byte{req})
+	assert.NoError(t, err)
+	assert.Equal(t, "one", string(b))
+	b, _, err = rp.MgrCommand([][]byte{req})
+	assert.NoError(t, err)
+	assert.Equal(t, "two", string(b))
+}
+
+func TestReplayBadGoldenFile(t *testing.T) {
+	_, err := NewReplayCommander(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestRecordSaveError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodir", "golden.json")
+	rc := NewRecordingCommander(newFakeCommander(), path)
+	_, _, err := rc.MgrCommand([][]byte{
+		[]byte(`{"prefix": "fs volume ls", "format": "json"}`)})
+	assert.Error(t, err)
+}
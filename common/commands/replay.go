@@ -0,0 +1,124 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// ErrUnexpectedCommand is returned by a ReplayCommander when a command is
+// executed that was not recorded, or was executed more often than recorded.
+var ErrUnexpectedCommand = errors.New("unexpected command")
+
+// ReplayCommander is a RadosCommander that serves the responses recorded in a
+// golden file by a RecordingCommander, without talking to a Ceph cluster.
+//
+// Incoming commands are matched against the recorded commands of the same
+// kind by comparing the JSON values of the request buffers, so the order of
+// keys within JSON objects is not significant. If a command was recorded more
+// than once, the responses are served in the order they were recorded.
+// Commands that do not match any remaining record fail with
+// ErrUnexpectedCommand.
+type ReplayCommander struct {
+	mutex   sync.Mutex
+	records []CommandRecord
+	used    []bool
+}
+
+// NewReplayCommander returns a ReplayCommander serving the commands recorded
+// in the golden file at path.
+func NewReplayCommander(path string) (*ReplayCommander, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []CommandRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid golden file %q: %w", path, err)
+	}
+	return NewReplayCommanderFromRecords(records), nil
+}
+
+// NewReplayCommanderFromRecords returns a ReplayCommander serving the given
+// command records.
+func NewReplayCommanderFromRecords(records []CommandRecord) *ReplayCommander {
+	return &ReplayCommander{
+		records: records,
+		used:    make([]bool, len(records)),
+	}
+}
+
+// MgrCommand returns the recorded response to the given MGR command.
+func (r *ReplayCommander) MgrCommand(buf [][]byte) ([]byte, string, error) {
+	return r.replay(MgrCommandKind, "", buf)
+}
+
+// MonCommand returns the recorded response to the given MON command.
+func (r *ReplayCommander) MonCommand(buf []byte) ([]byte, string, error) {
+	return r.replay(MonCommandKind, "", [][]byte{buf})
+}
+
+// Unused returns the recorded commands that have not been replayed yet.
+// Tests can use this to verify that all expected commands were executed.
+func (r *ReplayCommander) Unused() []CommandRecord {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var out []CommandRecord
+	for i := range r.records {
+		if !r.used[i] {
+			out = append(out, r.records[i])
+		}
+	}
+	return out
+}
+
+func (r *ReplayCommander) replay(
+	kind, target string, buf [][]byte) ([]byte, string, error) {
+
+	req, err := newCommandRecord(kind, buf)
+	if err != nil {
+		return nil, "", err
+	}
+	want, err := decodeRequest(req.Request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.records {
+		if r.used[i] || r.records[i].Kind != kind ||
+			r.records[i].Target != target {
+			continue
+		}
+		got, err := decodeRequest(r.records[i].Request)
+		if err != nil {
+			return nil, "", err
+		}
+		if reflect.DeepEqual(want, got) {
+			r.used[i] = true
+			return r.records[i].response()
+		}
+	}
+	if target != "" {
+		kind += "." + target
+	}
+	return nil, "", fmt.Errorf("%w: %s %s", ErrUnexpectedCommand, kind, buf)
+}
+
+// decodeRequest converts the request buffers into generic Go values so that
+// requests can be compared independently of the order of keys in objects.
+func decodeRequest(req []json.RawMessage) ([]interface{}, error) {
+	out := make([]interface{}, len(req))
+	for i := range req {
+		if err := json.Unmarshal(req[i], &out[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,48 @@
+//go:build ceph_preview
+
+package hooks
+
+import (
+	"fmt"
+
+	intHooks "github.com/ceph/go-ceph/internal/hooks"
+)
+
+// span is a minimal stand-in for a tracing span, such as an OpenTelemetry
+// span.
+type span struct {
+	name  string
+	attrs map[string]string
+}
+
+func (s *span) end(err error) {
+	fmt.Printf("span %s %v err=%v\n", s.name, s.attrs, err)
+}
+
+// tracingHook creates a span for every operation.
+type tracingHook struct{}
+
+func (tracingHook) OpStart(op *Op) {
+	op.Context = &span{
+		name:  op.Name,
+		attrs: map[string]string{"command": op.Command},
+	}
+}
+
+func (tracingHook) OpDone(op *Op) {
+	op.Context.(*span).end(op.Err)
+}
+
+func Example_tracing() {
+	SetGlobal(tracingHook{})
+	defer SetGlobal(nil)
+
+	// this is what rados.Conn does when sending a command to the MGR
+	t := intHooks.Start(intHooks.Select(nil), Op{
+		Name:    "rados_mgr_command",
+		Command: `{"prefix":"fs volume ls"}`,
+	})
+	t.Done(0, nil)
+	// Output:
+	// span rados_mgr_command map[command:{"prefix":"fs volume ls"}] err=<nil>
+}
@@ -0,0 +1,54 @@
+//go:build ceph_preview
+
+/*
+Package hooks allows go-ceph consumers to observe the calls made into the
+ceph libraries, for example to collect metrics or to create tracing spans.
+
+A Hook can be set globally, using SetGlobal, or for a single rados connection,
+using the SetHook method of rados.Conn. The Hook is called with an Op value
+describing the name of the C function called, the target of the operation
+(pool, namespace, object, image or path), the number of bytes read or written,
+the duration of the call and the error returned. When no Hook is set, the
+overhead of the hooks is negligible.
+
+Every call the rados, rados/striper, rbd and cephfs packages make into
+librados, libradosstriper, librbd and libcephfs is reported, with these
+exceptions:
+
+  - Calls that only release memory or other resources returned by an earlier
+    call, such as rados_buffer_free or rbd_snap_list_end.
+  - The steps added to a rados.WriteOp or rados.ReadOp, and the options set
+    on an rbd.ImageOptions or cephfs.UserPerm. They are only sent to the
+    cluster by a later call, such as Operate or CreateImage, which is
+    reported.
+  - Iterating over results that were already fetched, such as the entries
+    of a ListXattrs or the omap values of a ReadOp.
+  - Querying the version of the libraries.
+
+The asynchronous rbd.Image Aio functions are reported when they complete. The
+rbd calls use the Hook of the rados.Conn the image or pool was opened from.
+The cephfs calls use the Hook of the rados.Conn a mount was created from with
+CreateFromRados, other mounts use the global Hook.
+*/
+package hooks
+
+import (
+	intHooks "github.com/ceph/go-ceph/internal/hooks"
+)
+
+// Op describes a single call into one of the ceph libraries.
+type Op = intHooks.Op
+
+// Hook is called for every call into a ceph library that go-ceph reports.
+type Hook = intHooks.Hook
+
+// StartHook can be implemented in addition to Hook, if the Hook needs to be
+// notified of an operation before it starts, for example to create a span.
+type StartHook = intHooks.StartHook
+
+// SetGlobal sets the Hook that is called for all operations, unless a more
+// specific Hook, such as the one of a rados.Conn, is set. Setting a nil Hook
+// disables the global Hook.
+func SetGlobal(h Hook) {
+	intHooks.SetGlobal(h)
+}
@@ -0,0 +1,59 @@
+//go:build ceph_preview
+
+package hooks
+
+const (
+	// ResultOk is the result label value of successful operations.
+	ResultOk = "ok"
+	// ResultError is the result label value of failed operations.
+	ResultError = "error"
+)
+
+// Collector receives the metrics produced by a MetricsHook. The methods are
+// designed to map directly onto Prometheus style counter and histogram
+// vectors. For example, using the Prometheus client library:
+//
+//	func (c *promCollector) IncOps(op, result string) {
+//		c.ops.WithLabelValues(op, result).Inc()
+//	}
+//
+//	func (c *promCollector) ObserveDuration(op string, seconds float64) {
+//		c.latency.WithLabelValues(op).Observe(seconds)
+//	}
+//
+//	func (c *promCollector) AddBytes(op string, n int64) {
+//		c.bytes.WithLabelValues(op).Add(float64(n))
+//	}
+type Collector interface {
+	// IncOps increments the number of operations with the given name and
+	// result, which is one of ResultOk or ResultError.
+	IncOps(op, result string)
+	// ObserveDuration records the duration of an operation in seconds.
+	ObserveDuration(op string, seconds float64)
+	// AddBytes adds to the number of bytes read or written by an operation.
+	AddBytes(op string, n int64)
+}
+
+// MetricsHook is a Hook that converts the operations it observes into
+// counters and latency observations of a Collector.
+type MetricsHook struct {
+	collector Collector
+}
+
+// NewMetricsHook returns a MetricsHook that reports to the given Collector.
+func NewMetricsHook(c Collector) *MetricsHook {
+	return &MetricsHook{collector: c}
+}
+
+// OpDone implements the Hook interface.
+func (m *MetricsHook) OpDone(op *Op) {
+	result := ResultOk
+	if op.Err != nil {
+		result = ResultError
+	}
+	m.collector.IncOps(op.Name, result)
+	m.collector.ObserveDuration(op.Name, op.Duration.Seconds())
+	if op.Bytes > 0 {
+		m.collector.AddBytes(op.Name, op.Bytes)
+	}
+}
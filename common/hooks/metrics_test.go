@@ -0,0 +1,94 @@
+//go:build ceph_preview
+
+package hooks
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	intHooks "github.com/ceph/go-ceph/internal/hooks"
+)
+
+// memCollector is an in-memory Collector, standing in for a set of
+// Prometheus counter and histogram vectors.
+type memCollector struct {
+	mutex     sync.Mutex
+	ops       map[[2]string]int
+	durations map[string][]float64
+	bytes     map[string]int64
+}
+
+func newMemCollector() *memCollector {
+	return &memCollector{
+		ops:       map[[2]string]int{},
+		durations: map[string][]float64{},
+		bytes:     map[string]int64{},
+	}
+}
+
+func (c *memCollector) IncOps(op, result string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ops[[2]string{op, result}]++
+}
+
+func (c *memCollector) ObserveDuration(op string, seconds float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.durations[op] = append(c.durations[op], seconds)
+}
+
+func (c *memCollector) AddBytes(op string, n int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bytes[op] += n
+}
+
+func TestMetricsHook(t *testing.T) {
+	c := newMemCollector()
+	h := NewMetricsHook(c)
+
+	for i := 0; i < 3; i++ {
+		tr := intHooks.Start(h, Op{Name: "rados_write", Pool: "p1", Object: "o1"})
+		time.Sleep(time.Millisecond)
+		tr.Done(4096, nil)
+	}
+	tr := intHooks.Start(h, Op{Name: "rados_write", Pool: "p1", Object: "o2"})
+	tr.Done(0, errors.New("failed"))
+	tr = intHooks.Start(h, Op{Name: "rados_read", Pool: "p1", Object: "o1"})
+	tr.Done(100, nil)
+
+	assert.Equal(t, 3, c.ops[[2]string{"rados_write", ResultOk}])
+	assert.Equal(t, 1, c.ops[[2]string{"rados_write", ResultError}])
+	assert.Equal(t, 1, c.ops[[2]string{"rados_read", ResultOk}])
+	assert.Equal(t, int64(3*4096), c.bytes["rados_write"])
+	assert.Equal(t, int64(100), c.bytes["rados_read"])
+	if assert.Len(t, c.durations["rados_write"], 4) {
+		assert.GreaterOrEqual(t, c.durations["rados_write"][0], 0.001)
+	}
+}
+
+func TestSetGlobal(t *testing.T) {
+	defer SetGlobal(nil)
+
+	assert.Nil(t, intHooks.Select(nil))
+	c := newMemCollector()
+	SetGlobal(NewMetricsHook(c))
+	h := intHooks.Select(nil)
+	if assert.NotNil(t, h) {
+		intHooks.Start(h, Op{Name: "rbd_read"}).Done(1, nil)
+	}
+	assert.Equal(t, 1, c.ops[[2]string{"rbd_read", ResultOk}])
+
+	// a specific hook takes precedence over the global one
+	c2 := newMemCollector()
+	h2 := NewMetricsHook(c2)
+	assert.Equal(t, Hook(h2), intHooks.Select(h2))
+
+	SetGlobal(nil)
+	assert.Nil(t, intHooks.Select(nil))
+}
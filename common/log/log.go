@@ -9,9 +9,11 @@ import (
 // SetWarnf sets the log.Printf compatible receiver for warning logs.
 func SetWarnf(f func(format string, v ...interface{})) {
 	intLog.Warnf = f
+	intLog.SetReceivers()
 }
 
 // SetDebugf sets the log.Printf compatible receiver for debug logs.
 func SetDebugf(f func(format string, v ...interface{})) {
 	intLog.Debugf = f
+	intLog.SetReceivers()
 }
@@ -0,0 +1,19 @@
+//go:build ceph_preview
+
+package log
+
+import (
+	"log/slog"
+
+	intLog "github.com/ceph/go-ceph/internal/log"
+)
+
+// SetLogger sets the slog.Logger that receives the go-ceph logs. The logs
+// carry attributes, such as the pool, image or errno, that relate to the
+// message. Warnings are logged at slog.LevelWarn and debug messages at
+// slog.LevelDebug. While a Logger is set, it takes precedence over the
+// receivers set with SetWarnf and SetDebugf. Setting a nil Logger passes the
+// logs as text to those receivers again.
+func SetLogger(l *slog.Logger) {
+	intLog.SetLogger(l)
+}
@@ -0,0 +1,69 @@
+//go:build ceph_preview
+
+package admintest
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+// RecordEnabled returns true if the environment variable
+// GO_CEPH_TEST_RECORD indicates that the golden files used by the tests
+// should be recorded from a live cluster instead of being replayed.
+func RecordEnabled() bool {
+	rec := os.Getenv("GO_CEPH_TEST_RECORD")
+	ok, err := strconv.ParseBool(rec)
+	return ok && err == nil
+}
+
+// GoldenCommander is implemented by the commanders returned by Golden. It
+// can execute all the kinds of commands that are stored in golden files.
+type GoldenCommander interface {
+	ccom.RadosCommander
+	ccom.OSDCommander
+	ccom.PGCommander
+	ccom.MonTargetCommander
+}
+
+var (
+	recordersMutex sync.Mutex
+	recorders      = map[string]*ccom.RecordingCommander{}
+)
+
+// Golden returns a commander for tests based on the golden file at path.
+// Normally the commands are replayed from the golden file. If RecordEnabled
+// returns true, the commands are sent to the cluster of the default config
+// instead, and are recorded to path. All the tests using the same path share
+// one recording, so running the tests of a package with GO_CEPH_TEST_RECORD
+// set regenerates its golden file. Assertions on recorded values must be
+// updated after a new recording.
+func Golden(t *testing.T, path string) GoldenCommander {
+	if !RecordEnabled() {
+		rp, err := ccom.NewReplayCommander(path)
+		require.NoError(t, err)
+		return rp
+	}
+	recordersMutex.Lock()
+	defer recordersMutex.Unlock()
+	rc, ok := recorders[path]
+	if !ok {
+		rc = ccom.NewRecordingCommander(NewConn(t), path)
+		recorders[path] = rc
+	}
+	return rc
+}
+
+// Unused returns the commands of the golden file that were not replayed by
+// a commander returned by Golden. It returns nil while recording.
+func Unused(c GoldenCommander) []ccom.CommandRecord {
+	if rp, ok := c.(*ccom.ReplayCommander); ok {
+		return rp.Unused()
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"encoding/json"
+
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+// RawMonTargetCommand takes a byte buffer and sends it to the named MON as a
+// command. The buffer is expected to contain preformatted JSON.
+func RawMonTargetCommand(m ccom.MonTargetCommander, name string, buf []byte) Response {
+	if err := validate(m); err != nil {
+		return Response{err: err}
+	}
+	return NewResponse(m.MonCommandTarget(name, [][]byte{buf}))
+}
+
+// MarshalMonTargetCommand takes an generic interface{} value, converts it to
+// JSON and sends the json to the named MON as a command.
+func MarshalMonTargetCommand(m ccom.MonTargetCommander, name string, v interface{}) Response {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Response{err: err}
+	}
+	return RawMonTargetCommand(m, name, b)
+}
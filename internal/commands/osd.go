@@ -0,0 +1,28 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"encoding/json"
+
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+// RawOsdCommand takes a byte buffer and sends it to the given OSD as a
+// command. The buffer is expected to contain preformatted JSON.
+func RawOsdCommand(o ccom.OSDCommander, osd int, buf []byte) Response {
+	if err := validate(o); err != nil {
+		return Response{err: err}
+	}
+	return NewResponse(o.OsdCommand(osd, [][]byte{buf}))
+}
+
+// MarshalOsdCommand takes an generic interface{} value, converts it to JSON
+// and sends the json to the given OSD as a command.
+func MarshalOsdCommand(o ccom.OSDCommander, osd int, v interface{}) Response {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Response{err: err}
+	}
+	return RawOsdCommand(o, osd, b)
+}
@@ -0,0 +1,28 @@
+//go:build ceph_preview
+
+package commands
+
+import (
+	"encoding/json"
+
+	ccom "github.com/ceph/go-ceph/common/commands"
+)
+
+// RawPGCommand takes a byte buffer and sends it to the given PG as a
+// command. The buffer is expected to contain preformatted JSON.
+func RawPGCommand(p ccom.PGCommander, pgid string, buf []byte) Response {
+	if err := validate(p); err != nil {
+		return Response{err: err}
+	}
+	return NewResponse(p.PGCommand([]byte(pgid), [][]byte{buf}))
+}
+
+// MarshalPGCommand takes an generic interface{} value, converts it to JSON
+// and sends the json to the given PG as a command.
+func MarshalPGCommand(p ccom.PGCommander, pgid string, v interface{}) Response {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Response{err: err}
+	}
+	return RawPGCommand(p, pgid, b)
+}
@@ -2,9 +2,12 @@ package dlsym
 
 import (
 	"errors"
+	"syscall"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/ceph/go-ceph/internal/errutil"
 )
 
 func TestLookupSymbol(t *testing.T) {
@@ -20,3 +23,41 @@ func TestLookupSymbol(t *testing.T) {
 		assert.True(t, errors.Is(err, ErrUndefinedSymbol))
 	})
 }
+
+func TestSymbol(t *testing.T) {
+	t.Run("ValidSymbol", func(t *testing.T) {
+		s := NewSymbol("dlsym")
+		assert.Equal(t, "dlsym", s.Name())
+		assert.True(t, s.Available())
+		ptr, err := s.Pointer()
+		assert.NotNil(t, ptr)
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidSymbol", func(t *testing.T) {
+		s := NewSymbol("go_ceph_dlsym")
+		assert.False(t, s.Available())
+		ptr, err := s.Pointer()
+		assert.Nil(t, ptr)
+		assert.ErrorIs(t, err, ErrUndefinedSymbol)
+		var nie NotImplementedError
+		if assert.True(t, errors.As(err, &nie)) {
+			assert.Equal(t, "go_ceph_dlsym", nie.Symbol)
+			assert.Equal(t, -int(syscall.ENOSYS), nie.ErrorCode())
+		}
+		assert.EqualError(t, err,
+			"API call not implemented client-side: go_ceph_dlsym")
+	})
+
+	t.Run("Available", func(t *testing.T) {
+		assert.True(t, Available())
+		assert.True(t, Available(NewSymbol("dlsym"), NewSymbol("dlerror")))
+		assert.False(t, Available(NewSymbol("dlsym"), NewSymbol("go_ceph_dlsym")))
+	})
+
+	t.Run("MatchesENOSYS", func(t *testing.T) {
+		err := NotImplementedError{Symbol: "x"}
+		assert.ErrorIs(t, err, errutil.GetError("rbd", -int(syscall.ENOSYS)))
+		assert.NotErrorIs(t, err, errutil.GetError("rbd", -int(syscall.ENOENT)))
+	})
+}
@@ -0,0 +1,94 @@
+package dlsym
+
+// #include <errno.h>
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// NotImplementedError is returned by functions that depend on a symbol that
+// is not provided by the ceph library loaded at runtime. This usually means
+// the installed ceph version is older than the one that introduced the
+// function.
+type NotImplementedError struct {
+	// Symbol is the name of the missing symbol.
+	Symbol string
+	err    error
+}
+
+// Error implements the error interface.
+func (e NotImplementedError) Error() string {
+	return fmt.Sprintf("API call not implemented client-side: %s", e.Symbol)
+}
+
+// Unwrap returns the error of the symbol lookup, which wraps
+// ErrUndefinedSymbol.
+func (e NotImplementedError) Unwrap() error {
+	return e.err
+}
+
+// ErrorCode returns -ENOSYS, the error code used by the ceph libraries for
+// functions that are not implemented.
+func (e NotImplementedError) ErrorCode() int {
+	return -C.ENOSYS
+}
+
+// Is returns true for errors that carry the ENOSYS error code, so that
+// NotImplementedError matches the ErrNotImplemented values of the go-ceph
+// packages.
+func (e NotImplementedError) Is(target error) bool {
+	ec, ok := target.(interface{ ErrorCode() int })
+	return ok && ec.ErrorCode() == -C.ENOSYS
+}
+
+// Symbol is a symbol of a dynamically loaded library that is resolved on
+// first use. This allows go-ceph to use functions of newer ceph versions
+// without failing to start when an older version of the library is installed.
+type Symbol struct {
+	name string
+	once sync.Once
+	ptr  unsafe.Pointer
+	err  error
+}
+
+// NewSymbol returns a Symbol for the given name. The symbol is not resolved
+// until Pointer or Available is called.
+func NewSymbol(name string) *Symbol {
+	return &Symbol{name: name}
+}
+
+// Name returns the name of the symbol.
+func (s *Symbol) Name() string {
+	return s.name
+}
+
+// Pointer returns the address of the symbol, resolving it on the first call.
+// If the symbol can not be found, a NotImplementedError is returned.
+func (s *Symbol) Pointer() (unsafe.Pointer, error) {
+	s.once.Do(func() {
+		s.ptr, s.err = LookupSymbol(s.name)
+		if s.err != nil {
+			s.err = NotImplementedError{Symbol: s.name, err: s.err}
+		}
+	})
+	return s.ptr, s.err
+}
+
+// Available returns true if the symbol can be resolved.
+func (s *Symbol) Available() bool {
+	_, err := s.Pointer()
+	return err == nil
+}
+
+// Available returns true if all the given symbols can be resolved.
+func Available(symbols ...*Symbol) bool {
+	for _, s := range symbols {
+		if !s.Available() {
+			return false
+		}
+	}
+	return true
+}
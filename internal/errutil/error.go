@@ -29,14 +29,16 @@ func (e cephError) Unwrap() error {
 	return e.errno
 }
 
-// Is checks if both errors have the same errno.
+// Is checks if both errors have the same errno. Errors of other types match
+// if they provide the same error code with an ErrorCode method, like the
+// errors of the cgo-free radosapi package.
 func (e cephError) Is(err error) bool {
-	ce, ok := err.(cephError)
+	ce, ok := err.(interface{ ErrorCode() int })
 	if !ok {
 		return false
 	}
 
-	return e.errno == ce.errno
+	return int(e.errno) == ce.ErrorCode()
 }
 
 // ErrorCode returns the errno of the error.
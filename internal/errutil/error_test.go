@@ -19,4 +19,18 @@ func TestCephError(t *testing.T) {
 	rbdErr := GetError("rbd", 2)
 	assert.True(t, errors.Is(cephFSErr, rbdErr))
 	assert.True(t, errors.Unwrap(cephFSErr) == errors.Unwrap(rbdErr))
+
+	// errors of other types match by their error code
+	assert.True(t, errors.Is(rbdErr, codeError(2)))
+	assert.False(t, errors.Is(rbdErr, codeError(5)))
+}
+
+type codeError int
+
+func (e codeError) Error() string {
+	return "code error"
+}
+
+func (e codeError) ErrorCode() int {
+	return int(e)
 }
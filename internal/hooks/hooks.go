@@ -0,0 +1,146 @@
+// Package hooks is the internal package for go-ceph metrics and tracing
+// hooks. go-ceph code uses the functions in this package to report calls into
+// the ceph libraries to a Hook. No Hook is set by default, in which case the
+// functions in this package do (almost) nothing. Hooks can be set by
+// consumers of go-ceph with the external package common/hooks.
+package hooks
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Op describes a single call into one of the ceph libraries.
+type Op struct {
+	// Name is the name of the C function called, for example "rados_write".
+	Name string
+	// Pool and Namespace are set for operations on a rados I/O context.
+	Pool      string
+	Namespace string
+	// Object is set for operations on a rados object.
+	Object string
+	// Image is set for operations on an rbd image.
+	Image string
+	// Path is set for operations on a cephfs file.
+	Path string
+	// Command is set for the JSON command of admin commands.
+	Command string
+	// Bytes is the number of bytes read or written.
+	Bytes int64
+	// Start is the time the operation started.
+	Start time.Time
+	// Duration is the time the operation took.
+	Duration time.Duration
+	// Err is the error returned by the operation, if any.
+	Err error
+	// Context can be set by the Hook in OpStart to pass a value, for example
+	// a tracing span, to OpDone.
+	Context interface{}
+}
+
+// Hook is called for every call into a ceph library that go-ceph reports.
+type Hook interface {
+	// OpDone is called after a call into a ceph library completed. All the
+	// fields of the Op are set.
+	OpDone(op *Op)
+}
+
+// StartHook can be implemented in addition to Hook, if the Hook needs to be
+// notified of an operation before it starts, for example to create a span.
+type StartHook interface {
+	// OpStart is called before a call into a ceph library starts. The Bytes,
+	// Duration and Err fields are not yet set.
+	OpStart(op *Op)
+}
+
+type hookBox struct {
+	hook Hook
+}
+
+// Holder holds a Hook that can be replaced while other goroutines use it. The
+// zero value holds no Hook.
+type Holder struct {
+	box atomic.Pointer[hookBox]
+}
+
+// Set replaces the Hook of the Holder. A nil Hook clears the Holder.
+func (h *Holder) Set(hook Hook) {
+	if hook == nil {
+		h.box.Store(nil)
+		return
+	}
+	h.box.Store(&hookBox{hook})
+}
+
+// Get returns the Hook of the Holder or nil.
+func (h *Holder) Get() Hook {
+	if b := h.box.Load(); b != nil {
+		return b.hook
+	}
+	return nil
+}
+
+var global Holder
+
+// SetGlobal sets the Hook that is used for all operations that do not have a
+// more specific hook set. A nil Hook disables the global Hook.
+func SetGlobal(h Hook) {
+	global.Set(h)
+}
+
+// Select returns the given Hook if it is not nil and the global hook
+// otherwise.
+func Select(h Hook) Hook {
+	if h != nil {
+		return h
+	}
+	return global.Get()
+}
+
+// Lookup returns the Hook specific to a go-ceph object such as a *rados.Conn
+// or a *rados.IOContext, and a partially filled in Op for it. The Hook is nil
+// if no specific Hook is set, so callers must pass it through Select. Lookup
+// is set by the rados package, so that packages using rados connections can
+// use the same Hooks.
+var Lookup = func(interface{}) (Hook, Op) {
+	return nil, Op{}
+}
+
+// Tracker measures a single operation. A nil *Tracker is valid and does
+// nothing, this is what Start returns if no Hook is set.
+type Tracker struct {
+	hook Hook
+	op   Op
+}
+
+// Start returns a Tracker for the given Op that reports to the Hook h. If h is
+// nil, Start returns nil.
+func Start(h Hook, op Op) *Tracker {
+	if h == nil {
+		return nil
+	}
+	t := &Tracker{hook: h, op: op}
+	if sh, ok := h.(StartHook); ok {
+		sh.OpStart(&t.op)
+	}
+	t.op.Start = time.Now()
+	return t
+}
+
+// Done completes the operation and reports it to the hook.
+func (t *Tracker) Done(n int64, err error) {
+	if t == nil {
+		return
+	}
+	t.op.Duration = time.Since(t.op.Start)
+	t.op.Bytes = n
+	t.op.Err = err
+	t.hook.OpDone(&t.op)
+}
+
+// DoneErr completes an operation that did not read or write any data,
+// reports it to the hook and returns err.
+func (t *Tracker) DoneErr(err error) error {
+	t.Done(0, err)
+	return err
+}
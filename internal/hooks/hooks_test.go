@@ -0,0 +1,102 @@
+package hooks
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordHook struct {
+	started []Op
+	done    []Op
+}
+
+func (r *recordHook) OpStart(op *Op) {
+	op.Context = len(r.started)
+	r.started = append(r.started, *op)
+}
+
+func (r *recordHook) OpDone(op *Op) {
+	r.done = append(r.done, *op)
+}
+
+func TestTracker(t *testing.T) {
+	t.Run("nilHook", func(t *testing.T) {
+		tr := Start(nil, Op{Name: "rados_write"})
+		assert.Nil(t, tr)
+		// must not panic
+		tr.Done(5, nil)
+	})
+
+	t.Run("startAndDone", func(t *testing.T) {
+		h := &recordHook{}
+		e := errors.New("boom")
+		Start(h, Op{Name: "rbd_write", Image: "img1"}).Done(512, nil)
+		Start(h, Op{Name: "rbd_read", Image: "img1"}).Done(0, e)
+		if assert.Len(t, h.started, 2) && assert.Len(t, h.done, 2) {
+			assert.Equal(t, "rbd_write", h.started[0].Name)
+			assert.Equal(t, 0, h.done[0].Context)
+			assert.Equal(t, int64(512), h.done[0].Bytes)
+			assert.NoError(t, h.done[0].Err)
+			assert.False(t, h.done[0].Start.IsZero())
+			assert.Equal(t, "img1", h.done[1].Image)
+			assert.Equal(t, 1, h.done[1].Context)
+			assert.Equal(t, e, h.done[1].Err)
+		}
+	})
+
+	t.Run("doneErr", func(t *testing.T) {
+		var nilTracker *Tracker
+		e := errors.New("boom")
+		assert.Equal(t, e, nilTracker.DoneErr(e))
+
+		h := &recordHook{}
+		assert.Equal(t, e, Start(h, Op{Name: "rados_pool_create"}).DoneErr(e))
+		assert.NoError(t, Start(h, Op{Name: "rados_pool_delete"}).DoneErr(nil))
+		if assert.Len(t, h.done, 2) {
+			assert.Equal(t, e, h.done[0].Err)
+			assert.Equal(t, int64(0), h.done[0].Bytes)
+			assert.NoError(t, h.done[1].Err)
+		}
+	})
+
+	t.Run("select", func(t *testing.T) {
+		defer SetGlobal(nil)
+		g := &recordHook{}
+		h := &recordHook{}
+		assert.Nil(t, Select(nil))
+		SetGlobal(g)
+		assert.Equal(t, Hook(g), Select(nil))
+		assert.Equal(t, Hook(h), Select(h))
+		hk, op := Lookup(nil)
+		assert.Nil(t, hk)
+		assert.Equal(t, Op{}, op)
+	})
+
+	t.Run("holder", func(t *testing.T) {
+		var hd Holder
+		assert.Nil(t, hd.Get())
+		h := &recordHook{}
+		hd.Set(h)
+		assert.Equal(t, Hook(h), hd.Get())
+		hd.Set(nil)
+		assert.Nil(t, hd.Get())
+
+		// the Hook can be replaced while it is used
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					hd.Set(&recordHook{})
+					_ = hd.Get()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.NotNil(t, hd.Get())
+	})
+}
@@ -5,6 +5,16 @@
 // package common/log by the go-ceph consumers.
 package log
 
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
 func noop(string, ...interface{}) {}
 
 // These variables are set by the common log package.
@@ -12,3 +22,102 @@ var (
 	Warnf  = noop
 	Debugf = noop
 )
+
+var (
+	logger    atomic.Pointer[slog.Logger]
+	receivers atomic.Bool
+)
+
+// SetReceivers is called by the common log package after it set Warnf or
+// Debugf. Until then, structured logs are not formatted for the receivers if
+// no slog.Logger is set.
+func SetReceivers() {
+	receivers.Store(true)
+}
+
+// SetLogger sets the slog.Logger that receives the structured logs. If l is
+// nil, structured logs are passed as text to Warnf and Debugf.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// Warn logs a warning message with the given key/value pairs or slog.Attr
+// values as attributes.
+func Warn(msg string, args ...any) {
+	logAttrs(slog.LevelWarn, Warnf, msg, args)
+}
+
+// Debug logs a debug message with the given key/value pairs or slog.Attr
+// values as attributes.
+func Debug(msg string, args ...any) {
+	logAttrs(slog.LevelDebug, Debugf, msg, args)
+}
+
+// Log logs a message at the given level with the given key/value pairs or
+// slog.Attr values as attributes. Levels below slog.LevelWarn are passed to
+// Debugf if no slog.Logger is set.
+func Log(level slog.Level, msg string, args ...any) {
+	printf := Debugf
+	if level >= slog.LevelWarn {
+		printf = Warnf
+	}
+	logAttrs(level, printf, msg, args)
+}
+
+func logAttrs(level slog.Level, printf func(string, ...interface{}),
+	msg string, args []any) {
+
+	l := logger.Load()
+	if l == nil {
+		if receivers.Load() {
+			printf("%s", formatText(msg, args))
+		}
+		return
+	}
+	ctx := context.Background()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	// skip runtime.Callers, logAttrs and the exported function, so that the
+	// source of the record is the go-ceph code that logged the message
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
+
+// formatText formats a message and its attributes in the style of the
+// slog.TextHandler, for example: `message key=value other="some value"`.
+func formatText(msg string, args []any) string {
+	r := slog.NewRecord(time.Time{}, 0, msg, 0)
+	r.Add(args...)
+	var b strings.Builder
+	b.WriteString(msg)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, "", a)
+		return true
+	})
+	return b.String()
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			writeAttr(b, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	s := v.String()
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		s = fmt.Sprintf("%q", s)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, s)
+}
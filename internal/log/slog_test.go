@@ -0,0 +1,63 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructuredFallback(t *testing.T) {
+	var out []string
+	Warnf = func(format string, v ...interface{}) {
+		out = append(out, format)
+		out = append(out, v[0].(string))
+	}
+	defer func() { Warnf = noop }()
+
+	// nothing is formatted until the receivers are marked as set
+	Warn("not formatted", "key", "value")
+	assert.Empty(t, out)
+	SetReceivers()
+	defer receivers.Store(false)
+
+	Warn("unknown watcher", "watcher_id", 42, "errno", -2,
+		slog.Group("pool", "name", "my pool"))
+	assert.Equal(t, []string{
+		"%s",
+		`unknown watcher watcher_id=42 errno=-2 pool.name="my pool"`,
+	}, out)
+
+	// debug messages are not passed to Warnf
+	out = nil
+	Debug("nothing")
+	assert.Empty(t, out)
+}
+
+func TestStructuredLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelWarn,
+	})))
+	defer SetLogger(nil)
+	Warnf = func(string, ...interface{}) {
+		t.Error("Warnf must not be called while a logger is set")
+	}
+	defer func() { Warnf = noop }()
+
+	Warn("unreachable object", "pool", "p1")
+	Debug("filtered out")
+	Log(slog.LevelError, "failed", "errno", -5)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], "level=WARN")
+		assert.Contains(t, lines[0], "slog_test.go")
+		assert.Contains(t, lines[0], `msg="unreachable object" pool=p1`)
+		assert.Contains(t, lines[1], "level=ERROR")
+		assert.Contains(t, lines[1], "msg=failed errno=-5")
+	}
+}
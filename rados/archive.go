@@ -0,0 +1,376 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Archive format
+//
+// An archive holds the objects of a pool or namespace, as written by
+// ExportArchive or an ArchiveWriter. All integers are little endian.
+//
+//	archive  = magic version flags record* end
+//	magic    = "GCRADOSA" (8 bytes)
+//	version  = uint32, currently 1
+//	flags    = uint32, currently 0
+//	record   = type:uint8 length:uint32 payload:length*byte crc:uint32
+//
+// The crc of a record is the CRC-32C (Castagnoli) of its type, length and
+// payload. The record types are:
+//
+//	1: object
+//	   namespace:string name:string locator:string
+//	   mtime_sec:int64 mtime_nsec:uint32
+//	   data:uint64 length and bytes
+//	   xattr_count:uint32 (name:string value:bytes)*
+//	   omap_count:uint32 (key:string value:bytes)*
+//	255: end
+//	   object_count:uint64
+//
+// Strings and bytes are encoded as a uint32 length followed by the content.
+// Xattrs and omap keys are sorted. The end record is required, so that
+// truncated archives are detected. Omap headers are not part of the format,
+// as librados has no C API to read or write them.
+
+const (
+	archiveMagic   = "GCRADOSA"
+	archiveVersion = 1
+
+	archiveRecordObject = 1
+	archiveRecordEnd    = 255
+
+	// maxArchiveRecord is the largest payload of a record.
+	maxArchiveRecord uint64 = math.MaxUint32
+)
+
+var (
+	// ErrArchiveInvalid is returned when reading data that is not a valid
+	// archive, or an archive of an unsupported version.
+	ErrArchiveInvalid = errors.New("invalid object archive")
+	// ErrArchiveChecksum is returned when the checksum of an archive
+	// record does not match its content.
+	ErrArchiveChecksum = errors.New("object archive checksum mismatch")
+)
+
+var archiveCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ArchiveObject is an object stored in an archive.
+type ArchiveObject struct {
+	Namespace string
+	Name      string
+	Locator   string
+	ModTime   time.Time
+	Data      []byte
+	Xattrs    map[string][]byte
+	Omap      map[string][]byte
+}
+
+// ArchiveWriter writes objects to an archive.
+type ArchiveWriter struct {
+	w     *bufio.Writer
+	count uint64
+	buf   bytes.Buffer
+}
+
+// NewArchiveWriter starts a new archive on w. The archive must be completed
+// with Close.
+func NewArchiveWriter(w io.Writer) (*ArchiveWriter, error) {
+	aw := &ArchiveWriter{w: bufio.NewWriter(w)}
+	var hdr [16]byte
+	copy(hdr[:8], archiveMagic)
+	binary.LittleEndian.PutUint32(hdr[8:], archiveVersion)
+	if _, err := aw.w.Write(hdr[:]); err != nil {
+		return nil, err
+	}
+	return aw, nil
+}
+
+func (aw *ArchiveWriter) putUint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	aw.buf.Write(b[:])
+}
+
+func (aw *ArchiveWriter) putUint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	aw.buf.Write(b[:])
+}
+
+func (aw *ArchiveWriter) putBytes(b []byte) {
+	aw.putUint32(uint32(len(b)))
+	aw.buf.Write(b)
+}
+
+func (aw *ArchiveWriter) putString(s string) {
+	aw.putUint32(uint32(len(s)))
+	aw.buf.WriteString(s)
+}
+
+func (aw *ArchiveWriter) putMap(m map[string][]byte) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	aw.putUint32(uint32(len(keys)))
+	for _, k := range keys {
+		aw.putString(k)
+		aw.putBytes(m[k])
+	}
+}
+
+func (aw *ArchiveWriter) writeRecord(typ byte) error {
+	if uint64(aw.buf.Len()) > maxArchiveRecord {
+		return fmt.Errorf("archive record too large: %d bytes", aw.buf.Len())
+	}
+	var hdr [5]byte
+	hdr[0] = typ
+	binary.LittleEndian.PutUint32(hdr[1:], uint32(aw.buf.Len()))
+	crc := crc32.Update(0, archiveCRCTable, hdr[:])
+	crc = crc32.Update(crc, archiveCRCTable, aw.buf.Bytes())
+	var trailer [4]byte
+	binary.LittleEndian.PutUint32(trailer[:], crc)
+	for _, b := range [][]byte{hdr[:], aw.buf.Bytes(), trailer[:]} {
+		if _, err := aw.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteObject adds an object to the archive.
+func (aw *ArchiveWriter) WriteObject(obj *ArchiveObject) error {
+	aw.buf.Reset()
+	aw.putString(obj.Namespace)
+	aw.putString(obj.Name)
+	aw.putString(obj.Locator)
+	aw.putUint64(uint64(obj.ModTime.Unix()))
+	aw.putUint32(uint32(obj.ModTime.Nanosecond()))
+	aw.putUint64(uint64(len(obj.Data)))
+	aw.buf.Write(obj.Data)
+	aw.putMap(obj.Xattrs)
+	aw.putMap(obj.Omap)
+	if err := aw.writeRecord(archiveRecordObject); err != nil {
+		return err
+	}
+	aw.count++
+	return nil
+}
+
+// Close completes the archive and flushes it to the underlying writer. It
+// does not close the underlying writer.
+func (aw *ArchiveWriter) Close() error {
+	aw.buf.Reset()
+	aw.putUint64(aw.count)
+	if err := aw.writeRecord(archiveRecordEnd); err != nil {
+		return err
+	}
+	return aw.w.Flush()
+}
+
+// ArchiveReader reads the objects of an archive.
+type ArchiveReader struct {
+	r      *bufio.Reader
+	record uint64
+	done   bool
+	// payload is the part of the current record that is not decoded yet
+	payload []byte
+}
+
+// NewArchiveReader reads the header of the archive from r and returns an
+// ArchiveReader for its objects.
+func NewArchiveReader(r io.Reader) (*ArchiveReader, error) {
+	ar := &ArchiveReader{r: bufio.NewReader(r)}
+	var hdr [16]byte
+	if _, err := io.ReadFull(ar.r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArchiveInvalid, err)
+	}
+	if string(hdr[:8]) != archiveMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrArchiveInvalid)
+	}
+	if v := binary.LittleEndian.Uint32(hdr[8:]); v != archiveVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d",
+			ErrArchiveInvalid, v)
+	}
+	// no flags are defined yet, a flag set by a later version may change
+	// the meaning of the records
+	if f := binary.LittleEndian.Uint32(hdr[12:]); f != 0 {
+		return nil, fmt.Errorf("%w: unsupported flags %#x",
+			ErrArchiveInvalid, f)
+	}
+	return ar, nil
+}
+
+func (ar *ArchiveReader) readRecord() (byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(ar.r, hdr[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	length := binary.LittleEndian.Uint32(hdr[1:])
+	payload := make([]byte, 0, min(int(length), 1<<20))
+	buf := bytes.NewBuffer(payload)
+	if _, err := io.CopyN(buf, ar.r, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	var trailer [4]byte
+	if _, err := io.ReadFull(ar.r, trailer[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	ar.record++
+	crc := crc32.Update(0, archiveCRCTable, hdr[:])
+	crc = crc32.Update(crc, archiveCRCTable, buf.Bytes())
+	if crc != binary.LittleEndian.Uint32(trailer[:]) {
+		return 0, fmt.Errorf("%w: record %d", ErrArchiveChecksum, ar.record)
+	}
+	ar.payload = buf.Bytes()
+	return hdr[0], nil
+}
+
+func (ar *ArchiveReader) take(n uint64) ([]byte, error) {
+	if n > uint64(len(ar.payload)) {
+		return nil, fmt.Errorf("%w: record %d is too short",
+			ErrArchiveInvalid, ar.record)
+	}
+	b := ar.payload[:n:n]
+	ar.payload = ar.payload[n:]
+	return b, nil
+}
+
+func (ar *ArchiveReader) getUint32() (uint32, error) {
+	b, err := ar.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (ar *ArchiveReader) getUint64() (uint64, error) {
+	b, err := ar.take(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (ar *ArchiveReader) getBytes() ([]byte, error) {
+	n, err := ar.getUint32()
+	if err != nil {
+		return nil, err
+	}
+	return ar.take(uint64(n))
+}
+
+func (ar *ArchiveReader) getString() (string, error) {
+	b, err := ar.getBytes()
+	return string(b), err
+}
+
+func (ar *ArchiveReader) getMap() (map[string][]byte, error) {
+	n, err := ar.getUint32()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string][]byte{}
+	for i := uint32(0); i < n; i++ {
+		k, err := ar.getString()
+		if err != nil {
+			return nil, err
+		}
+		v, err := ar.getBytes()
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func (ar *ArchiveReader) decodeObject() (*ArchiveObject, error) {
+	var (
+		obj ArchiveObject
+		err error
+	)
+	strs := []*string{&obj.Namespace, &obj.Name, &obj.Locator}
+	for _, s := range strs {
+		if *s, err = ar.getString(); err != nil {
+			return nil, err
+		}
+	}
+	sec, err := ar.getUint64()
+	if err != nil {
+		return nil, err
+	}
+	nsec, err := ar.getUint32()
+	if err != nil {
+		return nil, err
+	}
+	obj.ModTime = time.Unix(int64(sec), int64(nsec))
+	size, err := ar.getUint64()
+	if err != nil {
+		return nil, err
+	}
+	if obj.Data, err = ar.take(size); err != nil {
+		return nil, err
+	}
+	if obj.Xattrs, err = ar.getMap(); err != nil {
+		return nil, err
+	}
+	if obj.Omap, err = ar.getMap(); err != nil {
+		return nil, err
+	}
+	if len(ar.payload) != 0 {
+		return nil, fmt.Errorf("%w: trailing data in record %d",
+			ErrArchiveInvalid, ar.record)
+	}
+	return &obj, nil
+}
+
+// Next returns the next object of the archive. It returns io.EOF after the
+// last object, and io.ErrUnexpectedEOF if the archive is truncated.
+func (ar *ArchiveReader) Next() (*ArchiveObject, error) {
+	if ar.done {
+		return nil, io.EOF
+	}
+	typ, err := ar.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case archiveRecordObject:
+		return ar.decodeObject()
+	case archiveRecordEnd:
+		count, err := ar.getUint64()
+		if err != nil {
+			return nil, err
+		}
+		if count != ar.record-1 {
+			return nil, fmt.Errorf("%w: expected %d objects, found %d",
+				ErrArchiveInvalid, count, ar.record-1)
+		}
+		ar.done = true
+		return nil, io.EOF
+	}
+	return nil, fmt.Errorf("%w: unknown type %d of record %d",
+		ErrArchiveInvalid, typ, ar.record)
+}
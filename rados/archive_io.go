@@ -0,0 +1,191 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/sys/unix"
+)
+
+// archiveOmapBatch is the number of omap pairs requested at once when
+// exporting objects.
+const archiveOmapBatch = 1000
+
+// privateIOContext opens a new IOContext for the pool of ioctx, so that the
+// namespace and locator can be changed without affecting the caller.
+func privateIOContext(ioctx *IOContext) (*IOContext, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	name, err := ioctx.GetPoolName()
+	if err != nil {
+		return nil, err
+	}
+	return ioctx.conn.OpenIOContext(name)
+}
+
+func readArchiveObject(ioctx *IOContext, oid string) (*ArchiveObject, error) {
+	stat, err := ioctx.Stat(oid)
+	if err != nil {
+		return nil, err
+	}
+	obj := &ArchiveObject{
+		Name:    oid,
+		ModTime: stat.ModTime,
+		Data:    make([]byte, stat.Size),
+	}
+	for off := 0; off < len(obj.Data); {
+		n, err := ioctx.Read(oid, obj.Data[off:], uint64(off))
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			// truncated since Stat
+			obj.Data = obj.Data[:off]
+			break
+		}
+		off += n
+	}
+	if obj.Xattrs, err = ioctx.ListXattrs(oid); err != nil {
+		return nil, err
+	}
+	obj.Omap, err = ioctx.GetAllOmapValues(oid, "", "", archiveOmapBatch)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// ExportArchive writes the objects of the pool of ioctx to w, in the archive
+// format read by ImportArchive and ArchiveReader. Only the objects in the
+// namespace of ioctx are exported, or the objects in all namespaces if the
+// namespace is set to AllNamespaces. It returns the number of exported
+// objects.
+//
+// WARNING: omap headers are lost. librados has no C API to read or write
+// them, so they are neither exported nor restored by ImportArchive. Objects
+// whose omap header matters can not be backed up with ExportArchive.
+//
+// The objects are read one call at a time, so an object that is modified
+// during the export may be archived partially modified. Objects removed
+// during the export are skipped.
+func ExportArchive(ioctx *IOContext, w io.Writer) (int, error) {
+	priv, err := privateIOContext(ioctx)
+	if err != nil {
+		return 0, err
+	}
+	defer priv.Destroy()
+
+	iter, err := ioctx.Iter()
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	aw, err := NewArchiveWriter(w)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for iter.Next() {
+		priv.SetNamespace(iter.Namespace())
+		priv.SetLocator(iter.Locator())
+		obj, err := readArchiveObject(priv, iter.Value())
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("exporting %q: %w", iter.Value(), err)
+		}
+		obj.Namespace = iter.Namespace()
+		obj.Locator = iter.Locator()
+		if err := aw.WriteObject(obj); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := iter.Err(); err != nil {
+		return count, err
+	}
+	return count, aw.Close()
+}
+
+// ImportOptions control how ImportArchive restores objects.
+type ImportOptions struct {
+	// Namespace, if not nil, replaces the namespace of all the imported
+	// objects.
+	Namespace *string
+	// Overwrite removes existing objects before importing them. Without
+	// Overwrite, importing an object that already exists fails with
+	// ErrObjectExists. The removal and import of an object are not atomic.
+	Overwrite bool
+}
+
+func importArchiveObject(ioctx *IOContext, obj *ArchiveObject,
+	opts *ImportOptions) error {
+
+	if opts.Overwrite {
+		if err := ioctx.Delete(obj.Name); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	op := CreateWriteOp()
+	defer op.Release()
+	op.Create(CreateExclusive)
+	if len(obj.Data) > 0 {
+		op.WriteFull(obj.Data)
+	}
+	for name, value := range obj.Xattrs {
+		op.SetXattr(name, value)
+	}
+	if len(obj.Omap) > 0 {
+		op.SetOmap(obj.Omap)
+	}
+	mtime := Timespec(unix.NsecToTimespec(obj.ModTime.UnixNano()))
+	return op.OperateWithMtime(ioctx, obj.Name, mtime, OperationNoFlag)
+}
+
+// ImportArchive restores the objects of an archive written by ExportArchive
+// or an ArchiveWriter into the pool of ioctx. Every object is created with
+// its data, xattrs, omap and modification time in a single WriteOp. The
+// archive is verified while it is read, so objects that precede a corrupt
+// record are imported before the error is returned. It returns the number
+// of imported objects.
+func ImportArchive(ioctx *IOContext, r io.Reader, opts *ImportOptions) (int, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	priv, err := privateIOContext(ioctx)
+	if err != nil {
+		return 0, err
+	}
+	defer priv.Destroy()
+
+	ar, err := NewArchiveReader(r)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for {
+		obj, err := ar.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		ns := obj.Namespace
+		if opts.Namespace != nil {
+			ns = *opts.Namespace
+		}
+		priv.SetNamespace(ns)
+		priv.SetLocator(obj.Locator)
+		if err := importArchiveObject(priv, obj, opts); err != nil {
+			return count, fmt.Errorf("importing %q: %w", obj.Name, err)
+		}
+		count++
+	}
+}
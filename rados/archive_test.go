@@ -0,0 +1,176 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testArchiveObjects() []*ArchiveObject {
+	return []*ArchiveObject{
+		{
+			Namespace: "ns",
+			Name:      "obj1",
+			Locator:   "loc",
+			ModTime:   time.Unix(1700000000, 5),
+			Data:      []byte("hello"),
+			Xattrs:    map[string][]byte{"a": []byte("1"), "b": {}},
+			Omap:      map[string][]byte{"k": []byte("v")},
+		},
+		{
+			Name:    "obj2",
+			ModTime: time.Unix(1700000001, 0),
+			Data:    []byte{},
+			Xattrs:  map[string][]byte{},
+			Omap:    map[string][]byte{},
+		},
+	}
+}
+
+func writeTestArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	aw, err := NewArchiveWriter(&buf)
+	require.NoError(t, err)
+	for _, obj := range testArchiveObjects() {
+		require.NoError(t, aw.WriteObject(obj))
+	}
+	require.NoError(t, aw.Close())
+	return buf.Bytes()
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	ar, err := NewArchiveReader(bytes.NewReader(writeTestArchive(t)))
+	require.NoError(t, err)
+	for _, expected := range testArchiveObjects() {
+		obj, err := ar.Next()
+		require.NoError(t, err)
+		assert.Equal(t, expected.Namespace, obj.Namespace)
+		assert.Equal(t, expected.Name, obj.Name)
+		assert.Equal(t, expected.Locator, obj.Locator)
+		assert.True(t, expected.ModTime.Equal(obj.ModTime))
+		assert.Equal(t, expected.Data, obj.Data)
+		assert.Equal(t, expected.Xattrs, obj.Xattrs)
+		assert.Equal(t, expected.Omap, obj.Omap)
+	}
+	_, err = ar.Next()
+	assert.Equal(t, io.EOF, err)
+	_, err = ar.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestArchiveInvalid(t *testing.T) {
+	data := writeTestArchive(t)
+
+	t.Run("badMagic", func(t *testing.T) {
+		_, err := NewArchiveReader(bytes.NewReader([]byte("not an archive!!")))
+		assert.ErrorIs(t, err, ErrArchiveInvalid)
+	})
+	t.Run("badVersion", func(t *testing.T) {
+		b := append([]byte(nil), data...)
+		b[8] = 2
+		_, err := NewArchiveReader(bytes.NewReader(b))
+		assert.ErrorIs(t, err, ErrArchiveInvalid)
+	})
+	t.Run("badFlags", func(t *testing.T) {
+		b := append([]byte(nil), data...)
+		b[12] = 1
+		_, err := NewArchiveReader(bytes.NewReader(b))
+		assert.ErrorIs(t, err, ErrArchiveInvalid)
+	})
+	t.Run("checksum", func(t *testing.T) {
+		b := append([]byte(nil), data...)
+		b[30] ^= 0xff
+		ar, err := NewArchiveReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		_, err = ar.Next()
+		assert.ErrorIs(t, err, ErrArchiveChecksum)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		// drop the end record
+		ar, err := NewArchiveReader(bytes.NewReader(data[:len(data)-17]))
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err = ar.Next()
+			require.NoError(t, err)
+		}
+		_, err = ar.Next()
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+	})
+}
+
+func (suite *RadosTestSuite) TestExportImportArchive() {
+	suite.SetupConnection()
+	t := suite.T()
+
+	ioctx, err := suite.conn.OpenIOContext(suite.pool)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+	ns := "export-" + uuid.Must(uuid.NewV4()).String()
+	ioctx.SetNamespace(ns)
+
+	mtime := Timespec{Sec: 1600000000}
+	op := CreateWriteOp()
+	op.Create(CreateExclusive)
+	op.WriteFull([]byte("data"))
+	op.SetXattr("x", []byte("y"))
+	op.SetOmap(map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")})
+	require.NoError(t, op.OperateWithMtime(ioctx, "obj1", mtime, OperationNoFlag))
+	op.Release()
+	require.NoError(t, ioctx.Create("empty", CreateExclusive))
+	ioctx.SetLocator("loc")
+	require.NoError(t, ioctx.WriteFull("located", []byte("here")))
+	ioctx.SetLocator("")
+
+	var buf bytes.Buffer
+	count, err := ExportArchive(ioctx, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	target := ns + "-import"
+	count, err = ImportArchive(ioctx, bytes.NewReader(buf.Bytes()),
+		&ImportOptions{Namespace: &target})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	dst, err := suite.conn.OpenIOContext(suite.pool)
+	require.NoError(t, err)
+	defer dst.Destroy()
+	dst.SetNamespace(target)
+
+	data := make([]byte, 10)
+	n, err := dst.Read("obj1", data, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data[:n]))
+	stat, err := dst.Stat("obj1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1600000000), stat.ModTime.Unix())
+	xattrs, err := dst.ListXattrs("obj1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"x": []byte("y")}, xattrs)
+	omap, err := dst.GetAllOmapValues("obj1", "", "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, omap, 2)
+	stat, err = dst.Stat("empty")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, stat.Size)
+	dst.SetLocator("loc")
+	n, err = dst.Read("located", data, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "here", string(data[:n]))
+
+	// importing again fails unless existing objects are overwritten
+	_, err = ImportArchive(ioctx, bytes.NewReader(buf.Bytes()),
+		&ImportOptions{Namespace: &target})
+	assert.ErrorIs(t, err, ErrObjectExists)
+	count, err = ImportArchive(ioctx, bytes.NewReader(buf.Bytes()),
+		&ImportOptions{Namespace: &target, Overwrite: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,77 @@
+package rados
+
+/*
+#cgo LDFLAGS: -lrados
+#include <stdlib.h>
+#include <rados/librados.h>
+
+extern void clusterLogCallback(void *, char *, char *, char *, char *,
+	uint64_t, uint64_t, uint64_t, char *, char *);
+
+// inline wrapper to cast away the constness of the callback arguments
+static inline int wrap_rados_monitor_log2(rados_t cluster, const char *level) {
+	return rados_monitor_log2(cluster, level,
+		(rados_log_callback2_t)clusterLogCallback, NULL);
+};
+*/
+import "C"
+
+import (
+	"log/slog"
+	"time"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/log"
+)
+
+// clusterLogLevels maps the levels of the cluster log to slog levels.
+var clusterLogLevels = map[string]slog.Level{
+	"[DBG]": slog.LevelDebug,
+	"[INF]": slog.LevelInfo,
+	"[SEC]": slog.LevelInfo,
+	"[WRN]": slog.LevelWarn,
+	"[ERR]": slog.LevelError,
+}
+
+// monitorLog subscribes to the cluster log messages of the given level and
+// above and forwards them to the go-ceph logger. A nil level ends the
+// subscription.
+//
+// Implements:
+//
+//	int rados_monitor_log2(rados_t cluster, const char *level,
+//	                       rados_log_callback2_t cb, void *arg);
+func (c *Conn) monitorLog(level *string) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+	if level == nil {
+		// the level is not used to unsubscribe, but it must be valid
+		cLevel := C.CString("info")
+		defer C.free(unsafe.Pointer(cLevel))
+		t := c.startOp("rados_monitor_log2", "")
+		return t.DoneErr(getError(C.rados_monitor_log2(c.cluster, cLevel, nil, nil)))
+	}
+	cLevel := C.CString(*level)
+	defer C.free(unsafe.Pointer(cLevel))
+	t := c.startOp("rados_monitor_log2", "")
+	return t.DoneErr(getError(C.wrap_rados_monitor_log2(c.cluster, cLevel)))
+}
+
+//export clusterLogCallback
+func clusterLogCallback(
+	_ unsafe.Pointer, _, cChannel, cWho, cName *C.char,
+	sec, nsec, seq C.uint64_t, cLevel, cMsg *C.char) {
+
+	level, ok := clusterLogLevels[C.GoString(cLevel)]
+	if !ok {
+		level = slog.LevelInfo
+	}
+	log.Log(level, C.GoString(cMsg),
+		"source", "cluster",
+		"channel", C.GoString(cChannel),
+		"who", C.GoString(cWho),
+		"name", C.GoString(cName),
+		"stamp", time.Unix(int64(sec), int64(nsec)),
+		"seq", uint64(seq))
+}
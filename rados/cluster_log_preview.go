@@ -0,0 +1,29 @@
+//go:build ceph_preview
+
+package rados
+
+// ForwardClusterLog subscribes to the messages of the cluster log and
+// forwards them to the go-ceph logger configured with the common/log package.
+// Only messages with the given level or above are forwarded. Valid levels
+// are "debug", "info", "warn" (or "warning"), "err" (or "error") and "sec".
+// Calling ForwardClusterLog again changes the level of the subscription.
+//
+// The messages are logged with the slog level matching their cluster log
+// level and carry the "channel", "who", "name", "stamp" and "seq" attributes.
+// Note that librados and libcephfs do not provide a callback for their
+// client-side debug log, which is only written to stderr or a log file
+// according to the log_to_stderr and log_file options.
+//
+// Implements:
+//
+//	int rados_monitor_log2(rados_t cluster, const char *level,
+//	                       rados_log_callback2_t cb, void *arg);
+func (c *Conn) ForwardClusterLog(level string) error {
+	return c.monitorLog(&level)
+}
+
+// StopClusterLog ends the forwarding of the cluster log started with
+// ForwardClusterLog.
+func (c *Conn) StopClusterLog() error {
+	return c.monitorLog(nil)
+}
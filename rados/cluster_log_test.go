@@ -0,0 +1,67 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/common/log"
+)
+
+// chanHandler is a slog.Handler that sends the message of every record to a
+// channel.
+type chanHandler struct {
+	msgs chan string
+}
+
+func (h *chanHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *chanHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *chanHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *chanHandler) Handle(_ context.Context, r slog.Record) error {
+	select {
+	case h.msgs <- r.Message:
+	default:
+	}
+	return nil
+}
+
+func (suite *RadosTestSuite) TestForwardClusterLog() {
+	suite.SetupConnection()
+	t := suite.T()
+
+	h := &chanHandler{msgs: make(chan string, 64)}
+	log.SetLogger(slog.New(h))
+	defer log.SetLogger(nil)
+
+	err := suite.conn.ForwardClusterLog("invalid")
+	assert.Error(t, err)
+
+	err = suite.conn.ForwardClusterLog("info")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, suite.conn.StopClusterLog())
+	}()
+
+	text := "go-ceph cluster log test " + suite.GenObjectName()
+	_, _, err = suite.conn.MonCommand([]byte(
+		`{"prefix": "log", "logtext": ["` + text + `"]}`))
+	require.NoError(t, err)
+
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case msg := <-h.msgs:
+			if msg == text {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("cluster log message %q not received", text)
+		}
+	}
+}
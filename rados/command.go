@@ -27,6 +27,7 @@ func (c *Conn) MonCommandWithInputBuffer(args, inputBuffer []byte) ([]byte, stri
 	co := cutil.NewCommandOutput().SetFreeFunc(radosBufferFree)
 	defer co.Free()
 
+	t := c.startCommand("rados_mon_command", args)
 	ret := C.rados_mon_command(
 		c.cluster,
 		(**C.char)(ci.Cmd()),
@@ -38,7 +39,9 @@ func (c *Conn) MonCommandWithInputBuffer(args, inputBuffer []byte) ([]byte, stri
 		(**C.char)(co.Outs()),
 		(*C.size_t)(co.OutsLen()))
 	buf, status := co.GoValues()
-	return buf, status, getError(ret)
+	err := getError(ret)
+	t.Done(int64(len(buf)), err)
+	return buf, status, err
 }
 
 // PGCommand sends a command to one of the PGs
@@ -71,6 +74,7 @@ func (c *Conn) PGCommandWithInputBuffer(pgid []byte, args [][]byte, inputBuffer
 	co := cutil.NewCommandOutput().SetFreeFunc(radosBufferFree)
 	defer co.Free()
 
+	t := c.startCommand("rados_pg_command", firstArg(args))
 	ret := C.rados_pg_command(
 		c.cluster,
 		name,
@@ -83,7 +87,9 @@ func (c *Conn) PGCommandWithInputBuffer(pgid []byte, args [][]byte, inputBuffer
 		(**C.char)(co.Outs()),
 		(*C.size_t)(co.OutsLen()))
 	buf, status := co.GoValues()
-	return buf, status, getError(ret)
+	err := getError(ret)
+	t.Done(int64(len(buf)), err)
+	return buf, status, err
 }
 
 // MgrCommand sends a command to a ceph-mgr.
@@ -106,6 +112,7 @@ func (c *Conn) MgrCommandWithInputBuffer(args [][]byte, inputBuffer []byte) ([]b
 	co := cutil.NewCommandOutput().SetFreeFunc(radosBufferFree)
 	defer co.Free()
 
+	t := c.startCommand("rados_mgr_command", firstArg(args))
 	ret := C.rados_mgr_command(
 		c.cluster,
 		(**C.char)(ci.Cmd()),
@@ -117,7 +124,9 @@ func (c *Conn) MgrCommandWithInputBuffer(args [][]byte, inputBuffer []byte) ([]b
 		(**C.char)(co.Outs()),
 		(*C.size_t)(co.OutsLen()))
 	buf, status := co.GoValues()
-	return buf, status, getError(ret)
+	err := getError(ret)
+	t.Done(int64(len(buf)), err)
+	return buf, status, err
 }
 
 // OsdCommand sends a command to the specified ceph OSD.
@@ -143,6 +152,7 @@ func (c *Conn) OsdCommandWithInputBuffer(
 	co := cutil.NewCommandOutput().SetFreeFunc(radosBufferFree)
 	defer co.Free()
 
+	t := c.startCommand("rados_osd_command", firstArg(args))
 	ret := C.rados_osd_command(
 		c.cluster,
 		C.int(osd),
@@ -155,7 +165,9 @@ func (c *Conn) OsdCommandWithInputBuffer(
 		(**C.char)(co.Outs()),
 		(*C.size_t)(co.OutsLen()))
 	buf, status := co.GoValues()
-	return buf, status, getError(ret)
+	err := getError(ret)
+	t.Done(int64(len(buf)), err)
+	return buf, status, err
 }
 
 // MonCommandTarget sends a command to a specified monitor.
@@ -182,6 +194,7 @@ func (c *Conn) MonCommandTargetWithInputBuffer(
 	co := cutil.NewCommandOutput().SetFreeFunc(radosBufferFree)
 	defer co.Free()
 
+	t := c.startCommand("rados_mon_command_target", firstArg(args))
 	ret := C.rados_mon_command_target(
 		c.cluster,
 		cName,
@@ -194,5 +207,7 @@ func (c *Conn) MonCommandTargetWithInputBuffer(
 		(**C.char)(co.Outs()),
 		(*C.size_t)(co.OutsLen()))
 	buf, status := co.GoValues()
-	return buf, status, getError(ret)
+	err := getError(ret)
+	t.Done(int64(len(buf)), err)
+	return buf, status, err
 }
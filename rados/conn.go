@@ -9,6 +9,7 @@ import (
 	"unsafe"
 
 	"github.com/ceph/go-ceph/internal/cutil"
+	"github.com/ceph/go-ceph/internal/hooks"
 	"github.com/ceph/go-ceph/internal/retry"
 )
 
@@ -30,6 +31,7 @@ type ClusterStat struct {
 type Conn struct {
 	cluster   C.rados_t
 	connected bool
+	hook      hooks.Holder
 }
 
 // ClusterRef represents a fundamental RADOS cluster connection.
@@ -48,8 +50,10 @@ func (c *Conn) PingMonitor(id string) (string, error) {
 	var strlen C.size_t
 	var strout *C.char
 
+	t := c.startOp("rados_ping_monitor", "")
 	ret := C.rados_ping_monitor(c.cluster, cid, &strout, &strlen)
 	defer C.rados_buffer_free(strout)
+	t.Done(0, getError(ret))
 
 	if ret == 0 {
 		reply := C.GoStringN(strout, (C.int)(strlen))
@@ -61,7 +65,9 @@ func (c *Conn) PingMonitor(id string) (string, error) {
 // Connect establishes a connection to a RADOS cluster. It returns an error,
 // if any.
 func (c *Conn) Connect() error {
+	t := c.startOp("rados_connect", "")
 	ret := C.rados_connect(c.cluster)
+	t.Done(0, getError(ret))
 	if ret != 0 {
 		return getError(ret)
 	}
@@ -81,15 +87,17 @@ func (c *Conn) Shutdown() {
 func (c *Conn) ReadConfigFile(path string) error {
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
+	t := c.startOp("rados_conf_read_file", "")
 	ret := C.rados_conf_read_file(c.cluster, cPath)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // ReadDefaultConfigFile configures the connection using a Ceph configuration
 // file located at default locations.
 func (c *Conn) ReadDefaultConfigFile() error {
+	t := c.startOp("rados_conf_read_file", "")
 	ret := C.rados_conf_read_file(c.cluster, nil)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // OpenIOContext creates and returns a new IOContext for the given pool.
@@ -101,8 +109,10 @@ func (c *Conn) ReadDefaultConfigFile() error {
 func (c *Conn) OpenIOContext(pool string) (*IOContext, error) {
 	cPool := C.CString(pool)
 	defer C.free(unsafe.Pointer(cPool))
-	ioctx := &IOContext{conn: c}
+	ioctx := &IOContext{conn: c, pool: pool}
+	t := c.startOp("rados_ioctx_create", pool)
 	ret := C.rados_ioctx_create(c.cluster, cPool, &ioctx.ioctx)
+	t.Done(0, getError(ret))
 	if ret == 0 {
 		return ioctx, nil
 	}
@@ -113,8 +123,10 @@ func (c *Conn) OpenIOContext(pool string) (*IOContext, error) {
 func (c *Conn) ListPools() (names []string, err error) {
 	buf := make([]byte, 4096)
 	for {
+		t := c.startOp("rados_pool_list", "")
 		ret := C.rados_pool_list(c.cluster,
 			(*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))
+		t.Done(0, getErrorIfNegative(ret))
 		if ret < 0 {
 			return nil, getError(ret)
 		}
@@ -135,8 +147,9 @@ func (c *Conn) SetConfigOption(option, value string) error {
 	cOpt, cVal := C.CString(option), C.CString(value)
 	defer C.free(unsafe.Pointer(cOpt))
 	defer C.free(unsafe.Pointer(cVal))
+	t := c.startOp("rados_conf_set", "")
 	ret := C.rados_conf_set(c.cluster, cOpt, cVal)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GetConfigOption returns the value of the Ceph configuration option
@@ -149,12 +162,13 @@ func (c *Conn) GetConfigOption(name string) (value string, err error) {
 	// range from 4k to 256KiB
 	retry.WithSizes(4096, 1<<18, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := c.startOp("rados_conf_get", "")
 		ret := C.rados_conf_get(
 			c.cluster,
 			cOption,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.size_t(len(buf)))
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.DoubleSize.If(err == errNameTooLong)
 	})
 	if err != nil {
@@ -167,8 +181,9 @@ func (c *Conn) GetConfigOption(name string) (value string, err error) {
 // WaitForLatestOSDMap blocks the caller until the latest OSD map has been
 // retrieved.
 func (c *Conn) WaitForLatestOSDMap() error {
+	t := c.startOp("rados_wait_for_latest_osdmap", "")
 	ret := C.rados_wait_for_latest_osdmap(c.cluster)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 func (c *Conn) ensureConnected() error {
@@ -185,7 +200,9 @@ func (c *Conn) GetClusterStats() (stat ClusterStat, err error) {
 		return ClusterStat{}, err
 	}
 	cStat := C.struct_rados_cluster_stat_t{}
+	t := c.startOp("rados_cluster_stat", "")
 	ret := C.rados_cluster_stat(c.cluster, &cStat)
+	t.Done(0, getError(ret))
 	if ret < 0 {
 		return ClusterStat{}, getError(ret)
 	}
@@ -217,8 +234,9 @@ func (c *Conn) ParseConfigArgv(argv []string) error {
 		defer C.free(unsafe.Pointer(cargv[i]))
 	}
 
+	t := c.startOp("rados_conf_parse_argv", "")
 	ret := C.rados_conf_parse_argv(c.cluster, C.int(len(cargv)), &cargv[0])
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // ParseCmdLineArgs configures the connection from command line arguments.
@@ -240,17 +258,20 @@ func (c *Conn) ParseCmdLineArgs(args []string) error {
 // ParseDefaultConfigEnv configures the connection from the default Ceph
 // environment variable CEPH_ARGS.
 func (c *Conn) ParseDefaultConfigEnv() error {
+	t := c.startOp("rados_conf_parse_env", "")
 	ret := C.rados_conf_parse_env(c.cluster, nil)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GetFSID returns the fsid of the cluster as a hexadecimal string. The fsid
 // is a unique identifier of an entire Ceph cluster.
 func (c *Conn) GetFSID() (fsid string, err error) {
 	buf := make([]byte, 37)
+	t := c.startOp("rados_cluster_fsid", "")
 	ret := C.rados_cluster_fsid(c.cluster,
 		(*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))
 	// FIXME: the success case isn't documented correctly in librados.h
+	t.Done(0, getErrorIfNegative(ret))
 	if ret == 36 {
 		fsid = C.GoString((*C.char)(unsafe.Pointer(&buf[0])))
 		return fsid, nil
@@ -262,15 +283,19 @@ func (c *Conn) GetFSID() (fsid string, err error) {
 // connection instance.
 func (c *Conn) GetInstanceID() uint64 {
 	// FIXME: are there any error cases for this?
-	return uint64(C.rados_get_instance_id(c.cluster))
+	t := c.startOp("rados_get_instance_id", "")
+	id := C.rados_get_instance_id(c.cluster)
+	t.Done(0, nil)
+	return uint64(id)
 }
 
 // MakePool creates a new pool with default settings.
 func (c *Conn) MakePool(name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
+	t := c.startOp("rados_pool_create", name)
 	ret := C.rados_pool_create(c.cluster, cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // DeletePool deletes a pool and all the data inside the pool.
@@ -280,8 +305,9 @@ func (c *Conn) DeletePool(name string) error {
 	}
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
+	t := c.startOp("rados_pool_delete", name)
 	ret := C.rados_pool_delete(c.cluster, cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GetPoolByName returns the ID of the pool with a given name.
@@ -291,7 +317,9 @@ func (c *Conn) GetPoolByName(name string) (int64, error) {
 	}
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
+	t := c.startOp("rados_pool_lookup", name)
 	ret := C.rados_pool_lookup(c.cluster, cName)
+	t.Done(0, getErrorIfNegative(C.int(ret)))
 	if ret < 0 {
 		return 0, getError(C.int(ret))
 	}
@@ -305,7 +333,9 @@ func (c *Conn) GetPoolByID(id int64) (string, error) {
 		return "", err
 	}
 	cid := C.int64_t(id)
+	t := c.startOp("rados_pool_reverse_lookup", "")
 	ret := C.rados_pool_reverse_lookup(c.cluster, cid, (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return "", getError(ret)
 	}
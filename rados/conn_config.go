@@ -0,0 +1,163 @@
+//go:build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <rados/librados.h>
+import "C"
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultClusterName is the cluster name used by a ConnConfig without
+	// a ClusterName.
+	DefaultClusterName = "ceph"
+	// DefaultUser is the user used by a ConnConfig without a User.
+	DefaultUser = "admin"
+)
+
+// ConnConfig describes how a Conn is created and configured, as an
+// alternative to calling NewConnWithClusterAndUser, ReadConfigFile and
+// SetConfigOption one by one.
+//
+// The configuration is applied in the following order: the configuration
+// file, the monitor hosts and keys, the Options and lastly the timeouts. A
+// setting that is applied later overrides the earlier ones.
+type ConnConfig struct {
+	// ClusterName is the name of the cluster. Defaults to "ceph".
+	ClusterName string
+	// User is the ID of the client, without the "client." prefix.
+	// Defaults to "admin".
+	User string
+
+	// ConfigFile is the path of the Ceph configuration file to read. If it
+	// is empty and no MonHosts are set, the configuration file is looked up
+	// at the default locations.
+	ConfigFile string
+	// MonHosts are the addresses of the monitors (mon_host).
+	MonHosts []string
+	// Key is the secret key of the user (key).
+	Key string
+	// Keyring is the path of the keyring file of the user (keyring).
+	Keyring string
+
+	// Options are set on the connection with SetConfigOption.
+	Options map[string]string
+
+	// MountTimeout limits the time spent to connect to the cluster
+	// (client_mount_timeout).
+	MountTimeout time.Duration
+	// MonOpTimeout limits the time spent waiting for the monitors to
+	// reply to a request (rados_mon_op_timeout).
+	MonOpTimeout time.Duration
+	// OSDOpTimeout limits the time spent waiting for the OSDs to reply to a
+	// request (rados_osd_op_timeout).
+	OSDOpTimeout time.Duration
+}
+
+func (cfg *ConnConfig) clusterName() string {
+	if cfg.ClusterName == "" {
+		return DefaultClusterName
+	}
+	return cfg.ClusterName
+}
+
+func (cfg *ConnConfig) user() string {
+	if cfg.User == "" {
+		return DefaultUser
+	}
+	return cfg.User
+}
+
+// formatSeconds formats a duration as used by the timeout options of Ceph.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// settings returns the configuration options to set on the connection, in
+// the order they are applied.
+func (cfg *ConnConfig) settings() [][2]string {
+	var s [][2]string
+	if len(cfg.MonHosts) > 0 {
+		s = append(s, [2]string{"mon_host", strings.Join(cfg.MonHosts, ",")})
+	}
+	if cfg.Key != "" {
+		s = append(s, [2]string{"key", cfg.Key})
+	}
+	if cfg.Keyring != "" {
+		s = append(s, [2]string{"keyring", cfg.Keyring})
+	}
+	names := make([]string, 0, len(cfg.Options))
+	for name := range cfg.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s = append(s, [2]string{name, cfg.Options[name]})
+	}
+	timeouts := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"client_mount_timeout", cfg.MountTimeout},
+		{"rados_mon_op_timeout", cfg.MonOpTimeout},
+		{"rados_osd_op_timeout", cfg.OSDOpTimeout},
+	}
+	for _, t := range timeouts {
+		if t.d > 0 {
+			s = append(s, [2]string{t.name, formatSeconds(t.d)})
+		}
+	}
+	return s
+}
+
+// NewConnWithConfig creates a new connection object configured as described
+// by cfg. The connection is not connected to the cluster yet, call Connect
+// to do so.
+func NewConnWithConfig(cfg ConnConfig) (*Conn, error) {
+	conn, err := NewConnWithClusterAndUser(
+		cfg.clusterName(), "client."+cfg.user())
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.apply(conn); err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (cfg *ConnConfig) apply(conn *Conn) error {
+	var err error
+	switch {
+	case cfg.ConfigFile != "":
+		err = conn.ReadConfigFile(cfg.ConfigFile)
+	case len(cfg.MonHosts) == 0:
+		err = conn.ReadDefaultConfigFile()
+	}
+	if err != nil {
+		return err
+	}
+	for _, s := range cfg.settings() {
+		if err := conn.SetConfigOption(s[0], s[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discardConn releases a connection that is not connected to the cluster,
+// without waiting for the finalizer.
+func discardConn(conn *Conn) {
+	if conn.cluster != nil {
+		t := conn.startOp("rados_shutdown", "")
+		C.rados_shutdown(conn.cluster)
+		t.Done(0, nil)
+		conn.cluster = nil
+	}
+}
@@ -0,0 +1,20 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"github.com/ceph/go-ceph/common/hooks"
+)
+
+// SetHook sets a Hook that is called for the calls into librados made using
+// this connection and the IOContexts created from it. The Hook is also used
+// by the rbd package for images opened using these IOContexts and by cephfs
+// mounts created from this connection. This hook takes precedence over the
+// global hook set by hooks.SetGlobal. Setting a nil Hook makes the connection
+// use the global hook again.
+//
+// SetHook can be called while the connection is in use. Calls that already
+// started report to the previous Hook.
+func (c *Conn) SetHook(h hooks.Hook) {
+	c.hook.Set(h)
+}
@@ -0,0 +1,97 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ceph/go-ceph/common/hooks"
+)
+
+type recordingHook struct {
+	ops []hooks.Op
+}
+
+func (r *recordingHook) OpDone(op *hooks.Op) {
+	r.ops = append(r.ops, *op)
+}
+
+func (suite *RadosTestSuite) TestConnHook() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	h := &recordingHook{}
+	suite.conn.SetHook(h)
+	defer suite.conn.SetHook(nil)
+
+	oid := suite.GenObjectName()
+	data := []byte("hooked on data")
+	err := suite.ioctx.Write(oid, data, 0)
+	ta.NoError(err)
+	buf := make([]byte, 64)
+	n, err := suite.ioctx.Read(oid, buf, 0)
+	ta.NoError(err)
+	ta.Equal(len(data), n)
+	_, err = suite.ioctx.Stat("no-such-object")
+	ta.Error(err)
+	_, _, err = suite.conn.MonCommand([]byte(`{"prefix": "mon dump", "format": "json"}`))
+	ta.NoError(err)
+
+	if ta.Len(h.ops, 4) {
+		ta.Equal("rados_write", h.ops[0].Name)
+		ta.Equal(suite.pool, h.ops[0].Pool)
+		ta.Equal(oid, h.ops[0].Object)
+		ta.EqualValues(len(data), h.ops[0].Bytes)
+		ta.NoError(h.ops[0].Err)
+
+		ta.Equal("rados_read", h.ops[1].Name)
+		ta.EqualValues(len(data), h.ops[1].Bytes)
+
+		ta.Equal("rados_stat", h.ops[2].Name)
+		ta.ErrorIs(h.ops[2].Err, ErrNotFound)
+
+		ta.Equal("rados_mon_command", h.ops[3].Name)
+		ta.Contains(h.ops[3].Command, "mon dump")
+		ta.Greater(h.ops[3].Bytes, int64(0))
+	}
+
+	// lock calls are reported
+	h.ops = nil
+	_, err = suite.ioctx.LockExclusive(oid, "lock", "cookie", "", 0, nil)
+	ta.NoError(err)
+	_, err = suite.ioctx.Unlock(oid, "lock", "cookie")
+	ta.NoError(err)
+	if ta.Len(h.ops, 2) {
+		ta.Equal("rados_lock_exclusive", h.ops[0].Name)
+		ta.Equal(oid, h.ops[0].Object)
+		ta.Equal("rados_unlock", h.ops[1].Name)
+	}
+
+	// pool and snapshot calls are reported
+	h.ops = nil
+	_, err = suite.conn.GetPoolByName(suite.pool)
+	ta.NoError(err)
+	err = suite.ioctx.CreateSnap("hooked")
+	ta.NoError(err)
+	err = suite.ioctx.RemoveSnap("hooked")
+	ta.NoError(err)
+	if ta.Len(h.ops, 3) {
+		ta.Equal("rados_pool_lookup", h.ops[0].Name)
+		ta.Equal(suite.pool, h.ops[0].Pool)
+		ta.Equal("rados_ioctx_snap_create", h.ops[1].Name)
+		ta.Equal(suite.pool, h.ops[1].Pool)
+		ta.Equal("rados_ioctx_snap_remove", h.ops[2].Name)
+	}
+
+	// global hook is used when no conn hook is set
+	suite.conn.SetHook(nil)
+	g := &recordingHook{}
+	hooks.SetGlobal(g)
+	defer hooks.SetGlobal(nil)
+	err = suite.ioctx.Delete(oid)
+	ta.NoError(err)
+	ta.Len(h.ops, 3)
+	if ta.Len(g.ops, 1) {
+		ta.Equal("rados_remove", g.ops[0].Name)
+	}
+}
@@ -0,0 +1,292 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+)
+
+// ErrSharedConnReleased is returned when a SharedConn is used after it was
+// released.
+var ErrSharedConnReleased = errors.New("shared connection was released")
+
+// connKey identifies the connections that are shared by a ConnManager.
+type connKey struct {
+	cluster string
+	user    string
+}
+
+// ioctxKey identifies the IOContexts that are cached by a ConnManager.
+type ioctxKey struct {
+	pool      string
+	namespace string
+}
+
+// managedConn is the state of a connection shared by a ConnManager.
+type managedConn struct {
+	cfg  ConnConfig
+	refs int
+
+	// connMutex serializes connecting, so that a slow connect does not
+	// block the manager
+	connMutex sync.Mutex
+	// conn and ioctxs are protected by the mutex of the manager
+	conn   *Conn
+	ioctxs map[ioctxKey]*IOContext
+	// retired holds the connections and IOContexts that were replaced
+	// after a fatal error. They may still be in use and are only released
+	// when the last reference is released.
+	retired       []*Conn
+	retiredIOCtxs []*IOContext
+}
+
+// ConnManager hands out connections that are shared by all users of the
+// same cluster and user. The connections are created from a ConnConfig and
+// connected on first use. A connection is shut down when the last
+// SharedConn referring to it is released.
+//
+// The manager does not watch the connections it hands out. Errors returned
+// by calls that use a shared connection, or one of its IOContexts, must be
+// passed to SharedConn.ReportError, so that a connection that can no longer
+// be used (see IsFatalError) is replaced. Only the fatal errors of the calls
+// made by the manager itself, such as opening an IOContext, are detected
+// without ReportError.
+//
+// A ConnManager is safe for concurrent use.
+type ConnManager struct {
+	mutex sync.Mutex
+	conns map[connKey]*managedConn
+}
+
+// NewConnManager returns a new ConnManager.
+func NewConnManager() *ConnManager {
+	return &ConnManager{conns: map[connKey]*managedConn{}}
+}
+
+// SharedConn is a reference to a connection shared by a ConnManager. It must
+// be released with Release when it is no longer needed.
+type SharedConn struct {
+	manager *ConnManager
+	key     connKey
+	mc      *managedConn
+	once    sync.Once
+	// released is protected by the mutex of the manager
+	released bool
+}
+
+// Get returns a reference to the connection for the cluster and user of
+// cfg. If the manager already has a connection for them, the rest of cfg is
+// ignored and the existing connection is shared. Get does not connect to
+// the cluster, this happens on the first call to Conn or IOContext.
+func (m *ConnManager) Get(cfg ConnConfig) *SharedConn {
+	key := connKey{cluster: cfg.clusterName(), user: cfg.user()}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	mc, ok := m.conns[key]
+	if !ok {
+		mc = &managedConn{cfg: cfg, ioctxs: map[ioctxKey]*IOContext{}}
+		m.conns[key] = mc
+	}
+	mc.refs++
+	return &SharedConn{manager: m, key: key, mc: mc}
+}
+
+// Conn returns the connection, connecting to the cluster if needed. The
+// connection must not be shut down by the caller. Callers should not keep
+// the connection around, but call Conn again, so that they pick up a new
+// connection after a fatal error was reported with ReportError. Conn
+// returns ErrSharedConnReleased if s was released.
+func (s *SharedConn) Conn() (*Conn, error) {
+	m, mc := s.manager, s.mc
+	m.mutex.Lock()
+	conn, released := mc.conn, s.released
+	m.mutex.Unlock()
+	if released {
+		return nil, ErrSharedConnReleased
+	}
+	if conn != nil {
+		return conn, nil
+	}
+
+	mc.connMutex.Lock()
+	defer mc.connMutex.Unlock()
+	m.mutex.Lock()
+	conn, released = mc.conn, s.released
+	m.mutex.Unlock()
+	if released {
+		return nil, ErrSharedConnReleased
+	}
+	if conn != nil {
+		// connected while waiting for the connMutex
+		return conn, nil
+	}
+	conn, err := NewConnWithConfig(mc.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Connect(); err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+	m.mutex.Lock()
+	if s.released {
+		// released while connecting, the connection would never be shut
+		// down
+		m.mutex.Unlock()
+		conn.Shutdown()
+		return nil, ErrSharedConnReleased
+	}
+	mc.conn = conn
+	m.mutex.Unlock()
+	return conn, nil
+}
+
+// IOContext returns an IOContext for the pool with the namespace set. The
+// IOContexts are cached and shared by all users of the connection, so the
+// caller must neither destroy the IOContext nor change its namespace or
+// other settings. If opening the IOContext fails with a fatal error, the
+// connection is replaced and the IOContext is opened again. IOContext
+// returns ErrSharedConnReleased if s was released.
+func (s *SharedConn) IOContext(pool, namespace string) (*IOContext, error) {
+	m, mc := s.manager, s.mc
+	key := ioctxKey{pool: pool, namespace: namespace}
+	m.mutex.Lock()
+	ioctx, ok := mc.ioctxs[key]
+	released := s.released
+	m.mutex.Unlock()
+	if released {
+		return nil, ErrSharedConnReleased
+	}
+	if ok {
+		return ioctx, nil
+	}
+
+	conn, ioctx, err := s.openIOContext(pool)
+	if err != nil {
+		return nil, err
+	}
+	ioctx.SetNamespace(namespace)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if s.released {
+		ioctx.Destroy()
+		return nil, ErrSharedConnReleased
+	}
+	if cached, ok := mc.ioctxs[key]; ok && cached.conn == conn {
+		// opened concurrently by another caller
+		ioctx.Destroy()
+		return cached, nil
+	}
+	if mc.conn != conn {
+		// the connection was retired in the meantime
+		mc.retiredIOCtxs = append(mc.retiredIOCtxs, ioctx)
+		return ioctx, nil
+	}
+	mc.ioctxs[key] = ioctx
+	return ioctx, nil
+}
+
+// openIOContext opens an IOContext for the pool. If this fails with a fatal
+// error, the connection is retired and the IOContext is opened once more
+// with a new connection.
+func (s *SharedConn) openIOContext(pool string) (*Conn, *IOContext, error) {
+	conn, err := s.Conn()
+	if err != nil {
+		return nil, nil, err
+	}
+	ioctx, err := conn.OpenIOContext(pool)
+	if err != nil && IsFatalError(err) && s.retire(conn) {
+		if conn, err = s.Conn(); err != nil {
+			return nil, nil, err
+		}
+		ioctx, err = conn.OpenIOContext(pool)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, ioctx, nil
+}
+
+// retire retires conn and its IOContexts, if conn is still the current
+// connection. It returns true if conn was retired.
+func (s *SharedConn) retire(conn *Conn) bool {
+	m, mc := s.manager, s.mc
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if s.released || conn == nil || mc.conn != conn {
+		return false
+	}
+	mc.retired = append(mc.retired, mc.conn)
+	for _, ioctx := range mc.ioctxs {
+		mc.retiredIOCtxs = append(mc.retiredIOCtxs, ioctx)
+	}
+	mc.conn = nil
+	mc.ioctxs = map[ioctxKey]*IOContext{}
+	return true
+}
+
+// IsFatalError returns true if err indicates that a connection can no
+// longer be used and needs to be replaced, for example because the client
+// was blocklisted.
+func IsFatalError(err error) bool {
+	var ec interface{ ErrorCode() int }
+	if !errors.As(err, &ec) {
+		return false
+	}
+	switch ec.ErrorCode() {
+	case -int(syscall.ESHUTDOWN), -int(syscall.ENOTCONN):
+		return true
+	}
+	return false
+}
+
+// ReportError informs the manager about an error returned by a call using
+// the connection or one of its IOContexts. If the error is fatal, see
+// IsFatalError, the connection and its IOContexts are retired and the next
+// call to Conn or IOContext reconnects. ReportError returns true if the
+// connection was retired.
+func (s *SharedConn) ReportError(err error) bool {
+	if !IsFatalError(err) {
+		return false
+	}
+	s.manager.mutex.Lock()
+	conn := s.mc.conn
+	s.manager.mutex.Unlock()
+	return s.retire(conn)
+}
+
+// Release the reference to the connection. When the last reference is
+// released, the cached IOContexts are destroyed and the connection is shut
+// down. Calling Release more than once has no effect. After Release, Conn
+// and IOContext return ErrSharedConnReleased.
+func (s *SharedConn) Release() {
+	s.once.Do(func() {
+		m, mc := s.manager, s.mc
+		m.mutex.Lock()
+		s.released = true
+		mc.refs--
+		if mc.refs > 0 {
+			m.mutex.Unlock()
+			return
+		}
+		delete(m.conns, s.key)
+		m.mutex.Unlock()
+
+		// no other references exist, so no one can be connecting
+		for _, ioctx := range mc.ioctxs {
+			ioctx.Destroy()
+		}
+		for _, ioctx := range mc.retiredIOCtxs {
+			ioctx.Destroy()
+		}
+		if mc.conn != nil {
+			mc.conn.Shutdown()
+		}
+		for _, conn := range mc.retired {
+			conn.Shutdown()
+		}
+	})
+}
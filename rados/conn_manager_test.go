@@ -0,0 +1,123 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+func TestConnConfigSettings(t *testing.T) {
+	cfg := ConnConfig{
+		MonHosts: []string{"10.0.0.1:6789", "10.0.0.2:6789"},
+		Key:      "secret",
+		Options: map[string]string{
+			"rados_osd_op_timeout": "1",
+			"debug_ms":             "0",
+		},
+		MountTimeout: 1500 * time.Millisecond,
+		OSDOpTimeout: 30 * time.Second,
+	}
+	assert.Equal(t, DefaultClusterName, cfg.clusterName())
+	assert.Equal(t, DefaultUser, cfg.user())
+	assert.Equal(t, [][2]string{
+		{"mon_host", "10.0.0.1:6789,10.0.0.2:6789"},
+		{"key", "secret"},
+		{"debug_ms", "0"},
+		{"rados_osd_op_timeout", "1"},
+		{"client_mount_timeout", "1.5"},
+		{"rados_osd_op_timeout", "30"},
+	}, cfg.settings())
+}
+
+func TestIsFatalError(t *testing.T) {
+	assert.True(t, IsFatalError(ErrNotConnected))
+	assert.True(t, IsFatalError(errutil.GetError("rados", -int(syscall.ESHUTDOWN))))
+	assert.False(t, IsFatalError(ErrNotFound))
+	assert.False(t, IsFatalError(errors.New("other")))
+	assert.False(t, IsFatalError(nil))
+}
+
+func TestSharedConnReleased(t *testing.T) {
+	m := NewConnManager()
+	s := m.Get(ConnConfig{})
+	s.Release()
+	_, err := s.Conn()
+	assert.ErrorIs(t, err, ErrSharedConnReleased)
+	_, err = s.IOContext("pool", "")
+	assert.ErrorIs(t, err, ErrSharedConnReleased)
+	assert.False(t, s.ReportError(ErrNotConnected))
+	assert.Empty(t, m.conns)
+}
+
+func (suite *RadosTestSuite) TestConnManager() {
+	t := suite.T()
+
+	m := NewConnManager()
+	s1 := m.Get(ConnConfig{MountTimeout: 5 * time.Second})
+	s2 := m.Get(ConnConfig{})
+	defer s2.Release()
+
+	var (
+		wg    sync.WaitGroup
+		conns [4]*Conn
+	)
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := s1.Conn()
+			assert.NoError(t, err)
+			conns[i] = conn
+		}(i)
+	}
+	wg.Wait()
+	for _, conn := range conns {
+		assert.Same(t, conns[0], conn)
+	}
+	conn, err := s2.Conn()
+	require.NoError(t, err)
+	assert.Same(t, conns[0], conn)
+	timeout, err := conn.GetConfigOption("client_mount_timeout")
+	assert.NoError(t, err)
+	secs, err := strconv.ParseFloat(timeout, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, secs)
+
+	ioctx1, err := s1.IOContext(suite.pool, "ns")
+	require.NoError(t, err)
+	ioctx2, err := s2.IOContext(suite.pool, "ns")
+	require.NoError(t, err)
+	assert.Same(t, ioctx1, ioctx2)
+	ns, err := ioctx1.GetNamespace()
+	assert.NoError(t, err)
+	assert.Equal(t, "ns", ns)
+	ioctx3, err := s2.IOContext(suite.pool, "")
+	require.NoError(t, err)
+	assert.NotSame(t, ioctx1, ioctx3)
+
+	// releasing one reference keeps the connection
+	s1.Release()
+	s1.Release()
+	assert.NoError(t, ioctx1.WriteFull(suite.GenObjectName(), []byte("x")))
+	_, err = s1.Conn()
+	assert.ErrorIs(t, err, ErrSharedConnReleased)
+
+	assert.False(t, s2.ReportError(ErrNotFound))
+	assert.True(t, s2.ReportError(ErrNotConnected))
+	conn2, err := s2.Conn()
+	require.NoError(t, err)
+	assert.NotSame(t, conn, conn2)
+	ioctx4, err := s2.IOContext(suite.pool, "ns")
+	require.NoError(t, err)
+	assert.NotSame(t, ioctx1, ioctx4)
+}
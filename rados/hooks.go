@@ -0,0 +1,93 @@
+package rados
+
+import "C"
+
+import (
+	"github.com/ceph/go-ceph/internal/hooks"
+)
+
+func init() {
+	hooks.Lookup = lookupHook
+}
+
+// lookupHook returns the hook set for a *Conn or *IOContext, for use by other
+// go-ceph packages that work on top of rados connections.
+func lookupHook(v interface{}) (hooks.Hook, hooks.Op) {
+	switch x := v.(type) {
+	case *IOContext:
+		if x != nil {
+			return x.connHook(),
+				hooks.Op{Pool: x.pool, Namespace: x.namespace}
+		}
+	case *Conn:
+		if x != nil {
+			return x.hook.Get(), hooks.Op{}
+		}
+	}
+	return nil, hooks.Op{}
+}
+
+func (ioctx *IOContext) connHook() hooks.Hook {
+	if ioctx.conn != nil {
+		return ioctx.conn.hook.Get()
+	}
+	return nil
+}
+
+// startOp returns a tracker for an operation on the object oid, or nil if no
+// hook is set.
+func (ioctx *IOContext) startOp(name, oid string) *hooks.Tracker {
+	h := hooks.Select(ioctx.connHook())
+	if h == nil {
+		return nil
+	}
+	return hooks.Start(h, hooks.Op{
+		Name:      name,
+		Pool:      ioctx.pool,
+		Namespace: ioctx.namespace,
+		Object:    oid,
+	})
+}
+
+// startOp returns a tracker for an operation on the cluster, or nil if no
+// hook is set. pool is the name of the pool the operation works on, if any.
+func (c *Conn) startOp(name, pool string) *hooks.Tracker {
+	h := hooks.Select(c.hook.Get())
+	if h == nil {
+		return nil
+	}
+	return hooks.Start(h, hooks.Op{Name: name, Pool: pool})
+}
+
+// startCommand returns a tracker for a command, or nil if no hook is set.
+func (c *Conn) startCommand(name string, cmd []byte) *hooks.Tracker {
+	h := hooks.Select(c.hook.Get())
+	if h == nil {
+		return nil
+	}
+	return hooks.Start(h, hooks.Op{Name: name, Command: string(cmd)})
+}
+
+func firstArg(args [][]byte) []byte {
+	if len(args) == 0 {
+		return nil
+	}
+	return args[0]
+}
+
+// writtenBytes returns the number of bytes written by a call returning ret
+// that was passed a buffer of size n.
+func writtenBytes(ret C.int, n int) int64 {
+	if ret < 0 {
+		return 0
+	}
+	return int64(n)
+}
+
+// readBytes returns the number of bytes read by a call returning ret.
+func readBytes(ret C.int) int64 {
+	if ret < 0 {
+		return 0
+	}
+	return int64(ret)
+}
@@ -104,6 +104,11 @@ type IOContext struct {
 	// that Go's GC doesn't trigger the Conn's finalizer before this
 	// IOContext is destroyed.
 	conn *Conn
+
+	// pool and namespace are only kept to identify the target of operations
+	// reported to hooks.
+	pool      string
+	namespace string
 }
 
 // validate returns an error if the ioctx is not ready to be used
@@ -134,7 +139,10 @@ func (ioctx *IOContext) SetNamespace(namespace string) {
 		cns = C.CString(namespace)
 		defer C.free(unsafe.Pointer(cns))
 	}
+	ioctx.namespace = namespace
+	t := ioctx.startOp("rados_ioctx_set_namespace", "")
 	C.rados_ioctx_set_namespace(ioctx.ioctx, cns)
+	t.Done(0, nil)
 }
 
 // Create a new object with key oid.
@@ -161,12 +169,14 @@ func (ioctx *IOContext) Write(oid string, data []byte, offset uint64) error {
 		dataPointer = unsafe.Pointer(&data[0])
 	}
 
+	t := ioctx.startOp("rados_write", oid)
 	ret := C.rados_write(ioctx.ioctx, coid,
 		(*C.char)(dataPointer),
 		(C.size_t)(len(data)),
 		(C.uint64_t)(offset))
-
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // WriteFull writes len(data) bytes to the object with key oid.
@@ -176,10 +186,13 @@ func (ioctx *IOContext) WriteFull(oid string, data []byte) error {
 	coid := C.CString(oid)
 	defer C.free(unsafe.Pointer(coid))
 
+	t := ioctx.startOp("rados_write_full", oid)
 	ret := C.rados_write_full(ioctx.ioctx, coid,
 		(*C.char)(unsafe.Pointer(&data[0])),
 		(C.size_t)(len(data)))
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // Append appends len(data) bytes to the object with key oid.
@@ -189,10 +202,13 @@ func (ioctx *IOContext) Append(oid string, data []byte) error {
 	coid := C.CString(oid)
 	defer C.free(unsafe.Pointer(coid))
 
+	t := ioctx.startOp("rados_append", oid)
 	ret := C.rados_append(ioctx.ioctx, coid,
 		(*C.char)(unsafe.Pointer(&data[0])),
 		(C.size_t)(len(data)))
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // Read reads up to len(data) bytes from the object with key oid starting at byte
@@ -206,12 +222,14 @@ func (ioctx *IOContext) Read(oid string, data []byte, offset uint64) (int, error
 		buf = (*C.char)(unsafe.Pointer(&data[0]))
 	}
 
+	t := ioctx.startOp("rados_read", oid)
 	ret := C.rados_read(
 		ioctx.ioctx,
 		coid,
 		buf,
 		(C.size_t)(len(data)),
 		(C.uint64_t)(offset))
+	t.Done(readBytes(ret), getErrorIfNegative(ret))
 
 	if ret >= 0 {
 		return int(ret), nil
@@ -224,7 +242,11 @@ func (ioctx *IOContext) Delete(oid string) error {
 	coid := C.CString(oid)
 	defer C.free(unsafe.Pointer(coid))
 
-	return getError(C.rados_remove(ioctx.ioctx, coid))
+	t := ioctx.startOp("rados_remove", oid)
+	ret := C.rados_remove(ioctx.ioctx, coid)
+	err := getError(ret)
+	t.Done(0, err)
+	return err
 }
 
 // Truncate resizes the object with key oid to size size. If the operation
@@ -235,14 +257,20 @@ func (ioctx *IOContext) Truncate(oid string, size uint64) error {
 	coid := C.CString(oid)
 	defer C.free(unsafe.Pointer(coid))
 
-	return getError(C.rados_trunc(ioctx.ioctx, coid, (C.uint64_t)(size)))
+	t := ioctx.startOp("rados_trunc", oid)
+	ret := C.rados_trunc(ioctx.ioctx, coid, (C.uint64_t)(size))
+	err := getError(ret)
+	t.Done(0, err)
+	return err
 }
 
 // Destroy informs librados that the I/O context is no longer in use.
 // Resources associated with the context may not be freed immediately, and the
 // context should not be used again after calling this method.
 func (ioctx *IOContext) Destroy() {
+	t := ioctx.startOp("rados_ioctx_destroy", "")
 	C.rados_ioctx_destroy(ioctx.ioctx)
+	t.Done(0, nil)
 }
 
 // GetPoolStats returns a set of statistics about the pool associated with this I/O
@@ -254,7 +282,9 @@ func (ioctx *IOContext) Destroy() {
 //	                          struct rados_pool_stat_t *stats);
 func (ioctx *IOContext) GetPoolStats() (stat PoolStat, err error) {
 	cStat := C.struct_rados_pool_stat_t{}
+	t := ioctx.startOp("rados_ioctx_pool_stat", "")
 	ret := C.rados_ioctx_pool_stat(ioctx.ioctx, &cStat)
+	t.Done(0, getError(ret))
 	if ret < 0 {
 		return PoolStat{}, getError(ret)
 	}
@@ -280,7 +310,9 @@ func (ioctx *IOContext) GetPoolStats() (stat PoolStat, err error) {
 //
 //	int64_t rados_ioctx_get_id(rados_ioctx_t io)
 func (ioctx *IOContext) GetPoolID() int64 {
+	t := ioctx.startOp("rados_ioctx_get_id", "")
 	ret := C.rados_ioctx_get_id(ioctx.ioctx)
+	t.Done(0, nil)
 	return int64(ret)
 }
 
@@ -292,11 +324,12 @@ func (ioctx *IOContext) GetPoolName() (name string, err error) {
 	)
 	retry.WithSizes(128, 8192, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := ioctx.startOp("rados_ioctx_get_pool_name", "")
 		ret = C.rados_ioctx_get_pool_name(
 			ioctx.ioctx,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.unsigned(len(buf)))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -333,7 +366,9 @@ func (ioctx *IOContext) ListObjects(listFn ObjectListFunc) error {
 
 	for {
 		res := (*C.rados_object_list_item)(unsafe.Pointer(&results[0]))
+		t := ioctx.startOp("rados_object_list", "")
 		ret := C.rados_object_list(ioctx.ioctx, next, finish, pageResults, nil, filterLen, res, &next)
+		t.Done(0, getErrorIfNegative(ret))
 		if ret < 0 {
 			return getError(ret)
 		}
@@ -358,11 +393,13 @@ func (ioctx *IOContext) Stat(object string) (stat ObjectStat, err error) {
 	cObject := C.CString(object)
 	defer C.free(unsafe.Pointer(cObject))
 
+	t := ioctx.startOp("rados_stat", object)
 	ret := C.rados_stat(
 		ioctx.ioctx,
 		cObject,
 		&cPsize,
 		&cPmtime)
+	t.Done(0, getError(ret))
 
 	if ret < 0 {
 		return ObjectStat{}, getError(ret)
@@ -381,12 +418,14 @@ func (ioctx *IOContext) GetXattr(object string, name string, data []byte) (int,
 	defer C.free(unsafe.Pointer(cObject))
 	defer C.free(unsafe.Pointer(cName))
 
+	t := ioctx.startOp("rados_getxattr", object)
 	ret := C.rados_getxattr(
 		ioctx.ioctx,
 		cObject,
 		cName,
 		(*C.char)(unsafe.Pointer(&data[0])),
 		(C.size_t)(len(data)))
+	t.Done(readBytes(ret), getErrorIfNegative(ret))
 
 	if ret >= 0 {
 		return int(ret), nil
@@ -401,14 +440,16 @@ func (ioctx *IOContext) SetXattr(object string, name string, data []byte) error
 	defer C.free(unsafe.Pointer(cObject))
 	defer C.free(unsafe.Pointer(cName))
 
+	t := ioctx.startOp("rados_setxattr", object)
 	ret := C.rados_setxattr(
 		ioctx.ioctx,
 		cObject,
 		cName,
 		(*C.char)(unsafe.Pointer(&data[0])),
 		(C.size_t)(len(data)))
-
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // ListXattrs lists all the xattrs for an object. The xattrs are returned as a
@@ -419,7 +460,9 @@ func (ioctx *IOContext) ListXattrs(oid string) (map[string][]byte, error) {
 
 	var it C.rados_xattrs_iter_t
 
+	t := ioctx.startOp("rados_getxattrs", oid)
 	ret := C.rados_getxattrs(ioctx.ioctx, coid, &it)
+	t.Done(0, getError(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -451,12 +494,14 @@ func (ioctx *IOContext) RmXattr(oid string, name string) error {
 	defer C.free(unsafe.Pointer(coid))
 	defer C.free(unsafe.Pointer(cName))
 
+	t := ioctx.startOp("rados_rmxattr", oid)
 	ret := C.rados_rmxattr(
 		ioctx.ioctx,
 		coid,
 		cName)
-
-	return getError(ret)
+	err := getError(ret)
+	t.Done(0, err)
+	return err
 }
 
 // LockExclusive takes an exclusive lock on an object.
@@ -482,6 +527,7 @@ func (ioctx *IOContext) LockExclusive(oid, name, cookie, desc string, duration t
 	defer C.free(unsafe.Pointer(cCookie))
 	defer C.free(unsafe.Pointer(cDesc))
 
+	t := ioctx.startOp("rados_lock_exclusive", oid)
 	ret := C.rados_lock_exclusive(
 		ioctx.ioctx,
 		coid,
@@ -490,6 +536,7 @@ func (ioctx *IOContext) LockExclusive(oid, name, cookie, desc string, duration t
 		cDesc,
 		&cDuration,
 		cFlags)
+	t.Done(0, getError(ret))
 
 	// 0 on success, negative error code on failure
 	// -EBUSY if the lock is already held by another (client, cookie) pair
@@ -532,6 +579,7 @@ func (ioctx *IOContext) LockShared(oid, name, cookie, tag, desc string, duration
 	defer C.free(unsafe.Pointer(cTag))
 	defer C.free(unsafe.Pointer(cDesc))
 
+	t := ioctx.startOp("rados_lock_shared", oid)
 	ret := C.rados_lock_shared(
 		ioctx.ioctx,
 		coid,
@@ -541,6 +589,7 @@ func (ioctx *IOContext) LockShared(oid, name, cookie, tag, desc string, duration
 		cDesc,
 		&cDuration,
 		cFlags)
+	t.Done(0, getError(ret))
 
 	// 0 on success, negative error code on failure
 	// -EBUSY if the lock is already held by another (client, cookie) pair
@@ -571,11 +620,13 @@ func (ioctx *IOContext) Unlock(oid, name, cookie string) (int, error) {
 	// 0 on success, negative error code on failure
 	// -ENOENT if the lock is not held by the specified (client, cookie) pair
 
+	t := ioctx.startOp("rados_unlock", oid)
 	ret := C.rados_unlock(
 		ioctx.ioctx,
 		coid,
 		cName,
 		cCookie)
+	t.Done(0, getError(ret))
 
 	switch ret {
 	case 0:
@@ -614,6 +665,7 @@ func (ioctx *IOContext) ListLockers(oid, name string) (*LockInfo, error) {
 	defer C.free(unsafe.Pointer(cCookies))
 	defer C.free(unsafe.Pointer(cAddrs))
 
+	t := ioctx.startOp("rados_list_lockers", oid)
 	ret := C.rados_list_lockers(
 		ioctx.ioctx,
 		coid,
@@ -627,6 +679,7 @@ func (ioctx *IOContext) ListLockers(oid, name string) (*LockInfo, error) {
 		&cCookiesLen,
 		cAddrs,
 		&cAddrsLen)
+	t.Done(0, getErrorIfNegative(ret))
 
 	splitCString := func(items *C.char, itemsLen C.size_t) []string {
 		currLen := 0
@@ -661,12 +714,14 @@ func (ioctx *IOContext) BreakLock(oid, name, client, cookie string) (int, error)
 	// -ENOENT if the lock is not held by the specified (client, cookie) pair
 	// -EINVAL if the client cannot be parsed
 
+	t := ioctx.startOp("rados_break_lock", oid)
 	ret := C.rados_break_lock(
 		ioctx.ioctx,
 		coid,
 		cName,
 		cClient,
 		cCookie)
+	t.Done(0, getError(ret))
 
 	switch ret {
 	case 0:
@@ -690,7 +745,9 @@ func (ioctx *IOContext) GetLastVersion() (uint64, error) {
 	if err := ioctx.validate(); err != nil {
 		return 0, err
 	}
+	t := ioctx.startOp("rados_get_last_version", "")
 	v := C.rados_get_last_version(ioctx.ioctx)
+	t.Done(0, nil)
 	return uint64(v), nil
 }
 
@@ -711,11 +768,12 @@ func (ioctx *IOContext) GetNamespace() (string, error) {
 	)
 	retry.WithSizes(128, 8192, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := ioctx.startOp("rados_ioctx_get_namespace", "")
 		ret = C.rados_ioctx_get_namespace(
 			ioctx.ioctx,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.unsigned(len(buf)))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -0,0 +1,460 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+// ioctxAPI implements the radosapi interfaces with an IOContext.
+type ioctxAPI struct {
+	ioctx *IOContext
+}
+
+var _ radosapi.IOContext = ioctxAPI{}
+
+// API returns the IOContext as a radosapi.IOContext, for code that is
+// written against the interfaces of the radosapi package. The in-memory
+// radosfake package implements the same interfaces.
+func (ioctx *IOContext) API() radosapi.IOContext {
+	return ioctxAPI{ioctx: ioctx}
+}
+
+// apiError converts the errors that are not identified by an error code to
+// the corresponding errors of the radosapi package.
+func apiError(err error) error {
+	if errors.Is(err, ErrOperationIncomplete) {
+		return radosapi.ErrOperationIncomplete
+	}
+	return err
+}
+
+func (a ioctxAPI) Create(oid string, exclusive radosapi.CreateOption) error {
+	return a.ioctx.Create(oid, CreateOption(exclusive))
+}
+
+func (a ioctxAPI) Write(oid string, data []byte, offset uint64) error {
+	return a.ioctx.Write(oid, data, offset)
+}
+
+func (a ioctxAPI) WriteFull(oid string, data []byte) error {
+	return a.ioctx.WriteFull(oid, data)
+}
+
+func (a ioctxAPI) Append(oid string, data []byte) error {
+	return a.ioctx.Append(oid, data)
+}
+
+func (a ioctxAPI) Read(oid string, data []byte, offset uint64) (int, error) {
+	return a.ioctx.Read(oid, data, offset)
+}
+
+func (a ioctxAPI) Delete(oid string) error {
+	return a.ioctx.Delete(oid)
+}
+
+func (a ioctxAPI) Truncate(oid string, size uint64) error {
+	return a.ioctx.Truncate(oid, size)
+}
+
+func (a ioctxAPI) Stat(object string) (radosapi.ObjectStat, error) {
+	stat, err := a.ioctx.Stat(object)
+	return radosapi.ObjectStat(stat), err
+}
+
+func (a ioctxAPI) ListObjects(listFn radosapi.ObjectListFunc) error {
+	return a.ioctx.ListObjects(ObjectListFunc(listFn))
+}
+
+func (a ioctxAPI) SetNamespace(namespace string) {
+	a.ioctx.SetNamespace(namespace)
+}
+
+func (a ioctxAPI) GetNamespace() (string, error) {
+	return a.ioctx.GetNamespace()
+}
+
+func (a ioctxAPI) GetLastVersion() (uint64, error) {
+	return a.ioctx.GetLastVersion()
+}
+
+func (a ioctxAPI) GetXattr(object string, name string, data []byte) (int, error) {
+	return a.ioctx.GetXattr(object, name, data)
+}
+
+func (a ioctxAPI) SetXattr(object string, name string, data []byte) error {
+	return a.ioctx.SetXattr(object, name, data)
+}
+
+func (a ioctxAPI) ListXattrs(oid string) (map[string][]byte, error) {
+	return a.ioctx.ListXattrs(oid)
+}
+
+func (a ioctxAPI) RmXattr(oid string, name string) error {
+	return a.ioctx.RmXattr(oid, name)
+}
+
+func (a ioctxAPI) SetOmap(oid string, pairs map[string][]byte) error {
+	return a.ioctx.SetOmap(oid, pairs)
+}
+
+func (a ioctxAPI) ListOmapValues(oid string, startAfter string,
+	filterPrefix string, maxReturn int64, listFn radosapi.OmapListFunc) error {
+
+	return a.ioctx.ListOmapValues(
+		oid, startAfter, filterPrefix, maxReturn, OmapListFunc(listFn))
+}
+
+func (a ioctxAPI) GetOmapValues(oid string, startAfter string,
+	filterPrefix string, maxReturn int64) (map[string][]byte, error) {
+
+	return a.ioctx.GetOmapValues(oid, startAfter, filterPrefix, maxReturn)
+}
+
+func (a ioctxAPI) GetAllOmapValues(oid string, startAfter string,
+	filterPrefix string, iteratorSize int64) (map[string][]byte, error) {
+
+	return a.ioctx.GetAllOmapValues(oid, startAfter, filterPrefix, iteratorSize)
+}
+
+func (a ioctxAPI) RmOmapKeys(oid string, keys []string) error {
+	return a.ioctx.RmOmapKeys(oid, keys)
+}
+
+func (a ioctxAPI) CleanOmap(oid string) error {
+	return a.ioctx.CleanOmap(oid)
+}
+
+func (a ioctxAPI) LockExclusive(oid, name, cookie, desc string,
+	duration time.Duration, flags *byte) (int, error) {
+
+	return a.ioctx.LockExclusive(oid, name, cookie, desc, duration, flags)
+}
+
+func (a ioctxAPI) LockShared(oid, name, cookie, tag, desc string,
+	duration time.Duration, flags *byte) (int, error) {
+
+	return a.ioctx.LockShared(oid, name, cookie, tag, desc, duration, flags)
+}
+
+func (a ioctxAPI) Unlock(oid, name, cookie string) (int, error) {
+	return a.ioctx.Unlock(oid, name, cookie)
+}
+
+func (a ioctxAPI) ListLockers(oid, name string) (*radosapi.LockInfo, error) {
+	info, err := a.ioctx.ListLockers(oid, name)
+	if info == nil {
+		return nil, err
+	}
+	return (*radosapi.LockInfo)(info), err
+}
+
+func (a ioctxAPI) BreakLock(oid, name, client, cookie string) (int, error) {
+	return a.ioctx.BreakLock(oid, name, client, cookie)
+}
+
+func (a ioctxAPI) Watch(obj string) (radosapi.Watcher, error) {
+	return a.WatchWithTimeout(obj, 0)
+}
+
+func (a ioctxAPI) WatchWithTimeout(oid string,
+	timeout time.Duration) (radosapi.Watcher, error) {
+
+	w, err := a.ioctx.WatchWithTimeout(oid, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return newWatcherAPI(w), nil
+}
+
+func convertNotifyResults(acks []NotifyAck, timeouts []NotifyTimeout) (
+	[]radosapi.NotifyAck, []radosapi.NotifyTimeout) {
+
+	apiAcks := make([]radosapi.NotifyAck, len(acks))
+	for i, ack := range acks {
+		apiAcks[i] = radosapi.NotifyAck{
+			WatcherID:  radosapi.WatcherID(ack.WatcherID),
+			NotifierID: radosapi.NotifierID(ack.NotifierID),
+			Response:   ack.Response,
+		}
+	}
+	apiTimeouts := make([]radosapi.NotifyTimeout, len(timeouts))
+	for i, timeout := range timeouts {
+		apiTimeouts[i] = radosapi.NotifyTimeout{
+			WatcherID:  radosapi.WatcherID(timeout.WatcherID),
+			NotifierID: radosapi.NotifierID(timeout.NotifierID),
+		}
+	}
+	return apiAcks, apiTimeouts
+}
+
+func (a ioctxAPI) Notify(obj string, data []byte) (
+	[]radosapi.NotifyAck, []radosapi.NotifyTimeout, error) {
+
+	acks, timeouts, err := a.ioctx.Notify(obj, data)
+	apiAcks, apiTimeouts := convertNotifyResults(acks, timeouts)
+	return apiAcks, apiTimeouts, err
+}
+
+func (a ioctxAPI) NotifyWithTimeout(obj string, data []byte,
+	timeout time.Duration) ([]radosapi.NotifyAck, []radosapi.NotifyTimeout, error) {
+
+	acks, timeouts, err := a.ioctx.NotifyWithTimeout(obj, data, timeout)
+	apiAcks, apiTimeouts := convertNotifyResults(acks, timeouts)
+	return apiAcks, apiTimeouts, err
+}
+
+func (a ioctxAPI) CreateSnap(snapName string) error {
+	return a.ioctx.CreateSnap(snapName)
+}
+
+func (a ioctxAPI) RemoveSnap(snapName string) error {
+	return a.ioctx.RemoveSnap(snapName)
+}
+
+func (a ioctxAPI) LookupSnap(snapName string) (radosapi.SnapID, error) {
+	id, err := a.ioctx.LookupSnap(snapName)
+	return radosapi.SnapID(id), err
+}
+
+func (a ioctxAPI) GetSnapName(snapID radosapi.SnapID) (string, error) {
+	return a.ioctx.GetSnapName(SnapID(snapID))
+}
+
+func (a ioctxAPI) GetSnapStamp(snapID radosapi.SnapID) (time.Time, error) {
+	return a.ioctx.GetSnapStamp(SnapID(snapID))
+}
+
+func (a ioctxAPI) ListSnaps() ([]radosapi.SnapID, error) {
+	ids, err := a.ioctx.ListSnaps()
+	if ids == nil {
+		return nil, err
+	}
+	apiIDs := make([]radosapi.SnapID, len(ids))
+	for i, id := range ids {
+		apiIDs[i] = radosapi.SnapID(id)
+	}
+	return apiIDs, err
+}
+
+func (a ioctxAPI) RollbackSnap(oid, snapName string) error {
+	return a.ioctx.RollbackSnap(oid, snapName)
+}
+
+func (a ioctxAPI) SetReadSnap(snapID radosapi.SnapID) error {
+	return a.ioctx.SetReadSnap(SnapID(snapID))
+}
+
+func (a ioctxAPI) NewWriteOperation() radosapi.WriteOperation {
+	return &writeOpAPI{op: CreateWriteOp(), ioctx: a.ioctx}
+}
+
+func (a ioctxAPI) NewReadOperation() radosapi.ReadOperation {
+	return &readOpAPI{op: CreateReadOp(), ioctx: a.ioctx}
+}
+
+// writeOpAPI implements radosapi.WriteOperation with a WriteOp that is bound
+// to an IOContext.
+type writeOpAPI struct {
+	op    *WriteOp
+	ioctx *IOContext
+	// results copy the results of the steps after Operate
+	results []func()
+}
+
+func (o *writeOpAPI) Create(exclusive radosapi.CreateOption) {
+	o.op.Create(CreateOption(exclusive))
+}
+
+func (o *writeOpAPI) SetOmap(pairs map[string][]byte) {
+	o.op.SetOmap(pairs)
+}
+
+func (o *writeOpAPI) RmOmapKeys(keys []string) {
+	o.op.RmOmapKeys(keys)
+}
+
+func (o *writeOpAPI) CleanOmap() {
+	o.op.CleanOmap()
+}
+
+func (o *writeOpAPI) AssertExists() {
+	o.op.AssertExists()
+}
+
+func (o *writeOpAPI) AssertVersion(ver uint64) {
+	o.op.AssertVersion(ver)
+}
+
+func (o *writeOpAPI) Write(b []byte, offset uint64) {
+	o.op.Write(b, offset)
+}
+
+func (o *writeOpAPI) WriteFull(b []byte) {
+	o.op.WriteFull(b)
+}
+
+func (o *writeOpAPI) WriteSame(b []byte, writeLen, offset uint64) {
+	o.op.WriteSame(b, writeLen, offset)
+}
+
+func (o *writeOpAPI) Remove() {
+	o.op.Remove()
+}
+
+func (o *writeOpAPI) SetXattr(name string, value []byte) {
+	o.op.SetXattr(name, value)
+}
+
+func (o *writeOpAPI) SetAllocationHint(expectedObjectSize uint64,
+	expectedWriteSize uint64, flags radosapi.AllocHintFlags) {
+
+	o.op.SetAllocationHint(
+		expectedObjectSize, expectedWriteSize, AllocHintFlags(flags))
+}
+
+func (o *writeOpAPI) CmpExt(b []byte, offset uint64) *radosapi.WriteOpCmpExtStep {
+	step := o.op.CmpExt(b, offset)
+	apiStep := &radosapi.WriteOpCmpExtStep{}
+	o.results = append(o.results, func() {
+		apiStep.Result = step.Result
+	})
+	return apiStep
+}
+
+func (o *writeOpAPI) Operate(oid string, flags radosapi.OperationFlags) error {
+	err := o.op.Operate(o.ioctx, oid, OperationFlags(flags))
+	for _, result := range o.results {
+		result()
+	}
+	return err
+}
+
+func (o *writeOpAPI) Release() {
+	o.op.Release()
+}
+
+// readOpAPI implements radosapi.ReadOperation with a ReadOp that is bound to
+// an IOContext.
+type readOpAPI struct {
+	op    *ReadOp
+	ioctx *IOContext
+	// results copy the results of the steps after Operate
+	results []func()
+}
+
+// omapIteratorAPI implements radosapi.OmapIterator with the omap steps of a
+// ReadOp.
+type omapIteratorAPI struct {
+	next func() (*OmapKeyValue, error)
+}
+
+func (it omapIteratorAPI) Next() (*radosapi.OmapKeyValue, error) {
+	kv, err := it.next()
+	if kv == nil {
+		return nil, apiError(err)
+	}
+	return (*radosapi.OmapKeyValue)(kv), apiError(err)
+}
+
+func (o *readOpAPI) AssertExists() {
+	o.op.AssertExists()
+}
+
+func (o *readOpAPI) AssertVersion(ver uint64) {
+	o.op.AssertVersion(ver)
+}
+
+func (o *readOpAPI) GetOmapValues(startAfter, filterPrefix string,
+	maxReturn uint64) radosapi.OmapIterator {
+
+	return omapIteratorAPI{
+		next: o.op.GetOmapValues(startAfter, filterPrefix, maxReturn).Next,
+	}
+}
+
+func (o *readOpAPI) GetOmapValuesByKeys(keys []string) radosapi.OmapIterator {
+	return omapIteratorAPI{next: o.op.GetOmapValuesByKeys(keys).Next}
+}
+
+func (o *readOpAPI) Read(offset uint64, buffer []byte) *radosapi.ReadOpReadStep {
+	step := o.op.Read(offset, buffer)
+	apiStep := &radosapi.ReadOpReadStep{}
+	o.results = append(o.results, func() {
+		apiStep.BytesRead = step.BytesRead
+		apiStep.Result = step.Result
+	})
+	return apiStep
+}
+
+func (o *readOpAPI) Operate(oid string, flags radosapi.OperationFlags) error {
+	err := o.op.Operate(o.ioctx, oid, OperationFlags(flags))
+	for _, result := range o.results {
+		result()
+	}
+	return err
+}
+
+func (o *readOpAPI) Release() {
+	o.op.Release()
+}
+
+// watcherAPI implements radosapi.Watcher with a Watcher. The events of the
+// Watcher are forwarded to a channel of radosapi.NotifyEvents.
+type watcherAPI struct {
+	w      *Watcher
+	events chan radosapi.NotifyEvent
+}
+
+func newWatcherAPI(w *Watcher) *watcherAPI {
+	wa := &watcherAPI{w: w, events: make(chan radosapi.NotifyEvent)}
+	go func() {
+		defer close(wa.events)
+		for ev := range w.Events() {
+			select {
+			case wa.events <- radosapi.NotifyEvent{
+				ID:         radosapi.NotifyID(ev.ID),
+				WatcherID:  radosapi.WatcherID(ev.WatcherID),
+				NotifierID: radosapi.NotifierID(ev.NotifierID),
+				Data:       ev.Data,
+			}:
+			case <-w.done:
+				return
+			}
+		}
+	}()
+	return wa
+}
+
+func (wa *watcherAPI) ID() radosapi.WatcherID {
+	return radosapi.WatcherID(wa.w.ID())
+}
+
+func (wa *watcherAPI) Events() <-chan radosapi.NotifyEvent {
+	return wa.events
+}
+
+func (wa *watcherAPI) Errors() <-chan error {
+	return wa.w.Errors()
+}
+
+func (wa *watcherAPI) Check() (time.Duration, error) {
+	return wa.w.Check()
+}
+
+func (wa *watcherAPI) Ack(ev radosapi.NotifyEvent, response []byte) error {
+	ne := &NotifyEvent{
+		ID:         NotifyID(ev.ID),
+		WatcherID:  wa.w.ID(),
+		NotifierID: NotifierID(ev.NotifierID),
+		Data:       ev.Data,
+	}
+	return ne.Ack(response)
+}
+
+func (wa *watcherAPI) Delete() error {
+	return wa.w.Delete()
+}
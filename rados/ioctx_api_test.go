@@ -0,0 +1,137 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+func TestRadosAPIConstants(t *testing.T) {
+	// the constants of the radosapi package are passed to librados as is
+	assert.EqualValues(t, CreateExclusive, radosapi.CreateExclusive)
+	assert.EqualValues(t, CreateIdempotent, radosapi.CreateIdempotent)
+	assert.EqualValues(t, AllNamespaces, radosapi.AllNamespaces)
+	assert.EqualValues(t, SnapHead, radosapi.SnapHead)
+	assert.EqualValues(t, OperationNoFlag, radosapi.OperationNoFlag)
+	assert.EqualValues(t, OperationBalanceReads, radosapi.OperationBalanceReads)
+	assert.EqualValues(t, OperationLocalizeReads, radosapi.OperationLocalizeReads)
+	assert.EqualValues(t, OperationOrderReadsWrites, radosapi.OperationOrderReadsWrites)
+	assert.EqualValues(t, OperationIgnoreCache, radosapi.OperationIgnoreCache)
+	assert.EqualValues(t, OperationSkipRWLocks, radosapi.OperationSkipRWLocks)
+	assert.EqualValues(t, OperationIgnoreOverlay, radosapi.OperationIgnoreOverlay)
+	assert.EqualValues(t, OperationFullTry, radosapi.OperationFullTry)
+	assert.EqualValues(t, OperationFullForce, radosapi.OperationFullForce)
+	assert.EqualValues(t, OperationIgnoreRedirect, radosapi.OperationIgnoreRedirect)
+	assert.EqualValues(t, OperationOrderSnap, radosapi.OperationOrderSnap)
+	assert.EqualValues(t, AllocHintSequentialWrite, radosapi.AllocHintSequentialWrite)
+	assert.EqualValues(t, AllocHintRandomWrite, radosapi.AllocHintRandomWrite)
+	assert.EqualValues(t, AllocHintSequentialRead, radosapi.AllocHintSequentialRead)
+	assert.EqualValues(t, AllocHintRandomRead, radosapi.AllocHintRandomRead)
+	assert.EqualValues(t, AllocHintAppendOnly, radosapi.AllocHintAppendOnly)
+	assert.EqualValues(t, AllocHintImmutable, radosapi.AllocHintImmutable)
+	assert.EqualValues(t, AllocHintShortlived, radosapi.AllocHintShortlived)
+	assert.EqualValues(t, AllocHintLonglived, radosapi.AllocHintLonglived)
+	assert.EqualValues(t, AllocHintCompressible, radosapi.AllocHintCompressible)
+	assert.EqualValues(t, AllocHintIncompressible, radosapi.AllocHintIncompressible)
+
+	// errors match in both directions
+	assert.ErrorIs(t, ErrNotFound, radosapi.ErrNotFound)
+	assert.ErrorIs(t, radosapi.ErrNotFound, ErrNotFound)
+	assert.ErrorIs(t, ErrObjectExists, radosapi.ErrObjectExists)
+	assert.ErrorIs(t, ErrNotConnected, radosapi.ErrNotConnected)
+	assert.ErrorIs(t, ErrPermissionDenied, radosapi.ErrPermissionDenied)
+}
+
+func (suite *RadosTestSuite) TestIOContextAPI() {
+	suite.SetupConnection()
+	t := suite.T()
+
+	api := suite.ioctx.API()
+	oid := suite.GenObjectName()
+	defer func() { _ = api.Delete(oid) }()
+
+	_, err := api.Stat(oid)
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+
+	wop := api.NewWriteOperation()
+	defer wop.Release()
+	wop.Create(radosapi.CreateExclusive)
+	wop.WriteFull([]byte("hello"))
+	wop.SetOmap(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	cmp := wop.CmpExt([]byte("hello"), 0)
+	require.NoError(t, wop.Operate(oid, radosapi.OperationNoFlag))
+	assert.Equal(t, 0, cmp.Result)
+
+	rop := api.NewReadOperation()
+	defer rop.Release()
+	rop.AssertExists()
+	buf := make([]byte, 5)
+	read := rop.Read(0, buf)
+	iter := rop.GetOmapValuesByKeys([]string{"b"})
+	_, err = iter.Next()
+	assert.ErrorIs(t, err, radosapi.ErrOperationIncomplete)
+	require.NoError(t, rop.Operate(oid, radosapi.OperationNoFlag))
+	assert.EqualValues(t, 5, read.BytesRead)
+	assert.Equal(t, "hello", string(buf))
+	kv, err := iter.Next()
+	assert.NoError(t, err)
+	require.NotNil(t, kv)
+	assert.Equal(t, "b", kv.Key)
+	kv, err = iter.Next()
+	assert.NoError(t, err)
+	assert.Nil(t, kv)
+
+	st, err := api.Stat(oid)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, st.Size)
+	assert.ErrorIs(t, api.Create(oid, radosapi.CreateExclusive), ErrObjectExists)
+
+	_, err = api.LockExclusive(oid, "lock", "cookie", "", 0, nil)
+	assert.NoError(t, err)
+	info, err := api.ListLockers(oid, "lock")
+	assert.NoError(t, err)
+	require.NotNil(t, info)
+	assert.True(t, info.Exclusive)
+	assert.Equal(t, []string{"cookie"}, info.Cookies)
+	_, err = api.Unlock(oid, "lock", "cookie")
+	assert.NoError(t, err)
+}
+
+func (suite *RadosTestSuite) TestIOContextAPIWatcher() {
+	suite.SetupConnection()
+	t := suite.T()
+
+	api := suite.ioctx.API()
+	oid := suite.GenObjectName()
+	require.NoError(t, api.Create(oid, radosapi.CreateIdempotent))
+	defer func() { _ = api.Delete(oid) }()
+
+	w, err := api.Watch(oid)
+	require.NoError(t, err)
+	_, err = w.Check()
+	assert.NoError(t, err)
+	go func() {
+		for ev := range w.Events() {
+			assert.Equal(t, w.ID(), ev.WatcherID)
+			assert.Equal(t, []byte("ping"), ev.Data)
+			assert.NoError(t, w.Ack(ev, []byte("pong")))
+		}
+	}()
+
+	acks, timeouts, err := api.NotifyWithTimeout(oid, []byte("ping"), 10*time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, timeouts, 0)
+	require.Len(t, acks, 1)
+	assert.Equal(t, w.ID(), acks[0].WatcherID)
+	assert.Equal(t, []byte("pong"), acks[0].Response)
+
+	assert.NoError(t, w.Delete())
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+}
@@ -15,9 +15,11 @@ import "C"
 //	int rados_ioctx_pool_required_alignment2(rados_ioctx_t io, uint64_t *alignment)
 func (ioctx *IOContext) Alignment() (uint64, error) {
 	var alignSizeBytes C.uint64_t
+	t := ioctx.startOp("rados_ioctx_pool_required_alignment2", "")
 	ret := C.rados_ioctx_pool_required_alignment2(
 		ioctx.ioctx,
 		&alignSizeBytes)
+	t.Done(0, getError(ret))
 	if ret != 0 {
 		return 0, getError(ret)
 	}
@@ -0,0 +1,90 @@
+package rados
+
+/*
+#cgo LDFLAGS: -lrados
+#include <rados/librados.h>
+
+// rados_full_try_fn matches the signature of rados_set_pool_full_try,
+// rados_unset_pool_full_try and their deprecated osdmap variants.
+typedef void(*rados_full_try_fn)(rados_ioctx_t io);
+
+// rados_full_try_dlsym takes *fn as rados_full_try_fn and calls the
+// dynamically loaded function passed as 1st argument.
+static inline void rados_full_try_dlsym(void *fn, rados_ioctx_t io) {
+	// cast function pointer fn to rados_full_try_fn and call the function
+	((rados_full_try_fn) fn)(io);
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/dlsym"
+)
+
+// Ceph octopus deprecates rados_set_osdmap_full_try() and implements
+// rados_set_pool_full_try(). The same applies to the unset functions. The
+// functions are resolved at runtime, so that the variant provided by the
+// installed librados is used.
+var (
+	radosSetPoolFullTry     = dlsym.NewSymbol("rados_set_pool_full_try")
+	radosUnsetPoolFullTry   = dlsym.NewSymbol("rados_unset_pool_full_try")
+	radosSetOsdmapFullTry   = dlsym.NewSymbol("rados_set_osdmap_full_try")
+	radosUnsetOsdmapFullTry = dlsym.NewSymbol("rados_unset_osdmap_full_try")
+)
+
+// resolveFullTry returns the current function if it is available and the
+// deprecated function otherwise.
+func resolveFullTry(current, deprecated *dlsym.Symbol) (unsafe.Pointer, error) {
+	fn, err := current.Pointer()
+	if err == nil {
+		return fn, nil
+	}
+	if fn, derr := deprecated.Pointer(); derr == nil {
+		return fn, nil
+	}
+	return nil, err
+}
+
+// SetPoolFullTry makes sure to send requests to the cluster despite
+// the cluster or pool being marked full; ops will either succeed(e.g., delete)
+// or return EDQUOT or ENOSPC.
+//
+// Implements:
+//
+//	void rados_set_pool_full_try(rados_ioctx_t io);
+//	void rados_set_osdmap_full_try(rados_ioctx_t io);
+func (ioctx *IOContext) SetPoolFullTry() error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	fn, err := resolveFullTry(radosSetPoolFullTry, radosSetOsdmapFullTry)
+	if err != nil {
+		return err
+	}
+	t := ioctx.startOp("rados_set_pool_full_try", "")
+	C.rados_full_try_dlsym(fn, ioctx.ioctx)
+	t.Done(0, nil)
+	return nil
+}
+
+// UnsetPoolFullTry unsets the flag set by SetPoolFullTry()
+//
+// Implements:
+//
+//	void rados_unset_pool_full_try(rados_ioctx_t io);
+//	void rados_unset_osdmap_full_try(rados_ioctx_t io);
+func (ioctx *IOContext) UnsetPoolFullTry() error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	fn, err := resolveFullTry(radosUnsetPoolFullTry, radosUnsetOsdmapFullTry)
+	if err != nil {
+		return err
+	}
+	t := ioctx.startOp("rados_unset_pool_full_try", "")
+	C.rados_full_try_dlsym(fn, ioctx.ioctx)
+	t.Done(0, nil)
+	return nil
+}
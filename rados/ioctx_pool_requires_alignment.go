@@ -15,9 +15,11 @@ import "C"
 //	int rados_ioctx_pool_requires_alignment2(rados_ioctx_t io, int *req)
 func (ioctx *IOContext) RequiresAlignment() (bool, error) {
 	var alignRequired C.int
+	t := ioctx.startOp("rados_ioctx_pool_requires_alignment2", "")
 	ret := C.rados_ioctx_pool_requires_alignment2(
 		ioctx.ioctx,
 		&alignRequired)
+	t.Done(0, getError(ret))
 	if ret != 0 {
 		return false, getError(ret)
 	}
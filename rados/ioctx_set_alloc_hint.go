@@ -26,11 +26,12 @@ func (ioctx *IOContext) SetAllocationHint(oid string, expectedObjectSize uint64,
 	coid := C.CString(oid)
 	defer C.free(unsafe.Pointer(coid))
 
-	return getError(C.rados_set_alloc_hint2(
+	t := ioctx.startOp("rados_set_alloc_hint2", oid)
+	return t.DoneErr(getError(C.rados_set_alloc_hint2(
 		ioctx.ioctx,
 		coid,
 		(C.uint64_t)(expectedObjectSize),
 		(C.uint64_t)(expectedWriteSize),
 		(C.uint32_t)(flags),
-	))
+	)))
 }
@@ -8,9 +8,11 @@ import "C"
 // Iter supports iterating over objects in the ioctx.
 type Iter struct {
 	ctx       C.rados_list_ctx_t
+	ioctx     *IOContext
 	err       error
 	entry     string
 	namespace string
+	locator   string
 }
 
 // IterToken supports reporting on and seeking to different positions.
@@ -18,21 +20,28 @@ type IterToken uint32
 
 // Iter returns a Iterator object that can be used to list the object names in the current pool
 func (ioctx *IOContext) Iter() (*Iter, error) {
-	iter := Iter{}
-	if cerr := C.rados_nobjects_list_open(ioctx.ioctx, &iter.ctx); cerr < 0 {
-		return nil, getError(cerr)
+	iter := Iter{ioctx: ioctx}
+	t := ioctx.startOp("rados_nobjects_list_open", "")
+	cerr := C.rados_nobjects_list_open(ioctx.ioctx, &iter.ctx)
+	if err := t.DoneErr(getError(cerr)); err != nil {
+		return nil, err
 	}
 	return &iter, nil
 }
 
 // Token returns a token marking the current position of the iterator. To be used in combination with Iter.Seek()
 func (iter *Iter) Token() IterToken {
-	return IterToken(C.rados_nobjects_list_get_pg_hash_position(iter.ctx))
+	t := iter.ioctx.startOp("rados_nobjects_list_get_pg_hash_position", "")
+	pos := C.rados_nobjects_list_get_pg_hash_position(iter.ctx)
+	t.Done(0, nil)
+	return IterToken(pos)
 }
 
 // Seek moves the iterator to the position indicated by the token.
 func (iter *Iter) Seek(token IterToken) {
+	t := iter.ioctx.startOp("rados_nobjects_list_seek", "")
 	C.rados_nobjects_list_seek(iter.ctx, C.uint32_t(token))
+	t.Done(0, nil)
 }
 
 // Next retrieves the next object name in the pool/namespace iterator.
@@ -51,13 +60,18 @@ func (iter *Iter) Seek(token IterToken) {
 //	return iter.Err()
 func (iter *Iter) Next() bool {
 	var cEntry *C.char
+	var cLocator *C.char
 	var cNamespace *C.char
-	if cerr := C.rados_nobjects_list_next(iter.ctx, &cEntry, nil, &cNamespace); cerr < 0 {
+	t := iter.ioctx.startOp("rados_nobjects_list_next", "")
+	cerr := C.rados_nobjects_list_next(iter.ctx, &cEntry, &cLocator, &cNamespace)
+	t.Done(0, getError(cerr))
+	if cerr < 0 {
 		iter.err = getError(cerr)
 		return false
 	}
 	iter.entry = C.GoString(cEntry)
 	iter.namespace = C.GoString(cNamespace)
+	iter.locator = C.GoString(cLocator)
 	return true
 }
 
@@ -88,5 +102,7 @@ func (iter *Iter) Err() error {
 // Close the iterator cursor on the server. Be aware that iterators are not closed automatically
 // at the end of iteration.
 func (iter *Iter) Close() {
+	t := iter.ioctx.startOp("rados_nobjects_list_close", "")
 	C.rados_nobjects_list_close(iter.ctx)
+	t.Done(0, nil)
 }
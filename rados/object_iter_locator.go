@@ -0,0 +1,13 @@
+//go:build ceph_preview
+
+package rados
+
+// Locator returns the locator key associated with the current value of the
+// iterator, after a successful call to Next. The locator is empty for
+// objects that are placed by their name.
+func (iter *Iter) Locator() string {
+	if iter.err != nil {
+		return ""
+	}
+	return iter.locator
+}
@@ -118,7 +118,7 @@ func (o *operation) update(kind opKind, ret C.int) error {
 
 func opStepFinalizer(s opStep) {
 	if s != nil {
-		log.Warnf("unreachable opStep object found. Cleaning up.")
+		log.Warn("unreachable opStep object found. Cleaning up.")
 		s.free()
 	}
 }
@@ -68,7 +68,9 @@ func makeConn() *Conn {
 
 func newConn(user *C.char) (*Conn, error) {
 	conn := makeConn()
+	t := conn.startOp("rados_create", "")
 	ret := C.rados_create(&conn.cluster, user)
+	t.Done(0, getError(ret))
 
 	if ret != 0 {
 		return nil, getError(ret)
@@ -102,7 +104,9 @@ func NewConnWithClusterAndUser(clusterName string, userName string) (*Conn, erro
 	defer C.free(unsafe.Pointer(cName))
 
 	conn := makeConn()
+	t := conn.startOp("rados_create2", "")
 	ret := C.rados_create2(&conn.cluster, cClusterName, cName, 0)
+	t.Done(0, getError(ret))
 	if ret != 0 {
 		return nil, getError(ret)
 	}
@@ -122,8 +126,10 @@ func NewConnWithClusterAndUser(clusterName string, userName string) (*Conn, erro
 // called.
 func freeConn(conn *Conn) {
 	if conn.cluster != nil {
-		log.Warnf("unreachable Conn object has not been shut down. Cleaning up.")
+		log.Warn("unreachable Conn object has not been shut down. Cleaning up.")
+		t := conn.startOp("rados_shutdown", "")
 		C.rados_shutdown(conn.cluster)
+		t.Done(0, nil)
 		// prevent calling rados_shutdown() more than once
 		conn.cluster = nil
 	}
@@ -19,7 +19,9 @@ func (c *Conn) GetAddrs() (string, error) {
 	var cAddrs *C.char
 	defer C.free(unsafe.Pointer(cAddrs))
 
+	t := c.startOp("rados_getaddrs", "")
 	ret := C.rados_getaddrs(c.cluster, &cAddrs)
+	t.Done(0, getError(ret))
 	if ret < 0 {
 		return "", getError(ret)
 	}
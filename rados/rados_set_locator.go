@@ -18,6 +18,7 @@ import (
 //
 //	void rados_ioctx_locator_set_key(rados_ioctx_t io, const char *key);
 func (ioctx *IOContext) SetLocator(locator string) {
+	t := ioctx.startOp("rados_ioctx_locator_set_key", "")
 	if locator == "" {
 		C.rados_ioctx_locator_set_key(ioctx.ioctx, nil)
 	} else {
@@ -25,4 +26,5 @@ func (ioctx *IOContext) SetLocator(locator string) {
 		defer C.free(unsafe.Pointer(cLoc))
 		C.rados_ioctx_locator_set_key(ioctx.ioctx, cLoc)
 	}
+	t.Done(0, nil)
 }
@@ -0,0 +1,10 @@
+/*
+Package radosapi contains the interfaces of the RADOS I/O functions and the
+types they use, without depending on librados.
+
+Code written against the interfaces can use a rados.IOContext, through its
+API method, or the in-memory implementation of the radosfake package. As the
+package does not use cgo, code that only depends on the interfaces and the
+fake can be built and tested without the Ceph libraries.
+*/
+package radosapi
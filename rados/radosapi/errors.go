@@ -0,0 +1,56 @@
+//go:build ceph_preview
+
+package radosapi
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// errorCode is an error for a negative error code of librados. It matches
+// the errors of the go-ceph packages with the same error code, when compared
+// with errors.Is, without depending on librados.
+type errorCode int
+
+// Error implements the error interface.
+func (e errorCode) Error() string {
+	return fmt.Sprintf("rados: ret=%d, %s", int(e), syscall.Errno(-e))
+}
+
+// ErrorCode returns the (negative) error code of the error.
+func (e errorCode) ErrorCode() int {
+	return int(e)
+}
+
+// Is returns true for errors with the same error code.
+func (e errorCode) Is(target error) bool {
+	ec, ok := target.(interface{ ErrorCode() int })
+	return ok && ec.ErrorCode() == int(e)
+}
+
+// ErrorFromCode returns an error for the negative error code ret, as
+// returned by librados, or nil if ret is not negative. The error matches the
+// errors of the rados package with the same error code. Implementations of
+// the interfaces can use it to return the errors of librados.
+func ErrorFromCode(ret int) error {
+	if ret >= 0 {
+		return nil
+	}
+	return errorCode(ret)
+}
+
+var (
+	// ErrNotConnected is returned when functions are called without a
+	// connection to the cluster.
+	ErrNotConnected = ErrorFromCode(-int(syscall.ENOTCONN))
+	// ErrNotFound indicates a missing resource.
+	ErrNotFound = ErrorFromCode(-int(syscall.ENOENT))
+	// ErrPermissionDenied indicates a permissions issue.
+	ErrPermissionDenied = ErrorFromCode(-int(syscall.EPERM))
+	// ErrObjectExists indicates that an exclusive object creation failed.
+	ErrObjectExists = ErrorFromCode(-int(syscall.EEXIST))
+	// ErrOperationIncomplete is returned from read operation steps for
+	// which the operation has not been performed yet.
+	ErrOperationIncomplete = errors.New("Operation has not been performed yet")
+)
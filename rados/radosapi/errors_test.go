@@ -0,0 +1,35 @@
+//go:build ceph_preview
+
+package radosapi
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCodeError int
+
+func (e testCodeError) Error() string {
+	return "test error"
+}
+
+func (e testCodeError) ErrorCode() int {
+	return int(e)
+}
+
+func TestErrorFromCode(t *testing.T) {
+	assert.NoError(t, ErrorFromCode(0))
+	assert.NoError(t, ErrorFromCode(3))
+
+	err := ErrorFromCode(-int(syscall.ENOENT))
+	assert.Equal(t, "rados: ret=-2, no such file or directory", err.Error())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", err), ErrNotFound)
+	assert.ErrorIs(t, err, testCodeError(-int(syscall.ENOENT)))
+	assert.NotErrorIs(t, err, ErrObjectExists)
+	assert.NotErrorIs(t, err, errors.New("no such file or directory"))
+}
@@ -0,0 +1,155 @@
+//go:build ceph_preview
+
+package radosapi
+
+import (
+	"time"
+)
+
+// ObjectIO is the interface of the IOContext functions that create, read,
+// write and remove objects.
+type ObjectIO interface {
+	Create(oid string, exclusive CreateOption) error
+	Write(oid string, data []byte, offset uint64) error
+	WriteFull(oid string, data []byte) error
+	Append(oid string, data []byte) error
+	Read(oid string, data []byte, offset uint64) (int, error)
+	Delete(oid string) error
+	Truncate(oid string, size uint64) error
+	Stat(object string) (ObjectStat, error)
+	ListObjects(listFn ObjectListFunc) error
+	SetNamespace(namespace string)
+	GetNamespace() (string, error)
+	GetLastVersion() (uint64, error)
+}
+
+// XattrIO is the interface of the IOContext functions that manage the
+// extended attributes of objects.
+type XattrIO interface {
+	GetXattr(object string, name string, data []byte) (int, error)
+	SetXattr(object string, name string, data []byte) error
+	ListXattrs(oid string) (map[string][]byte, error)
+	RmXattr(oid string, name string) error
+}
+
+// OmapIO is the interface of the IOContext functions that manage the omap
+// key/value pairs of objects.
+type OmapIO interface {
+	SetOmap(oid string, pairs map[string][]byte) error
+	ListOmapValues(oid string, startAfter string, filterPrefix string,
+		maxReturn int64, listFn OmapListFunc) error
+	GetOmapValues(oid string, startAfter string, filterPrefix string,
+		maxReturn int64) (map[string][]byte, error)
+	GetAllOmapValues(oid string, startAfter string, filterPrefix string,
+		iteratorSize int64) (map[string][]byte, error)
+	RmOmapKeys(oid string, keys []string) error
+	CleanOmap(oid string) error
+}
+
+// LockIO is the interface of the IOContext functions that manage advisory
+// locks on objects.
+type LockIO interface {
+	LockExclusive(oid, name, cookie, desc string, duration time.Duration,
+		flags *byte) (int, error)
+	LockShared(oid, name, cookie, tag, desc string, duration time.Duration,
+		flags *byte) (int, error)
+	Unlock(oid, name, cookie string) (int, error)
+	ListLockers(oid, name string) (*LockInfo, error)
+	BreakLock(oid, name, client, cookie string) (int, error)
+}
+
+// Watcher receives the notifications for an object. Events and Errors are
+// closed when the Watcher is deleted.
+type Watcher interface {
+	ID() WatcherID
+	Events() <-chan NotifyEvent
+	Errors() <-chan error
+	Check() (time.Duration, error)
+	// Ack sends an acknowledgement with the response data to the notifier
+	// of an event received by the Watcher.
+	Ack(ev NotifyEvent, response []byte) error
+	Delete() error
+}
+
+// WatchNotifyIO is the interface of the IOContext functions that watch
+// objects and send notifications to the watchers.
+type WatchNotifyIO interface {
+	Watch(obj string) (Watcher, error)
+	WatchWithTimeout(oid string, timeout time.Duration) (Watcher, error)
+	Notify(obj string, data []byte) ([]NotifyAck, []NotifyTimeout, error)
+	NotifyWithTimeout(obj string, data []byte,
+		timeout time.Duration) ([]NotifyAck, []NotifyTimeout, error)
+}
+
+// SnapshotIO is the interface of the IOContext functions that manage pool
+// snapshots.
+type SnapshotIO interface {
+	CreateSnap(snapName string) error
+	RemoveSnap(snapName string) error
+	LookupSnap(snapName string) (SnapID, error)
+	GetSnapName(snapID SnapID) (string, error)
+	GetSnapStamp(snapID SnapID) (time.Time, error)
+	ListSnaps() ([]SnapID, error)
+	RollbackSnap(oid, snapName string) error
+	SetReadSnap(snapID SnapID) error
+}
+
+// OmapIterator is the interface of the steps returning omap key/value pairs
+// from a ReadOperation. Next returns nil if iteration is exhausted.
+type OmapIterator interface {
+	Next() (*OmapKeyValue, error)
+}
+
+// WriteOperation is the interface of a write operation that is bound to an
+// IOContext. Class methods (Exec) are not part of the interface.
+type WriteOperation interface {
+	Create(exclusive CreateOption)
+	SetOmap(pairs map[string][]byte)
+	RmOmapKeys(keys []string)
+	CleanOmap()
+	AssertExists()
+	AssertVersion(ver uint64)
+	Write(b []byte, offset uint64)
+	WriteFull(b []byte)
+	WriteSame(b []byte, writeLen, offset uint64)
+	Remove()
+	SetXattr(name string, value []byte)
+	SetAllocationHint(expectedObjectSize uint64, expectedWriteSize uint64,
+		flags AllocHintFlags)
+	CmpExt(b []byte, offset uint64) *WriteOpCmpExtStep
+	Operate(oid string, flags OperationFlags) error
+	Release()
+}
+
+// ReadOperation is the interface of a read operation that is bound to an
+// IOContext. Class methods (Exec) are not part of the interface.
+type ReadOperation interface {
+	AssertExists()
+	AssertVersion(ver uint64)
+	GetOmapValues(startAfter, filterPrefix string,
+		maxReturn uint64) OmapIterator
+	GetOmapValuesByKeys(keys []string) OmapIterator
+	Read(offset uint64, buffer []byte) *ReadOpReadStep
+	Operate(oid string, flags OperationFlags) error
+	Release()
+}
+
+// OperationIO is the interface of the IOContext functions that create read
+// and write operations bound to the IOContext.
+type OperationIO interface {
+	NewWriteOperation() WriteOperation
+	NewReadOperation() ReadOperation
+}
+
+// IOContext combines all the interfaces of the functions of an IOContext.
+// Code written against IOContext, or one of the smaller interfaces, can be
+// tested without a Ceph cluster by using the radosfake package.
+type IOContext interface {
+	ObjectIO
+	XattrIO
+	OmapIO
+	LockIO
+	WatchNotifyIO
+	SnapshotIO
+	OperationIO
+}
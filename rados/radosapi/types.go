@@ -0,0 +1,171 @@
+//go:build ceph_preview
+
+package radosapi
+
+import (
+	"time"
+)
+
+// The constants have the values of the librados definitions they mirror, so
+// that they can be passed on to librados unchanged.
+
+// CreateOption is passed to Create and should be one of CreateExclusive or
+// CreateIdempotent.
+type CreateOption int
+
+const (
+	// CreateExclusive if used with Create and the object already exists,
+	// the function will return an error.
+	CreateExclusive = CreateOption(1)
+	// CreateIdempotent if used with Create and the object already exists,
+	// the function will not return an error.
+	CreateIdempotent = CreateOption(0)
+)
+
+// AllNamespaces is used to reset a selected namespace to all namespaces. See
+// the SetNamespace function.
+const AllNamespaces = "\001"
+
+// OperationFlags control the behavior of read and write operations.
+type OperationFlags int
+
+const (
+	// OperationNoFlag indicates no special behavior is requested.
+	OperationNoFlag = OperationFlags(0)
+	// OperationBalanceReads matches LIBRADOS_OPERATION_BALANCE_READS.
+	OperationBalanceReads = OperationFlags(1)
+	// OperationLocalizeReads matches LIBRADOS_OPERATION_LOCALIZE_READS.
+	OperationLocalizeReads = OperationFlags(2)
+	// OperationOrderReadsWrites matches
+	// LIBRADOS_OPERATION_ORDER_READS_WRITES.
+	OperationOrderReadsWrites = OperationFlags(4)
+	// OperationIgnoreCache matches LIBRADOS_OPERATION_IGNORE_CACHE.
+	OperationIgnoreCache = OperationFlags(8)
+	// OperationSkipRWLocks matches LIBRADOS_OPERATION_SKIPRWLOCKS.
+	OperationSkipRWLocks = OperationFlags(16)
+	// OperationIgnoreOverlay matches LIBRADOS_OPERATION_IGNORE_OVERLAY.
+	OperationIgnoreOverlay = OperationFlags(32)
+	// OperationFullTry send request to a full cluster or pool, ops such as
+	// delete can succeed while other ops will return out-of-space errors.
+	OperationFullTry = OperationFlags(64)
+	// OperationFullForce matches LIBRADOS_OPERATION_FULL_FORCE.
+	OperationFullForce = OperationFlags(128)
+	// OperationIgnoreRedirect matches LIBRADOS_OPERATION_IGNORE_REDIRECT.
+	OperationIgnoreRedirect = OperationFlags(256)
+	// OperationOrderSnap matches LIBRADOS_OPERATION_ORDERSNAP.
+	OperationOrderSnap = OperationFlags(512)
+)
+
+// AllocHintFlags control the behavior of read and write operations.
+type AllocHintFlags uint32
+
+const (
+	// AllocHintNoHint indicates no predefined behavior
+	AllocHintNoHint = AllocHintFlags(0)
+	// AllocHintSequentialWrite matches
+	// LIBRADOS_ALLOC_HINT_FLAG_SEQUENTIAL_WRITE.
+	AllocHintSequentialWrite = AllocHintFlags(1)
+	// AllocHintRandomWrite matches LIBRADOS_ALLOC_HINT_FLAG_RANDOM_WRITE.
+	AllocHintRandomWrite = AllocHintFlags(2)
+	// AllocHintSequentialRead matches
+	// LIBRADOS_ALLOC_HINT_FLAG_SEQUENTIAL_READ.
+	AllocHintSequentialRead = AllocHintFlags(4)
+	// AllocHintRandomRead matches LIBRADOS_ALLOC_HINT_FLAG_RANDOM_READ.
+	AllocHintRandomRead = AllocHintFlags(8)
+	// AllocHintAppendOnly matches LIBRADOS_ALLOC_HINT_FLAG_APPEND_ONLY.
+	AllocHintAppendOnly = AllocHintFlags(16)
+	// AllocHintImmutable matches LIBRADOS_ALLOC_HINT_FLAG_IMMUTABLE.
+	AllocHintImmutable = AllocHintFlags(32)
+	// AllocHintShortlived matches LIBRADOS_ALLOC_HINT_FLAG_SHORTLIVED.
+	AllocHintShortlived = AllocHintFlags(64)
+	// AllocHintLonglived matches LIBRADOS_ALLOC_HINT_FLAG_LONGLIVED.
+	AllocHintLonglived = AllocHintFlags(128)
+	// AllocHintCompressible matches LIBRADOS_ALLOC_HINT_FLAG_COMPRESSIBLE.
+	AllocHintCompressible = AllocHintFlags(256)
+	// AllocHintIncompressible matches
+	// LIBRADOS_ALLOC_HINT_FLAG_INCOMPRESSIBLE.
+	AllocHintIncompressible = AllocHintFlags(512)
+)
+
+// SnapID represents the ID of a rados snapshot.
+type SnapID uint64
+
+// SnapHead is the representation of LIBRADOS_SNAP_HEAD. SnapHead can be used
+// to reset the IOContext to stop reading from a snapshot.
+const SnapHead = SnapID(^uint64(1))
+
+// ObjectStat represents an object stat information
+type ObjectStat struct {
+	// current length in bytes
+	Size uint64
+	// last modification time
+	ModTime time.Time
+}
+
+// LockInfo represents information on a current Ceph lock
+type LockInfo struct {
+	NumLockers int
+	Exclusive  bool
+	Tag        string
+	Clients    []string
+	Cookies    []string
+	Addrs      []string
+}
+
+// OmapKeyValue items are returned by the Next call of an OmapIterator.
+type OmapKeyValue struct {
+	Key   string
+	Value []byte
+}
+
+// ObjectListFunc is the type of the function called for each object visited
+// by ListObjects.
+type ObjectListFunc func(oid string)
+
+// OmapListFunc is the type of the function called for each omap key
+// visited by ListOmapValues.
+type OmapListFunc func(key string, value []byte)
+
+// ReadOpReadStep holds the result of the Read step of a ReadOperation.
+// Result is valid only after Operate() was called.
+type ReadOpReadStep struct {
+	BytesRead int64 // Bytes read by this action.
+	Result    int   // Result of this action.
+}
+
+// WriteOpCmpExtStep holds the result of the CmpExt step of a
+// WriteOperation. Result is valid only after Operate() was called.
+type WriteOpCmpExtStep struct {
+	// Result of the CmpExt write operation.
+	Result int
+}
+
+type (
+	// WatcherID is the unique id of a Watcher.
+	WatcherID uint64
+	// NotifyID is the unique id of a NotifyEvent.
+	NotifyID uint64
+	// NotifierID is the unique id of a notifying client.
+	NotifierID uint64
+)
+
+// NotifyEvent is received by a watcher for each notification.
+type NotifyEvent struct {
+	ID         NotifyID
+	WatcherID  WatcherID
+	NotifierID NotifierID
+	Data       []byte
+}
+
+// NotifyAck represents an acknowleged notification.
+type NotifyAck struct {
+	WatcherID  WatcherID
+	NotifierID NotifierID
+	Response   []byte
+}
+
+// NotifyTimeout represents an unacknowleged notification.
+type NotifyTimeout struct {
+	WatcherID  WatcherID
+	NotifierID NotifierID
+}
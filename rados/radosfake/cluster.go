@@ -0,0 +1,185 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+// Cluster is an in-memory stand-in for a Ceph cluster. It holds pools of
+// objects that are shared by all the connections created with NewConn.
+type Cluster struct {
+	mutex      sync.Mutex
+	pools      map[string]*pool
+	lastPoolID int64
+	lastGID    uint64
+
+	lastNotifyID  uint64
+	lastWatcherID uint64
+	watches       map[radosapi.WatcherID]*watch
+	notifies      map[radosapi.NotifyID]*notify
+}
+
+// NewCluster returns a new, empty Cluster.
+func NewCluster() *Cluster {
+	return &Cluster{
+		pools:    map[string]*pool{},
+		lastGID:  4100,
+		watches:  map[radosapi.WatcherID]*watch{},
+		notifies: map[radosapi.NotifyID]*notify{},
+	}
+}
+
+// Conn is a client connection to a fake Cluster. Every Conn has a unique
+// global id, so locks and watches taken through different connections behave
+// like those of different clients.
+type Conn struct {
+	cluster *Cluster
+	gid     uint64
+}
+
+// NewConn returns a new connection to the cluster.
+func (c *Cluster) NewConn() *Conn {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lastGID++
+	return &Conn{cluster: c, gid: c.lastGID}
+}
+
+// GetInstanceID returns the global id of the connection.
+func (conn *Conn) GetInstanceID() uint64 {
+	return conn.gid
+}
+
+// MakePool creates a new pool with the given name.
+func (conn *Conn) MakePool(name string) error {
+	c := conn.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.pools[name]; ok {
+		return radosapi.ErrObjectExists
+	}
+	c.lastPoolID++
+	c.pools[name] = newPool(c.lastPoolID, name)
+	return nil
+}
+
+// DeletePool deletes the pool with the given name and all the objects in it.
+func (conn *Conn) DeletePool(name string) error {
+	c := conn.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	p, ok := c.pools[name]
+	if !ok {
+		return radosapi.ErrNotFound
+	}
+	p.deleted = true
+	delete(c.pools, name)
+	return nil
+}
+
+// ListPools returns the names of all pools of the cluster.
+func (conn *Conn) ListPools() ([]string, error) {
+	c := conn.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	names := make([]string, 0, len(c.pools))
+	for name := range c.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// OpenIOContext returns an IOContext for the given pool.
+func (conn *Conn) OpenIOContext(name string) (*IOContext, error) {
+	c := conn.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	p, ok := c.pools[name]
+	if !ok {
+		return nil, radosapi.ErrNotFound
+	}
+	return &IOContext{conn: conn, pool: p, readSnap: radosapi.SnapHead}, nil
+}
+
+type objKey struct {
+	namespace string
+	oid       string
+}
+
+type pool struct {
+	id          int64
+	name        string
+	deleted     bool
+	lastVersion uint64
+	objects     map[objKey]*object
+	lastSnapID  uint64
+	snaps       map[radosapi.SnapID]*snapshot
+}
+
+func newPool(id int64, name string) *pool {
+	return &pool{
+		id:      id,
+		name:    name,
+		objects: map[objKey]*object{},
+		snaps:   map[radosapi.SnapID]*snapshot{},
+	}
+}
+
+type object struct {
+	data    []byte
+	mtime   time.Time
+	version uint64
+	xattrs  map[string][]byte
+	omap    map[string][]byte
+	locks   map[string]*objLock
+}
+
+func newObject() *object {
+	return &object{
+		xattrs: map[string][]byte{},
+		omap:   map[string][]byte{},
+		locks:  map[string]*objLock{},
+	}
+}
+
+func copyBytes(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
+
+func copyMap(m map[string][]byte) map[string][]byte {
+	out := make(map[string][]byte, len(m))
+	for k, v := range m {
+		out[k] = copyBytes(v)
+	}
+	return out
+}
+
+// clone returns a deep copy of the object.
+func (o *object) clone() *object {
+	c := &object{
+		data:    copyBytes(o.data),
+		mtime:   o.mtime,
+		version: o.version,
+		xattrs:  copyMap(o.xattrs),
+		omap:    copyMap(o.omap),
+		locks:   make(map[string]*objLock, len(o.locks)),
+	}
+	for name, l := range o.locks {
+		c.locks[name] = l.clone()
+	}
+	return c
+}
+
+func cloneObjects(objects map[objKey]*object) map[objKey]*object {
+	out := make(map[objKey]*object, len(objects))
+	for k, o := range objects {
+		out[k] = o.clone()
+	}
+	return out
+}
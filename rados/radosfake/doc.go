@@ -0,0 +1,18 @@
+/*
+Package radosfake contains an in-memory implementation of the IOContext
+interfaces of the radosapi package, for testing code that uses RADOS without a
+Ceph cluster. Like radosapi, the package does not use cgo, so such tests can
+be built without the Ceph libraries.
+
+A Cluster holds pools of objects that are shared by all the connections
+created with its NewConn method. Every connection behaves like a separate
+client, so locks and watches taken through different connections interact
+like those of different clients of a real cluster. The IOContext type
+implements radosapi.IOContext, including read and write operations, pool
+snapshots and watch/notify.
+
+The fake aims to return the same errors as the rados package for the same
+conditions, but it does not model the timing, placement or failure behavior
+of a real cluster. Object classes (Exec) are not supported.
+*/
+package radosfake
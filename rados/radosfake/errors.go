@@ -0,0 +1,28 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"syscall"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+// maxErrno is the value librados uses to report the offset of a mismatch of
+// a CmpExt step.
+const maxErrno = 4095
+
+// getError returns an error that matches the errors returned by the rados
+// package for the same (negative) error code.
+func getError(e int) error {
+	return radosapi.ErrorFromCode(e)
+}
+
+var (
+	errNoData   = getError(-int(syscall.ENODATA))
+	errRange    = getError(-int(syscall.ERANGE))
+	errOverflow = getError(-int(syscall.EOVERFLOW))
+	errInvalid  = getError(-int(syscall.EINVAL))
+	errTimedOut = getError(-int(syscall.ETIMEDOUT))
+	errNotConn  = getError(-int(syscall.ENOTCONN))
+)
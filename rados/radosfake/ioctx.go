@@ -0,0 +1,355 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+// IOContext is an in-memory implementation of radosapi.IOContext for a pool
+// of a fake Cluster.
+type IOContext struct {
+	conn        *Conn
+	pool        *pool
+	namespace   string
+	readSnap    radosapi.SnapID
+	lastVersion uint64
+}
+
+var _ radosapi.IOContext = (*IOContext)(nil)
+
+// with calls f with the cluster locked, if the pool of the IOContext still
+// exists.
+func (ioctx *IOContext) with(f func(p *pool) error) error {
+	c := ioctx.conn.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if ioctx.pool.deleted {
+		return radosapi.ErrNotFound
+	}
+	return f(ioctx.pool)
+}
+
+func (ioctx *IOContext) key(oid string) objKey {
+	return objKey{namespace: ioctx.namespace, oid: oid}
+}
+
+// view returns the objects visible to reads, taking the snapshot set with
+// SetReadSnap into account.
+func (ioctx *IOContext) view(p *pool) (map[objKey]*object, error) {
+	if ioctx.readSnap == radosapi.SnapHead {
+		return p.objects, nil
+	}
+	snap, ok := p.snaps[ioctx.readSnap]
+	if !ok {
+		return nil, radosapi.ErrNotFound
+	}
+	return snap.objects, nil
+}
+
+// lookup returns the object with the given name as visible to reads.
+func (ioctx *IOContext) lookup(p *pool, oid string) (*object, error) {
+	objects, err := ioctx.view(p)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := objects[ioctx.key(oid)]
+	if !ok {
+		return nil, radosapi.ErrNotFound
+	}
+	ioctx.lastVersion = obj.version
+	return obj, nil
+}
+
+// Destroy informs the fake that the IOContext is no longer in use. It exists
+// for parity with rados.IOContext and does nothing.
+func (*IOContext) Destroy() {}
+
+// GetPoolID returns the ID of the pool of the IOContext.
+func (ioctx *IOContext) GetPoolID() int64 {
+	return ioctx.pool.id
+}
+
+// GetPoolName returns the name of the pool of the IOContext.
+func (ioctx *IOContext) GetPoolName() (string, error) {
+	return ioctx.pool.name, nil
+}
+
+// SetNamespace sets the namespace for objects within the IOContext.
+// radosapi.AllNamespaces selects all namespaces for ListObjects.
+func (ioctx *IOContext) SetNamespace(namespace string) {
+	ioctx.namespace = namespace
+}
+
+// GetNamespace gets the namespace used for objects within the IOContext.
+func (ioctx *IOContext) GetNamespace() (string, error) {
+	return ioctx.namespace, nil
+}
+
+// GetLastVersion returns the version of the last object read or written.
+func (ioctx *IOContext) GetLastVersion() (uint64, error) {
+	c := ioctx.conn.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return ioctx.lastVersion, nil
+}
+
+// Create a new object with key oid.
+func (ioctx *IOContext) Create(oid string, exclusive radosapi.CreateOption) error {
+	op := ioctx.newWriteOp()
+	op.Create(exclusive)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// Write writes len(data) bytes to the object with key oid starting at byte
+// offset offset.
+func (ioctx *IOContext) Write(oid string, data []byte, offset uint64) error {
+	op := ioctx.newWriteOp()
+	op.Write(data, offset)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// WriteFull writes len(data) bytes to the object with key oid, replacing the
+// previous content of the object.
+func (ioctx *IOContext) WriteFull(oid string, data []byte) error {
+	op := ioctx.newWriteOp()
+	op.WriteFull(data)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// Append appends len(data) bytes to the object with key oid.
+func (ioctx *IOContext) Append(oid string, data []byte) error {
+	op := ioctx.newWriteOp()
+	op.append(data)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// Read reads up to len(data) bytes from the object with key oid starting at
+// byte offset offset. It returns the number of bytes read.
+func (ioctx *IOContext) Read(oid string, data []byte, offset uint64) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	var n int
+	err := ioctx.with(func(p *pool) error {
+		obj, err := ioctx.lookup(p, oid)
+		if err != nil {
+			return err
+		}
+		if offset < uint64(len(obj.data)) {
+			n = copy(data, obj.data[offset:])
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Delete deletes the object with key oid.
+func (ioctx *IOContext) Delete(oid string) error {
+	op := ioctx.newWriteOp()
+	op.Remove()
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// Truncate resizes the object with key oid to size size, creating the object
+// if it does not exist.
+func (ioctx *IOContext) Truncate(oid string, size uint64) error {
+	op := ioctx.newWriteOp()
+	op.truncate(size)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// Stat returns the size of the object and its last modification time.
+func (ioctx *IOContext) Stat(object string) (stat radosapi.ObjectStat, err error) {
+	err = ioctx.with(func(p *pool) error {
+		obj, err := ioctx.lookup(p, object)
+		if err != nil {
+			return err
+		}
+		stat = radosapi.ObjectStat{
+			Size:    uint64(len(obj.data)),
+			ModTime: time.Unix(obj.mtime.Unix(), 0),
+		}
+		return nil
+	})
+	return stat, err
+}
+
+// ListObjects calls listFn for every object in the namespace of the
+// IOContext, or in all namespaces if it is set to radosapi.AllNamespaces. The
+// objects are listed in the order of their names.
+func (ioctx *IOContext) ListObjects(listFn radosapi.ObjectListFunc) error {
+	var names []string
+	err := ioctx.with(func(p *pool) error {
+		objects, err := ioctx.view(p)
+		if err != nil {
+			return err
+		}
+		for k := range objects {
+			if ioctx.namespace == radosapi.AllNamespaces ||
+				k.namespace == ioctx.namespace {
+				names = append(names, k.oid)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		listFn(name)
+	}
+	return nil
+}
+
+// GetXattr copies the value of the xattr name of the object into data and
+// returns the length of the value.
+func (ioctx *IOContext) GetXattr(object string, name string, data []byte) (int, error) {
+	var n int
+	err := ioctx.with(func(p *pool) error {
+		obj, err := ioctx.lookup(p, object)
+		if err != nil {
+			return err
+		}
+		v, ok := obj.xattrs[name]
+		if !ok {
+			return errNoData
+		}
+		if len(v) > len(data) {
+			return errRange
+		}
+		n = copy(data, v)
+		return nil
+	})
+	return n, err
+}
+
+// SetXattr sets the xattr name of the object to data.
+func (ioctx *IOContext) SetXattr(object string, name string, data []byte) error {
+	op := ioctx.newWriteOp()
+	op.SetXattr(name, data)
+	return op.Operate(object, radosapi.OperationNoFlag)
+}
+
+// ListXattrs returns all the xattrs of an object.
+func (ioctx *IOContext) ListXattrs(oid string) (map[string][]byte, error) {
+	var m map[string][]byte
+	err := ioctx.with(func(p *pool) error {
+		obj, err := ioctx.lookup(p, oid)
+		if err != nil {
+			return err
+		}
+		m = copyMap(obj.xattrs)
+		return nil
+	})
+	return m, err
+}
+
+// RmXattr removes the xattr name from the object oid.
+func (ioctx *IOContext) RmXattr(oid string, name string) error {
+	op := ioctx.newWriteOp()
+	op.rmXattr(name)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// SetOmap appends the map pairs to the omap of the object oid.
+func (ioctx *IOContext) SetOmap(oid string, pairs map[string][]byte) error {
+	op := ioctx.newWriteOp()
+	op.SetOmap(pairs)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// omapRange returns the omap pairs after startAfter that begin with
+// filterPrefix, ordered by key, and at most maxReturn of them.
+func omapRange(omap map[string][]byte, startAfter, filterPrefix string,
+	maxReturn uint64) []radosapi.OmapKeyValue {
+
+	keys := make([]string, 0, len(omap))
+	for k := range omap {
+		if k > startAfter && strings.HasPrefix(k, filterPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if uint64(len(keys)) > maxReturn {
+		keys = keys[:maxReturn]
+	}
+	kvs := make([]radosapi.OmapKeyValue, len(keys))
+	for i, k := range keys {
+		kvs[i] = radosapi.OmapKeyValue{Key: k, Value: copyBytes(omap[k])}
+	}
+	return kvs
+}
+
+// ListOmapValues calls listFn for the omap pairs of the object oid after
+// startAfter that begin with filterPrefix, at most maxReturn times.
+func (ioctx *IOContext) ListOmapValues(oid string, startAfter string,
+	filterPrefix string, maxReturn int64, listFn radosapi.OmapListFunc) error {
+
+	var kvs []radosapi.OmapKeyValue
+	err := ioctx.with(func(p *pool) error {
+		obj, err := ioctx.lookup(p, oid)
+		if err != nil {
+			return err
+		}
+		kvs = omapRange(obj.omap, startAfter, filterPrefix, uint64(maxReturn))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		listFn(kv.Key, kv.Value)
+	}
+	return nil
+}
+
+// GetOmapValues returns the omap pairs of the object oid after startAfter
+// that begin with filterPrefix, at most maxReturn of them.
+func (ioctx *IOContext) GetOmapValues(oid string, startAfter string,
+	filterPrefix string, maxReturn int64) (map[string][]byte, error) {
+
+	omap := map[string][]byte{}
+	err := ioctx.ListOmapValues(
+		oid, startAfter, filterPrefix, maxReturn,
+		func(key string, value []byte) {
+			omap[key] = value
+		},
+	)
+	return omap, err
+}
+
+// GetAllOmapValues returns all the omap pairs of the object oid after
+// startAfter that begin with filterPrefix. The iteratorSize is ignored.
+func (ioctx *IOContext) GetAllOmapValues(oid string, startAfter string,
+	filterPrefix string, iteratorSize int64) (map[string][]byte, error) {
+
+	omap := map[string][]byte{}
+	err := ioctx.ListOmapValues(
+		oid, startAfter, filterPrefix, math.MaxInt64,
+		func(key string, value []byte) {
+			omap[key] = value
+		},
+	)
+	return omap, err
+}
+
+// RmOmapKeys removes the given keys from the omap of the object oid.
+func (ioctx *IOContext) RmOmapKeys(oid string, keys []string) error {
+	op := ioctx.newWriteOp()
+	op.RmOmapKeys(keys)
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
+
+// CleanOmap clears the omap of the object oid.
+func (ioctx *IOContext) CleanOmap(oid string) error {
+	op := ioctx.newWriteOp()
+	op.CleanOmap()
+	return op.Operate(oid, radosapi.OperationNoFlag)
+}
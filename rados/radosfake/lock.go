@@ -0,0 +1,257 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+const (
+	// lockFlagMayRenew allows a holder to renew its lock, like
+	// LOCK_FLAG_MAY_RENEW of librados.
+	lockFlagMayRenew = 1
+	// lockFlagMustRenew only renews a lock that is already held, like
+	// LOCK_FLAG_MUST_RENEW of librados.
+	lockFlagMustRenew = 2
+)
+
+type lockHolder struct {
+	client  string
+	cookie  string
+	desc    string
+	expires time.Time
+	addr    string
+}
+
+type objLock struct {
+	exclusive bool
+	tag       string
+	holders   []*lockHolder
+}
+
+func (l *objLock) clone() *objLock {
+	c := &objLock{exclusive: l.exclusive, tag: l.tag}
+	for _, h := range l.holders {
+		hc := *h
+		c.holders = append(c.holders, &hc)
+	}
+	return c
+}
+
+// prune removes the holders whose lock expired.
+func (l *objLock) prune(now time.Time) {
+	holders := l.holders[:0]
+	for _, h := range l.holders {
+		if h.expires.IsZero() || now.Before(h.expires) {
+			holders = append(holders, h)
+		}
+	}
+	l.holders = holders
+}
+
+func (l *objLock) find(client, cookie string) int {
+	for i, h := range l.holders {
+		if h.client == client && h.cookie == cookie {
+			return i
+		}
+	}
+	return -1
+}
+
+func (conn *Conn) client() string {
+	return fmt.Sprintf("client.%d", conn.gid)
+}
+
+func (conn *Conn) addr() string {
+	return fmt.Sprintf("127.0.0.1:0/%d", conn.gid)
+}
+
+// lock adds a step taking the lock name on the object. The return code of the
+// lock is stored in ret.
+func (w *WriteOp) lock(ret *int, name, cookie, tag, desc string,
+	exclusive bool, duration time.Duration, flags *byte) {
+
+	conn := w.ioctx.conn
+	w.add(func(st *writeState) error {
+		var f byte
+		if flags != nil {
+			f = *flags
+		}
+		now := time.Now()
+		var l *objLock
+		if st.obj != nil {
+			l = st.obj.locks[name]
+		}
+		if l != nil {
+			l.prune(now)
+			if len(l.holders) == 0 {
+				l = nil
+			}
+		}
+		client := conn.client()
+		idx := -1
+		if l != nil {
+			idx = l.find(client, cookie)
+		}
+		switch {
+		case f&lockFlagMustRenew != 0 && idx < 0:
+			*ret = -int(syscall.ENOENT)
+			return nil
+		case idx >= 0 && f&(lockFlagMayRenew|lockFlagMustRenew) == 0:
+			*ret = -int(syscall.EEXIST)
+			return nil
+		case l != nil && idx < 0 &&
+			(exclusive || l.exclusive || l.tag != tag):
+			*ret = -int(syscall.EBUSY)
+			return nil
+		case l != nil && l.exclusive != exclusive:
+			*ret = -int(syscall.EBUSY)
+			return nil
+		}
+		obj := st.ensure()
+		if l == nil {
+			l = &objLock{exclusive: exclusive, tag: tag}
+			obj.locks[name] = l
+		}
+		h := &lockHolder{
+			client: client,
+			cookie: cookie,
+			desc:   desc,
+			addr:   conn.addr(),
+		}
+		if duration != 0 {
+			h.expires = now.Add(duration)
+		}
+		if idx >= 0 {
+			l.holders[idx] = h
+		} else {
+			l.holders = append(l.holders, h)
+		}
+		*ret = 0
+		return nil
+	})
+}
+
+// unlock adds a step releasing the lock name held by client and cookie. The
+// return code is stored in ret.
+func (w *WriteOp) unlock(ret *int, name, client, cookie string) {
+	w.add(func(st *writeState) error {
+		*ret = -int(syscall.ENOENT)
+		if st.obj == nil {
+			return nil
+		}
+		l := st.obj.locks[name]
+		if l == nil {
+			return nil
+		}
+		l.prune(time.Now())
+		idx := l.find(client, cookie)
+		if idx < 0 {
+			return nil
+		}
+		l.holders = append(l.holders[:idx], l.holders[idx+1:]...)
+		if len(l.holders) == 0 {
+			delete(st.obj.locks, name)
+		}
+		st.modified = true
+		*ret = 0
+		return nil
+	})
+}
+
+func (ioctx *IOContext) lock(oid, name, cookie, tag, desc string,
+	exclusive bool, duration time.Duration, flags *byte) (int, error) {
+
+	var ret int
+	op := ioctx.newWriteOp()
+	op.lock(&ret, name, cookie, tag, desc, exclusive, duration, flags)
+	if err := op.Operate(oid, radosapi.OperationNoFlag); err != nil {
+		return 0, err
+	}
+	return ret, nil
+}
+
+// LockExclusive takes an exclusive lock on an object. Like the rados package
+// it returns -EBUSY without an error if the lock is held by another (client,
+// cookie) pair and -EEXIST if it is already held by the same pair.
+func (ioctx *IOContext) LockExclusive(oid, name, cookie, desc string,
+	duration time.Duration, flags *byte) (int, error) {
+
+	return ioctx.lock(oid, name, cookie, "", desc, true, duration, flags)
+}
+
+// LockShared takes a shared lock on an object. Like the rados package it
+// returns -EBUSY without an error if the lock is held exclusively or with a
+// different tag and -EEXIST if it is already held by the same (client,
+// cookie) pair.
+func (ioctx *IOContext) LockShared(oid, name, cookie, tag, desc string,
+	duration time.Duration, flags *byte) (int, error) {
+
+	return ioctx.lock(oid, name, cookie, tag, desc, false, duration, flags)
+}
+
+// Unlock releases a lock held by the connection of the IOContext. It returns
+// -ENOENT without an error if the lock is not held.
+func (ioctx *IOContext) Unlock(oid, name, cookie string) (int, error) {
+	var ret int
+	op := ioctx.newWriteOp()
+	op.unlock(&ret, name, ioctx.conn.client(), cookie)
+	if err := op.Operate(oid, radosapi.OperationNoFlag); err != nil {
+		return 0, err
+	}
+	return ret, nil
+}
+
+// ListLockers lists the clients holding the lock name on the object.
+func (ioctx *IOContext) ListLockers(oid, name string) (*radosapi.LockInfo, error) {
+	info := &radosapi.LockInfo{
+		Clients: []string{},
+		Cookies: []string{},
+		Addrs:   []string{},
+	}
+	err := ioctx.with(func(p *pool) error {
+		obj, err := ioctx.lookup(p, oid)
+		if err != nil {
+			return err
+		}
+		l := obj.locks[name]
+		if l == nil {
+			return nil
+		}
+		l.prune(time.Now())
+		info.Exclusive = l.exclusive
+		info.Tag = l.tag
+		for _, h := range l.holders {
+			info.Clients = append(info.Clients, h.client)
+			info.Cookies = append(info.Cookies, h.cookie)
+			info.Addrs = append(info.Addrs, h.addr)
+		}
+		info.NumLockers = len(l.holders)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// BreakLock releases a lock held by another client. It returns -ENOENT
+// without an error if the lock is not held by the client and cookie, and
+// -EINVAL if the client name is not valid.
+func (ioctx *IOContext) BreakLock(oid, name, client, cookie string) (int, error) {
+	var gid uint64
+	if _, err := fmt.Sscanf(client, "client.%d", &gid); err != nil {
+		return -int(syscall.EINVAL), nil
+	}
+	var ret int
+	op := ioctx.newWriteOp()
+	op.unlock(&ret, name, client, cookie)
+	if err := op.Operate(oid, radosapi.OperationNoFlag); err != nil {
+		return 0, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,489 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+func newTestIOContext(t *testing.T) (*Cluster, *IOContext) {
+	c := NewCluster()
+	conn := c.NewConn()
+	require.NoError(t, conn.MakePool("pool"))
+	ioctx, err := conn.OpenIOContext("pool")
+	require.NoError(t, err)
+	return c, ioctx
+}
+
+func TestPools(t *testing.T) {
+	c := NewCluster()
+	conn := c.NewConn()
+	assert.NoError(t, conn.MakePool("b"))
+	assert.NoError(t, conn.MakePool("a"))
+	assert.ErrorIs(t, conn.MakePool("a"), radosapi.ErrObjectExists)
+
+	names, err := conn.ListPools()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	ioctx, err := conn.OpenIOContext("a")
+	require.NoError(t, err)
+	assert.NoError(t, ioctx.WriteFull("obj", []byte("data")))
+
+	assert.NoError(t, conn.DeletePool("a"))
+	assert.ErrorIs(t, conn.DeletePool("a"), radosapi.ErrNotFound)
+	_, err = conn.OpenIOContext("a")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+	_, err = ioctx.Stat("obj")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+}
+
+func TestObjects(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	assert.NoError(t, ioctx.Create("obj", radosapi.CreateExclusive))
+	assert.ErrorIs(t,
+		ioctx.Create("obj", radosapi.CreateExclusive), radosapi.ErrObjectExists)
+	assert.NoError(t, ioctx.Create("obj", radosapi.CreateIdempotent))
+
+	assert.NoError(t, ioctx.Write("obj", []byte("world"), 6))
+	assert.NoError(t, ioctx.Write("obj", []byte("hello"), 0))
+	assert.NoError(t, ioctx.Append("obj", []byte("!")))
+
+	buf := make([]byte, 20)
+	n, err := ioctx.Read("obj", buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello\x00world!"), buf[:n])
+
+	n, err = ioctx.Read("obj", buf, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	assert.NoError(t, ioctx.Truncate("obj", 5))
+	stat, err := ioctx.Stat("obj")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, stat.Size)
+
+	assert.NoError(t, ioctx.WriteFull("obj", []byte("new")))
+	n, err = ioctx.Read("obj", buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(buf[:n]))
+
+	assert.NoError(t, ioctx.Delete("obj"))
+	assert.ErrorIs(t, ioctx.Delete("obj"), radosapi.ErrNotFound)
+	_, err = ioctx.Read("obj", buf, 0)
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+}
+
+func TestVersions(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	assert.NoError(t, ioctx.WriteFull("obj", []byte("a")))
+	v1, err := ioctx.GetLastVersion()
+	assert.NoError(t, err)
+	assert.NoError(t, ioctx.WriteFull("obj", []byte("b")))
+	v2, err := ioctx.GetLastVersion()
+	assert.NoError(t, err)
+	assert.Greater(t, v2, v1)
+
+	_, err = ioctx.Stat("obj")
+	assert.NoError(t, err)
+	v, err := ioctx.GetLastVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, v2, v)
+}
+
+func TestNamespaces(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	assert.NoError(t, ioctx.WriteFull("a", nil))
+	ioctx.SetNamespace("ns")
+	ns, err := ioctx.GetNamespace()
+	assert.NoError(t, err)
+	assert.Equal(t, "ns", ns)
+	assert.NoError(t, ioctx.WriteFull("b", nil))
+	_, err = ioctx.Stat("a")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+
+	list := func() []string {
+		names := []string{}
+		assert.NoError(t, ioctx.ListObjects(func(oid string) {
+			names = append(names, oid)
+		}))
+		return names
+	}
+	assert.Equal(t, []string{"b"}, list())
+	ioctx.SetNamespace("")
+	assert.Equal(t, []string{"a"}, list())
+	ioctx.SetNamespace(radosapi.AllNamespaces)
+	assert.Equal(t, []string{"a", "b"}, list())
+}
+
+func TestXattrs(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	assert.NoError(t, ioctx.SetXattr("obj", "a", []byte("value")))
+	assert.NoError(t, ioctx.SetXattr("obj", "b", []byte("other")))
+
+	buf := make([]byte, 10)
+	n, err := ioctx.GetXattr("obj", "a", buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(buf[:n]))
+	_, err = ioctx.GetXattr("obj", "a", buf[:2])
+	assert.ErrorIs(t, err, errRange)
+	_, err = ioctx.GetXattr("obj", "c", buf)
+	assert.ErrorIs(t, err, errNoData)
+
+	xattrs, err := ioctx.ListXattrs("obj")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"a": []byte("value"),
+		"b": []byte("other"),
+	}, xattrs)
+
+	assert.NoError(t, ioctx.RmXattr("obj", "a"))
+	assert.ErrorIs(t, ioctx.RmXattr("obj", "a"), errNoData)
+	assert.ErrorIs(t, ioctx.RmXattr("missing", "a"), radosapi.ErrNotFound)
+}
+
+func TestOmap(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	assert.NoError(t, ioctx.SetOmap("obj", map[string][]byte{
+		"k1": []byte("v1"),
+		"k2": []byte("v2"),
+		"k3": []byte("v3"),
+		"x1": []byte("x"),
+	}))
+
+	omap, err := ioctx.GetOmapValues("obj", "k1", "k", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"k2": []byte("v2"),
+		"k3": []byte("v3"),
+	}, omap)
+
+	var keys []string
+	assert.NoError(t, ioctx.ListOmapValues("obj", "", "", 2,
+		func(key string, _ []byte) {
+			keys = append(keys, key)
+		}))
+	assert.Equal(t, []string{"k1", "k2"}, keys)
+
+	omap, err = ioctx.GetAllOmapValues("obj", "", "", 1)
+	assert.NoError(t, err)
+	assert.Len(t, omap, 4)
+
+	assert.NoError(t, ioctx.RmOmapKeys("obj", []string{"k1", "x1"}))
+	omap, err = ioctx.GetAllOmapValues("obj", "", "", 1)
+	assert.NoError(t, err)
+	assert.Len(t, omap, 2)
+
+	assert.NoError(t, ioctx.CleanOmap("obj"))
+	omap, err = ioctx.GetAllOmapValues("obj", "", "", 1)
+	assert.NoError(t, err)
+	assert.Len(t, omap, 0)
+
+	assert.ErrorIs(t, ioctx.CleanOmap("missing"), radosapi.ErrNotFound)
+}
+
+func TestWriteOperation(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	op := ioctx.NewWriteOperation()
+	op.Create(radosapi.CreateExclusive)
+	op.WriteFull([]byte("abcdef"))
+	op.SetXattr("x", []byte("y"))
+	op.SetOmap(map[string][]byte{"k": []byte("v")})
+	assert.NoError(t, op.Operate("obj", radosapi.OperationNoFlag))
+	op.Release()
+	ver, err := ioctx.GetLastVersion()
+	assert.NoError(t, err)
+
+	// a failing step discards the changes of all steps
+	op = ioctx.NewWriteOperation()
+	op.Write([]byte("zz"), 0)
+	op.AssertVersion(ver + 1)
+	err = op.Operate("obj", radosapi.OperationNoFlag)
+	assert.ErrorIs(t, err, errOverflow)
+	buf := make([]byte, 10)
+	n, err := ioctx.Read("obj", buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", string(buf[:n]))
+
+	op = ioctx.NewWriteOperation()
+	cmp := op.CmpExt([]byte("abxd"), 0)
+	op.Write([]byte("zz"), 0)
+	err = op.Operate("obj", radosapi.OperationNoFlag)
+	assert.Error(t, err)
+	assert.Equal(t, -maxErrno-2, cmp.Result)
+
+	op = ioctx.NewWriteOperation()
+	cmp = op.CmpExt([]byte("abcd"), 0)
+	op.AssertVersion(ver)
+	op.WriteSame([]byte("12"), 4, 6)
+	assert.NoError(t, op.Operate("obj", radosapi.OperationNoFlag))
+	assert.Equal(t, 0, cmp.Result)
+	n, err = ioctx.Read("obj", buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef1212", string(buf[:n]))
+
+	op = ioctx.NewWriteOperation()
+	op.WriteSame([]byte("123"), 4, 0)
+	assert.ErrorIs(t,
+		op.Operate("obj", radosapi.OperationNoFlag), errInvalid)
+
+	op = ioctx.NewWriteOperation()
+	op.AssertExists()
+	op.Remove()
+	assert.NoError(t, op.Operate("obj", radosapi.OperationNoFlag))
+	_, err = ioctx.Stat("obj")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+}
+
+func TestReadOperation(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	assert.NoError(t, ioctx.WriteFull("obj", []byte("hello")))
+	assert.NoError(t, ioctx.SetOmap("obj", map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+		"c": []byte("3"),
+	}))
+	ver, err := ioctx.GetLastVersion()
+	assert.NoError(t, err)
+
+	op := ioctx.NewReadOperation()
+	defer op.Release()
+	op.AssertExists()
+	op.AssertVersion(ver)
+	buf := make([]byte, 3)
+	read := op.Read(1, buf)
+	iter := op.GetOmapValues("a", "", 10)
+	byKeys := op.GetOmapValuesByKeys([]string{"c", "missing", "a"})
+
+	_, err = iter.Next()
+	assert.ErrorIs(t, err, radosapi.ErrOperationIncomplete)
+
+	assert.NoError(t, op.Operate("obj", radosapi.OperationNoFlag))
+	assert.EqualValues(t, 3, read.BytesRead)
+	assert.Equal(t, "ell", string(buf))
+
+	collect := func(it radosapi.OmapIterator) []string {
+		var keys []string
+		for {
+			kv, err := it.Next()
+			require.NoError(t, err)
+			if kv == nil {
+				return keys
+			}
+			keys = append(keys, kv.Key)
+		}
+	}
+	assert.Equal(t, []string{"b", "c"}, collect(iter))
+	assert.Equal(t, []string{"a", "c"}, collect(byKeys))
+
+	op2 := ioctx.NewReadOperation()
+	op2.AssertVersion(ver - 1)
+	assert.ErrorIs(t,
+		op2.Operate("obj", radosapi.OperationNoFlag), errRange)
+	assert.ErrorIs(t,
+		op2.Operate("missing", radosapi.OperationNoFlag), radosapi.ErrNotFound)
+}
+
+func TestLocks(t *testing.T) {
+	c, ioctx1 := newTestIOContext(t)
+	ioctx2, err := c.NewConn().OpenIOContext("pool")
+	require.NoError(t, err)
+
+	ret, err := ioctx1.LockExclusive("obj", "lock", "cookie", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	ret, err = ioctx1.LockExclusive("obj", "lock", "cookie", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, -int(syscall.EEXIST), ret)
+	ret, err = ioctx2.LockExclusive("obj", "lock", "cookie", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, -int(syscall.EBUSY), ret)
+	ret, err = ioctx2.LockShared("obj", "lock", "cookie", "", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, -int(syscall.EBUSY), ret)
+
+	info, err := ioctx2.ListLockers("obj", "lock")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.NumLockers)
+	assert.True(t, info.Exclusive)
+	assert.Equal(t, []string{"cookie"}, info.Cookies)
+	client := info.Clients[0]
+
+	ret, err = ioctx2.Unlock("obj", "lock", "cookie")
+	assert.NoError(t, err)
+	assert.Equal(t, -int(syscall.ENOENT), ret)
+	ret, err = ioctx2.BreakLock("obj", "lock", "bogus", "cookie")
+	assert.NoError(t, err)
+	assert.Equal(t, -int(syscall.EINVAL), ret)
+	ret, err = ioctx2.BreakLock("obj", "lock", client, "cookie")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+
+	ret, err = ioctx1.LockShared("obj", "lock", "c1", "tag", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	ret, err = ioctx2.LockShared("obj", "lock", "c2", "tag", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	ret, err = ioctx2.LockShared("obj", "lock", "c3", "other", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, -int(syscall.EBUSY), ret)
+	info, err = ioctx1.ListLockers("obj", "lock")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, info.NumLockers)
+	assert.Equal(t, "tag", info.Tag)
+
+	ret, err = ioctx1.Unlock("obj", "lock", "c1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	ret, err = ioctx2.Unlock("obj", "lock", "c2")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+
+	// expired locks are released
+	ret, err = ioctx1.LockExclusive(
+		"obj", "lock", "cookie", "desc", time.Millisecond, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	time.Sleep(5 * time.Millisecond)
+	ret, err = ioctx2.LockExclusive("obj", "lock", "cookie", "desc", 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+
+	var mayRenew byte = lockFlagMayRenew
+	ret, err = ioctx2.LockExclusive(
+		"obj", "lock", "cookie", "desc", 0, &mayRenew)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+}
+
+func TestSnapshots(t *testing.T) {
+	_, ioctx := newTestIOContext(t)
+
+	assert.NoError(t, ioctx.WriteFull("obj", []byte("v1")))
+	assert.NoError(t, ioctx.CreateSnap("s1"))
+	assert.ErrorIs(t, ioctx.CreateSnap("s1"), radosapi.ErrObjectExists)
+	assert.NoError(t, ioctx.WriteFull("obj", []byte("v2")))
+	assert.NoError(t, ioctx.WriteFull("new", []byte("new")))
+	assert.NoError(t, ioctx.CreateSnap("s2"))
+
+	ids, err := ioctx.ListSnaps()
+	assert.NoError(t, err)
+	require.Len(t, ids, 2)
+	id, err := ioctx.LookupSnap("s1")
+	assert.NoError(t, err)
+	assert.Equal(t, ids[0], id)
+	name, err := ioctx.GetSnapName(ids[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "s2", name)
+	_, err = ioctx.GetSnapStamp(ids[1])
+	assert.NoError(t, err)
+	_, err = ioctx.LookupSnap("missing")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+
+	buf := make([]byte, 10)
+	assert.NoError(t, ioctx.SetReadSnap(id))
+	n, err := ioctx.Read("obj", buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(buf[:n]))
+	_, err = ioctx.Stat("new")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+	assert.NoError(t, ioctx.SetReadSnap(radosapi.SnapHead))
+
+	assert.NoError(t, ioctx.RollbackSnap("obj", "s1"))
+	n, err = ioctx.Read("obj", buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(buf[:n]))
+	assert.NoError(t, ioctx.RollbackSnap("new", "s1"))
+	_, err = ioctx.Stat("new")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+
+	assert.NoError(t, ioctx.RemoveSnap("s1"))
+	assert.ErrorIs(t, ioctx.RemoveSnap("s1"), radosapi.ErrNotFound)
+}
+
+func TestWatchNotify(t *testing.T) {
+	c, ioctx1 := newTestIOContext(t)
+	conn2 := c.NewConn()
+	ioctx2, err := conn2.OpenIOContext("pool")
+	require.NoError(t, err)
+
+	_, err = ioctx1.Watch("obj")
+	assert.ErrorIs(t, err, radosapi.ErrNotFound)
+
+	assert.NoError(t, ioctx1.Create("obj", radosapi.CreateIdempotent))
+	w, err := ioctx2.Watch("obj")
+	require.NoError(t, err)
+	_, err = w.Check()
+	assert.NoError(t, err)
+
+	go func() {
+		for ev := range w.Events() {
+			assert.Equal(t, []byte("ping"), ev.Data)
+			assert.Equal(t, w.ID(), ev.WatcherID)
+			assert.NoError(t, w.Ack(ev, []byte("pong")))
+		}
+	}()
+
+	acks, timeouts, err := ioctx1.Notify("obj", []byte("ping"))
+	assert.NoError(t, err)
+	assert.Len(t, timeouts, 0)
+	require.Len(t, acks, 1)
+	assert.Equal(t, w.ID(), acks[0].WatcherID)
+	assert.EqualValues(t, conn2.GetInstanceID(), acks[0].NotifierID)
+	assert.Equal(t, []byte("pong"), acks[0].Response)
+	// the notification is gone once Notify returns
+	assert.ErrorIs(t, w.Ack(radosapi.NotifyEvent{ID: 1}, nil), radosapi.ErrNotFound)
+
+	// a watcher that does not ack times out
+	w2, err := ioctx2.Watch("obj")
+	require.NoError(t, err)
+	acks, timeouts, err = ioctx1.NotifyWithTimeout(
+		"obj", []byte("ping"), 50*time.Millisecond)
+	assert.ErrorIs(t, err, errTimedOut)
+	assert.Len(t, acks, 1)
+	require.Len(t, timeouts, 1)
+	assert.Equal(t, w2.ID(), timeouts[0].WatcherID)
+
+	assert.NoError(t, w2.Delete())
+	_, err = w2.Check()
+	assert.ErrorIs(t, err, errNotConn)
+	_, ok := <-w2.Events()
+	assert.False(t, ok)
+	assert.NoError(t, w.Delete())
+	// deleting again is a no-op
+	assert.NoError(t, w.Delete())
+
+	acks, timeouts, err = ioctx1.Notify("obj", nil)
+	assert.NoError(t, err)
+	assert.Len(t, acks, 0)
+	assert.Len(t, timeouts, 0)
+}
+
+func TestIOContextAPI(t *testing.T) {
+	// code written against the interfaces works with the fake
+	var api radosapi.IOContext
+	_, api = newTestIOContext(t)
+	assert.NoError(t, api.WriteFull("b", nil))
+	assert.NoError(t, api.WriteFull("a", nil))
+	var names []string
+	assert.NoError(t, api.ListObjects(func(oid string) {
+		names = append(names, oid)
+	}))
+	assert.True(t, sort.StringsAreSorted(names))
+}
@@ -0,0 +1,130 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"sort"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+type readStep func(obj *object) error
+
+// ReadOp is the in-memory implementation of radosapi.ReadOperation.
+type ReadOp struct {
+	ioctx *IOContext
+	steps []readStep
+}
+
+// NewReadOperation returns a new ReadOp bound to the IOContext.
+func (ioctx *IOContext) NewReadOperation() radosapi.ReadOperation {
+	return &ReadOp{ioctx: ioctx}
+}
+
+func (r *ReadOp) add(step readStep) {
+	r.steps = append(r.steps, step)
+}
+
+// Operate performs the steps of the operation on the object oid.
+func (r *ReadOp) Operate(oid string, _ radosapi.OperationFlags) error {
+	ioctx := r.ioctx
+	return ioctx.with(func(p *pool) error {
+		obj, err := ioctx.lookup(p, oid)
+		if err != nil {
+			return err
+		}
+		for _, step := range r.steps {
+			if err := step(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Release the resources of the operation. It exists for parity with
+// rados.ReadOp and does nothing.
+func (*ReadOp) Release() {}
+
+// AssertExists ensures the object exists.
+func (r *ReadOp) AssertExists() {
+	r.add(func(*object) error { return nil })
+}
+
+// AssertVersion ensures the version of the object equals ver.
+func (r *ReadOp) AssertVersion(ver uint64) {
+	r.add(func(obj *object) error {
+		return assertVersion(obj, ver)
+	})
+}
+
+// omapIter implements radosapi.OmapIterator for the fake read operations.
+type omapIter struct {
+	kvs   []radosapi.OmapKeyValue
+	pos   int
+	ready bool
+}
+
+// Next returns the next key value pair or nil if iteration is exhausted.
+func (it *omapIter) Next() (*radosapi.OmapKeyValue, error) {
+	if !it.ready {
+		return nil, radosapi.ErrOperationIncomplete
+	}
+	if it.pos >= len(it.kvs) {
+		return nil, nil
+	}
+	kv := it.kvs[it.pos]
+	it.pos++
+	return &kv, nil
+}
+
+// GetOmapValues adds a step that returns the omap pairs after startAfter that
+// begin with filterPrefix, at most maxReturn of them.
+func (r *ReadOp) GetOmapValues(startAfter, filterPrefix string,
+	maxReturn uint64) radosapi.OmapIterator {
+
+	it := &omapIter{}
+	r.add(func(obj *object) error {
+		it.kvs = omapRange(obj.omap, startAfter, filterPrefix, maxReturn)
+		it.ready = true
+		return nil
+	})
+	return it
+}
+
+// GetOmapValuesByKeys adds a step that returns the omap pairs with the given
+// keys. Keys that are not set are skipped.
+func (r *ReadOp) GetOmapValuesByKeys(keys []string) radosapi.OmapIterator {
+	it := &omapIter{}
+	r.add(func(obj *object) error {
+		it.kvs = nil
+		for _, k := range keys {
+			if v, ok := obj.omap[k]; ok {
+				it.kvs = append(it.kvs,
+					radosapi.OmapKeyValue{Key: k, Value: copyBytes(v)})
+			}
+		}
+		sort.Slice(it.kvs, func(i, j int) bool {
+			return it.kvs[i].Key < it.kvs[j].Key
+		})
+		it.ready = true
+		return nil
+	})
+	return it
+}
+
+// Read adds a step that reads up to len(buffer) bytes of the object starting
+// at offset into buffer.
+func (r *ReadOp) Read(offset uint64, buffer []byte) *radosapi.ReadOpReadStep {
+	step := &radosapi.ReadOpReadStep{}
+	r.add(func(obj *object) error {
+		var n int
+		if offset < uint64(len(obj.data)) {
+			n = copy(buffer, obj.data[offset:])
+		}
+		step.BytesRead = int64(n)
+		step.Result = 0
+		return nil
+	})
+	return step
+}
@@ -0,0 +1,157 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+// snapshot is a pool snapshot. It holds a copy of all the objects of the pool
+// at the time the snapshot was taken.
+type snapshot struct {
+	seq     uint64
+	id      radosapi.SnapID
+	name    string
+	stamp   time.Time
+	objects map[objKey]*object
+}
+
+func (p *pool) snapByName(name string) (*snapshot, error) {
+	for _, s := range p.snaps {
+		if s.name == name {
+			return s, nil
+		}
+	}
+	return nil, radosapi.ErrNotFound
+}
+
+func (p *pool) snapByID(id radosapi.SnapID) (*snapshot, error) {
+	s, ok := p.snaps[id]
+	if !ok {
+		return nil, radosapi.ErrNotFound
+	}
+	return s, nil
+}
+
+// CreateSnap creates a pool-wide snapshot.
+func (ioctx *IOContext) CreateSnap(snapName string) error {
+	return ioctx.with(func(p *pool) error {
+		if _, err := p.snapByName(snapName); err == nil {
+			return radosapi.ErrObjectExists
+		}
+		p.lastSnapID++
+		id := radosapi.SnapID(p.lastSnapID)
+		p.snaps[id] = &snapshot{
+			seq:     p.lastSnapID,
+			id:      id,
+			name:    snapName,
+			stamp:   time.Now(),
+			objects: cloneObjects(p.objects),
+		}
+		return nil
+	})
+}
+
+// RemoveSnap deletes the pool snapshot.
+func (ioctx *IOContext) RemoveSnap(snapName string) error {
+	return ioctx.with(func(p *pool) error {
+		s, err := p.snapByName(snapName)
+		if err != nil {
+			return err
+		}
+		delete(p.snaps, s.id)
+		return nil
+	})
+}
+
+// LookupSnap returns the ID of a pool snapshot.
+func (ioctx *IOContext) LookupSnap(snapName string) (id radosapi.SnapID, err error) {
+	err = ioctx.with(func(p *pool) error {
+		s, err := p.snapByName(snapName)
+		if err == nil {
+			id = s.id
+		}
+		return err
+	})
+	return id, err
+}
+
+// GetSnapName returns the name of a pool snapshot with the given snapshot ID.
+func (ioctx *IOContext) GetSnapName(snapID radosapi.SnapID) (name string, err error) {
+	err = ioctx.with(func(p *pool) error {
+		s, err := p.snapByID(snapID)
+		if err == nil {
+			name = s.name
+		}
+		return err
+	})
+	return name, err
+}
+
+// GetSnapStamp returns the timestamp of a pool snapshot.
+func (ioctx *IOContext) GetSnapStamp(snapID radosapi.SnapID) (stamp time.Time, err error) {
+	err = ioctx.with(func(p *pool) error {
+		s, err := p.snapByID(snapID)
+		if err == nil {
+			stamp = time.Unix(s.stamp.Unix(), 0)
+		}
+		return err
+	})
+	return stamp, err
+}
+
+// ListSnaps returns the IDs of all the pool snapshots, in the order they were
+// created.
+func (ioctx *IOContext) ListSnaps() (ids []radosapi.SnapID, err error) {
+	err = ioctx.with(func(p *pool) error {
+		snaps := make([]*snapshot, 0, len(p.snaps))
+		for _, s := range p.snaps {
+			snaps = append(snaps, s)
+		}
+		sort.Slice(snaps, func(i, j int) bool {
+			return snaps[i].seq < snaps[j].seq
+		})
+		ids = make([]radosapi.SnapID, len(snaps))
+		for i, s := range snaps {
+			ids[i] = s.id
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// RollbackSnap rolls back the object with key oid to the pool snapshot. If
+// the object did not exist when the snapshot was taken, it is removed.
+func (ioctx *IOContext) RollbackSnap(oid, snapName string) error {
+	return ioctx.with(func(p *pool) error {
+		s, err := p.snapByName(snapName)
+		if err != nil {
+			return err
+		}
+		key := ioctx.key(oid)
+		old, ok := s.objects[key]
+		if !ok {
+			delete(p.objects, key)
+			return nil
+		}
+		obj := old.clone()
+		p.lastVersion++
+		obj.version = p.lastVersion
+		obj.mtime = time.Now()
+		p.objects[key] = obj
+		ioctx.lastVersion = obj.version
+		return nil
+	})
+}
+
+// SetReadSnap sets the snapshot from which reads are performed. Pass
+// radosapi.SnapHead for no snapshot.
+func (ioctx *IOContext) SetReadSnap(snapID radosapi.SnapID) error {
+	return ioctx.with(func(*pool) error {
+		ioctx.readSnap = snapID
+		return nil
+	})
+}
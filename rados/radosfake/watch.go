@@ -0,0 +1,258 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+// defaultNotifyTimeout is the timeout used by Notify, like the default of
+// the client_notify_timeout option of Ceph.
+const defaultNotifyTimeout = 10 * time.Second
+
+type watch struct {
+	pool    *pool
+	key     objKey
+	conn    *Conn
+	watcher *watcher
+}
+
+// notify is a notification waiting for the acks of the watchers.
+type notify struct {
+	acks map[radosapi.WatcherID]chan []byte
+}
+
+// watcher is the radosapi.Watcher returned by the fake.
+type watcher struct {
+	id      radosapi.WatcherID
+	cluster *Cluster
+	events  chan radosapi.NotifyEvent
+	errors  chan error
+	done    chan struct{}
+	// sending is held for reading while events are sent to the watcher, so
+	// that Delete does not close the channels under a sender
+	sending sync.RWMutex
+}
+
+var _ radosapi.Watcher = (*watcher)(nil)
+
+// ID returns the WatcherID of the watcher.
+func (w *watcher) ID() radosapi.WatcherID {
+	return w.id
+}
+
+// Events returns a read-only channel that receives all notifications that
+// are sent to the watched object.
+func (w *watcher) Events() <-chan radosapi.NotifyEvent {
+	return w.events
+}
+
+// Errors returns a read-only channel for the errors of the watcher. The fake
+// reports no errors and the channel is only closed by Delete.
+func (w *watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Check returns ENOTCONN if the watch is no longer registered.
+func (w *watcher) Check() (time.Duration, error) {
+	c := w.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	wt, ok := c.watches[w.id]
+	if !ok || wt.pool.deleted {
+		return 0, errNotConn
+	}
+	return 0, nil
+}
+
+// Ack passes the response to the pending notification of the event.
+func (w *watcher) Ack(ev radosapi.NotifyEvent, response []byte) error {
+	c := w.cluster
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	n, ok := c.notifies[ev.ID]
+	if !ok {
+		return radosapi.ErrNotFound
+	}
+	ch, ok := n.acks[w.id]
+	if !ok {
+		return radosapi.ErrNotFound
+	}
+	select {
+	case ch <- copyBytes(response):
+	default: // already acked
+	}
+	return nil
+}
+
+// Delete unregisters the watch and closes the channels of the watcher.
+func (w *watcher) Delete() error {
+	c := w.cluster
+	c.mutex.Lock()
+	_, ok := c.watches[w.id]
+	delete(c.watches, w.id)
+	c.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	close(w.done)
+	w.sending.Lock()
+	defer w.sending.Unlock()
+	close(w.events)
+	close(w.errors)
+	return nil
+}
+
+// send delivers the event to the watcher, unless it is deleted first.
+func (w *watcher) send(ev radosapi.NotifyEvent) {
+	w.sending.RLock()
+	defer w.sending.RUnlock()
+	select {
+	case <-w.done:
+	case w.events <- ev:
+	}
+}
+
+// Watch creates a Watcher for the object with key oid. The object must
+// exist.
+func (ioctx *IOContext) Watch(obj string) (radosapi.Watcher, error) {
+	return ioctx.WatchWithTimeout(obj, 0)
+}
+
+// WatchWithTimeout creates a Watcher for the object with key oid. The timeout
+// is ignored, as the watches of the fake never time out.
+func (ioctx *IOContext) WatchWithTimeout(oid string,
+	_ time.Duration) (radosapi.Watcher, error) {
+
+	var w *watcher
+	err := ioctx.with(func(p *pool) error {
+		key := ioctx.key(oid)
+		if _, ok := p.objects[key]; !ok {
+			return radosapi.ErrNotFound
+		}
+		c := ioctx.conn.cluster
+		c.lastWatcherID++
+		w = &watcher{
+			id:      radosapi.WatcherID(c.lastWatcherID),
+			cluster: c,
+			events:  make(chan radosapi.NotifyEvent),
+			errors:  make(chan error),
+			done:    make(chan struct{}),
+		}
+		c.watches[w.id] = &watch{
+			pool:    p,
+			key:     key,
+			conn:    ioctx.conn,
+			watcher: w,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Notify sends a notification with the provided data to all Watchers of the
+// object with key obj and waits for their acks.
+func (ioctx *IOContext) Notify(obj string, data []byte) (
+	[]radosapi.NotifyAck, []radosapi.NotifyTimeout, error) {
+
+	return ioctx.NotifyWithTimeout(obj, data, 0)
+}
+
+// NotifyWithTimeout is like Notify but waits at most timeout for the acks of
+// the watchers. If some watchers did not ack in time, they are returned as
+// NotifyTimeouts together with ETIMEDOUT.
+func (ioctx *IOContext) NotifyWithTimeout(obj string, data []byte,
+	timeout time.Duration) ([]radosapi.NotifyAck, []radosapi.NotifyTimeout, error) {
+
+	if timeout == 0 {
+		timeout = defaultNotifyTimeout
+	}
+	c := ioctx.conn.cluster
+	var (
+		id      radosapi.NotifyID
+		n       = &notify{acks: map[radosapi.WatcherID]chan []byte{}}
+		targets []*watch
+	)
+	err := ioctx.with(func(p *pool) error {
+		key := ioctx.key(obj)
+		if _, ok := p.objects[key]; !ok {
+			return radosapi.ErrNotFound
+		}
+		for wid, wt := range c.watches {
+			if wt.pool == p && wt.key == key {
+				n.acks[wid] = make(chan []byte, 1)
+				targets = append(targets, wt)
+			}
+		}
+		c.lastNotifyID++
+		id = radosapi.NotifyID(c.lastNotifyID)
+		c.notifies[id] = n
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		c.mutex.Lock()
+		delete(c.notifies, id)
+		c.mutex.Unlock()
+	}()
+
+	notifier := radosapi.NotifierID(ioctx.conn.gid)
+	for _, wt := range targets {
+		go wt.watcher.send(radosapi.NotifyEvent{
+			ID:         id,
+			WatcherID:  wt.watcher.id,
+			NotifierID: notifier,
+			Data:       copyBytes(data),
+		})
+	}
+
+	var (
+		acks     []radosapi.NotifyAck
+		timeouts []radosapi.NotifyTimeout
+		timer    = time.NewTimer(timeout)
+		expired  bool
+	)
+	defer timer.Stop()
+	for _, wt := range targets {
+		wid := wt.watcher.id
+		watcherNotifier := radosapi.NotifierID(wt.conn.gid)
+		if !expired {
+			select {
+			case resp := <-n.acks[wid]:
+				acks = append(acks, radosapi.NotifyAck{
+					WatcherID:  wid,
+					NotifierID: watcherNotifier,
+					Response:   resp,
+				})
+				continue
+			case <-timer.C:
+				expired = true
+			}
+		}
+		select {
+		case resp := <-n.acks[wid]:
+			acks = append(acks, radosapi.NotifyAck{
+				WatcherID:  wid,
+				NotifierID: watcherNotifier,
+				Response:   resp,
+			})
+		default:
+			timeouts = append(timeouts, radosapi.NotifyTimeout{
+				WatcherID:  wid,
+				NotifierID: watcherNotifier,
+			})
+		}
+	}
+	if len(timeouts) > 0 {
+		return acks, timeouts, errTimedOut
+	}
+	return acks, timeouts, nil
+}
@@ -0,0 +1,308 @@
+//go:build ceph_preview
+
+package radosfake
+
+import (
+	"time"
+
+	"github.com/ceph/go-ceph/rados/radosapi"
+)
+
+// writeState is the state of the object that is modified by the steps of a
+// WriteOp.
+type writeState struct {
+	// obj is a copy of the object, or nil if the object does not exist
+	obj      *object
+	modified bool
+}
+
+// ensure creates the object if it does not exist and marks it as modified.
+func (st *writeState) ensure() *object {
+	if st.obj == nil {
+		st.obj = newObject()
+	}
+	st.modified = true
+	return st.obj
+}
+
+// existing returns the object, or ErrNotFound if it does not exist.
+func (st *writeState) existing() (*object, error) {
+	if st.obj == nil {
+		return nil, radosapi.ErrNotFound
+	}
+	return st.obj, nil
+}
+
+type writeStep func(st *writeState) error
+
+// WriteOp is the in-memory implementation of radosapi.WriteOperation. The steps
+// of the operation are applied atomically: if one step fails, none of the
+// changes are applied.
+type WriteOp struct {
+	ioctx *IOContext
+	steps []writeStep
+}
+
+func (ioctx *IOContext) newWriteOp() *WriteOp {
+	return &WriteOp{ioctx: ioctx}
+}
+
+// NewWriteOperation returns a new WriteOp bound to the IOContext.
+func (ioctx *IOContext) NewWriteOperation() radosapi.WriteOperation {
+	return ioctx.newWriteOp()
+}
+
+func (w *WriteOp) add(step writeStep) {
+	w.steps = append(w.steps, step)
+}
+
+// Operate applies the steps of the operation to the object oid.
+func (w *WriteOp) Operate(oid string, _ radosapi.OperationFlags) error {
+	ioctx := w.ioctx
+	return ioctx.with(func(p *pool) error {
+		key := ioctx.key(oid)
+		cur := p.objects[key]
+		st := &writeState{}
+		if cur != nil {
+			st.obj = cur.clone()
+			ioctx.lastVersion = cur.version
+		}
+		for _, step := range w.steps {
+			if err := step(st); err != nil {
+				return err
+			}
+		}
+		if !st.modified {
+			return nil
+		}
+		p.lastVersion++
+		ioctx.lastVersion = p.lastVersion
+		if st.obj == nil {
+			delete(p.objects, key)
+			return nil
+		}
+		st.obj.version = p.lastVersion
+		st.obj.mtime = time.Now()
+		p.objects[key] = st.obj
+		return nil
+	})
+}
+
+// Release the resources of the operation. It exists for parity with
+// rados.WriteOp and does nothing.
+func (*WriteOp) Release() {}
+
+// Create the object.
+func (w *WriteOp) Create(exclusive radosapi.CreateOption) {
+	w.add(func(st *writeState) error {
+		if st.obj != nil && exclusive == radosapi.CreateExclusive {
+			return radosapi.ErrObjectExists
+		}
+		st.ensure()
+		return nil
+	})
+}
+
+// SetOmap sets the given key/value pairs in the omap of the object.
+func (w *WriteOp) SetOmap(pairs map[string][]byte) {
+	w.add(func(st *writeState) error {
+		obj := st.ensure()
+		for k, v := range pairs {
+			obj.omap[k] = copyBytes(v)
+		}
+		return nil
+	})
+}
+
+// RmOmapKeys removes the given keys from the omap of the object.
+func (w *WriteOp) RmOmapKeys(keys []string) {
+	w.add(func(st *writeState) error {
+		obj, err := st.existing()
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			delete(obj.omap, k)
+		}
+		st.modified = true
+		return nil
+	})
+}
+
+// CleanOmap clears the omap of the object.
+func (w *WriteOp) CleanOmap() {
+	w.add(func(st *writeState) error {
+		obj, err := st.existing()
+		if err != nil {
+			return err
+		}
+		obj.omap = map[string][]byte{}
+		st.modified = true
+		return nil
+	})
+}
+
+// AssertExists ensures the object exists.
+func (w *WriteOp) AssertExists() {
+	w.add(func(st *writeState) error {
+		_, err := st.existing()
+		return err
+	})
+}
+
+// assertVersion is shared by the AssertVersion steps of read and write
+// operations.
+func assertVersion(obj *object, ver uint64) error {
+	switch {
+	case ver < obj.version:
+		return errRange
+	case ver > obj.version:
+		return errOverflow
+	}
+	return nil
+}
+
+// AssertVersion ensures the version of the object equals ver.
+func (w *WriteOp) AssertVersion(ver uint64) {
+	w.add(func(st *writeState) error {
+		obj, err := st.existing()
+		if err != nil {
+			return err
+		}
+		return assertVersion(obj, ver)
+	})
+}
+
+// writeAt writes b at offset into the data of obj, extending the data with
+// zeros if needed.
+func writeAt(obj *object, b []byte, offset uint64) {
+	end := offset + uint64(len(b))
+	if end > uint64(len(obj.data)) {
+		obj.data = append(obj.data, make([]byte, end-uint64(len(obj.data)))...)
+	}
+	copy(obj.data[offset:], b)
+}
+
+// Write b at offset into the object.
+func (w *WriteOp) Write(b []byte, offset uint64) {
+	w.add(func(st *writeState) error {
+		writeAt(st.ensure(), b, offset)
+		return nil
+	})
+}
+
+// WriteFull replaces the data of the object with b.
+func (w *WriteOp) WriteFull(b []byte) {
+	w.add(func(st *writeState) error {
+		st.ensure().data = copyBytes(b)
+		return nil
+	})
+}
+
+// WriteSame writes b repeatedly to the object, until writeLen bytes starting
+// at offset have been written. writeLen must be a multiple of len(b).
+func (w *WriteOp) WriteSame(b []byte, writeLen, offset uint64) {
+	w.add(func(st *writeState) error {
+		if len(b) == 0 || writeLen%uint64(len(b)) != 0 {
+			return errInvalid
+		}
+		obj := st.ensure()
+		for off := offset; off < offset+writeLen; off += uint64(len(b)) {
+			writeAt(obj, b, off)
+		}
+		return nil
+	})
+}
+
+// Remove the object.
+func (w *WriteOp) Remove() {
+	w.add(func(st *writeState) error {
+		if _, err := st.existing(); err != nil {
+			return err
+		}
+		st.obj = nil
+		st.modified = true
+		return nil
+	})
+}
+
+// SetXattr sets the xattr name of the object to value.
+func (w *WriteOp) SetXattr(name string, value []byte) {
+	w.add(func(st *writeState) error {
+		st.ensure().xattrs[name] = copyBytes(value)
+		return nil
+	})
+}
+
+// SetAllocationHint is accepted for parity with rados.WriteOp. It creates the
+// object if it does not exist, but has no other effect.
+func (w *WriteOp) SetAllocationHint(_ uint64, _ uint64, _ radosapi.AllocHintFlags) {
+	w.add(func(st *writeState) error {
+		st.ensure()
+		return nil
+	})
+}
+
+// CmpExt compares b with the data of the object at offset. If the data
+// differs, the operation fails and the Result of the returned step is set to
+// -MAX_ERRNO minus the offset of the first mismatch, like librados does.
+func (w *WriteOp) CmpExt(b []byte, offset uint64) *radosapi.WriteOpCmpExtStep {
+	step := &radosapi.WriteOpCmpExtStep{}
+	w.add(func(st *writeState) error {
+		obj, err := st.existing()
+		if err != nil {
+			return err
+		}
+		for i := range b {
+			var c byte
+			if pos := offset + uint64(i); pos < uint64(len(obj.data)) {
+				c = obj.data[pos]
+			}
+			if c != b[i] {
+				step.Result = -maxErrno - i
+				return getError(step.Result)
+			}
+		}
+		step.Result = 0
+		return nil
+	})
+	return step
+}
+
+// append adds b to the end of the object.
+func (w *WriteOp) append(b []byte) {
+	w.add(func(st *writeState) error {
+		obj := st.ensure()
+		obj.data = append(obj.data, b...)
+		return nil
+	})
+}
+
+// truncate resizes the object to size.
+func (w *WriteOp) truncate(size uint64) {
+	w.add(func(st *writeState) error {
+		obj := st.ensure()
+		if size <= uint64(len(obj.data)) {
+			obj.data = obj.data[:size]
+		} else {
+			obj.data = append(obj.data, make([]byte, size-uint64(len(obj.data)))...)
+		}
+		return nil
+	})
+}
+
+// rmXattr removes the xattr name from the object.
+func (w *WriteOp) rmXattr(name string) {
+	w.add(func(st *writeState) error {
+		obj, err := st.existing()
+		if err != nil {
+			return err
+		}
+		if _, ok := obj.xattrs[name]; !ok {
+			return errNoData
+		}
+		delete(obj.xattrs, name)
+		st.modified = true
+		return nil
+	})
+}
@@ -41,7 +41,9 @@ func (r *ReadOp) Operate(ioctx *IOContext, oid string, flags OperationFlags) err
 	cOid := C.CString(oid)
 	defer C.free(unsafe.Pointer(cOid))
 
+	t := ioctx.startOp("rados_read_op_operate", oid)
 	ret := C.rados_read_op_operate(r.op, ioctx.ioctx, cOid, C.int(flags))
+	t.Done(0, getError(ret))
 	return r.update(readOp, ret)
 }
 
@@ -24,8 +24,9 @@ func (ioctx *IOContext) CreateSnap(snapName string) error {
 	cSnapName := C.CString(snapName)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := ioctx.startOp("rados_ioctx_snap_create", "")
 	ret := C.rados_ioctx_snap_create(ioctx.ioctx, cSnapName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // RemoveSnap deletes the pool snapshot.
@@ -41,8 +42,9 @@ func (ioctx *IOContext) RemoveSnap(snapName string) error {
 	cSnapName := C.CString(snapName)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := ioctx.startOp("rados_ioctx_snap_remove", "")
 	ret := C.rados_ioctx_snap_remove(ioctx.ioctx, cSnapName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // SnapID represents the ID of a rados snapshot.
@@ -63,11 +65,12 @@ func (ioctx *IOContext) LookupSnap(snapName string) (SnapID, error) {
 	cSnapName := C.CString(snapName)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := ioctx.startOp("rados_ioctx_snap_lookup", "")
 	ret := C.rados_ioctx_snap_lookup(
 		ioctx.ioctx,
 		cSnapName,
 		(*C.rados_snap_t)(&snapID))
-	return snapID, getError(ret)
+	return snapID, t.DoneErr(getError(ret))
 }
 
 // GetSnapName returns the name of a pool snapshot with the given snapshot ID.
@@ -88,12 +91,13 @@ func (ioctx *IOContext) GetSnapName(snapID SnapID) (string, error) {
 	retry.WithSizes(1024, 1<<16, func(length int) retry.Hint {
 		cLen := C.int(length)
 		buf = make([]byte, cLen)
+		t := ioctx.startOp("rados_ioctx_snap_get_name", "")
 		ret := C.rados_ioctx_snap_get_name(
 			ioctx.ioctx,
 			(C.rados_snap_t)(snapID),
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			cLen)
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.Size(int(cLen)).If(err == errRange)
 	})
 
@@ -115,11 +119,12 @@ func (ioctx *IOContext) GetSnapStamp(snapID SnapID) (time.Time, error) {
 		return time.Unix(int64(cTime), 0), err
 	}
 
+	t := ioctx.startOp("rados_ioctx_snap_get_stamp", "")
 	ret := C.rados_ioctx_snap_get_stamp(
 		ioctx.ioctx,
 		(C.rados_snap_t)(snapID),
 		&cTime)
-	return time.Unix(int64(cTime), 0), getError(ret)
+	return time.Unix(int64(cTime), 0), t.DoneErr(getError(ret))
 }
 
 // ListSnaps returns a slice containing the SnapIDs of existing pool snapshots.
@@ -141,11 +146,12 @@ func (ioctx *IOContext) ListSnaps() ([]SnapID, error) {
 	retry.WithSizes(100, 1000, func(maxlen int) retry.Hint {
 		cLen = C.int(maxlen)
 		snapList = make([]SnapID, cLen)
+		t := ioctx.startOp("rados_ioctx_snap_list", "")
 		ret = C.rados_ioctx_snap_list(
 			ioctx.ioctx,
 			(*C.rados_snap_t)(unsafe.Pointer(&snapList[0])),
 			cLen)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cLen)).If(err == errRange)
 	})
 
@@ -171,8 +177,9 @@ func (ioctx *IOContext) RollbackSnap(oid, snapName string) error {
 	cSnapName := C.CString(snapName)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := ioctx.startOp("rados_ioctx_snap_rollback", oid)
 	ret := C.rados_ioctx_snap_rollback(ioctx.ioctx, coid, cSnapName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // SnapHead is the representation of LIBRADOS_SNAP_HEAD from librados.
@@ -191,6 +198,8 @@ func (ioctx *IOContext) SetReadSnap(snapID SnapID) error {
 		return err
 	}
 
+	t := ioctx.startOp("rados_ioctx_snap_set_read", "")
 	C.rados_ioctx_snap_set_read(ioctx.ioctx, (C.rados_snap_t)(snapID))
+	t.Done(0, nil)
 	return nil
 }
@@ -0,0 +1,36 @@
+package striper
+
+import "C"
+
+import (
+	"github.com/ceph/go-ceph/internal/hooks"
+	"github.com/ceph/go-ceph/rados"
+)
+
+// startOp returns a tracker for an operation on the striped object soid in
+// ioctx, or nil if no hook is set.
+func startOp(ioctx *rados.IOContext, name, soid string) *hooks.Tracker {
+	h, op := hooks.Lookup(ioctx)
+	h = hooks.Select(h)
+	if h == nil {
+		return nil
+	}
+	op.Name = name
+	op.Object = soid
+	return hooks.Start(h, op)
+}
+
+// startOp returns a tracker for an operation of the striper on the striped
+// object soid, or nil if no hook is set.
+func (s *Striper) startOp(name, soid string) *hooks.Tracker {
+	return startOp(s.ioctx, name, soid)
+}
+
+// writtenBytes returns the number of bytes written by a call returning ret
+// that was passed a buffer of size n.
+func writtenBytes(ret C.int, n int) int64 {
+	if ret < 0 {
+		return 0
+	}
+	return int64(n)
+}
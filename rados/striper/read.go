@@ -27,6 +27,7 @@ func (s *Striper) Read(soid string, data []byte, offset uint64) (int, error) {
 		bufptr = (*C.char)(unsafe.Pointer(&data[0]))
 	}
 
+	t := s.startOp("rados_striper_read", soid)
 	ret := C.rados_striper_read(
 		s.striper,
 		csoid,
@@ -34,7 +35,8 @@ func (s *Striper) Read(soid string, data []byte, offset uint64) (int, error) {
 		C.size_t(len(data)),
 		C.uint64_t(offset))
 	if ret >= 0 {
+		t.Done(int64(ret), nil)
 		return int(ret), nil
 	}
-	return 0, getError(ret)
+	return 0, t.DoneErr(getError(ret))
 }
@@ -30,14 +30,15 @@ func (s *Striper) Stat(soid string) (StatInfo, error) {
 		size  C.uint64_t
 		mtime C.time_t
 	)
+	t := s.startOp("rados_striper_stat", soid)
 	ret := C.rados_striper_stat(
 		s.striper,
 		csoid,
 		&size,
 		&mtime)
 
-	if ret < 0 {
-		return StatInfo{}, getError(ret)
+	if err := t.DoneErr(getError(ret)); err != nil {
+		return StatInfo{}, err
 	}
 	modts := Timespec{Sec: int64(mtime)}
 	return StatInfo{
@@ -29,14 +29,15 @@ func (s *Striper) Stat(soid string) (StatInfo, error) {
 		size  C.uint64_t
 		mtime C.struct_timespec
 	)
+	t := s.startOp("rados_striper_stat2", soid)
 	ret := C.rados_striper_stat2(
 		s.striper,
 		csoid,
 		&size,
 		&mtime)
 
-	if ret < 0 {
-		return StatInfo{}, getError(ret)
+	if err := t.DoneErr(getError(ret)); err != nil {
+		return StatInfo{}, err
 	}
 	return StatInfo{
 		Size:    uint64(size),
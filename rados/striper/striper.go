@@ -32,8 +32,9 @@ type Layout struct {
 // New returns a rados Striper object created from a rados IOContext.
 func New(ioctx *rados.IOContext) (*Striper, error) {
 	var s C.rados_striper_t
+	t := startOp(ioctx, "rados_striper_create", "")
 	ret := C.rados_striper_create(cephIoctx(ioctx), &s)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return nil, err
 	}
 	return &Striper{s, ioctx}, nil
@@ -72,11 +73,12 @@ func (s *Striper) Destroy() {
 //	int rados_striper_set_object_layout_stripe_unit(rados_striper_t striper,
 //	                                                unsigned int stripe_unit);
 func (s *Striper) SetObjectLayoutStripeUnit(count uint) error {
+	t := s.startOp("rados_striper_set_object_layout_stripe_unit", "")
 	ret := C.rados_striper_set_object_layout_stripe_unit(
 		s.striper,
 		C.uint(count),
 	)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // SetObjectLayoutStripeCount sets the stripe count value used to layout
@@ -87,11 +89,12 @@ func (s *Striper) SetObjectLayoutStripeUnit(count uint) error {
 //	int rados_striper_set_object_layout_stripe_count(rados_striper_t striper,
 //	                                                 unsigned int stripe_count);
 func (s *Striper) SetObjectLayoutStripeCount(count uint) error {
+	t := s.startOp("rados_striper_set_object_layout_stripe_count", "")
 	ret := C.rados_striper_set_object_layout_stripe_count(
 		s.striper,
 		C.uint(count),
 	)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // SetObjectLayoutObjectSize sets the object size value used to layout
@@ -102,11 +105,12 @@ func (s *Striper) SetObjectLayoutStripeCount(count uint) error {
 //	int rados_striper_set_object_layout_object_size(rados_striper_t striper,
 //	                                                unsigned int object_size);
 func (s *Striper) SetObjectLayoutObjectSize(count uint) error {
+	t := s.startOp("rados_striper_set_object_layout_object_size", "")
 	ret := C.rados_striper_set_object_layout_object_size(
 		s.striper,
 		C.uint(count),
 	)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // cephIoctx returns a ceph rados_ioctx_t given a go-ceph rados IOContext.
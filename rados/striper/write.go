@@ -21,13 +21,16 @@ func (s *Striper) Write(soid string, data []byte, offset uint64) error {
 	defer C.free(unsafe.Pointer(csoid))
 
 	bufptr := (*C.char)(unsafe.Pointer(&data[0]))
+	t := s.startOp("rados_striper_write", soid)
 	ret := C.rados_striper_write(
 		s.striper,
 		csoid,
 		bufptr,
 		C.size_t(len(data)),
 		C.uint64_t(offset))
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // WriteFull writes all of the bytes in data to the striped object, truncating
@@ -44,12 +47,15 @@ func (s *Striper) WriteFull(soid string, data []byte) error {
 	defer C.free(unsafe.Pointer(csoid))
 
 	bufptr := (*C.char)(unsafe.Pointer(&data[0]))
+	t := s.startOp("rados_striper_write_full", soid)
 	ret := C.rados_striper_write_full(
 		s.striper,
 		csoid,
 		bufptr,
 		C.size_t(len(data)))
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // Append the bytes in data to the end of the striped object.
@@ -65,12 +71,15 @@ func (s *Striper) Append(soid string, data []byte) error {
 	defer C.free(unsafe.Pointer(csoid))
 
 	bufptr := (*C.char)(unsafe.Pointer(&data[0]))
+	t := s.startOp("rados_striper_append", soid)
 	ret := C.rados_striper_append(
 		s.striper,
 		csoid,
 		bufptr,
 		C.size_t(len(data)))
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // Remove a striped RADOS object.
@@ -83,8 +92,9 @@ func (s *Striper) Remove(soid string) error {
 	csoid := C.CString(soid)
 	defer C.free(unsafe.Pointer(csoid))
 
+	t := s.startOp("rados_striper_remove", soid)
 	ret := C.rados_striper_remove(s.striper, csoid)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Truncate a striped object, setting it to the specified size.
@@ -96,9 +106,10 @@ func (s *Striper) Truncate(soid string, size uint64) error {
 	csoid := C.CString(soid)
 	defer C.free(unsafe.Pointer(csoid))
 
+	t := s.startOp("rados_striper_trunc", soid)
 	ret := C.rados_striper_trunc(
 		s.striper,
 		csoid,
 		C.uint64_t(size))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
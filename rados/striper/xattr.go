@@ -28,6 +28,7 @@ func (s *Striper) GetXattr(soid string, name string, data []byte) (int, error) {
 	defer C.free(unsafe.Pointer(csoid))
 	defer C.free(unsafe.Pointer(cName))
 
+	t := s.startOp("rados_striper_getxattr", soid)
 	ret := C.rados_striper_getxattr(
 		s.striper,
 		csoid,
@@ -36,9 +37,10 @@ func (s *Striper) GetXattr(soid string, name string, data []byte) (int, error) {
 		(C.size_t)(len(data)))
 
 	if ret >= 0 {
+		t.Done(int64(ret), nil)
 		return int(ret), nil
 	}
-	return 0, getError(ret)
+	return 0, t.DoneErr(getError(ret))
 }
 
 // SetXattr sets an extended attribute (xattr) of the given name on the
@@ -57,6 +59,7 @@ func (s *Striper) SetXattr(soid string, name string, data []byte) error {
 	defer C.free(unsafe.Pointer(csoid))
 	defer C.free(unsafe.Pointer(cName))
 
+	t := s.startOp("rados_striper_setxattr", soid)
 	ret := C.rados_striper_setxattr(
 		s.striper,
 		csoid,
@@ -64,7 +67,9 @@ func (s *Striper) SetXattr(soid string, name string, data []byte) error {
 		(*C.char)(unsafe.Pointer(&data[0])),
 		(C.size_t)(len(data)))
 
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(data)), err)
+	return err
 }
 
 // RmXattr removes the extended attribute (xattr) of the given name from the
@@ -81,8 +86,9 @@ func (s *Striper) RmXattr(soid string, name string) error {
 	defer C.free(unsafe.Pointer(csoid))
 	defer C.free(unsafe.Pointer(cName))
 
+	t := s.startOp("rados_striper_rmxattr", soid)
 	ret := C.rados_striper_rmxattr(s.striper, csoid, cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // getXattrsNext wraps the function to fetch a xattr name/value pair
@@ -127,9 +133,10 @@ func (s *Striper) ListXattrs(soid string) (map[string][]byte, error) {
 	defer C.free(unsafe.Pointer(csoid))
 
 	var it C.rados_xattrs_iter_t
+	t := s.startOp("rados_striper_getxattrs", soid)
 	ret := C.rados_striper_getxattrs(s.striper, csoid, &it)
-	if ret < 0 {
-		return nil, getError(ret)
+	if err := t.DoneErr(getError(ret)); err != nil {
+		return nil, err
 	}
 	defer C.rados_striper_getxattrs_end(it)
 
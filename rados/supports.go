@@ -0,0 +1,42 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"github.com/ceph/go-ceph/internal/dlsym"
+)
+
+// NotImplementedError is returned by functions that require a function that
+// is not provided by the version of librados loaded at runtime.
+type NotImplementedError = dlsym.NotImplementedError
+
+// APIFeature identifies a part of the go-ceph rados API that depends on
+// functions that are not provided by all versions of librados.
+type APIFeature string
+
+const (
+	// APIPoolFullTry is required by IOContext.SetPoolFullTry and
+	// IOContext.UnsetPoolFullTry.
+	APIPoolFullTry = APIFeature("PoolFullTry")
+)
+
+var apiFeatureSymbols = map[APIFeature][][]*dlsym.Symbol{
+	// either of the function sets is sufficient
+	APIPoolFullTry: {
+		{radosSetPoolFullTry, radosUnsetPoolFullTry},
+		{radosSetOsdmapFullTry, radosUnsetOsdmapFullTry},
+	},
+}
+
+// Supports returns true if the librados library loaded at runtime provides
+// everything the given APIFeature requires. Functions of unsupported
+// features return a NotImplementedError. Supports returns false for unknown
+// features.
+func Supports(f APIFeature) bool {
+	for _, symbols := range apiFeatureSymbols[f] {
+		if dlsym.Available(symbols...) {
+			return true
+		}
+	}
+	return false
+}
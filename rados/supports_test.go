@@ -0,0 +1,15 @@
+//go:build ceph_preview
+
+package rados
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupports(t *testing.T) {
+	assert.False(t, Supports(APIFeature("NoSuchFeature")))
+	// every supported ceph version provides one of the variants
+	assert.True(t, Supports(APIPoolFullTry))
+}
@@ -112,6 +112,7 @@ func (ioctx *IOContext) WatchWithTimeout(oid string, timeout time.Duration) (*Wa
 	var id C.uint64_t
 	watchersMtx.Lock()
 	defer watchersMtx.Unlock()
+	t := ioctx.startOp("rados_watch3", oid)
 	ret := C.rados_watch3(
 		ioctx.ioctx,
 		cObj,
@@ -121,7 +122,7 @@ func (ioctx *IOContext) WatchWithTimeout(oid string, timeout time.Duration) (*Wa
 		C.uint32_t(timeout.Milliseconds()/1000),
 		nil,
 	)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return nil, err
 	}
 	evCh := make(chan NotifyEvent)
@@ -163,7 +164,9 @@ func (w *Watcher) Errors() <-chan error {
 //
 //	int rados_watch_check(rados_ioctx_t io, uint64_t cookie)
 func (w *Watcher) Check() (time.Duration, error) {
+	t := w.ioctx.startOp("rados_watch_check", w.oid)
 	ret := C.rados_watch_check(w.ioctx.ioctx, C.uint64_t(w.id))
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return 0, getError(ret)
 	}
@@ -185,7 +188,9 @@ func (w *Watcher) Delete() error {
 	if !ok {
 		return nil
 	}
+	t := w.ioctx.startOp("rados_unwatch2", w.oid)
 	ret := C.rados_unwatch2(w.ioctx.ioctx, C.uint64_t(w.id))
+	t.Done(0, getError(ret))
 	if ret != 0 {
 		return getError(ret)
 	}
@@ -222,6 +227,7 @@ func (ioctx *IOContext) NotifyWithTimeout(obj string, data []byte, timeout time.
 	if len(data) > 0 {
 		dataPtr = (*C.char)(unsafe.Pointer(&data[0]))
 	}
+	t := ioctx.startOp("rados_notify2", obj)
 	ret := C.rados_notify2(
 		ioctx.ioctx,
 		cObj,
@@ -233,6 +239,7 @@ func (ioctx *IOContext) NotifyWithTimeout(obj string, data []byte, timeout time.
 	)
 	// cResponse has been set even if an error is returned, so we decode it anyway
 	acks, timeouts := decodeNotifyResponse(cResponse, responseLen)
+	t.Done(writtenBytes(ret, len(data)), getError(ret))
 	return acks, timeouts, getError(ret)
 }
 
@@ -257,6 +264,7 @@ func (ne *NotifyEvent) Ack(response []byte) error {
 	if len(response) > 0 {
 		respPtr = (*C.char)(unsafe.Pointer(&response[0]))
 	}
+	t := w.ioctx.startOp("rados_notify_ack", w.oid)
 	ret := C.rados_notify_ack(
 		w.ioctx.ioctx,
 		cOID,
@@ -265,7 +273,9 @@ func (ne *NotifyEvent) Ack(response []byte) error {
 		respPtr,
 		C.int(len(response)),
 	)
-	return getError(ret)
+	err := getError(ret)
+	t.Done(writtenBytes(ret, len(response)), err)
+	return err
 }
 
 // WatcherFlush flushes all pending notifications of the cluster.
@@ -277,8 +287,9 @@ func (c *Conn) WatcherFlush() error {
 	if !c.connected {
 		return ErrNotConnected
 	}
+	t := c.startOp("rados_watch_flush", "")
 	ret := C.rados_watch_flush(c.cluster)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // decoder for this notify response format:
@@ -349,7 +360,10 @@ func watchNotifyCb(_ unsafe.Pointer, notifyID C.uint64_t, id C.uint64_t,
 	watchersMtx.RUnlock()
 	if !ok {
 		// usually this should not happen, but who knows
-		log.Warnf("received notification for unknown watcher ID: %#v", ev)
+		log.Warn("received notification for unknown watcher ID",
+			"watcher_id", ev.WatcherID,
+			"notify_id", ev.ID,
+			"notifier_id", ev.NotifierID)
 		return
 	}
 	select {
@@ -365,7 +379,9 @@ func watchErrorCb(_ unsafe.Pointer, id C.uint64_t, err C.int) {
 	watchersMtx.RUnlock()
 	if !ok {
 		// usually this should not happen, but who knows
-		log.Warnf("received error for unknown watcher ID: id=%d err=%#v", id, err)
+		log.Warn("received error for unknown watcher ID",
+			"watcher_id", uint64(id),
+			"errno", int(err))
 		return
 	}
 	select {
@@ -55,8 +55,10 @@ func (w WriteOp) operate2(
 			ts.CTimespecPtr(cMtime))
 	}
 
+	t := ioctx.startOp("rados_write_op_operate2", oid)
 	ret := C.rados_write_op_operate2(
 		w.op, ioctx.ioctx, cOid, cMtime, C.int(flags))
+	t.Done(0, getError(ret))
 	return w.update(writeOp, ret)
 }
 
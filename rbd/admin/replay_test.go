@@ -0,0 +1,68 @@
+//go:build !nautilus && ceph_preview
+
+package admin
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/internal/admintest"
+	"github.com/ceph/go-ceph/internal/errutil"
+)
+
+// The golden file was recorded with an image rbd/img1 that has snapshot
+// based mirroring enabled.
+const goldenFile = "testdata/replay_msschedule.json"
+
+func TestMirrorSnapshotScheduleReplay(t *testing.T) {
+	ra := NewFromConn(admintest.Golden(t, goldenFile))
+	scheduler := ra.MirrorSnashotSchedule()
+	pool := NewLevelSpec("rbd", "", "")
+	image := NewLevelSpec("rbd", "", "img1")
+
+	err := scheduler.Add(pool, Interval("1h"), NoStartTime)
+	require.NoError(t, err)
+	err = scheduler.Add(image, Interval("1d"), StartTime("14:00:00-05:00"))
+	require.NoError(t, err)
+
+	// the schedules of the image include the schedules of its pool
+	l, err := scheduler.List(image)
+	require.NoError(t, err)
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].LevelSpecID < l[j].LevelSpecID
+	})
+	if assert.Len(t, l, 2) {
+		assert.Equal(t, "rbd/", l[0].Name)
+		assert.Equal(t, "2", l[0].LevelSpecID)
+		assert.Equal(t, []ScheduleTerm{{"1h", ""}}, l[0].Schedule)
+		assert.Equal(t, "rbd/img1", l[1].Name)
+		assert.Equal(t, "2//10b46b8b4567", l[1].LevelSpecID)
+		assert.Equal(t, []ScheduleTerm{{"1d", "14:00:00-05:00"}}, l[1].Schedule)
+	}
+
+	s, err := scheduler.Status(pool)
+	require.NoError(t, err)
+	assert.Equal(t,
+		[]ScheduledImage{{Image: "rbd/img1", ScheduleTime: "2023-09-12 10:00:00"}},
+		s)
+
+	err = scheduler.Remove(image, Interval("1d"), StartTime("14:00:00-05:00"))
+	require.NoError(t, err)
+	l, err = scheduler.List(image)
+	require.NoError(t, err)
+	if assert.Len(t, l, 1) {
+		assert.Equal(t, "rbd/", l[0].Name)
+	}
+
+	err = scheduler.Add(NewLevelSpec("nope", "", ""), Interval("1h"), NoStartTime)
+	assert.ErrorIs(t, err, errutil.GetError("rados", -2))
+
+	err = scheduler.Remove(pool, NoInterval, NoStartTime)
+	require.NoError(t, err)
+	l, err = scheduler.List(pool)
+	require.NoError(t, err)
+	assert.Empty(t, l)
+}
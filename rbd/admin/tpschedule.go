@@ -0,0 +1,161 @@
+//go:build !nautilus && ceph_preview
+
+package admin
+
+import (
+	"encoding/json"
+
+	ccom "github.com/ceph/go-ceph/common/commands"
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// TrashPurgeScheduleAdmin encapsulates management functions for
+// ceph rbd trash purge schedules.
+type TrashPurgeScheduleAdmin struct {
+	conn ccom.MgrCommander
+}
+
+// TrashPurgeSchedule returns a TrashPurgeScheduleAdmin type for
+// managing ceph rbd trash purge schedules.
+func (ra *RBDAdmin) TrashPurgeSchedule() *TrashPurgeScheduleAdmin {
+	return &TrashPurgeScheduleAdmin{conn: ra.conn}
+}
+
+// Add a new trash purge schedule to the given pool or namespace based on
+// the supplied level spec.
+//
+// Similar To:
+//
+//	rbd trash purge schedule add <level_spec> <interval> <start_time>
+func (tps *TrashPurgeScheduleAdmin) Add(l LevelSpec, i Interval, s StartTime) error {
+	m := map[string]string{
+		"prefix":     "rbd trash purge schedule add",
+		"level_spec": l.spec,
+		"format":     "json",
+	}
+	if i != NoInterval {
+		m["interval"] = string(i)
+	}
+	if s != NoStartTime {
+		m["start_time"] = string(s)
+	}
+	return commands.MarshalMgrCommand(tps.conn, m).NoData().End()
+}
+
+// TrashPurgeSchedule contains values representing an entire trash purge
+// schedule for a pool or namespace.
+type TrashPurgeSchedule struct {
+	Name        string
+	LevelSpecID string
+	Schedule    []ScheduleTerm
+}
+
+// List the trash purge schedules based on the supplied level spec.
+//
+// Similar To:
+//
+//	rbd trash purge schedule list <level_spec>
+func (tps *TrashPurgeScheduleAdmin) List(l LevelSpec) ([]TrashPurgeSchedule, error) {
+	m := map[string]string{
+		"prefix":     "rbd trash purge schedule list",
+		"level_spec": l.spec,
+		"format":     "json",
+	}
+	return parseTrashPurgeScheduleList(commands.MarshalMgrCommand(tps.conn, m))
+}
+
+func parseTrashPurgeScheduleList(res commands.Response) (
+	[]TrashPurgeSchedule, error) {
+
+	var ss snapshotScheduleMap
+	if err := res.NoStatus().Unmarshal(&ss).End(); err != nil {
+		return nil, err
+	}
+
+	var sched []TrashPurgeSchedule
+	for k, v := range ss {
+		sched = append(sched, TrashPurgeSchedule{
+			Name:        v.Name,
+			LevelSpecID: k,
+			Schedule:    v.Schedule,
+		})
+	}
+	return sched, nil
+}
+
+// Remove a trash purge schedule matching the supplied arguments.
+//
+// Similar To:
+//
+//	rbd trash purge schedule remove <level_spec> <interval> <start_time>
+func (tps *TrashPurgeScheduleAdmin) Remove(
+	l LevelSpec, i Interval, s StartTime) error {
+
+	m := map[string]string{
+		"prefix":     "rbd trash purge schedule remove",
+		"level_spec": l.spec,
+		"format":     "json",
+	}
+	if i != NoInterval {
+		m["interval"] = string(i)
+	}
+	if s != NoStartTime {
+		m["start_time"] = string(s)
+	}
+	return commands.MarshalMgrCommand(tps.conn, m).NoData().End()
+}
+
+// ScheduledPool contains a pool or namespace whose trash is scheduled to be
+// purged and when that will next occur.
+type ScheduledPool struct {
+	ScheduleTime ScheduleTime
+	PoolID       string
+	PoolName     string
+	Namespace    string
+}
+
+type scheduledPool struct {
+	ScheduleTime ScheduleTime `json:"schedule_time"`
+	// the mgr module reports the pool id as a string
+	PoolID    json.Number `json:"pool_id"`
+	PoolName  string      `json:"pool_name"`
+	Namespace string      `json:"namespace"`
+}
+
+type scheduledPoolWrapper struct {
+	Scheduled []scheduledPool `json:"scheduled"`
+}
+
+// Status returns the status of the trash purge schedules (eg. when the next
+// purge will take place) matching the supplied level spec.
+//
+// Similar To:
+//
+//	rbd trash purge schedule status <level_spec>
+func (tps *TrashPurgeScheduleAdmin) Status(l LevelSpec) ([]ScheduledPool, error) {
+	m := map[string]string{
+		"prefix":     "rbd trash purge schedule status",
+		"level_spec": l.spec,
+		"format":     "json",
+	}
+	return parseTrashPurgeScheduleStatus(commands.MarshalMgrCommand(tps.conn, m))
+}
+
+func parseTrashPurgeScheduleStatus(res commands.Response) (
+	[]ScheduledPool, error) {
+
+	var spw scheduledPoolWrapper
+	if err := res.NoStatus().Unmarshal(&spw).End(); err != nil {
+		return nil, err
+	}
+	pools := make([]ScheduledPool, len(spw.Scheduled))
+	for i, p := range spw.Scheduled {
+		pools[i] = ScheduledPool{
+			ScheduleTime: p.ScheduleTime,
+			PoolID:       p.PoolID.String(),
+			PoolName:     p.PoolName,
+			Namespace:    p.Namespace,
+		}
+	}
+	return pools, nil
+}
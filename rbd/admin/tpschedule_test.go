@@ -0,0 +1,155 @@
+//go:build !nautilus && ceph_preview
+
+package admin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+var tpsList1 = `
+{
+    "2": {
+        "name": "rbd/",
+        "schedule": [
+            {
+                "interval": "1d",
+                "start_time": null
+            }
+        ]
+    },
+    "2/ns1": {
+        "name": "rbd/ns1/",
+        "schedule": [
+            {
+                "interval": "6h",
+                "start_time": "2021-03-02T14:00:00"
+            }
+        ]
+    }
+}
+`
+
+var tpsStatus1 = `
+{
+    "scheduled": [
+        {
+            "namespace": "",
+            "pool_id": "2",
+            "pool_name": "rbd",
+            "schedule_time": "2021-03-03 00:00:00"
+        },
+        {
+            "namespace": "ns1",
+            "pool_id": "2",
+            "pool_name": "rbd",
+            "schedule_time": "2021-03-03 02:00:00"
+        }
+    ]
+}
+`
+
+func TestParseTrashPurgeScheduleList(t *testing.T) {
+	t.Run("list1", func(t *testing.T) {
+		r := commands.NewResponse([]byte(tpsList1), "", nil)
+		l, err := parseTrashPurgeScheduleList(r)
+		assert.NoError(t, err)
+		if assert.Len(t, l, 2) {
+			s1, s2 := l[0], l[1]
+			if s1.Name != "rbd/" {
+				s1, s2 = s2, s1
+			}
+			assert.Equal(t, "2", s1.LevelSpecID)
+			if assert.Len(t, s1.Schedule, 1) {
+				assert.EqualValues(t, "1d", s1.Schedule[0].Interval)
+				assert.EqualValues(t, "", s1.Schedule[0].StartTime)
+			}
+			assert.Equal(t, "rbd/ns1/", s2.Name)
+			assert.Equal(t, "2/ns1", s2.LevelSpecID)
+			if assert.Len(t, s2.Schedule, 1) {
+				assert.EqualValues(t, "6h", s2.Schedule[0].Interval)
+				assert.EqualValues(t, "2021-03-02T14:00:00", s2.Schedule[0].StartTime)
+			}
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		r := commands.NewResponse([]byte("{}"), "", nil)
+		l, err := parseTrashPurgeScheduleList(r)
+		assert.NoError(t, err)
+		assert.Len(t, l, 0)
+	})
+	t.Run("error", func(t *testing.T) {
+		r := commands.NewResponse([]byte{}, "", errors.New("yikes"))
+		_, err := parseTrashPurgeScheduleList(r)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseTrashPurgeScheduleStatus(t *testing.T) {
+	t.Run("status1", func(t *testing.T) {
+		r := commands.NewResponse([]byte(tpsStatus1), "", nil)
+		s, err := parseTrashPurgeScheduleStatus(r)
+		assert.NoError(t, err)
+		if assert.Len(t, s, 2) {
+			assert.Equal(t, ScheduledPool{
+				ScheduleTime: "2021-03-03 00:00:00",
+				PoolID:       "2",
+				PoolName:     "rbd",
+			}, s[0])
+			assert.Equal(t, "ns1", s[1].Namespace)
+		}
+	})
+	t.Run("numericPoolID", func(t *testing.T) {
+		r := commands.NewResponse(
+			[]byte(`{"scheduled": [{"pool_id": 7, "pool_name": "p"}]}`), "", nil)
+		s, err := parseTrashPurgeScheduleStatus(r)
+		assert.NoError(t, err)
+		if assert.Len(t, s, 1) {
+			assert.Equal(t, "7", s[0].PoolID)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		r := commands.NewResponse([]byte(`{"scheduled": []}`), "", nil)
+		s, err := parseTrashPurgeScheduleStatus(r)
+		assert.NoError(t, err)
+		assert.Len(t, s, 0)
+	})
+	t.Run("error", func(t *testing.T) {
+		r := commands.NewResponse([]byte{}, "", errors.New("zrkk"))
+		_, err := parseTrashPurgeScheduleStatus(r)
+		assert.Error(t, err)
+	})
+}
+
+func TestTrashPurgeScheduleAddListRemove(t *testing.T) {
+	ensureDefaultPool(t)
+	ra := getAdmin(t)
+	scheduler := ra.TrashPurgeSchedule()
+	level := NewLevelSpec(defaultPoolName, "", "")
+
+	err := scheduler.Add(level, Interval("1d"), NoStartTime)
+	assert.NoError(t, err)
+	defer func() {
+		err := scheduler.Remove(level, Interval("1d"), NoStartTime)
+		assert.NoError(t, err)
+	}()
+
+	slist, err := scheduler.List(level)
+	assert.NoError(t, err)
+	if assert.Len(t, slist, 1) {
+		assert.Equal(t, "rbd/", slist[0].Name)
+		if assert.Len(t, slist[0].Schedule, 1) {
+			assert.Equal(t, Interval("1d"), slist[0].Schedule[0].Interval)
+		}
+	}
+
+	_, err = scheduler.Status(level)
+	assert.NoError(t, err)
+
+	err = scheduler.Add(level, Interval("1d"), StartTime("henry"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,535 @@
+//go:build ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#undef _GNU_SOURCE
+#include <errno.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/uio.h>
+#include <rbd/librbd.h>
+
+extern void aioCallback(void*, uintptr_t);
+
+// inline wrapper to cast uintptr_t to void*
+static inline int wrap_rbd_aio_create_completion(uintptr_t arg,
+	rbd_completion_t *c) {
+	return rbd_aio_create_completion(
+		(void*)arg, (rbd_callback_t)aioCallback, c);
+}
+
+// inline wrapper to cast void* to uintptr_t
+static inline uintptr_t wrap_rbd_aio_get_arg(rbd_completion_t c) {
+	return (uintptr_t)rbd_aio_get_arg(c);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/callbacks"
+	"github.com/ceph/go-ceph/internal/hooks"
+	"github.com/ceph/go-ceph/rados"
+)
+
+// aioCallbacks tracks the completions of the asynchronous requests in flight
+var aioCallbacks = callbacks.New()
+
+// ErrMismatch is returned by a compare-and-write request if the data of the
+// image did not match the compare buffer.
+var ErrMismatch = getError(-C.EILSEQ)
+
+// AioCompletion tracks an asynchronous I/O request on an image. The request
+// completes in the background, use Done or Wait to find out when it has
+// completed, and what the result was.
+//
+// The buffers passed to an asynchronous request must not be accessed until
+// the request has completed. Every AioCompletion must be released with
+// Release once it is no longer needed.
+type AioCompletion struct {
+	comp    C.rbd_completion_t
+	cbIndex uintptr
+	done    chan struct{}
+	tracker *hooks.Tracker
+	image   *Image
+
+	// mutex protects polled and released. Once the image uses
+	// SetImageNotification, librbd queues the completion for
+	// rbd_poll_io_events after the callback has run, and the completion must
+	// not be released before PollIOEvents returned it.
+	mutex    sync.Mutex
+	polled   bool
+	released bool
+
+	// C memory used by the request, freed on completion
+	cmem []unsafe.Pointer
+	// Go buffers that the data read into C memory is copied to
+	reads   [][]byte
+	readSrc []unsafe.Pointer
+
+	mismatchOff *C.uint64_t
+
+	ret         int64
+	err         error
+	mismatchPos uint64
+}
+
+func (image *Image) newAioCompletion(name string) (*AioCompletion, error) {
+	c := &AioCompletion{done: make(chan struct{}), image: image}
+	c.cbIndex = aioCallbacks.Add(c)
+	ret := C.wrap_rbd_aio_create_completion(C.uintptr_t(c.cbIndex), &c.comp)
+	if ret < 0 {
+		aioCallbacks.Remove(c.cbIndex)
+		return nil, getError(ret)
+	}
+	c.tracker = image.startOp(name)
+	return c, nil
+}
+
+// cbytes returns a copy of b in C memory that is freed when the request
+// completes.
+func (c *AioCompletion) cbytes(b []byte) unsafe.Pointer {
+	p := C.CBytes(b)
+	c.cmem = append(c.cmem, p)
+	return p
+}
+
+// cread returns C memory of the size of b that the data read by the request
+// is copied to b from on completion.
+func (c *AioCompletion) cread(b []byte) unsafe.Pointer {
+	p := C.malloc(C.size_t(len(b)))
+	c.cmem = append(c.cmem, p)
+	c.reads = append(c.reads, b)
+	c.readSrc = append(c.readSrc, p)
+	return p
+}
+
+func (c *AioCompletion) freeMemory() {
+	for _, p := range c.cmem {
+		C.free(p)
+	}
+	c.cmem = nil
+	c.readSrc = nil
+	c.reads = nil
+	if c.mismatchOff != nil {
+		C.free(unsafe.Pointer(c.mismatchOff))
+		c.mismatchOff = nil
+	}
+}
+
+// submitted checks the return code of the function that submitted the
+// request. The completion is discarded if the request was not submitted.
+func (c *AioCompletion) submitted(ret C.int) (*AioCompletion, error) {
+	if ret < 0 {
+		err := getError(ret)
+		c.tracker.Done(0, err)
+		C.rbd_aio_release(c.comp)
+		aioCallbacks.Remove(c.cbIndex)
+		c.freeMemory()
+		return nil, err
+	}
+	return c, nil
+}
+
+// complete is called by librbd, once, when the request has completed.
+func (c *AioCompletion) complete() {
+	c.ret = int64(C.rbd_aio_get_return_value(c.comp))
+	if c.ret < 0 {
+		c.err = getError(C.int(c.ret))
+	}
+	if c.mismatchOff != nil {
+		c.mismatchPos = uint64(*c.mismatchOff)
+	}
+	if c.err == nil {
+		remaining := int(c.ret)
+		for i, b := range c.reads {
+			n := min(len(b), remaining)
+			if n <= 0 {
+				break
+			}
+			copy(b[:n], unsafe.Slice((*byte)(c.readSrc[i]), n))
+			remaining -= n
+		}
+	}
+	c.freeMemory()
+	c.tracker.Done(max(c.ret, 0), c.err)
+	close(c.done)
+}
+
+//export aioCallback
+func aioCallback(_ unsafe.Pointer, index uintptr) {
+	v := aioCallbacks.Lookup(index)
+	c := v.(*AioCompletion)
+	c.complete()
+}
+
+// Done returns a channel that is closed once the request has completed.
+func (c *AioCompletion) Done() <-chan struct{} {
+	return c.done
+}
+
+// IsComplete returns true if the request has completed.
+func (c *AioCompletion) IsComplete() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait blocks until the request has completed and returns its result. For
+// reads the result is the number of bytes read.
+func (c *AioCompletion) Wait() (int64, error) {
+	<-c.done
+	return c.ret, c.err
+}
+
+// MismatchOffset returns the offset, within the image, of the first byte
+// that did not match the compare buffer of a failed compare-and-write
+// request.
+func (c *AioCompletion) MismatchOffset() uint64 {
+	<-c.done
+	return c.mismatchPos
+}
+
+// Release waits for the request to complete and releases the resources
+// held by the completion. If the image uses SetImageNotification, a
+// completion that has not been returned by PollIOEvents yet is released by
+// the PollIOEvents call that would have returned it.
+//
+// Implements:
+//
+//	void rbd_aio_release(rbd_completion_t c);
+func (c *AioCompletion) Release() {
+	<-c.done
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.released {
+		return
+	}
+	c.released = true
+	if c.image.notify.Load() && !c.polled {
+		return
+	}
+	c.release()
+}
+
+// release frees the completion. The caller must hold the mutex.
+func (c *AioCompletion) release() {
+	C.rbd_aio_release(c.comp)
+	c.comp = nil
+	aioCallbacks.Remove(c.cbIndex)
+}
+
+// AioRead starts an asynchronous read of len(data) bytes from the image,
+// starting at offset off, into data.
+//
+// Implements:
+//
+//	int rbd_aio_read2(rbd_image_t image, uint64_t off, size_t len, char *buf,
+//	                  rbd_completion_t c, int op_flags);
+func (image *Image) AioRead(data []byte, off uint64, flags rados.OpFlags) (*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	c, err := image.newAioCompletion("rbd_aio_read")
+	if err != nil {
+		return nil, err
+	}
+	buf := c.cread(data)
+	ret := C.rbd_aio_read2(
+		image.image,
+		C.uint64_t(off),
+		C.size_t(len(data)),
+		(*C.char)(buf),
+		c.comp,
+		C.int(flags))
+	return c.submitted(ret)
+}
+
+// AioWrite starts an asynchronous write of data to the image, starting at
+// offset off.
+//
+// Implements:
+//
+//	int rbd_aio_write2(rbd_image_t image, uint64_t off, size_t len,
+//	                   const char *buf, rbd_completion_t c, int op_flags);
+func (image *Image) AioWrite(data []byte, off uint64, flags rados.OpFlags) (*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	c, err := image.newAioCompletion("rbd_aio_write")
+	if err != nil {
+		return nil, err
+	}
+	buf := c.cbytes(data)
+	ret := C.rbd_aio_write2(
+		image.image,
+		C.uint64_t(off),
+		C.size_t(len(data)),
+		(*C.char)(buf),
+		c.comp,
+		C.int(flags))
+	return c.submitted(ret)
+}
+
+// newIovec returns an array of iovec structures in C memory that is freed
+// when the request completes.
+func (c *AioCompletion) newIovec(n int) []C.struct_iovec {
+	p := C.calloc(C.size_t(max(n, 1)), C.size_t(C.sizeof_struct_iovec))
+	c.cmem = append(c.cmem, p)
+	return unsafe.Slice((*C.struct_iovec)(p), n)
+}
+
+// AioReadv starts an asynchronous read from the image, starting at offset
+// off, into the buffers of iov. The buffers are filled in order, as if
+// they were one contiguous buffer.
+//
+// Implements:
+//
+//	int rbd_aio_readv(rbd_image_t image, const struct iovec *iov, int iovcnt,
+//	                  uint64_t off, rbd_completion_t c);
+func (image *Image) AioReadv(iov [][]byte, off uint64) (*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	c, err := image.newAioCompletion("rbd_aio_readv")
+	if err != nil {
+		return nil, err
+	}
+	civ := c.newIovec(len(iov))
+	for i, b := range iov {
+		civ[i].iov_base = c.cread(b)
+		civ[i].iov_len = C.size_t(len(b))
+	}
+	ret := C.rbd_aio_readv(
+		image.image,
+		unsafe.SliceData(civ),
+		C.int(len(iov)),
+		C.uint64_t(off),
+		c.comp)
+	return c.submitted(ret)
+}
+
+// AioWritev starts an asynchronous write of the buffers of iov to the
+// image, starting at offset off. The buffers are written in order, as if
+// they were one contiguous buffer.
+//
+// Implements:
+//
+//	int rbd_aio_writev(rbd_image_t image, const struct iovec *iov,
+//	                   int iovcnt, uint64_t off, rbd_completion_t c);
+func (image *Image) AioWritev(iov [][]byte, off uint64) (*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	c, err := image.newAioCompletion("rbd_aio_writev")
+	if err != nil {
+		return nil, err
+	}
+	civ := c.newIovec(len(iov))
+	for i, b := range iov {
+		civ[i].iov_base = c.cbytes(b)
+		civ[i].iov_len = C.size_t(len(b))
+	}
+	ret := C.rbd_aio_writev(
+		image.image,
+		unsafe.SliceData(civ),
+		C.int(len(iov)),
+		C.uint64_t(off),
+		c.comp)
+	return c.submitted(ret)
+}
+
+// AioDiscard starts an asynchronous discard of length bytes of the image,
+// starting at offset off.
+//
+// Implements:
+//
+//	int rbd_aio_discard(rbd_image_t image, uint64_t off, uint64_t len,
+//	                    rbd_completion_t c);
+func (image *Image) AioDiscard(off, length uint64) (*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	c, err := image.newAioCompletion("rbd_aio_discard")
+	if err != nil {
+		return nil, err
+	}
+	ret := C.rbd_aio_discard(
+		image.image,
+		C.uint64_t(off),
+		C.uint64_t(length),
+		c.comp)
+	return c.submitted(ret)
+}
+
+// AioWriteSame starts an asynchronous write of n bytes to the image,
+// starting at offset off, by repeatedly writing data. n must be a multiple
+// of the length of data.
+//
+// Implements:
+//
+//	int rbd_aio_writesame(rbd_image_t image, uint64_t off, size_t len,
+//	                      const char *buf, size_t data_len,
+//	                      rbd_completion_t c, int op_flags);
+func (image *Image) AioWriteSame(off, n uint64, data []byte, flags rados.OpFlags) (*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, getError(-C.EINVAL)
+	}
+	c, err := image.newAioCompletion("rbd_aio_writesame")
+	if err != nil {
+		return nil, err
+	}
+	buf := c.cbytes(data)
+	ret := C.rbd_aio_writesame(
+		image.image,
+		C.uint64_t(off),
+		C.size_t(n),
+		(*C.char)(buf),
+		C.size_t(len(data)),
+		c.comp,
+		C.int(flags))
+	return c.submitted(ret)
+}
+
+// AioCompareAndWrite starts an asynchronous compare-and-write request. If
+// the data of the image, starting at offset off, matches cmp then data is
+// written in its place, atomically. Otherwise the request fails with
+// ErrMismatch and MismatchOffset returns the offset of the first byte that
+// did not match. cmp and data must have the same length.
+//
+// Implements:
+//
+//	ssize_t rbd_aio_compare_and_write(rbd_image_t image, uint64_t off,
+//	                                  size_t len, const char *cmp_buf,
+//	                                  const char *buf, rbd_completion_t c,
+//	                                  uint64_t *mismatch_off, int op_flags);
+func (image *Image) AioCompareAndWrite(
+	off uint64, cmp, data []byte, flags rados.OpFlags) (*AioCompletion, error) {
+
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	if len(cmp) != len(data) {
+		return nil, getError(-C.EINVAL)
+	}
+	c, err := image.newAioCompletion("rbd_aio_compare_and_write")
+	if err != nil {
+		return nil, err
+	}
+	cbuf := c.cbytes(cmp)
+	buf := c.cbytes(data)
+	c.mismatchOff = (*C.uint64_t)(C.calloc(1, C.size_t(unsafe.Sizeof(C.uint64_t(0)))))
+	ret := C.rbd_aio_compare_and_write(
+		image.image,
+		C.uint64_t(off),
+		C.size_t(len(data)),
+		(*C.char)(cbuf),
+		(*C.char)(buf),
+		c.comp,
+		c.mismatchOff,
+		C.int(flags))
+	return c.submitted(C.int(ret))
+}
+
+// AioFlush starts an asynchronous flush of all cached writes to storage.
+//
+// Implements:
+//
+//	int rbd_aio_flush(rbd_image_t image, rbd_completion_t c);
+func (image *Image) AioFlush() (*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	c, err := image.newAioCompletion("rbd_aio_flush")
+	if err != nil {
+		return nil, err
+	}
+	return c.submitted(C.rbd_aio_flush(image.image, c.comp))
+}
+
+// ImageEventType is the type of file descriptor that is notified about
+// completed asynchronous requests.
+type ImageEventType int
+
+const (
+	// EventTypePipe notifies completions by writing to a pipe.
+	EventTypePipe = ImageEventType(C.EVENT_TYPE_PIPE)
+	// EventTypeEventFD notifies completions by signaling an eventfd.
+	EventTypeEventFD = ImageEventType(C.EVENT_TYPE_EVENTFD)
+)
+
+// SetImageNotification makes librbd notify the file descriptor fd when an
+// asynchronous request on the image completes. The completed requests can
+// then be collected with PollIOEvents. The completions are still signaled
+// through their Done channels. SetImageNotification should be called before
+// any asynchronous request is started on the image.
+//
+// Implements:
+//
+//	int rbd_set_image_notification(rbd_image_t image, int fd, int type);
+func (image *Image) SetImageNotification(fd int, eventType ImageEventType) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	t := image.startOp("rbd_set_image_notification")
+	ret := C.rbd_set_image_notification(image.image, C.int(fd), C.int(eventType))
+	if ret == 0 {
+		image.notify.Store(true)
+	}
+	return t.DoneErr(getError(ret))
+}
+
+// PollIOEvents returns up to maxEvents asynchronous requests on the image that
+// have completed since the last call. It requires the image to have been
+// set up using SetImageNotification. Every completion is returned once.
+// Completions that have already been released are not returned, but are
+// freed, so PollIOEvents has to be called until all requests have been
+// collected before the image is closed.
+//
+// Implements:
+//
+//	int rbd_poll_io_events(rbd_image_t image, rbd_completion_t *comps,
+//	                       int numcomp);
+func (image *Image) PollIOEvents(maxEvents int) ([]*AioCompletion, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	if maxEvents <= 0 {
+		return nil, nil
+	}
+	comps := make([]C.rbd_completion_t, maxEvents)
+	t := image.startOp("rbd_poll_io_events")
+	ret := C.rbd_poll_io_events(image.image, &comps[0], C.int(maxEvents))
+	if err := t.DoneErr(getErrorIfNegative(ret)); err != nil {
+		return nil, err
+	}
+	out := make([]*AioCompletion, 0, int(ret))
+	for _, comp := range comps[:ret] {
+		index := uintptr(C.wrap_rbd_aio_get_arg(comp))
+		c, ok := aioCallbacks.Lookup(index).(*AioCompletion)
+		if !ok {
+			continue
+		}
+		c.mutex.Lock()
+		c.polled = true
+		released := c.released
+		if released {
+			c.release()
+		}
+		c.mutex.Unlock()
+		if !released {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,222 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+func TestAio(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	wait := func(t *testing.T, c *AioCompletion, err error) int64 {
+		t.Helper()
+		require.NoError(t, err)
+		defer c.Release()
+		n, err := c.Wait()
+		require.NoError(t, err)
+		return n
+	}
+
+	t.Run("readWrite", func(t *testing.T) {
+		data := []byte("asynchronous data")
+		c, err := img.AioWrite(data, 4096, rados.OpFlagNone)
+		wait(t, c, err)
+
+		buf := make([]byte, len(data))
+		c, err = img.AioRead(buf, 4096, rados.OpFlagNone)
+		assert.Equal(t, int64(len(data)), wait(t, c, err))
+		assert.Equal(t, data, buf)
+	})
+
+	t.Run("manyInFlight", func(t *testing.T) {
+		comps := make([]*AioCompletion, 16)
+		for i := range comps {
+			data := bytes.Repeat([]byte{byte('a' + i)}, 512)
+			comps[i], err = img.AioWrite(data, uint64(i*512), rados.OpFlagNone)
+			require.NoError(t, err)
+		}
+		for _, c := range comps {
+			<-c.Done()
+			assert.True(t, c.IsComplete())
+			_, err := c.Wait()
+			assert.NoError(t, err)
+			c.Release()
+		}
+		buf := make([]byte, 512)
+		c, err := img.AioRead(buf, 15*512, rados.OpFlagNone)
+		wait(t, c, err)
+		assert.Equal(t, bytes.Repeat([]byte{'p'}, 512), buf)
+	})
+
+	t.Run("vectored", func(t *testing.T) {
+		c, err := img.AioWritev(
+			[][]byte{[]byte("one"), []byte("two"), []byte("three")}, 8192)
+		wait(t, c, err)
+
+		iov := [][]byte{make([]byte, 5), make([]byte, 6)}
+		c, err = img.AioReadv(iov, 8192)
+		assert.Equal(t, int64(11), wait(t, c, err))
+		assert.Equal(t, "onetw", string(iov[0]))
+		assert.Equal(t, "othree", string(iov[1]))
+	})
+
+	t.Run("writeSameAndDiscard", func(t *testing.T) {
+		c, err := img.AioWriteSame(16384, 4096, []byte("xy"), rados.OpFlagNone)
+		wait(t, c, err)
+		buf := make([]byte, 4096)
+		c, err = img.AioRead(buf, 16384, rados.OpFlagNone)
+		wait(t, c, err)
+		assert.Equal(t, bytes.Repeat([]byte("xy"), 2048), buf)
+
+		c, err = img.AioDiscard(16384, 4096)
+		wait(t, c, err)
+		c, err = img.AioRead(buf, 16384, rados.OpFlagNone)
+		wait(t, c, err)
+		assert.Equal(t, make([]byte, 4096), buf)
+
+		_, err = img.AioWriteSame(16384, 4096, nil, rados.OpFlagNone)
+		assert.Error(t, err)
+	})
+
+	t.Run("compareAndWrite", func(t *testing.T) {
+		c, err := img.AioWrite([]byte("abcdefgh"), 32768, rados.OpFlagNone)
+		wait(t, c, err)
+
+		c, err = img.AioCompareAndWrite(
+			32768, []byte("abcdefgh"), []byte("ABCDEFGH"), rados.OpFlagNone)
+		wait(t, c, err)
+
+		c, err = img.AioCompareAndWrite(
+			32768, []byte("ABCdefgh"), []byte("12345678"), rados.OpFlagNone)
+		require.NoError(t, err)
+		_, err = c.Wait()
+		assert.ErrorIs(t, err, ErrMismatch)
+		assert.Equal(t, uint64(32768+3), c.MismatchOffset())
+		c.Release()
+
+		_, err = img.AioCompareAndWrite(
+			32768, []byte("abc"), []byte("ABCD"), rados.OpFlagNone)
+		assert.Error(t, err)
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		c, err := img.AioFlush()
+		wait(t, c, err)
+	})
+
+	t.Run("pollIOEvents", func(t *testing.T) {
+		img2, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img2.Close()) }()
+
+		fd, err := unix.Eventfd(0, unix.EFD_NONBLOCK)
+		require.NoError(t, err)
+		defer unix.Close(fd)
+		err = img2.SetImageNotification(fd, EventTypeEventFD)
+		require.NoError(t, err)
+
+		c, err := img2.AioWrite([]byte("polled"), 0, rados.OpFlagNone)
+		require.NoError(t, err)
+		defer c.Release()
+		<-c.Done()
+
+		var comps []*AioCompletion
+		for i := 0; i < 50 && len(comps) == 0; i++ {
+			comps, err = img2.PollIOEvents(8)
+			require.NoError(t, err)
+			if len(comps) == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		if assert.Len(t, comps, 1) {
+			assert.Same(t, c, comps[0])
+		}
+		comps, err = img2.PollIOEvents(8)
+		assert.NoError(t, err)
+		assert.Len(t, comps, 0)
+
+		comps, err = img2.PollIOEvents(0)
+		assert.NoError(t, err)
+		assert.Len(t, comps, 0)
+	})
+
+	t.Run("releaseBeforePoll", func(t *testing.T) {
+		img2, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img2.Close()) }()
+
+		fd, err := unix.Eventfd(0, unix.EFD_NONBLOCK)
+		require.NoError(t, err)
+		defer unix.Close(fd)
+		err = img2.SetImageNotification(fd, EventTypeEventFD)
+		require.NoError(t, err)
+
+		released, err := img2.AioWrite([]byte("released"), 0, rados.OpFlagNone)
+		require.NoError(t, err)
+		kept, err := img2.AioWrite([]byte("kept"), 4096, rados.OpFlagNone)
+		require.NoError(t, err)
+		defer kept.Release()
+		released.Release()
+		// the completion is freed once it has been polled
+		assert.NotNil(t, aioCallbacks.Lookup(released.cbIndex))
+		<-kept.Done()
+
+		var comps []*AioCompletion
+		for i := 0; i < 50; i++ {
+			polled, err := img2.PollIOEvents(8)
+			require.NoError(t, err)
+			comps = append(comps, polled...)
+			if len(comps) > 0 && aioCallbacks.Lookup(released.cbIndex) == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if assert.Len(t, comps, 1) {
+			assert.Same(t, kept, comps[0])
+		}
+		assert.Nil(t, aioCallbacks.Lookup(released.cbIndex))
+		assert.Nil(t, released.comp)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		closed := GetImage(ioctx, name)
+		_, err := closed.AioRead(make([]byte, 1), 0, rados.OpFlagNone)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		_, err = closed.AioWrite([]byte("x"), 0, rados.OpFlagNone)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		_, err = closed.AioFlush()
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		err = closed.SetImageNotification(0, EventTypePipe)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		_, err = closed.PollIOEvents(1)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
@@ -25,19 +25,13 @@ static inline int rbd_clone4_dlsym(void *fn, rados_ioctx_t p_ioctx,
 import "C"
 
 import (
-	"fmt"
-	"sync"
 	"unsafe"
 
 	"github.com/ceph/go-ceph/internal/dlsym"
 	"github.com/ceph/go-ceph/rados"
 )
 
-var (
-	rbdClone4Once sync.Once
-	rbdClone4     unsafe.Pointer
-	rbdClone4Err  error
-)
+var rbdClone4 = dlsym.NewSymbol("rbd_clone4")
 
 // CloneImageByID creates a clone of the image from a snapshot with the given
 // ID in the provided io-context with the given name and image options.
@@ -54,12 +48,9 @@ func CloneImageByID(ioctx *rados.IOContext, parentName string, snapID uint64,
 		return getError(C.EINVAL)
 	}
 
-	rbdClone4Once.Do(func() {
-		rbdClone4, rbdClone4Err = dlsym.LookupSymbol("rbd_clone4")
-	})
-
-	if rbdClone4Err != nil {
-		return fmt.Errorf("%w: %w", ErrNotImplemented, rbdClone4Err)
+	clone4, err := rbdClone4.Pointer()
+	if err != nil {
+		return err
 	}
 
 	cParentName := C.CString(parentName)
@@ -69,14 +60,16 @@ func CloneImageByID(ioctx *rados.IOContext, parentName string, snapID uint64,
 
 	// call rbd_clone4_dlsym with the function pointer to rbd_clone4 as 1st
 	// argument
+	t := startOp(destctx, "rbd_clone4", name)
 	ret := C.rbd_clone4_dlsym(
-		rbdClone4,
+		clone4,
 		cephIoctx(ioctx),
 		cParentName,
 		C.uint64_t(snapID),
 		cephIoctx(destctx),
 		cCloneName,
 		C.rbd_image_options_t(rio.options))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -0,0 +1,126 @@
+//go:build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <rados/librados.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"github.com/ceph/go-ceph/internal/retry"
+	"github.com/ceph/go-ceph/rados"
+)
+
+// ConfigSource indicates where the value of a configuration option comes
+// from.
+type ConfigSource int
+
+const (
+	// ConfigSourceConfig means the value comes from the configuration of
+	// the client.
+	ConfigSourceConfig = ConfigSource(C.RBD_CONFIG_SOURCE_CONFIG)
+	// ConfigSourcePool means the value is overridden in the pool metadata.
+	ConfigSourcePool = ConfigSource(C.RBD_CONFIG_SOURCE_POOL)
+	// ConfigSourceImage means the value is overridden in the image
+	// metadata.
+	ConfigSourceImage = ConfigSource(C.RBD_CONFIG_SOURCE_IMAGE)
+)
+
+// String returns the name of the source, as used by the rbd command.
+func (s ConfigSource) String() string {
+	switch s {
+	case ConfigSourceConfig:
+		return "config"
+	case ConfigSourcePool:
+		return "pool"
+	case ConfigSourceImage:
+		return "image"
+	}
+	return "unknown"
+}
+
+// ConfigOption is the effective value of an rbd configuration option.
+type ConfigOption struct {
+	Name   string
+	Value  string
+	Source ConfigSource
+}
+
+func convertConfigOptions(cOptions []C.rbd_config_option_t) []ConfigOption {
+	options := make([]ConfigOption, len(cOptions))
+	for i, o := range cOptions {
+		options[i] = ConfigOption{
+			Name:   C.GoString(o.name),
+			Value:  C.GoString(o.value),
+			Source: ConfigSource(o.source),
+		}
+	}
+	return options
+}
+
+// ListConfig returns the effective values of the rbd configuration options
+// of the image, along with their source.
+//
+// Implements:
+//
+//	int rbd_config_image_list(rbd_image_t image,
+//	                          rbd_config_option_t *options,
+//	                          int *max_options);
+func (image *Image) ListConfig() ([]ConfigOption, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+
+	var (
+		err      error
+		count    C.int
+		cOptions []C.rbd_config_option_t
+	)
+	retry.WithSizes(256, 65536, func(size int) retry.Hint {
+		count = C.int(size)
+		cOptions = make([]C.rbd_config_option_t, count)
+		t := image.startOp("rbd_config_image_list")
+		ret := C.rbd_config_image_list(image.image, &cOptions[0], &count)
+		err = t.DoneErr(getErrorIfNegative(ret))
+		return retry.Size(int(count)).If(err == errRange)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer C.rbd_config_image_list_cleanup(&cOptions[0], count)
+	return convertConfigOptions(cOptions[:count]), nil
+}
+
+// ListPoolConfig returns the effective values of the rbd configuration
+// options of the pool, along with their source.
+//
+// Implements:
+//
+//	int rbd_config_pool_list(rados_ioctx_t io_ctx,
+//	                         rbd_config_option_t *options,
+//	                         int *max_options);
+func ListPoolConfig(ioctx *rados.IOContext) ([]ConfigOption, error) {
+	if ioctx == nil {
+		return nil, ErrNoIOContext
+	}
+
+	var (
+		err      error
+		count    C.int
+		cOptions []C.rbd_config_option_t
+	)
+	retry.WithSizes(256, 65536, func(size int) retry.Hint {
+		count = C.int(size)
+		cOptions = make([]C.rbd_config_option_t, count)
+		t := startOp(ioctx, "rbd_config_pool_list", "")
+		ret := C.rbd_config_pool_list(cephIoctx(ioctx), &cOptions[0], &count)
+		err = t.DoneErr(getErrorIfNegative(ret))
+		return retry.Size(int(count)).If(err == errRange)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer C.rbd_config_pool_list_cleanup(&cOptions[0], count)
+	return convertConfigOptions(cOptions[:count]), nil
+}
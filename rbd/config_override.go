@@ -0,0 +1,292 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// configMetadataPrefix is the prefix of metadata keys that override
+// configuration options, for images as well as for pools.
+const configMetadataPrefix = "conf_"
+
+// ErrInvalidConfigOverride is returned if a configuration override is
+// rejected by validation.
+var ErrInvalidConfigOverride = errors.New("invalid rbd configuration override")
+
+// ImageQoS contains the QoS settings of an image or a pool. A nil field
+// means the setting is not changed by SetQoS or SetPoolQoS. Zero limits
+// mean no limit.
+type ImageQoS struct {
+	IOPSLimit      *uint64
+	IOPSBurst      *uint64
+	BPSLimit       *uint64
+	BPSBurst       *uint64
+	ReadIOPSLimit  *uint64
+	ReadIOPSBurst  *uint64
+	WriteIOPSLimit *uint64
+	WriteIOPSBurst *uint64
+	ReadBPSLimit   *uint64
+	ReadBPSBurst   *uint64
+	WriteBPSLimit  *uint64
+	WriteBPSBurst  *uint64
+}
+
+// qosOptions are the options of the fields of ImageQoS, in the order of
+// ImageQoS.fields. Every limit is followed by its burst.
+var qosOptions = []string{
+	"rbd_qos_iops_limit", "rbd_qos_iops_burst",
+	"rbd_qos_bps_limit", "rbd_qos_bps_burst",
+	"rbd_qos_read_iops_limit", "rbd_qos_read_iops_burst",
+	"rbd_qos_write_iops_limit", "rbd_qos_write_iops_burst",
+	"rbd_qos_read_bps_limit", "rbd_qos_read_bps_burst",
+	"rbd_qos_write_bps_limit", "rbd_qos_write_bps_burst",
+}
+
+func (q *ImageQoS) fields() []**uint64 {
+	return []**uint64{
+		&q.IOPSLimit, &q.IOPSBurst,
+		&q.BPSLimit, &q.BPSBurst,
+		&q.ReadIOPSLimit, &q.ReadIOPSBurst,
+		&q.WriteIOPSLimit, &q.WriteIOPSBurst,
+		&q.ReadBPSLimit, &q.ReadBPSBurst,
+		&q.WriteBPSLimit, &q.WriteBPSBurst,
+	}
+}
+
+// isQoSOption returns true if name is one of the options of ImageQoS.
+func isQoSOption(name string) bool {
+	for _, o := range qosOptions {
+		if name == o {
+			return true
+		}
+	}
+	return false
+}
+
+// validateConfigOverride checks that name is one of the known options and
+// that value fits the type of the option. QoS options have to be unsigned
+// integers. Options whose current value is a boolean only accept booleans.
+func validateConfigOverride(known []ConfigOption, name, value string) error {
+	if !strings.HasPrefix(name, "rbd_") {
+		return fmt.Errorf("%w: %q is not an rbd option",
+			ErrInvalidConfigOverride, name)
+	}
+	var current *ConfigOption
+	for i := range known {
+		if known[i].Name == name {
+			current = &known[i]
+			break
+		}
+	}
+	if current == nil {
+		return fmt.Errorf("%w: unknown option %q",
+			ErrInvalidConfigOverride, name)
+	}
+	if isQoSOption(name) {
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return fmt.Errorf("%w: %s requires an unsigned integer, got %q",
+				ErrInvalidConfigOverride, name, value)
+		}
+	}
+	if current.Value == "true" || current.Value == "false" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%w: %s requires a boolean, got %q",
+				ErrInvalidConfigOverride, name, value)
+		}
+	}
+	return nil
+}
+
+// validateQoS checks that no burst is below its limit, which librbd
+// rejects.
+func validateQoS(q *ImageQoS, current []ConfigOption) error {
+	value := func(name string, v *uint64) uint64 {
+		if v != nil {
+			return *v
+		}
+		for _, o := range current {
+			if o.Name == name {
+				n, _ := strconv.ParseUint(o.Value, 10, 64)
+				return n
+			}
+		}
+		return 0
+	}
+	fields := q.fields()
+	for i := 0; i < len(qosOptions); i += 2 {
+		limit := value(qosOptions[i], *fields[i])
+		burst := value(qosOptions[i+1], *fields[i+1])
+		if burst != 0 && burst < limit {
+			return fmt.Errorf("%w: %s (%d) is below %s (%d)",
+				ErrInvalidConfigOverride,
+				qosOptions[i+1], burst, qosOptions[i], limit)
+		}
+	}
+	return nil
+}
+
+// qosOverrides returns the options and values for the fields of q that are
+// set.
+func qosOverrides(q *ImageQoS) map[string]string {
+	overrides := map[string]string{}
+	for i, field := range q.fields() {
+		if *field != nil {
+			overrides[qosOptions[i]] = strconv.FormatUint(**field, 10)
+		}
+	}
+	return overrides
+}
+
+// qosFromConfig returns the effective QoS settings from a list of options.
+func qosFromConfig(options []ConfigOption) (*ImageQoS, error) {
+	q := &ImageQoS{}
+	fields := q.fields()
+	for _, o := range options {
+		for i, name := range qosOptions {
+			if o.Name != name {
+				continue
+			}
+			v, err := strconv.ParseUint(o.Value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			*fields[i] = &v
+		}
+	}
+	return q, nil
+}
+
+// SetConfigOverride overrides the configuration option name for the image.
+// The option must be an rbd option known to librbd, and the value must fit
+// its type, otherwise ErrInvalidConfigOverride is returned.
+func (image *Image) SetConfigOverride(name, value string) error {
+	known, err := image.ListConfig()
+	if err != nil {
+		return err
+	}
+	if err := validateConfigOverride(known, name, value); err != nil {
+		return err
+	}
+	return image.SetMetadata(configMetadataPrefix+name, value)
+}
+
+// RemoveConfigOverride removes the override of the configuration option
+// name from the image. Removing an option that is not overridden is not an
+// error.
+func (image *Image) RemoveConfigOverride(name string) error {
+	err := image.RemoveMetadata(configMetadataPrefix + name)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// SetPoolConfigOverride overrides the configuration option name for all
+// images in the pool that do not override it themselves. The option is
+// validated like by Image.SetConfigOverride.
+func SetPoolConfigOverride(ioctx *rados.IOContext, name, value string) error {
+	known, err := ListPoolConfig(ioctx)
+	if err != nil {
+		return err
+	}
+	if err := validateConfigOverride(known, name, value); err != nil {
+		return err
+	}
+	return SetPoolMetadata(ioctx, configMetadataPrefix+name, value)
+}
+
+// RemovePoolConfigOverride removes the override of the configuration option
+// name from the pool. Removing an option that is not overridden is not an
+// error.
+func RemovePoolConfigOverride(ioctx *rados.IOContext, name string) error {
+	err := RemovePoolMetadata(ioctx, configMetadataPrefix+name)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// GetQoS returns the effective QoS settings of the image. Fields are only
+// nil if the version of librbd does not know the option. Use ListConfig to
+// find out where the values come from.
+func (image *Image) GetQoS() (*ImageQoS, error) {
+	options, err := image.ListConfig()
+	if err != nil {
+		return nil, err
+	}
+	return qosFromConfig(options)
+}
+
+// SetQoS overrides the QoS settings of the image for all fields of q that
+// are set. A burst below the effective limit is rejected with
+// ErrInvalidConfigOverride.
+func (image *Image) SetQoS(q ImageQoS) error {
+	current, err := image.ListConfig()
+	if err != nil {
+		return err
+	}
+	if err := validateQoS(&q, current); err != nil {
+		return err
+	}
+	for name, value := range qosOverrides(&q) {
+		if err := image.SetMetadata(configMetadataPrefix+name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClearQoS removes all QoS overrides from the image.
+func (image *Image) ClearQoS() error {
+	for _, name := range qosOptions {
+		if err := image.RemoveConfigOverride(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPoolQoS returns the effective QoS settings of the pool. Fields are only
+// nil if the version of librbd does not know the option.
+func GetPoolQoS(ioctx *rados.IOContext) (*ImageQoS, error) {
+	options, err := ListPoolConfig(ioctx)
+	if err != nil {
+		return nil, err
+	}
+	return qosFromConfig(options)
+}
+
+// SetPoolQoS overrides the QoS settings of the pool for all fields of q that
+// are set. The settings apply to all images of the pool that do not override
+// them.
+func SetPoolQoS(ioctx *rados.IOContext, q ImageQoS) error {
+	current, err := ListPoolConfig(ioctx)
+	if err != nil {
+		return err
+	}
+	if err := validateQoS(&q, current); err != nil {
+		return err
+	}
+	for name, value := range qosOverrides(&q) {
+		if err := SetPoolMetadata(ioctx, configMetadataPrefix+name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClearPoolQoS removes all QoS overrides from the pool.
+func ClearPoolQoS(ioctx *rados.IOContext) error {
+	for _, name := range qosOptions {
+		if err := RemovePoolConfigOverride(ioctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
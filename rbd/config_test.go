@@ -0,0 +1,179 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigOverride(t *testing.T) {
+	known := []ConfigOption{
+		{Name: "rbd_qos_iops_limit", Value: "0"},
+		{Name: "rbd_cache", Value: "true"},
+		{Name: "rbd_default_features", Value: "61"},
+	}
+	assert.NoError(t, validateConfigOverride(known, "rbd_qos_iops_limit", "100"))
+	assert.NoError(t, validateConfigOverride(known, "rbd_cache", "false"))
+	assert.NoError(t, validateConfigOverride(known, "rbd_default_features", "layering"))
+
+	for _, c := range [][2]string{
+		{"rbd_qos_iops_limit", "-1"},
+		{"rbd_qos_iops_limit", "lots"},
+		{"rbd_cache", "maybe"},
+		{"rbd_no_such_option", "1"},
+		{"osd_max_backfills", "1"},
+	} {
+		err := validateConfigOverride(known, c[0], c[1])
+		assert.ErrorIs(t, err, ErrInvalidConfigOverride, c[0]+"="+c[1])
+	}
+}
+
+func TestValidateQoS(t *testing.T) {
+	limit, burst := uint64(100), uint64(50)
+	current := []ConfigOption{{Name: "rbd_qos_bps_limit", Value: "1000"}}
+	assert.NoError(t, validateQoS(&ImageQoS{IOPSLimit: &limit}, current))
+	assert.NoError(t, validateQoS(&ImageQoS{IOPSBurst: &limit}, current))
+	err := validateQoS(&ImageQoS{IOPSLimit: &limit, IOPSBurst: &burst}, current)
+	assert.ErrorIs(t, err, ErrInvalidConfigOverride)
+	// the effective limit counts if the limit is not set
+	err = validateQoS(&ImageQoS{BPSBurst: &burst}, current)
+	assert.ErrorIs(t, err, ErrInvalidConfigOverride)
+}
+
+func findConfigOption(options []ConfigOption, name string) *ConfigOption {
+	for i := range options {
+		if options[i].Name == name {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+func TestConfigOverrides(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	t.Run("list", func(t *testing.T) {
+		options, err := img.ListConfig()
+		require.NoError(t, err)
+		o := findConfigOption(options, "rbd_qos_iops_limit")
+		if assert.NotNil(t, o) {
+			assert.Equal(t, ConfigSourceConfig, o.Source)
+		}
+		options, err = ListPoolConfig(ioctx)
+		require.NoError(t, err)
+		assert.NotNil(t, findConfigOption(options, "rbd_qos_iops_limit"))
+	})
+
+	t.Run("sources", func(t *testing.T) {
+		err := SetPoolConfigOverride(ioctx, "rbd_qos_iops_limit", "1000")
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, RemovePoolConfigOverride(ioctx, "rbd_qos_iops_limit"))
+		}()
+		options, err := ListPoolConfig(ioctx)
+		require.NoError(t, err)
+		assert.Equal(t,
+			&ConfigOption{"rbd_qos_iops_limit", "1000", ConfigSourcePool},
+			findConfigOption(options, "rbd_qos_iops_limit"))
+
+		err = img.SetConfigOverride("rbd_qos_iops_limit", "500")
+		require.NoError(t, err)
+		options, err = img.ListConfig()
+		require.NoError(t, err)
+		assert.Equal(t,
+			&ConfigOption{"rbd_qos_iops_limit", "500", ConfigSourceImage},
+			findConfigOption(options, "rbd_qos_iops_limit"))
+
+		require.NoError(t, img.RemoveConfigOverride("rbd_qos_iops_limit"))
+		options, err = img.ListConfig()
+		require.NoError(t, err)
+		assert.Equal(t,
+			&ConfigOption{"rbd_qos_iops_limit", "1000", ConfigSourcePool},
+			findConfigOption(options, "rbd_qos_iops_limit"))
+
+		// removing again is fine
+		assert.NoError(t, img.RemoveConfigOverride("rbd_qos_iops_limit"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := img.SetConfigOverride("rbd_qos_iops_limit", "fast")
+		assert.ErrorIs(t, err, ErrInvalidConfigOverride)
+		err = SetPoolConfigOverride(ioctx, "rbd_no_such_option", "1")
+		assert.ErrorIs(t, err, ErrInvalidConfigOverride)
+	})
+
+	t.Run("qos", func(t *testing.T) {
+		iops, bps := uint64(200), uint64(1<<20)
+		err := img.SetQoS(ImageQoS{IOPSLimit: &iops, WriteBPSLimit: &bps})
+		require.NoError(t, err)
+		q, err := img.GetQoS()
+		require.NoError(t, err)
+		if assert.NotNil(t, q.IOPSLimit) && assert.NotNil(t, q.WriteBPSLimit) {
+			assert.Equal(t, iops, *q.IOPSLimit)
+			assert.Equal(t, bps, *q.WriteBPSLimit)
+		}
+		if assert.NotNil(t, q.BPSLimit) {
+			assert.Equal(t, uint64(0), *q.BPSLimit)
+		}
+
+		low := uint64(10)
+		err = img.SetQoS(ImageQoS{IOPSBurst: &low})
+		assert.ErrorIs(t, err, ErrInvalidConfigOverride)
+
+		require.NoError(t, img.ClearQoS())
+		q, err = img.GetQoS()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), *q.IOPSLimit)
+	})
+
+	t.Run("poolQoS", func(t *testing.T) {
+		iops := uint64(300)
+		err := SetPoolQoS(ioctx, ImageQoS{ReadIOPSLimit: &iops})
+		require.NoError(t, err)
+		q, err := GetPoolQoS(ioctx)
+		require.NoError(t, err)
+		assert.Equal(t, iops, *q.ReadIOPSLimit)
+		// the pool setting applies to the image
+		img2, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img2.Close()) }()
+		q, err = img2.GetQoS()
+		require.NoError(t, err)
+		assert.Equal(t, iops, *q.ReadIOPSLimit)
+
+		require.NoError(t, ClearPoolQoS(ioctx))
+		q, err = GetPoolQoS(ioctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), *q.ReadIOPSLimit)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		closed := GetImage(ioctx, name)
+		_, err := closed.ListConfig()
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		_, err = ListPoolConfig(nil)
+		assert.ErrorIs(t, err, ErrNoIOContext)
+	})
+}
@@ -108,6 +108,7 @@ func (image *Image) DiffIterate(config DiffIterateConfig) error {
 	cbIndex := diffIterateCallbacks.Add(config)
 	defer diffIterateCallbacks.Remove(cbIndex)
 
+	t := image.startOp("rbd_diff_iterate2")
 	ret := C.wrap_rbd_diff_iterate2(
 		image.image,
 		cSnapName,
@@ -116,6 +117,7 @@ func (image *Image) DiffIterate(config DiffIterateConfig) error {
 		C.uint8_t(config.IncludeParent),
 		C.uint8_t(config.WholeObject),
 		C.uintptr_t(cbIndex))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -32,19 +32,13 @@ static inline int rbd_diff_iterate3_dlsym(void *fn, rbd_image_t image,
 import "C"
 
 import (
-	"fmt"
-	"sync"
-	"unsafe"
-
 	"github.com/ceph/go-ceph/internal/callbacks"
 	"github.com/ceph/go-ceph/internal/dlsym"
 )
 
 var (
 	diffIterateByIDCallbacks = callbacks.New()
-	diffIterateByIDOnce      sync.Once
-	diffIterateById          unsafe.Pointer
-	diffIterateByIdErr       error
+	rbdDiffIterate3          = dlsym.NewSymbol("rbd_diff_iterate3")
 )
 
 // DiffIterateByIDConfig is used to define the parameters of a DiffIterateByID call.
@@ -85,12 +79,9 @@ func (image *Image) DiffIterateByID(config DiffIterateByIDConfig) error {
 		return getError(-C.EINVAL)
 	}
 
-	diffIterateByIDOnce.Do(func() {
-		diffIterateById, diffIterateByIdErr = dlsym.LookupSymbol("rbd_diff_iterate3")
-	})
-
-	if diffIterateByIdErr != nil {
-		return fmt.Errorf("%w: %w", ErrNotImplemented, diffIterateByIdErr)
+	diffIterate3, err := rbdDiffIterate3.Pointer()
+	if err != nil {
+		return err
 	}
 
 	cbIndex := diffIterateByIDCallbacks.Add(config)
@@ -104,14 +95,16 @@ func (image *Image) DiffIterateByID(config DiffIterateByIDConfig) error {
 		flags |= C.RBD_DIFF_ITERATE_FLAG_WHOLE_OBJECT
 	}
 
+	t := image.startOp("rbd_diff_iterate3")
 	ret := C.rbd_diff_iterate3_dlsym(
-		diffIterateById,
+		diffIterate3,
 		image.image,
 		C.uint64_t(config.FromSnapID),
 		C.uint64_t(config.Offset),
 		C.uint64_t(config.Length),
 		flags,
 		C.uintptr_t(cbIndex))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -0,0 +1,231 @@
+//go:build ceph_preview
+
+package rbd
+
+/*
+#include <errno.h>
+*/
+import "C"
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+const (
+	// defaultDiffWorkers is the number of concurrent diffs used by
+	// ParallelDiffIterate if no number is configured.
+	defaultDiffWorkers = 4
+	// defaultDiffRangeObjects is the number of objects in a range if no
+	// range size is configured.
+	defaultDiffRangeObjects = 256
+)
+
+// DiffExtent is an extent of an image reported by ParallelDiffIterate.
+type DiffExtent struct {
+	Offset uint64
+	Length uint64
+	// Exists is false if the extent is known to be zeros.
+	Exists bool
+}
+
+// DiffProgressCallback is called by ParallelDiffIterate whenever a range of
+// the image has been processed, with the number of bytes processed so far
+// and the total number of bytes.
+type DiffProgressCallback func(done, total uint64)
+
+// ParallelDiffIterateConfig is used to define the parameters of a
+// ParallelDiffIterate call. Offset and Length should always be specified.
+// The other values are optional.
+type ParallelDiffIterateConfig struct {
+	SnapName      string
+	Offset        uint64
+	Length        uint64
+	IncludeParent DiffIncludeParent
+	WholeObject   DiffWholeObject
+	// Workers is the number of ranges that are processed concurrently.
+	// Defaults to 4.
+	Workers int
+	// RangeSize is the size of the ranges the image is split into. It is
+	// rounded up to a multiple of the size of an object set. Defaults to
+	// 256 objects.
+	RangeSize uint64
+	// Progress is called after every range, if set. Calls are not made
+	// concurrently.
+	Progress DiffProgressCallback
+}
+
+type diffRange struct {
+	offset uint64
+	length uint64
+}
+
+// splitDiffRanges splits the given range of the image into ranges that are
+// aligned to multiples of rangeSize.
+func splitDiffRanges(offset, length, rangeSize uint64) []diffRange {
+	var ranges []diffRange
+	for end := offset + length; offset < end; {
+		next := min((offset/rangeSize+1)*rangeSize, end)
+		ranges = append(ranges, diffRange{offset, next - offset})
+		offset = next
+	}
+	return ranges
+}
+
+// coalesceExtents sorts extents and merges adjacent or overlapping extents
+// that have the same Exists value.
+func coalesceExtents(extents []DiffExtent) []DiffExtent {
+	sort.Slice(extents, func(i, j int) bool {
+		return extents[i].Offset < extents[j].Offset
+	})
+	merged := extents[:0]
+	for _, e := range extents {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			end := last.Offset + last.Length
+			if last.Exists == e.Exists && e.Offset <= end {
+				last.Length = max(end, e.Offset+e.Length) - last.Offset
+				continue
+			}
+		}
+		merged = append(merged, e)
+	}
+	return merged
+}
+
+// diffRangeSize returns the configured range size rounded up to a multiple
+// of the object set size of the image.
+func (image *Image) diffRangeSize(rangeSize uint64) (uint64, error) {
+	info, err := image.Stat()
+	if err != nil {
+		return 0, err
+	}
+	stripeCount, err := image.GetStripeCount()
+	if err != nil {
+		return 0, err
+	}
+	setSize := info.Obj_size * max(stripeCount, 1)
+	if rangeSize == 0 {
+		rangeSize = info.Obj_size * defaultDiffRangeObjects
+	}
+	return (rangeSize + setSize - 1) / setSize * setSize, nil
+}
+
+type diffRangeResult struct {
+	index   int
+	extents []DiffExtent
+	err     error
+}
+
+// ParallelDiffIterate returns the changed extents of an image, like
+// DiffIterate, but splits the range into object aligned ranges that are
+// diffed concurrently. The extents are returned sorted by offset, with
+// adjacent extents coalesced.
+//
+// The diff stops early, returning the error of the context, if ctx is
+// canceled.
+func (image *Image) ParallelDiffIterate(
+	ctx context.Context, config ParallelDiffIterateConfig) ([]DiffExtent, error) {
+
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	rangeSize, err := image.diffRangeSize(config.RangeSize)
+	if err != nil {
+		return nil, err
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = defaultDiffWorkers
+	}
+	ranges := splitDiffRanges(config.Offset, config.Length, rangeSize)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan int)
+	results := make(chan diffRangeResult)
+	wg := sync.WaitGroup{}
+	for i := 0; i < min(workers, len(ranges)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				extents, err := image.diffRange(ctx, config, ranges[index])
+				results <- diffRangeResult{index, extents, err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range ranges {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	perRange := make([][]DiffExtent, len(ranges))
+	done := uint64(0)
+	for r := range results {
+		if r.err != nil {
+			if err == nil {
+				err = r.err
+			}
+			cancel()
+			continue
+		}
+		perRange[r.index] = r.extents
+		done += ranges[r.index].length
+		if config.Progress != nil && err == nil {
+			config.Progress(done, config.Length)
+		}
+	}
+	if ctxErr := ctx.Err(); err == nil && ctxErr != nil {
+		// only a cancellation by the caller is left at this point
+		err = ctxErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var extents []DiffExtent
+	for _, e := range perRange {
+		extents = append(extents, e...)
+	}
+	return coalesceExtents(extents), nil
+}
+
+// diffRange runs a diff over a single range.
+func (image *Image) diffRange(ctx context.Context,
+	config ParallelDiffIterateConfig, r diffRange) ([]DiffExtent, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var extents []DiffExtent
+	err := image.DiffIterate(DiffIterateConfig{
+		SnapName:      config.SnapName,
+		Offset:        r.offset,
+		Length:        r.length,
+		IncludeParent: config.IncludeParent,
+		WholeObject:   config.WholeObject,
+		Callback: func(offset, length uint64, exists int, _ interface{}) int {
+			if ctx.Err() != nil {
+				return int(-C.ECANCELED)
+			}
+			extents = append(extents, DiffExtent{offset, length, exists != 0})
+			return 0
+		},
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return extents, err
+}
@@ -0,0 +1,133 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDiffRanges(t *testing.T) {
+	assert.Equal(t,
+		[]diffRange{{100, 924}, {1024, 1024}, {2048, 52}},
+		splitDiffRanges(100, 2000, 1024))
+	assert.Equal(t, []diffRange{{0, 1024}}, splitDiffRanges(0, 1024, 1024))
+	assert.Len(t, splitDiffRanges(0, 0, 1024), 0)
+}
+
+func TestCoalesceExtents(t *testing.T) {
+	extents := coalesceExtents([]DiffExtent{
+		{Offset: 4096, Length: 4096, Exists: true},
+		{Offset: 0, Length: 4096, Exists: true},
+		{Offset: 8192, Length: 4096, Exists: false},
+		{Offset: 12288, Length: 4096, Exists: false},
+		{Offset: 20480, Length: 4096, Exists: true},
+	})
+	assert.Equal(t, []DiffExtent{
+		{Offset: 0, Length: 8192, Exists: true},
+		{Offset: 8192, Length: 8192, Exists: false},
+		{Offset: 20480, Length: 4096, Exists: true},
+	}, extents)
+	assert.Len(t, coalesceExtents(nil), 0)
+}
+
+func TestParallelDiffIterate(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	// 16 objects of 1 MiB
+	err = quickCreate(ioctx, name, 16<<20, 20)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	for _, off := range []int64{0, 1 << 20, 5<<20 + 4096, 15 << 20} {
+		_, err = img.WriteAt([]byte("data"), off)
+		require.NoError(t, err)
+	}
+	size := uint64(16 << 20)
+
+	var serial []DiffExtent
+	err = img.DiffIterate(DiffIterateConfig{
+		Offset: 0,
+		Length: size,
+		Callback: func(o, l uint64, e int, _ interface{}) int {
+			serial = append(serial, DiffExtent{o, l, e != 0})
+			return 0
+		},
+	})
+	require.NoError(t, err)
+	serial = coalesceExtents(serial)
+
+	t.Run("matchesSerial", func(t *testing.T) {
+		var progress []uint64
+		extents, err := img.ParallelDiffIterate(context.Background(),
+			ParallelDiffIterateConfig{
+				Offset:    0,
+				Length:    size,
+				Workers:   3,
+				RangeSize: 1 << 20,
+				Progress: func(done, total uint64) {
+					assert.Equal(t, size, total)
+					progress = append(progress, done)
+				},
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, serial, extents)
+		if assert.Len(t, progress, 16) {
+			assert.Equal(t, size, progress[15])
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		extents, err := img.ParallelDiffIterate(context.Background(),
+			ParallelDiffIterateConfig{Offset: 0, Length: size})
+		assert.NoError(t, err)
+		assert.Equal(t, serial, extents)
+	})
+
+	t.Run("subRange", func(t *testing.T) {
+		extents, err := img.ParallelDiffIterate(context.Background(),
+			ParallelDiffIterateConfig{
+				Offset:    5 << 20,
+				Length:    1 << 20,
+				RangeSize: 1,
+			})
+		assert.NoError(t, err)
+		if assert.Len(t, extents, 1) {
+			assert.True(t, extents[0].Exists)
+			assert.LessOrEqual(t, uint64(5<<20), extents[0].Offset)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := img.ParallelDiffIterate(ctx,
+			ParallelDiffIterateConfig{Offset: 0, Length: size})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		closed := GetImage(ioctx, name)
+		_, err := closed.ParallelDiffIterate(context.Background(),
+			ParallelDiffIterateConfig{Offset: 0, Length: size})
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
@@ -0,0 +1,285 @@
+//go:build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// SnapshotDiskUsage is the disk usage of a snapshot of an image.
+type SnapshotDiskUsage struct {
+	Name string
+	ID   uint64
+	// ProvisionedBytes is the size of the image at the time of the snapshot.
+	ProvisionedBytes uint64
+	// UsedBytes is the size of the data written between the previous
+	// snapshot and this snapshot.
+	UsedBytes uint64
+}
+
+// ImageDiskUsage is the disk usage of an image and its snapshots, like
+// reported by the rbd du command. Data of the parent of a clone is not
+// included.
+type ImageDiskUsage struct {
+	Name string
+	// ProvisionedBytes is the current size of the image.
+	ProvisionedBytes uint64
+	// UsedBytes is the size of the data written since the latest snapshot.
+	UsedBytes uint64
+	// Snapshots contains the usage of the snapshots, oldest first.
+	Snapshots []SnapshotDiskUsage
+	// FastDiff is true if the usage was computed from a valid fast-diff
+	// object map. Without fast-diff every object of the image is scanned
+	// and the used sizes are not rounded up to whole objects.
+	FastDiff bool
+}
+
+// TotalUsedBytes returns the size of the data of the image and all its
+// snapshots.
+func (u *ImageDiskUsage) TotalUsedBytes() uint64 {
+	total := u.UsedBytes
+	for _, s := range u.Snapshots {
+		total += s.UsedBytes
+	}
+	return total
+}
+
+// PoolDiskUsage is the disk usage of the images of a pool.
+type PoolDiskUsage struct {
+	Images []ImageDiskUsage
+	// ProvisionedBytes is the sum of the current sizes of the images.
+	ProvisionedBytes uint64
+	// UsedBytes is the sum of the data of the images and their snapshots.
+	UsedBytes uint64
+}
+
+// diskUsageEntry is an element of the snapshot chain of an image, either a
+// snapshot or the image itself.
+type diskUsageEntry struct {
+	snapName string
+	fromSnap string
+	size     uint64
+	used     uint64
+	fastDiff bool
+}
+
+// runBounded calls fn for every index in [0, n), with at most workers
+// concurrent calls. It returns the first error returned by fn, after which
+// no further calls are started.
+func runBounded(n, workers int, fn func(int) error) error {
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		firstErr error
+		jobs     = make(chan int)
+	)
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fn(i); err != nil {
+					mutex.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		mutex.Lock()
+		failed := firstErr != nil
+		mutex.Unlock()
+		if failed {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+// DiskUsage returns the provisioned and used sizes of the image and of every
+// snapshot of the image. The snapshots are diffed concurrently, each one
+// against the previous snapshot, using the fast-diff object map where it is
+// valid and a scan of the objects of the image otherwise.
+//
+// The usage is computed for the image itself, even if the image is open at a
+// snapshot.
+func (image *Image) DiskUsage() (*ImageDiskUsage, error) {
+	return image.diskUsage(defaultDiffWorkers)
+}
+
+func (image *Image) diskUsage(workers int) (*ImageDiskUsage, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	snaps, err := image.GetSnapshotNames()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Id < snaps[j].Id })
+
+	head, err := image.openReadOnlyAt(NoSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	size, err := head.GetSize()
+	head.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]diskUsageEntry, len(snaps)+1)
+	for i, s := range snaps {
+		entries[i].snapName = s.Name
+		entries[i].size = s.Size
+		if i > 0 {
+			entries[i].fromSnap = snaps[i-1].Name
+		}
+	}
+	entries[len(snaps)].size = size
+	if len(snaps) > 0 {
+		entries[len(snaps)].fromSnap = snaps[len(snaps)-1].Name
+	}
+
+	err = runBounded(len(entries), workers, func(i int) error {
+		return image.diskUsageEntry(&entries[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &ImageDiskUsage{
+		Name:             image.name,
+		ProvisionedBytes: size,
+		UsedBytes:        entries[len(snaps)].used,
+		FastDiff:         true,
+	}
+	for i, s := range snaps {
+		usage.Snapshots = append(usage.Snapshots, SnapshotDiskUsage{
+			Name:             s.Name,
+			ID:               s.Id,
+			ProvisionedBytes: s.Size,
+			UsedBytes:        entries[i].used,
+		})
+	}
+	for _, e := range entries {
+		usage.FastDiff = usage.FastDiff && e.fastDiff
+	}
+	return usage, nil
+}
+
+// diskUsageEntry computes the used size of an entry of the snapshot chain
+// with a handle of the image open at the snapshot of the entry.
+func (image *Image) diskUsageEntry(e *diskUsageEntry) error {
+	img, err := image.openReadOnlyAt(e.snapName)
+	if err != nil {
+		return err
+	}
+	defer img.Close()
+
+	e.fastDiff, err = img.fastDiffValid()
+	if err != nil {
+		return err
+	}
+	config := DiffIterateConfig{
+		SnapName:      e.fromSnap,
+		Offset:        0,
+		Length:        e.size,
+		IncludeParent: ExcludeParent,
+		WholeObject:   DisableWholeObject,
+		Callback: func(_, length uint64, exists int, _ interface{}) int {
+			if exists != 0 {
+				e.used += length
+			}
+			return 0
+		},
+	}
+	if e.fastDiff {
+		config.WholeObject = EnableWholeObject
+	}
+	return img.DiffIterate(config)
+}
+
+// fastDiffValid returns true if the image has the fast-diff feature and
+// the fast-diff object map of the snapshot the image is open at is valid.
+//
+// Implements:
+//
+//	int rbd_get_flags(rbd_image_t image, uint64_t *flags);
+func (image *Image) fastDiffValid() (bool, error) {
+	features, err := image.GetFeatures()
+	if err != nil {
+		return false, err
+	}
+	if features&FeatureFastDiff == 0 {
+		return false, nil
+	}
+	var flags C.uint64_t
+	t := image.startOp("rbd_get_flags")
+	ret := C.rbd_get_flags(image.image, &flags)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
+		return false, getError(ret)
+	}
+	invalid := C.uint64_t(C.RBD_FLAG_OBJECT_MAP_INVALID | C.RBD_FLAG_FAST_DIFF_INVALID)
+	return flags&invalid == 0, nil
+}
+
+// DiskUsage returns the provisioned and used sizes of all images of the
+// pool, including their snapshots, like Image.DiskUsage. Up to four images
+// are processed concurrently. Images that are removed while the usage is
+// computed are skipped.
+func DiskUsage(ioctx *rados.IOContext) (*PoolDiskUsage, error) {
+	if ioctx == nil {
+		return nil, ErrNoIOContext
+	}
+	names, err := GetImageNames(ioctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	usages := make([]*ImageDiskUsage, len(names))
+	err = runBounded(len(names), defaultDiffWorkers, func(i int) error {
+		img, err := OpenImageReadOnly(ioctx, names[i], NoSnapshot)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer img.Close()
+		usages[i], err = img.diskUsage(1)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &PoolDiskUsage{}
+	for _, u := range usages {
+		if u == nil {
+			continue
+		}
+		pool.Images = append(pool.Images, *u)
+		pool.ProvisionedBytes += u.ProvisionedBytes
+		pool.UsedBytes += u.TotalUsedBytes()
+	}
+	return pool, nil
+}
@@ -0,0 +1,157 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBounded(t *testing.T) {
+	t.Run("all", func(t *testing.T) {
+		var running, peak, calls int32
+		err := runBounded(20, 3, func(int) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			atomic.AddInt32(&calls, 1)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 20, calls)
+		assert.LessOrEqual(t, peak, int32(3))
+	})
+	t.Run("error", func(t *testing.T) {
+		var calls int32
+		errBad := errors.New("bad")
+		err := runBounded(100, 1, func(i int) error {
+			atomic.AddInt32(&calls, 1)
+			if i == 2 {
+				return errBad
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, errBad)
+		assert.Less(t, calls, int32(100))
+	})
+	t.Run("empty", func(t *testing.T) {
+		err := runBounded(0, 4, func(int) error {
+			t.Error("unexpected call")
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestDiskUsage(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	objectSize := uint64(1) << testImageOrder
+	create := func(t *testing.T, features uint64) (string, *Image) {
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		require.NoError(t, options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+		require.NoError(t, options.SetUint64(ImageOptionFeatures, features))
+		name := GetUUID()
+		require.NoError(t, CreateImage(ioctx, name, 4*objectSize, options))
+		img, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		return name, img
+	}
+	// write a block to the first object, snapshot, then write a block to
+	// the third object and grow the image
+	populate := func(t *testing.T, img *Image) {
+		_, err := img.WriteAt(make([]byte, 4096), 0)
+		require.NoError(t, err)
+		_, err = img.WriteAt([]byte("data"), 4096)
+		require.NoError(t, err)
+		_, err = img.CreateSnapshot("snap1")
+		require.NoError(t, err)
+		_, err = img.WriteAt([]byte("more data"), int64(2*objectSize))
+		require.NoError(t, err)
+		require.NoError(t, img.Resize(5*objectSize))
+	}
+	cleanup := func(t *testing.T, name string, img *Image) {
+		snap := img.GetSnapshot("snap1")
+		assert.NoError(t, snap.Remove())
+		assert.NoError(t, img.Close())
+		assert.NoError(t, RemoveImage(ioctx, name))
+	}
+
+	fastName, fast := create(t, FeatureLayering|FeatureExclusiveLock|
+		FeatureObjectMap|FeatureFastDiff)
+	defer cleanup(t, fastName, fast)
+	populate(t, fast)
+	slowName, slow := create(t, FeatureLayering)
+	defer cleanup(t, slowName, slow)
+	populate(t, slow)
+
+	t.Run("fastDiff", func(t *testing.T) {
+		u, err := fast.DiskUsage()
+		require.NoError(t, err)
+		assert.Equal(t, fastName, u.Name)
+		assert.True(t, u.FastDiff)
+		assert.EqualValues(t, 5*objectSize, u.ProvisionedBytes)
+		// whole objects are accounted with fast-diff
+		assert.EqualValues(t, objectSize, u.UsedBytes)
+		if assert.Len(t, u.Snapshots, 1) {
+			assert.Equal(t, "snap1", u.Snapshots[0].Name)
+			assert.EqualValues(t, 4*objectSize, u.Snapshots[0].ProvisionedBytes)
+			assert.EqualValues(t, objectSize, u.Snapshots[0].UsedBytes)
+		}
+		assert.EqualValues(t, 2*objectSize, u.TotalUsedBytes())
+	})
+
+	t.Run("scan", func(t *testing.T) {
+		u, err := slow.DiskUsage()
+		require.NoError(t, err)
+		assert.False(t, u.FastDiff)
+		assert.EqualValues(t, 5*objectSize, u.ProvisionedBytes)
+		assert.Greater(t, u.UsedBytes, uint64(0))
+		assert.LessOrEqual(t, u.UsedBytes, uint64(objectSize))
+		if assert.Len(t, u.Snapshots, 1) {
+			assert.Greater(t, u.Snapshots[0].UsedBytes, uint64(0))
+			assert.LessOrEqual(t, u.Snapshots[0].UsedBytes, uint64(objectSize))
+		}
+	})
+
+	t.Run("pool", func(t *testing.T) {
+		p, err := DiskUsage(ioctx)
+		require.NoError(t, err)
+		require.Len(t, p.Images, 2)
+		var used uint64
+		for _, u := range p.Images {
+			used += u.TotalUsedBytes()
+			assert.Len(t, u.Snapshots, 1)
+		}
+		assert.EqualValues(t, 10*objectSize, p.ProvisionedBytes)
+		assert.Equal(t, used, p.UsedBytes)
+
+		_, err = DiskUsage(nil)
+		assert.ErrorIs(t, err, ErrNoIOContext)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		_, err := GetImage(ioctx, fastName).DiskUsage()
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
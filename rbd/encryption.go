@@ -122,11 +122,13 @@ func (image *Image) EncryptionFormat(opts EncryptionOptions) error {
 	encryptionOpts := opts.allocateEncryptionOptions()
 	defer encryptionOpts.free()
 
+	t := image.startOp("rbd_encryption_format")
 	ret := C.rbd_encryption_format(
 		image.image,
 		encryptionOpts.format,
 		encryptionOpts.opts,
 		encryptionOpts.optsSize)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -147,10 +149,11 @@ func (image *Image) EncryptionLoad(opts EncryptionOptions) error {
 	encryptionOpts := opts.allocateEncryptionOptions()
 	defer encryptionOpts.free()
 
+	t := image.startOp("rbd_encryption_load")
 	ret := C.rbd_encryption_load(
 		image.image,
 		encryptionOpts.format,
 		encryptionOpts.opts,
 		encryptionOpts.optsSize)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
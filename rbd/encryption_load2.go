@@ -94,9 +94,10 @@ func (image *Image) EncryptionLoad2(opts []EncryptionOptions) error {
 		}
 	}()
 
+	t := image.startOp("rbd_encryption_load2")
 	ret := C.rbd_encryption_load2(
 		image.image,
 		(*C.rbd_encryption_spec_t)(unsafe.Pointer(&cspecs[0])),
 		C.size_t(length))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
@@ -0,0 +1,240 @@
+//go:build ceph_preview
+
+package rbd
+
+/*
+#include <errno.h>
+*/
+import "C"
+
+import (
+	"bufio"
+	"io"
+	"sort"
+)
+
+// exportWindow is the size of the ranges of the image that are passed to
+// DiffIterate at once. The extents of a window are collected before any data
+// is read, so that no I/O is done from within the callback.
+const exportWindow = 1 << 30
+
+type diffExtent struct {
+	offset uint64
+	length uint64
+	exists bool
+}
+
+// diffExtents calls fn, in order of their offset, for every extent of src
+// that changed since fromSnap, or for every allocated extent if fromSnap is
+// empty. Extents of a parent image are included.
+func diffExtents(src *Image, fromSnap string, size uint64,
+	fn func(diffExtent) error) error {
+
+	for off := uint64(0); off < size; off += exportWindow {
+		var extents []diffExtent
+		err := src.DiffIterate(DiffIterateConfig{
+			SnapName:      fromSnap,
+			Offset:        off,
+			Length:        min(exportWindow, size-off),
+			IncludeParent: IncludeParent,
+			Callback: func(o, l uint64, exists int, _ interface{}) int {
+				extents = append(extents, diffExtent{o, l, exists != 0})
+				return 0
+			},
+		})
+		if err != nil {
+			return err
+		}
+		sort.Slice(extents, func(i, j int) bool {
+			return extents[i].offset < extents[j].offset
+		})
+		for _, e := range extents {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readChunks reads the given range of src in chunks of at most len(buf)
+// bytes, calling fn for every chunk.
+func readChunks(src *Image, off, length uint64, buf []byte,
+	fn func(off uint64, data []byte) error) error {
+
+	for end := off + length; off < end; {
+		n := min(end-off, uint64(len(buf)))
+		if _, err := src.ReadAt(buf[:n], int64(off)); err != nil {
+			return err
+		}
+		if err := fn(off, buf[:n]); err != nil {
+			return err
+		}
+		off += n
+	}
+	return nil
+}
+
+// exportDiff writes a diff of src, containing the changes since h.fromSnap,
+// to the encoder.
+func exportDiff(e *exportEncoder, src *Image, h *exportDiffHeader,
+	format ExportFormat) error {
+
+	e.diffHeader(h, format)
+	buf := make([]byte, maxDiffChunk)
+	err := diffExtents(src, h.fromSnap, h.size, func(x diffExtent) error {
+		if !x.exists {
+			e.diffZero(x.offset, x.length, format)
+			return e.err
+		}
+		return readChunks(src, x.offset, x.length, buf,
+			func(off uint64, data []byte) error {
+				e.diffData(off, data, format)
+				return e.err
+			})
+	})
+	if err != nil {
+		return err
+	}
+	e.diffEnd()
+	return e.err
+}
+
+// exportRaw writes the data of src, with holes filled with zeros.
+func exportRaw(e *exportEncoder, src *Image) error {
+	size, err := src.GetSize()
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, maxDiffChunk)
+	zeros := make([]byte, maxDiffChunk)
+	pos := uint64(0)
+	fill := func(end uint64) {
+		for ; pos < end; pos += min(end-pos, maxDiffChunk) {
+			e.write(zeros[:min(end-pos, maxDiffChunk)])
+		}
+	}
+	err = diffExtents(src, NoSnapshot, size, func(x diffExtent) error {
+		if !x.exists {
+			return nil
+		}
+		fill(x.offset)
+		return readChunks(src, x.offset, x.length, buf,
+			func(off uint64, data []byte) error {
+				e.write(data)
+				pos = off + uint64(len(data))
+				return e.err
+			})
+	})
+	if err != nil {
+		return err
+	}
+	fill(size)
+	return e.err
+}
+
+// openReadOnlyAt opens another read-only handle of the image at the given
+// snapshot.
+func (image *Image) openReadOnlyAt(snapName string) (*Image, error) {
+	if image.name != "" {
+		return OpenImageReadOnly(image.ioctx, image.name, snapName)
+	}
+	id, err := image.GetId()
+	if err != nil {
+		return nil, err
+	}
+	return OpenImageByIdReadOnly(image.ioctx, id, snapName)
+}
+
+// exportV2 writes the header of the image followed by a diff for every
+// snapshot and one for the image itself.
+func exportV2(e *exportEncoder, image *Image) error {
+	info, err := image.Stat()
+	if err != nil {
+		return err
+	}
+	h := &exportImageHeader{order: uint64(info.Order)}
+	if h.features, err = image.GetFeatures(); err != nil {
+		return err
+	}
+	if h.stripeUnit, err = image.GetStripeUnit(); err != nil {
+		return err
+	}
+	if h.stripeCount, err = image.GetStripeCount(); err != nil {
+		return err
+	}
+	if h.metadata, err = image.ListMetadata(); err != nil {
+		return err
+	}
+	snaps, err := image.GetSnapshotNames()
+	if err != nil {
+		return err
+	}
+	e.imageHeader(h)
+	e.write([]byte(imageDiffsBannerV2))
+	e.u64(uint64(len(snaps) + 1))
+
+	fromSnap := NoSnapshot
+	for i := 0; i <= len(snaps); i++ {
+		dh := &exportDiffHeader{fromSnap: fromSnap}
+		if i < len(snaps) {
+			dh.toSnap = snaps[i].Name
+			dh.protected, err = image.GetSnapshot(dh.toSnap).IsProtected()
+			if err != nil {
+				return err
+			}
+		}
+		if err := exportV2Diff(e, image, dh); err != nil {
+			return err
+		}
+		fromSnap = dh.toSnap
+	}
+	return nil
+}
+
+func exportV2Diff(e *exportEncoder, image *Image, h *exportDiffHeader) error {
+	src, err := image.openReadOnlyAt(h.toSnap)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	if h.size, err = src.GetSize(); err != nil {
+		return err
+	}
+	return exportDiff(e, src, h, ExportFormatV2)
+}
+
+// ExportImage writes the image to w in the format of the "rbd export"
+// command.
+//
+// With ExportFormatV1 the data of the image, or of the snapshot the image
+// was opened at, is written as is. Holes in the image are found with
+// DiffIterate and written as zeros without reading them.
+//
+// With ExportFormatV2 the properties and metadata of the image are written,
+// followed by the data of every snapshot and of the image itself. Only the
+// changed extents of every snapshot are written. The image has to be opened
+// with an IOContext, as additional handles are opened to read the
+// snapshots.
+func ExportImage(image *Image, w io.Writer, format ExportFormat) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	e := &exportEncoder{w: bw}
+	var err error
+	switch format {
+	case ExportFormatV1:
+		err = exportRaw(e, image)
+	case ExportFormatV2:
+		if err = image.validate(imageNeedsIOContext); err == nil {
+			err = exportV2(e, image)
+		}
+	default:
+		return getError(-C.EINVAL)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,95 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrDiffSnapshotMismatch is returned by ImportDiff if the diff does not
+// start at the most recent snapshot of the image.
+var ErrDiffSnapshotMismatch = errors.New(
+	"diff does not start at the latest snapshot of the image")
+
+// ExportDiff writes the changes of the image since fromSnap to w, in the
+// format of the "rbd export-diff" command. If fromSnap is empty, all
+// allocated extents of the image are written.
+//
+// The diff ends at the snapshot the image is opened at, or at the image
+// itself. The name of that snapshot is recorded in the diff, so that
+// ImportDiff creates it.
+func ExportDiff(image *Image, fromSnap string, w io.Writer) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	size, err := image.GetSize()
+	if err != nil {
+		return err
+	}
+	h := &exportDiffHeader{
+		fromSnap: fromSnap,
+		toSnap:   image.snapName,
+		size:     size,
+	}
+	bw := bufio.NewWriter(w)
+	if err := exportDiff(&exportEncoder{w: bw}, image, h, ExportFormatV1); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ImportDiff applies a diff, as written by ExportDiff or by the
+// "rbd export-diff" command, to the image.
+//
+// If the diff starts at a snapshot, that snapshot has to be the most recent
+// snapshot of the image, otherwise ErrDiffSnapshotMismatch is returned. The
+// image is resized to the size recorded in the diff and, if the diff ends at
+// a snapshot, that snapshot is created once the diff has been applied.
+func ImportDiff(image *Image, r io.Reader) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	d := newExportDecoder(r)
+	h, err := d.diffHeader(ExportFormatV1)
+	if err != nil {
+		return err
+	}
+	snaps, err := image.GetSnapshotNames()
+	if err != nil {
+		return err
+	}
+	// the most recent snapshot has the highest ID
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Id < snaps[j].Id })
+	if h.fromSnap != "" {
+		if len(snaps) == 0 || snaps[len(snaps)-1].Name != h.fromSnap {
+			return fmt.Errorf("%w: %q", ErrDiffSnapshotMismatch, h.fromSnap)
+		}
+	}
+	for _, snap := range snaps {
+		if h.toSnap != "" && snap.Name == h.toSnap {
+			return fmt.Errorf("snapshot %q: %w", h.toSnap, ErrExist)
+		}
+	}
+
+	size, err := image.GetSize()
+	if err != nil {
+		return err
+	}
+	if size != h.size {
+		if err := image.Resize(h.size); err != nil {
+			return err
+		}
+	}
+	if err := importDiff(d, image, ExportFormatV1, false); err != nil {
+		return err
+	}
+	if h.toSnap == "" {
+		return nil
+	}
+	_, err = image.CreateSnapshot(h.toSnap)
+	return err
+}
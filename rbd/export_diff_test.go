@@ -0,0 +1,131 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportDiff(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	srcName := GetUUID()
+	err = quickCreate(ioctx, srcName, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, srcName)) }()
+	src, err := OpenImage(ioctx, srcName, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, src.Close()) }()
+
+	dstName := GetUUID()
+	err = quickCreate(ioctx, dstName, 0, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, dstName)) }()
+	dst, err := OpenImage(ioctx, dstName, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, dst.Close()) }()
+
+	read := func(t *testing.T, img *Image, off int64, n int) string {
+		t.Helper()
+		buf := make([]byte, n)
+		_, err := img.ReadAt(buf, off)
+		require.NoError(t, err)
+		return string(buf)
+	}
+	exportAt := func(t *testing.T, snapName, fromSnap string) *bytes.Buffer {
+		t.Helper()
+		img, err := OpenImageReadOnly(ioctx, srcName, snapName)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img.Close()) }()
+		buf := &bytes.Buffer{}
+		require.NoError(t, ExportDiff(img, fromSnap, buf))
+		return buf
+	}
+
+	_, err = src.WriteAt([]byte("base"), 0)
+	require.NoError(t, err)
+	snap1, err := src.CreateSnapshot("snap1")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snap1.Remove()) }()
+	_, err = src.WriteAt([]byte("incremental"), 8192)
+	require.NoError(t, err)
+	_, err = src.Discard(0, 4096)
+	require.NoError(t, err)
+	snap2, err := src.CreateSnapshot("snap2")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snap2.Remove()) }()
+
+	full := exportAt(t, "snap1", NoSnapshot)
+	assert.True(t, bytes.HasPrefix(full.Bytes(), []byte("rbd diff v1\nt")))
+	incr := exportAt(t, "snap2", "snap1")
+	incrCopy := bytes.NewBuffer(incr.Bytes())
+
+	t.Run("mismatch", func(t *testing.T) {
+		err := ImportDiff(dst, bytes.NewReader(incr.Bytes()))
+		assert.ErrorIs(t, err, ErrDiffSnapshotMismatch)
+	})
+
+	defer func() {
+		for _, name := range []string{"snap1", "snap2"} {
+			_ = dst.GetSnapshot(name).Remove()
+		}
+	}()
+
+	t.Run("full", func(t *testing.T) {
+		err := ImportDiff(dst, full)
+		require.NoError(t, err)
+		size, err := dst.GetSize()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(testImageSize), size)
+		assert.Equal(t, "base", read(t, dst, 0, 4))
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		err := ImportDiff(dst, incr)
+		require.NoError(t, err)
+		assert.Equal(t, "incremental", read(t, dst, 8192, 11))
+		assert.Equal(t, string(make([]byte, 4)), read(t, dst, 0, 4))
+		snaps, err := dst.GetSnapshotNames()
+		assert.NoError(t, err)
+		if assert.Len(t, snaps, 2) {
+			assert.Equal(t, "snap1", snaps[0].Name)
+			assert.Equal(t, "snap2", snaps[1].Name)
+		}
+
+		// the diff has been applied already
+		err = ImportDiff(dst, incrCopy)
+		assert.ErrorIs(t, err, ErrDiffSnapshotMismatch)
+	})
+
+	t.Run("head", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := ExportDiff(src, "snap2", buf)
+		require.NoError(t, err)
+		h, err := newExportDecoder(buf).diffHeader(ExportFormatV1)
+		require.NoError(t, err)
+		assert.Equal(t, "snap2", h.fromSnap)
+		assert.Equal(t, "", h.toSnap)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		closed := GetImage(ioctx, srcName)
+		err := ExportDiff(closed, NoSnapshot, &bytes.Buffer{})
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		err = ImportDiff(closed, &bytes.Buffer{})
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
@@ -0,0 +1,460 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ExportFormat is the format of the stream written by ExportImage and read
+// by ImportImage. The formats match those of the "rbd export" and
+// "rbd import" commands.
+type ExportFormat int
+
+const (
+	// ExportFormatV1 is the raw data of the image, without snapshots or
+	// metadata.
+	ExportFormatV1 = ExportFormat(1)
+	// ExportFormatV2 contains the properties and metadata of the image,
+	// followed by the data of every snapshot and of the image itself, each
+	// as a diff to its predecessor.
+	ExportFormatV2 = ExportFormat(2)
+)
+
+// ErrInvalidExport is returned when a stream that is being imported is not
+// in the expected format.
+var ErrInvalidExport = errors.New("invalid rbd export stream")
+
+// The banners and tags below match src/tools/rbd/Utils.h.
+const (
+	imageBannerV2      = "rbd image v2\n"
+	imageDiffsBannerV2 = "rbd image diffs v2\n"
+	diffBannerV1       = "rbd diff v1\n"
+	diffBannerV2       = "rbd diff v2\n"
+
+	diffTagFromSnap         = byte('f')
+	diffTagToSnap           = byte('t')
+	diffTagImageSize        = byte('s')
+	diffTagWrite            = byte('w')
+	diffTagZero             = byte('z')
+	diffTagEnd              = byte('e')
+	diffTagProtectionStatus = byte('p')
+
+	imageTagOrder       = byte('O')
+	imageTagFeatures    = byte('T')
+	imageTagStripeUnit  = byte('U')
+	imageTagStripeCount = byte('C')
+	imageTagMeta        = byte('M')
+	imageTagEnd         = byte('E')
+
+	// maxDiffChunk limits the size of the data of a single write record
+	// that is written, and of the buffers used to read write records.
+	maxDiffChunk = 4 << 20
+	// maxTagLength limits the size of the values of header tags that are
+	// read into memory.
+	maxTagLength = 64 << 20
+)
+
+// exportImageHeader contains the properties of an image that are stored at
+// the start of a v2 export.
+type exportImageHeader struct {
+	order       uint64
+	features    uint64
+	stripeUnit  uint64
+	stripeCount uint64
+	metadata    map[string]string
+}
+
+// exportDiffHeader is the header of a diff. fromSnap and toSnap are empty
+// if the diff starts at the beginning of the image or ends at its head.
+type exportDiffHeader struct {
+	fromSnap  string
+	toSnap    string
+	protected bool
+	size      uint64
+}
+
+// exportEncoder writes the values of export streams in the encoding used by
+// ceph. The first error is kept and stops any further writes.
+type exportEncoder struct {
+	w   io.Writer
+	err error
+	buf [8]byte
+}
+
+func (e *exportEncoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *exportEncoder) u8(v byte) {
+	e.buf[0] = v
+	e.write(e.buf[:1])
+}
+
+func (e *exportEncoder) u32(v uint32) {
+	binary.LittleEndian.PutUint32(e.buf[:4], v)
+	e.write(e.buf[:4])
+}
+
+func (e *exportEncoder) u64(v uint64) {
+	binary.LittleEndian.PutUint64(e.buf[:8], v)
+	e.write(e.buf[:8])
+}
+
+func (e *exportEncoder) str(s string) {
+	e.u32(uint32(len(s)))
+	e.write([]byte(s))
+}
+
+// tag writes a tag along with the length of its value if the format
+// requires one.
+func (e *exportEncoder) tag(t byte, length uint64, format ExportFormat) {
+	e.u8(t)
+	if format == ExportFormatV2 {
+		e.u64(length)
+	}
+}
+
+func (e *exportEncoder) imageHeader(h *exportImageHeader) {
+	e.write([]byte(imageBannerV2))
+	for _, v := range []struct {
+		tag   byte
+		value uint64
+	}{
+		{imageTagOrder, h.order},
+		{imageTagFeatures, h.features},
+		{imageTagStripeUnit, h.stripeUnit},
+		{imageTagStripeCount, h.stripeCount},
+	} {
+		e.tag(v.tag, 8, ExportFormatV2)
+		e.u64(v.value)
+	}
+	keys := make([]string, 0, len(h.metadata))
+	for k := range h.metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := h.metadata[k]
+		e.tag(imageTagMeta, uint64(4+len(k)+4+len(v)), ExportFormatV2)
+		e.str(k)
+		e.str(v)
+	}
+	e.u8(imageTagEnd)
+}
+
+func (e *exportEncoder) diffHeader(h *exportDiffHeader, format ExportFormat) {
+	if format == ExportFormatV2 {
+		e.write([]byte(diffBannerV2))
+	} else {
+		e.write([]byte(diffBannerV1))
+	}
+	if h.fromSnap != "" {
+		e.tag(diffTagFromSnap, uint64(4+len(h.fromSnap)), format)
+		e.str(h.fromSnap)
+	}
+	if h.toSnap != "" {
+		e.tag(diffTagToSnap, uint64(4+len(h.toSnap)), format)
+		e.str(h.toSnap)
+		if format == ExportFormatV2 {
+			// the rbd tool announces a length of 8 but writes a single byte
+			e.tag(diffTagProtectionStatus, 8, format)
+			if h.protected {
+				e.u8(1)
+			} else {
+				e.u8(0)
+			}
+		}
+	}
+	e.tag(diffTagImageSize, 8, format)
+	e.u64(h.size)
+}
+
+// diffData writes a write record for data, or a zero record if data only
+// contains zeros.
+func (e *exportEncoder) diffData(off uint64, data []byte, format ExportFormat) {
+	if isZero(data) {
+		e.diffZero(off, uint64(len(data)), format)
+		return
+	}
+	e.tag(diffTagWrite, uint64(16+len(data)), format)
+	e.u64(off)
+	e.u64(uint64(len(data)))
+	e.write(data)
+}
+
+func (e *exportEncoder) diffZero(off, length uint64, format ExportFormat) {
+	e.tag(diffTagZero, 16, format)
+	e.u64(off)
+	e.u64(length)
+}
+
+func (e *exportEncoder) diffEnd() {
+	e.u8(diffTagEnd)
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// exportDecoder reads the values of export streams.
+type exportDecoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+func newExportDecoder(r io.Reader) *exportDecoder {
+	return &exportDecoder{r: bufio.NewReader(r)}
+}
+
+// full reads len(b) bytes. Running out of data is reported as an invalid
+// stream.
+func (d *exportDecoder) full(b []byte) error {
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: unexpected end of stream", ErrInvalidExport)
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *exportDecoder) u8() (byte, error) {
+	err := d.full(d.buf[:1])
+	return d.buf[0], err
+}
+
+func (d *exportDecoder) u32() (uint32, error) {
+	err := d.full(d.buf[:4])
+	return binary.LittleEndian.Uint32(d.buf[:4]), err
+}
+
+func (d *exportDecoder) u64() (uint64, error) {
+	err := d.full(d.buf[:8])
+	return binary.LittleEndian.Uint64(d.buf[:8]), err
+}
+
+func (d *exportDecoder) str() (string, error) {
+	n, err := d.u32()
+	if err != nil {
+		return "", err
+	}
+	if n > maxTagLength {
+		return "", fmt.Errorf("%w: string too long", ErrInvalidExport)
+	}
+	b := make([]byte, n)
+	if err := d.full(b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *exportDecoder) skip(n uint64) error {
+	if _, err := io.CopyN(io.Discard, d.r, int64(n)); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("%w: unexpected end of stream", ErrInvalidExport)
+		}
+		return err
+	}
+	return nil
+}
+
+// banner reads and verifies a banner.
+func (d *exportDecoder) banner(banner string) error {
+	b := make([]byte, len(banner))
+	if err := d.full(b); err != nil {
+		return err
+	}
+	if string(b) != banner {
+		return fmt.Errorf("%w: expected banner %q", ErrInvalidExport, banner)
+	}
+	return nil
+}
+
+// hasPrefix returns true if the stream starts with prefix, without
+// consuming it.
+func (d *exportDecoder) hasPrefix(prefix string) bool {
+	b, _ := d.r.Peek(len(prefix))
+	return bytes.Equal(b, []byte(prefix))
+}
+
+// tag reads a tag, and the length of its value if the format has one. End
+// tags have no length.
+func (d *exportDecoder) tag(endTag byte, format ExportFormat) (byte, uint64, error) {
+	t, err := d.u8()
+	if err != nil || t == endTag || format != ExportFormatV2 {
+		return t, 0, err
+	}
+	length, err := d.u64()
+	return t, length, err
+}
+
+func (d *exportDecoder) imageHeader() (*exportImageHeader, error) {
+	if err := d.banner(imageBannerV2); err != nil {
+		return nil, err
+	}
+	h := &exportImageHeader{metadata: map[string]string{}}
+	for {
+		t, length, err := d.tag(imageTagEnd, ExportFormatV2)
+		if err != nil {
+			return nil, err
+		}
+		var v *uint64
+		switch t {
+		case imageTagEnd:
+			return h, nil
+		case imageTagOrder:
+			v = &h.order
+		case imageTagFeatures:
+			v = &h.features
+		case imageTagStripeUnit:
+			v = &h.stripeUnit
+		case imageTagStripeCount:
+			v = &h.stripeCount
+		case imageTagMeta:
+			k, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+			val, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+			h.metadata[k] = val
+			continue
+		default:
+			// skip tags added by newer versions
+			if err := d.skip(length); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if length != 8 {
+			return nil, fmt.Errorf("%w: bad length of tag %q",
+				ErrInvalidExport, t)
+		}
+		if *v, err = d.u64(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// diffHeader reads the banner and header of a diff, up to and including the
+// image size.
+func (d *exportDecoder) diffHeader(format ExportFormat) (*exportDiffHeader, error) {
+	banner := diffBannerV1
+	if format == ExportFormatV2 {
+		banner = diffBannerV2
+	}
+	if err := d.banner(banner); err != nil {
+		return nil, err
+	}
+	h := &exportDiffHeader{}
+	for {
+		t, length, err := d.tag(diffTagEnd, format)
+		if err != nil {
+			return nil, err
+		}
+		switch t {
+		case diffTagFromSnap:
+			if h.fromSnap, err = d.str(); err != nil {
+				return nil, err
+			}
+		case diffTagToSnap:
+			if h.toSnap, err = d.str(); err != nil {
+				return nil, err
+			}
+		case diffTagProtectionStatus:
+			// the value is a single byte, regardless of the length
+			p, err := d.u8()
+			if err != nil {
+				return nil, err
+			}
+			h.protected = p != 0
+		case diffTagImageSize:
+			if h.size, err = d.u64(); err != nil {
+				return nil, err
+			}
+			return h, nil
+		default:
+			if format != ExportFormatV2 {
+				return nil, fmt.Errorf("%w: unexpected tag %q",
+					ErrInvalidExport, t)
+			}
+			if err := d.skip(length); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// diffRecord is a write or zero record of a diff.
+type diffRecord struct {
+	tag    byte
+	offset uint64
+	length uint64
+}
+
+// diffRecord reads the next record of a diff. For write records the data
+// has to be read with diffData before the next record is read. The end of
+// the diff is returned as a record with the diffTagEnd tag.
+func (d *exportDecoder) diffRecord(format ExportFormat) (*diffRecord, error) {
+	for {
+		t, length, err := d.tag(diffTagEnd, format)
+		if err != nil {
+			return nil, err
+		}
+		switch t {
+		case diffTagEnd:
+			return &diffRecord{tag: t}, nil
+		case diffTagWrite, diffTagZero:
+			rec := &diffRecord{tag: t}
+			if rec.offset, err = d.u64(); err != nil {
+				return nil, err
+			}
+			if rec.length, err = d.u64(); err != nil {
+				return nil, err
+			}
+			return rec, nil
+		default:
+			if format != ExportFormatV2 {
+				return nil, fmt.Errorf("%w: unexpected tag %q",
+					ErrInvalidExport, t)
+			}
+			if err := d.skip(length); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// diffData reads the data of a write record in chunks of at most
+// maxDiffChunk bytes, calling fn for every chunk. The buffer passed to fn is
+// reused.
+func (d *exportDecoder) diffData(rec *diffRecord, fn func(off uint64, data []byte) error) error {
+	buf := make([]byte, min(rec.length, maxDiffChunk))
+	for done := uint64(0); done < rec.length; {
+		n := min(rec.length-done, uint64(len(buf)))
+		if err := d.full(buf[:n]); err != nil {
+			return err
+		}
+		if err := fn(rec.offset+done, buf[:n]); err != nil {
+			return err
+		}
+		done += n
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportFormatDiffV1(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := &exportEncoder{w: buf}
+	e.diffHeader(&exportDiffHeader{
+		fromSnap: "a", toSnap: "b", protected: true, size: 4096}, ExportFormatV1)
+	e.diffData(512, []byte("xy"), ExportFormatV1)
+	e.diffData(1024, make([]byte, 16), ExportFormatV1)
+	e.diffEnd()
+	require.NoError(t, e.err)
+
+	expected := []byte("rbd diff v1\n" +
+		"f\x01\x00\x00\x00a" +
+		"t\x01\x00\x00\x00b" +
+		"s\x00\x10\x00\x00\x00\x00\x00\x00" +
+		"w\x00\x02\x00\x00\x00\x00\x00\x00\x02\x00\x00\x00\x00\x00\x00\x00xy" +
+		"z\x00\x04\x00\x00\x00\x00\x00\x00\x10\x00\x00\x00\x00\x00\x00\x00" +
+		"e")
+	assert.Equal(t, expected, buf.Bytes())
+
+	d := newExportDecoder(bytes.NewReader(buf.Bytes()))
+	h, err := d.diffHeader(ExportFormatV1)
+	require.NoError(t, err)
+	// protection status is only part of v2
+	assert.Equal(t, &exportDiffHeader{fromSnap: "a", toSnap: "b", size: 4096}, h)
+
+	rec, err := d.diffRecord(ExportFormatV1)
+	require.NoError(t, err)
+	assert.Equal(t, &diffRecord{tag: diffTagWrite, offset: 512, length: 2}, rec)
+	var data []byte
+	err = d.diffData(rec, func(off uint64, b []byte) error {
+		assert.Equal(t, uint64(512), off)
+		data = append(data, b...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "xy", string(data))
+
+	rec, err = d.diffRecord(ExportFormatV1)
+	require.NoError(t, err)
+	assert.Equal(t, &diffRecord{tag: diffTagZero, offset: 1024, length: 16}, rec)
+	rec, err = d.diffRecord(ExportFormatV1)
+	require.NoError(t, err)
+	assert.Equal(t, diffTagEnd, rec.tag)
+}
+
+func TestExportFormatV2(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := &exportEncoder{w: buf}
+	ih := &exportImageHeader{
+		order:       22,
+		features:    61,
+		stripeUnit:  4 << 20,
+		stripeCount: 1,
+		metadata:    map[string]string{"k": "v"},
+	}
+	e.imageHeader(ih)
+	e.diffHeader(&exportDiffHeader{toSnap: "s1", protected: true, size: 8192},
+		ExportFormatV2)
+	e.diffData(0, []byte("abc"), ExportFormatV2)
+	e.diffEnd()
+	require.NoError(t, e.err)
+
+	b := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(b, []byte(imageBannerV2+
+		"O\x08\x00\x00\x00\x00\x00\x00\x00\x16\x00\x00\x00\x00\x00\x00\x00")))
+	assert.Contains(t, string(b),
+		"M\x0a\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00k\x01\x00\x00\x00vE")
+	assert.Contains(t, string(b), "rbd diff v2\n"+
+		"t\x06\x00\x00\x00\x00\x00\x00\x00\x02\x00\x00\x00s1"+
+		"p\x08\x00\x00\x00\x00\x00\x00\x00\x01"+
+		"s\x08\x00\x00\x00\x00\x00\x00\x00\x00\x20\x00\x00\x00\x00\x00\x00"+
+		"w\x13\x00\x00\x00\x00\x00\x00\x00")
+
+	d := newExportDecoder(bytes.NewReader(b))
+	assert.True(t, d.hasPrefix(imageBannerV2))
+	h, err := d.imageHeader()
+	require.NoError(t, err)
+	assert.Equal(t, ih, h)
+	dh, err := d.diffHeader(ExportFormatV2)
+	require.NoError(t, err)
+	assert.Equal(t,
+		&exportDiffHeader{toSnap: "s1", protected: true, size: 8192}, dh)
+	rec, err := d.diffRecord(ExportFormatV2)
+	require.NoError(t, err)
+	assert.Equal(t, &diffRecord{tag: diffTagWrite, offset: 0, length: 3}, rec)
+	require.NoError(t, d.diffData(rec, func(uint64, []byte) error { return nil }))
+	rec, err = d.diffRecord(ExportFormatV2)
+	require.NoError(t, err)
+	assert.Equal(t, diffTagEnd, rec.tag)
+}
+
+func TestExportFormatInvalid(t *testing.T) {
+	t.Run("badBanner", func(t *testing.T) {
+		d := newExportDecoder(bytes.NewReader([]byte("rbd diff v9\n")))
+		_, err := d.diffHeader(ExportFormatV1)
+		assert.ErrorIs(t, err, ErrInvalidExport)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		d := newExportDecoder(bytes.NewReader([]byte("rbd diff v1\ns\x00")))
+		_, err := d.diffHeader(ExportFormatV1)
+		assert.ErrorIs(t, err, ErrInvalidExport)
+	})
+	t.Run("unknownTagV1", func(t *testing.T) {
+		d := newExportDecoder(bytes.NewReader([]byte("rbd diff v1\nq")))
+		_, err := d.diffHeader(ExportFormatV1)
+		assert.ErrorIs(t, err, ErrInvalidExport)
+	})
+	t.Run("unknownTagV2", func(t *testing.T) {
+		// unknown tags of v2 streams are skipped
+		d := newExportDecoder(bytes.NewReader([]byte("rbd diff v2\n" +
+			"q\x02\x00\x00\x00\x00\x00\x00\x00??" +
+			"s\x08\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00")))
+		h, err := d.diffHeader(ExportFormatV2)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), h.size)
+	})
+}
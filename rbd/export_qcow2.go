@@ -0,0 +1,169 @@
+//go:build ceph_preview
+
+package rbd
+
+/*
+#include <errno.h>
+*/
+import "C"
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// defaultExportQcow2Workers is the number of concurrent reads used by
+	// ExportQcow2 if no number is configured.
+	defaultExportQcow2Workers = 4
+	// maxExportQcow2Range is the largest range of clusters read at once.
+	maxExportQcow2Range = 4 * 1024 * 1024
+)
+
+// ExportQcow2Options control how ExportQcow2 writes a qcow2 file.
+type ExportQcow2Options struct {
+	// SnapName is the snapshot to export. If empty, the image, or the
+	// snapshot it was opened at, is exported.
+	SnapName string
+	// ClusterBits is the base 2 logarithm of the cluster size, between 9
+	// and 21. Defaults to 16, a cluster size of 64 KiB.
+	ClusterBits uint32
+	// Compress enables the zlib compression of the clusters. Clusters that
+	// do not shrink are stored uncompressed.
+	Compress bool
+	// Workers is the number of ranges that are read and compressed
+	// concurrently. Defaults to 4.
+	Workers int
+	// Progress is called after every range of allocated clusters, if set,
+	// with the number of bytes exported so far and the total number of bytes
+	// to export. Calls are not made concurrently.
+	Progress DiffProgressCallback
+}
+
+// ExportQcow2 writes the image, or one of its snapshots, to w as a qcow2
+// file that can be read by QEMU and imported with ImportFile.
+//
+// The allocated extents of the image, including those of a parent image,
+// are found with DiffIterate, and only the clusters containing them are
+// read. Clusters that only contain zeros are not written, so that the file
+// stays sparse. The file has no backing file and no internal snapshots.
+//
+// Exporting a snapshot that is not the one the image was opened at requires
+// an image opened with an IOContext, as another handle of the image is
+// opened to read the snapshot.
+func ExportQcow2(image *Image, w io.WriterAt, opts *ExportQcow2Options) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if opts == nil {
+		opts = &ExportQcow2Options{}
+	}
+	clusterBits := opts.ClusterBits
+	if clusterBits == 0 {
+		clusterBits = qcow2DefaultClusterBits
+	}
+	if clusterBits < qcow2MinClusterBits || clusterBits > qcow2MaxClusterBits {
+		return getError(-C.EINVAL)
+	}
+	src := image
+	if opts.SnapName != "" {
+		if err := image.validate(imageNeedsIOContext); err != nil {
+			return err
+		}
+		var err error
+		if src, err = image.openReadOnlyAt(opts.SnapName); err != nil {
+			return err
+		}
+		defer func() { _ = src.Close() }()
+	}
+	size, err := src.GetSize()
+	if err != nil {
+		return err
+	}
+
+	// collect the ranges of allocated clusters before reading any data
+	q := newQcow2Writer(w, size, clusterBits, opts.Compress)
+	cs := q.h.clusterSize()
+	var clusters []fileExtent
+	err = diffExtents(src, NoSnapshot, size, func(x diffExtent) error {
+		if !x.exists {
+			return nil
+		}
+		start := x.offset / cs * cs
+		end := min((x.offset+x.length+cs-1)/cs*cs, size)
+		if n := len(clusters); n > 0 && start <= clusters[n-1].offset+clusters[n-1].length {
+			last := &clusters[n-1]
+			last.length = max(last.offset+last.length, end) - last.offset
+			return nil
+		}
+		clusters = append(clusters, fileExtent{offset: start, length: end - start})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	maxRange := max(maxExportQcow2Range/cs, 1) * cs
+	var ranges []fileExtent
+	for _, c := range clusters {
+		for off := c.offset; off < c.offset+c.length; off += maxRange {
+			length := min(maxRange, c.offset+c.length-off)
+			ranges = append(ranges, fileExtent{offset: off, length: length})
+		}
+	}
+
+	total := uint64(0)
+	for _, r := range ranges {
+		total += r.length
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultExportQcow2Workers
+	}
+	var (
+		mutex sync.Mutex
+		done  uint64
+	)
+	err = runBounded(len(ranges), workers, func(i int) error {
+		r := ranges[i]
+		data := make([]byte, r.length)
+		if _, err := src.ReadAt(data, int64(r.offset)); err != nil {
+			return err
+		}
+		for off := uint64(0); off < r.length; off += cs {
+			cluster := data[off:min(off+cs, r.length)]
+			if err := q.writeCluster(r.offset+off, cluster); err != nil {
+				return err
+			}
+		}
+		if opts.Progress != nil {
+			mutex.Lock()
+			defer mutex.Unlock()
+			done += r.length
+			opts.Progress(done, total)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return q.finish()
+}
+
+// ExportQcow2File writes the image, or one of its snapshots, to a new qcow2
+// file at path, as done by ExportQcow2. The file must not exist. If the
+// export fails, the file is removed.
+func ExportQcow2File(image *Image, path string, opts *ExportQcow2Options) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	err = ExportQcow2(image, f, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportQcow2(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	const size = 8 << 20
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	// objects of 1 MiB
+	require.NoError(t, options.SetUint64(ImageOptionOrder, 20))
+	require.NoError(t, CreateImage(ioctx, name, size, options))
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	// the snapshot contains compressible data and a block that does not
+	// compress, the image additional data past the first MiB
+	snapContent := make([]byte, size)
+	copy(snapContent[4096:], bytes.Repeat([]byte("snapshot"), 4096))
+	random := testQcow2Disk(1<<16, 1)[1<<16:]
+	copy(snapContent[3<<20:], random)
+	_, err = img.WriteAt(snapContent[:4<<20], 0)
+	require.NoError(t, err)
+	snap, err := img.CreateSnapshot("snap")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snap.Remove()) }()
+	content := append([]byte(nil), snapContent...)
+	copy(content[6<<20:], bytes.Repeat([]byte("image"), 1000))
+	_, err = img.WriteAt(content[6<<20:7<<20], 6<<20)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	// roundTrip validates the file at path and imports it into a new image,
+	// whose content is compared to the expected content
+	roundTrip := func(t *testing.T, path string, expected []byte) {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		st, err := f.Stat()
+		require.NoError(t, err)
+		assert.NoError(t, ValidateQcow2(f, st.Size()))
+		t.Run("qemuImg", func(t *testing.T) {
+			qemuImgVerify(t, path, expected)
+		})
+
+		imported := GetUUID()
+		require.NoError(t, ImportFile(ioctx, imported, path, nil))
+		defer func() { assert.NoError(t, RemoveImage(ioctx, imported)) }()
+		dst, err := OpenImage(ioctx, imported, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, dst.Close()) }()
+		data := make([]byte, len(expected))
+		_, err = dst.ReadAt(data, 0)
+		assert.NoError(t, err)
+		assert.True(t, bytes.Equal(expected, data))
+	}
+
+	t.Run("image", func(t *testing.T) {
+		path := filepath.Join(dir, "image.qcow2")
+		var done, total uint64
+		err := ExportQcow2File(img, path, &ExportQcow2Options{
+			Progress: func(d, t uint64) { done, total = d, t },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, total, done)
+		// only the written objects are read
+		assert.EqualValues(t, 5<<20, total)
+		roundTrip(t, path, content)
+
+		// existing files are not overwritten
+		err = ExportQcow2File(img, path, nil)
+		assert.ErrorIs(t, err, os.ErrExist)
+	})
+
+	t.Run("snapshotCompressed", func(t *testing.T) {
+		path := filepath.Join(dir, "snap.qcow2")
+		err := ExportQcow2File(img, path, &ExportQcow2Options{
+			SnapName:    "snap",
+			ClusterBits: 12,
+			Compress:    true,
+			Workers:     2,
+		})
+		require.NoError(t, err)
+		roundTrip(t, path, snapContent)
+		st, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Less(t, st.Size(), int64(len(random)+32*4096))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var f memFile
+		err := ExportQcow2(img, &f, &ExportQcow2Options{ClusterBits: 30})
+		assert.Error(t, err)
+		err = ExportQcow2(img, &f, &ExportQcow2Options{SnapName: "missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = ExportQcow2(GetImage(ioctx, name), &f, nil)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
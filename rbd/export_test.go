@@ -0,0 +1,153 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportImage(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t, options.SetUint64(ImageOptionOrder, 20))
+	require.NoError(t, options.SetUint64(ImageOptionFeatures, FeatureLayering))
+	err = CreateImage(ioctx, name, 4<<20, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	_, err = img.WriteAt([]byte("first"), 1<<20)
+	require.NoError(t, err)
+	snap1, err := img.CreateSnapshot("snap1")
+	require.NoError(t, err)
+	require.NoError(t, snap1.Protect())
+	defer func() {
+		assert.NoError(t, snap1.Unprotect())
+		assert.NoError(t, snap1.Remove())
+	}()
+	_, err = img.WriteAt([]byte("second"), 3<<20)
+	require.NoError(t, err)
+	_, err = img.Discard(1<<20, 4096)
+	require.NoError(t, err)
+	require.NoError(t, img.SetMetadata("key", "value"))
+
+	read := func(t *testing.T, img *Image, off int64, n int) string {
+		t.Helper()
+		buf := make([]byte, n)
+		_, err := img.ReadAt(buf, off)
+		require.NoError(t, err)
+		return string(buf)
+	}
+
+	t.Run("raw", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := ExportImage(img, buf, ExportFormatV1)
+		require.NoError(t, err)
+		assert.Equal(t, 4<<20, buf.Len())
+		assert.Equal(t, "second", string(buf.Bytes()[3<<20:3<<20+6]))
+
+		name2 := GetUUID()
+		err = ImportImage(ioctx, name2, buf, nil)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name2)) }()
+		img2, err := OpenImage(ioctx, name2, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img2.Close()) }()
+		size, err := img2.GetSize()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(4<<20), size)
+		assert.Equal(t, "second", read(t, img2, 3<<20, 6))
+	})
+
+	t.Run("rawSnapshot", func(t *testing.T) {
+		simg, err := OpenImageReadOnly(ioctx, name, "snap1")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, simg.Close()) }()
+		buf := &bytes.Buffer{}
+		err = ExportImage(simg, buf, ExportFormatV1)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(buf.Bytes()[1<<20:1<<20+5]))
+		assert.Equal(t, make([]byte, 6), buf.Bytes()[3<<20:3<<20+6])
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := ExportImage(img, buf, ExportFormatV2)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("rbd image v2\n")))
+
+		name2 := GetUUID()
+		err = ImportImage(ioctx, name2, buf, nil)
+		require.NoError(t, err)
+		img2, err := OpenImage(ioctx, name2, NoSnapshot)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, img2.Close())
+			assert.NoError(t, RemoveImage(ioctx, name2))
+		}()
+
+		info, err := img2.Stat()
+		assert.NoError(t, err)
+		assert.Equal(t, 20, info.Order)
+		features, err := img2.GetFeatures()
+		assert.NoError(t, err)
+		assert.Equal(t, FeatureLayering, features)
+		value, err := img2.GetMetadata("key")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, "second", read(t, img2, 3<<20, 6))
+		assert.Equal(t, string(make([]byte, 5)), read(t, img2, 1<<20, 5))
+
+		snaps, err := img2.GetSnapshotNames()
+		assert.NoError(t, err)
+		if assert.Len(t, snaps, 1) {
+			assert.Equal(t, "snap1", snaps[0].Name)
+		}
+		snap := img2.GetSnapshot("snap1")
+		protected, err := snap.IsProtected()
+		assert.NoError(t, err)
+		assert.True(t, protected)
+		defer func() {
+			assert.NoError(t, snap.Unprotect())
+			assert.NoError(t, snap.Remove())
+		}()
+
+		simg, err := OpenImageReadOnly(ioctx, name2, "snap1")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, simg.Close()) }()
+		assert.Equal(t, "first", read(t, simg, 1<<20, 5))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := ExportImage(img, &bytes.Buffer{}, ExportFormat(3))
+		assert.Error(t, err)
+		err = ExportImage(GetImage(ioctx, name), &bytes.Buffer{}, ExportFormatV1)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+
+		buf := bytes.NewBufferString("rbd image v2\nE")
+		err = ImportImage(ioctx, GetUUID(), buf, nil)
+		assert.ErrorIs(t, err, ErrInvalidExport)
+		err = ImportImage(nil, GetUUID(), buf, nil)
+		assert.ErrorIs(t, err, ErrNoIOContext)
+	})
+}
@@ -170,7 +170,10 @@ func (image *Image) GetFeatures() (features uint64, err error) {
 		return 0, err
 	}
 
-	if ret := C.rbd_get_features(image.image, (*C.uint64_t)(&features)); ret < 0 {
+	t := image.startOp("rbd_get_features")
+	ret := C.rbd_get_features(image.image, (*C.uint64_t)(&features))
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return 0, getError(ret)
 	}
 
@@ -192,5 +195,6 @@ func (image *Image) UpdateFeatures(features uint64, enabled bool) error {
 	if enabled {
 		cEnabled = 1
 	}
-	return getError(C.rbd_update_features(image.image, C.uint64_t(features), cEnabled))
+	t := image.startOp("rbd_update_features")
+	return t.DoneErr(getError(C.rbd_update_features(image.image, C.uint64_t(features), cEnabled)))
 }
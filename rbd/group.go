@@ -24,8 +24,9 @@ func GroupCreate(ioctx *rados.IOContext, name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := startOp(ioctx, "rbd_group_create", "")
 	ret := C.rbd_group_create(cephIoctx(ioctx), cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupRemove is used to remove an image group.
@@ -37,8 +38,9 @@ func GroupRemove(ioctx *rados.IOContext, name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := startOp(ioctx, "rbd_group_remove", "")
 	ret := C.rbd_group_remove(cephIoctx(ioctx), cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupRename will rename an existing image group.
@@ -53,8 +55,9 @@ func GroupRename(ioctx *rados.IOContext, src, dest string) error {
 	cDest := C.CString(dest)
 	defer C.free(unsafe.Pointer(cDest))
 
+	t := startOp(ioctx, "rbd_group_rename", "")
 	ret := C.rbd_group_rename(cephIoctx(ioctx), cSrc, cDest)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupList returns a slice of image group names.
@@ -71,11 +74,12 @@ func GroupList(ioctx *rados.IOContext) ([]string, error) {
 	retry.WithSizes(1024, 262144, func(size int) retry.Hint {
 		cSize := C.size_t(size)
 		buf = make([]byte, cSize)
+		t := startOp(ioctx, "rbd_group_list", "")
 		ret = C.rbd_group_list(
 			cephIoctx(ioctx),
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 
@@ -106,12 +110,13 @@ func GroupImageAdd(groupIoctx *rados.IOContext, groupName string,
 	cImageName := C.CString(imageName)
 	defer C.free(unsafe.Pointer(cImageName))
 
+	t := startOp(imageIoctx, "rbd_group_image_add", imageName)
 	ret := C.rbd_group_image_add(
 		cephIoctx(groupIoctx),
 		cGroupName,
 		cephIoctx(imageIoctx),
 		cImageName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupImageRemove will remove the specified image from the named group.
@@ -131,12 +136,13 @@ func GroupImageRemove(groupIoctx *rados.IOContext, groupName string,
 	cImageName := C.CString(imageName)
 	defer C.free(unsafe.Pointer(cImageName))
 
+	t := startOp(imageIoctx, "rbd_group_image_remove", imageName)
 	ret := C.rbd_group_image_remove(
 		cephIoctx(groupIoctx),
 		cGroupName,
 		cephIoctx(imageIoctx),
 		cImageName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupImageRemoveByID will remove the specified image from the named group.
@@ -156,12 +162,13 @@ func GroupImageRemoveByID(groupIoctx *rados.IOContext, groupName string,
 	cid := C.CString(imageID)
 	defer C.free(unsafe.Pointer(cid))
 
+	t := startOp(imageIoctx, "rbd_group_image_remove_by_id", "")
 	ret := C.rbd_group_image_remove_by_id(
 		cephIoctx(groupIoctx),
 		cGroupName,
 		cephIoctx(imageIoctx),
 		cid)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupImageState indicates an image's state in a group.
@@ -203,13 +210,14 @@ func GroupImageList(ioctx *rados.IOContext, name string) ([]GroupImageInfo, erro
 	retry.WithSizes(1024, 262144, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		cImages = make([]C.rbd_group_image_info_t, cSize)
+		t := startOp(ioctx, "rbd_group_image_list", "")
 		ret := C.rbd_group_image_list(
 			cephIoctx(ioctx),
 			cName,
 			(*C.rbd_group_image_info_t)(unsafe.Pointer(&cImages[0])),
 			C.sizeof_rbd_group_image_info_t,
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 
@@ -250,11 +258,12 @@ func (image *Image) GetGroup() (GroupInfo, error) {
 	}
 
 	var cgi C.rbd_group_info_t
+	t := image.startOp("rbd_get_group")
 	ret := C.rbd_get_group(
 		image.image,
 		&cgi,
 		C.sizeof_rbd_group_info_t)
-	if err := getErrorIfNegative(ret); err != nil {
+	if err := t.DoneErr(getErrorIfNegative(ret)); err != nil {
 		return GroupInfo{}, err
 	}
 
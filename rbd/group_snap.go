@@ -39,8 +39,9 @@ func GroupSnapCreate(ioctx *rados.IOContext, group, snap string) error {
 	cSnapName := C.CString(snap)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := startOp(ioctx, "rbd_group_snap_create", "")
 	ret := C.rbd_group_snap_create(cephIoctx(ioctx), cGroupName, cSnapName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupSnapRemove removes an existing group snapshot.
@@ -56,8 +57,9 @@ func GroupSnapRemove(ioctx *rados.IOContext, group, snap string) error {
 	cSnapName := C.CString(snap)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := startOp(ioctx, "rbd_group_snap_remove", "")
 	ret := C.rbd_group_snap_remove(cephIoctx(ioctx), cGroupName, cSnapName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupSnapRename will rename an existing group snapshot.
@@ -76,9 +78,10 @@ func GroupSnapRename(ioctx *rados.IOContext, group, src, dest string) error {
 	cNewSnapName := C.CString(dest)
 	defer C.free(unsafe.Pointer(cNewSnapName))
 
+	t := startOp(ioctx, "rbd_group_snap_rename", "")
 	ret := C.rbd_group_snap_rename(
 		cephIoctx(ioctx), cGroupName, cOldSnapName, cNewSnapName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupSnapState represents the state of a group snapshot in GroupSnapInfo.
@@ -133,13 +136,14 @@ func GroupSnapList(ioctx *rados.IOContext, group string) ([]GroupSnapInfo, error
 	retry.WithSizes(1024, 262144, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		cSnaps = make([]C.rbd_group_snap_info_t, cSize)
+		t := startOp(ioctx, "rbd_group_snap_list", "")
 		ret := C.rbd_group_snap_list(
 			cephIoctx(ioctx),
 			cGroupName,
 			(*C.rbd_group_snap_info_t)(unsafe.Pointer(&cSnaps[0])),
 			C.sizeof_rbd_group_snap_info_t,
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 
@@ -175,8 +179,9 @@ func GroupSnapRollback(ioctx *rados.IOContext, group, snap string) error {
 	cSnapName := C.CString(snap)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := startOp(ioctx, "rbd_group_snap_rollback", "")
 	ret := C.rbd_group_snap_rollback(cephIoctx(ioctx), cGroupName, cSnapName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GroupSnapRollbackCallback defines the function signature needed for the
@@ -219,11 +224,13 @@ func GroupSnapRollbackWithProgress(
 	cbIndex := groupSnapRollbackCallbacks.Add(ctx)
 	defer groupSnapRollbackCallbacks.Remove(cbIndex)
 
+	t := startOp(ioctx, "rbd_group_snap_rollback_with_progress", "")
 	ret := C.wrap_rbd_group_snap_rollback_with_progress(
 		cephIoctx(ioctx),
 		cGroupName,
 		cSnapName,
 		C.uintptr_t(cbIndex))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
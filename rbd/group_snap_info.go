@@ -63,8 +63,6 @@ static inline int rbd_group_snap_get_info_cleanup_dlsym(void *fn,
 import "C"
 
 import (
-	"fmt"
-	"sync"
 	"unsafe"
 
 	"github.com/ceph/go-ceph/internal/cutil"
@@ -75,13 +73,8 @@ import (
 type imgSnapInfoArray [cutil.MaxIdx]C._rbd_group_image_snap_info_t
 
 var (
-	rbdGroupGetSnapInfoOnce sync.Once
-	rbdGroupGetSnapInfo     unsafe.Pointer
-	rbdGroupGetSnapInfoErr  error
-
-	rbdGroupSnapGetInfoCleanupOnce sync.Once
-	rbdGroupSnapGetInfoCleanup     unsafe.Pointer
-	rbdGroupSnapGetInfoCleanupErr  error
+	rbdGroupSnapGetInfo        = dlsym.NewSymbol("rbd_group_snap_get_info")
+	rbdGroupSnapGetInfoCleanup = dlsym.NewSymbol("rbd_group_snap_get_info_cleanup")
 )
 
 // GroupSnapGetInfo returns a slice of RBD image snapshots that are part of a
@@ -94,20 +87,13 @@ var (
 //	                        const char *snap_name,
 //	                        rbd_group_snap_info2_t *snaps);
 func GroupSnapGetInfo(ioctx *rados.IOContext, group, snap string) (GroupSnapInfo, error) {
-	rbdGroupGetSnapInfoOnce.Do(func() {
-		rbdGroupGetSnapInfo, rbdGroupGetSnapInfoErr = dlsym.LookupSymbol("rbd_group_snap_get_info")
-	})
-
-	if rbdGroupGetSnapInfoErr != nil {
-		return GroupSnapInfo{}, fmt.Errorf("%w: %w", ErrNotImplemented, rbdGroupGetSnapInfoErr)
+	getInfo, err := rbdGroupSnapGetInfo.Pointer()
+	if err != nil {
+		return GroupSnapInfo{}, err
 	}
-
-	rbdGroupSnapGetInfoCleanupOnce.Do(func() {
-		rbdGroupSnapGetInfoCleanup, rbdGroupSnapGetInfoCleanupErr = dlsym.LookupSymbol("rbd_group_snap_get_info_cleanup")
-	})
-
-	if rbdGroupSnapGetInfoCleanupErr != nil {
-		return GroupSnapInfo{}, fmt.Errorf("%w: %w", ErrNotImplemented, rbdGroupSnapGetInfoCleanupErr)
+	getInfoCleanup, err := rbdGroupSnapGetInfoCleanup.Pointer()
+	if err != nil {
+		return GroupSnapInfo{}, err
 	}
 
 	cGroupName := C.CString(group)
@@ -117,13 +103,14 @@ func GroupSnapGetInfo(ioctx *rados.IOContext, group, snap string) (GroupSnapInfo
 
 	cSnapInfo := C._rbd_group_snap_info2_t{}
 
+	t := startOp(ioctx, "rbd_group_snap_get_info", "")
 	ret := C.rbd_group_snap_get_info_dlsym(
-		rbdGroupGetSnapInfo,
+		getInfo,
 		cephIoctx(ioctx),
 		cGroupName,
 		cSnapName,
 		&cSnapInfo)
-	err := getErrorIfNegative(ret)
+	err = t.DoneErr(getErrorIfNegative(ret))
 	if err != nil {
 		return GroupSnapInfo{}, err
 	}
@@ -147,6 +134,6 @@ func GroupSnapGetInfo(ioctx *rados.IOContext, group, snap string) (GroupSnapInfo
 	}
 
 	// free C memory allocated by C.rbd_group_snap_get_info call
-	C.rbd_group_snap_get_info_cleanup_dlsym(rbdGroupSnapGetInfoCleanup, &cSnapInfo)
+	C.rbd_group_snap_get_info_cleanup_dlsym(getInfoCleanup, &cSnapInfo)
 	return snapInfo, nil
 }
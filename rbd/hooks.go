@@ -0,0 +1,26 @@
+package rbd
+
+import (
+	"github.com/ceph/go-ceph/internal/hooks"
+)
+
+// startOp returns a tracker for an operation, or nil if no hook is set.
+// target is the *rados.IOContext or *rados.Conn the operation works on, the
+// global hook is used if it is nil. image is the name of the image the
+// operation works on, if any.
+func startOp(target interface{}, name, image string) *hooks.Tracker {
+	h, op := hooks.Lookup(target)
+	h = hooks.Select(h)
+	if h == nil {
+		return nil
+	}
+	op.Name = name
+	op.Image = image
+	return hooks.Start(h, op)
+}
+
+// startOp returns a tracker for an operation on the image, or nil if no hook
+// is set.
+func (image *Image) startOp(name string) *hooks.Tracker {
+	return startOp(image.ioctx, name, image.name)
+}
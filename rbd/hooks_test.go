@@ -0,0 +1,73 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/common/hooks"
+)
+
+type recordingHook struct {
+	ops []hooks.Op
+}
+
+func (r *recordingHook) OpDone(op *hooks.Op) {
+	r.ops = append(r.ops, *op)
+}
+
+func TestHooks(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	h := &recordingHook{}
+	conn.SetHook(h)
+	defer conn.SetHook(nil)
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	err = img.Resize(testImageSize * 2)
+	assert.NoError(t, err)
+	snap, err := img.CreateSnapshot("hooked")
+	assert.NoError(t, err)
+	err = snap.Remove()
+	assert.NoError(t, err)
+	err = img.Close()
+	assert.NoError(t, err)
+	err = RemoveImage(ioctx, name)
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, op := range h.ops {
+		names = append(names, op.Name)
+		assert.Equal(t, poolname, op.Pool)
+		assert.Equal(t, name, op.Image)
+		assert.NoError(t, op.Err)
+	}
+	assert.Equal(t, []string{
+		"rbd_create4",
+		"rbd_open",
+		"rbd_resize",
+		"rbd_snap_create",
+		"rbd_snap_remove",
+		"rbd_close",
+		"rbd_remove",
+	}, names)
+}
@@ -0,0 +1,342 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+const (
+	// importBlockSize is the granularity at which zeros are skipped when
+	// writing to a newly created image.
+	importBlockSize = 4096
+	// importFeaturesMask contains the features that can not be requested
+	// when creating an image.
+	importFeaturesMask = FeatureOperations | FeatureMigrating | FeatureDataPool
+)
+
+// ImportImageOptions control how ImportImage creates the image.
+type ImportImageOptions struct {
+	// Format of the stream. If unset, the format is detected from the
+	// stream.
+	Format ExportFormat
+	// ImageOptions are used to create the image. Options that are not set
+	// are taken from the header of a v2 stream, if any.
+	ImageOptions *ImageOptions
+}
+
+// ImportImage creates the image name from a stream written by ExportImage
+// or by the "rbd export" command.
+//
+// For ExportFormatV2 streams the image is created with the order, features
+// and striping of the exported image, and its metadata and snapshots,
+// including their protection status, are recreated. Regions of the stream
+// that only contain zeros are not written to the new image.
+//
+// If the import fails after the image has been created, the partially
+// imported image is left in place.
+func ImportImage(ioctx *rados.IOContext, name string, r io.Reader,
+	opts *ImportImageOptions) error {
+
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if name == "" {
+		return ErrNoName
+	}
+	if opts == nil {
+		opts = &ImportImageOptions{}
+	}
+	d := newExportDecoder(r)
+	format := opts.Format
+	if format == 0 {
+		format = ExportFormatV1
+		if d.hasPrefix(imageBannerV2) {
+			format = ExportFormatV2
+		}
+	}
+	switch format {
+	case ExportFormatV1:
+		return importRaw(ioctx, name, d, opts.ImageOptions)
+	case ExportFormatV2:
+		return importV2(ioctx, name, d, opts.ImageOptions)
+	}
+	return fmt.Errorf("%w: unknown format %d", ErrInvalidExport, format)
+}
+
+// importImageOptions returns a copy of src, with the options from the header
+// of a v2 stream added if they are not set in src.
+func importImageOptions(src *ImageOptions, h *exportImageHeader) (*ImageOptions, error) {
+	rio := NewRbdImageOptions()
+	if src != nil {
+		if err := copyImageOptions(rio, src); err != nil {
+			rio.Destroy()
+			return nil, err
+		}
+	}
+	if h == nil {
+		return rio, nil
+	}
+	for _, o := range []struct {
+		option ImageOption
+		value  uint64
+	}{
+		{ImageOptionOrder, h.order},
+		{ImageOptionFeatures, h.features &^ importFeaturesMask},
+		{ImageOptionStripeUnit, h.stripeUnit},
+		{ImageOptionStripeCount, h.stripeCount},
+	} {
+		set, err := rio.IsSet(o.option)
+		if err == nil && !set {
+			err = rio.SetUint64(o.option, o.value)
+		}
+		if err != nil {
+			rio.Destroy()
+			return nil, err
+		}
+	}
+	return rio, nil
+}
+
+func copyImageOptions(dst, src *ImageOptions) error {
+	for _, o := range []ImageOption{
+		ImageOptionFormat,
+		ImageOptionFeatures,
+		ImageOptionOrder,
+		ImageOptionStripeUnit,
+		ImageOptionStripeCount,
+		ImageOptionJournalOrder,
+		ImageOptionJournalSplayWidth,
+		ImageOptionFeaturesSet,
+		ImageOptionFeaturesClear,
+	} {
+		if set, err := src.IsSet(o); err != nil || !set {
+			continue
+		}
+		v, err := src.GetUint64(o)
+		if err == nil {
+			err = dst.SetUint64(o, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, o := range []ImageOption{
+		ImageOptionJournalPool,
+		ImageOptionDataPool,
+	} {
+		if set, err := src.IsSet(o); err != nil || !set {
+			continue
+		}
+		v, err := src.GetString(o)
+		if err == nil {
+			err = dst.SetString(o, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSparse writes data to the newly created image dst, skipping blocks
+// that only contain zeros.
+func writeSparse(dst *Image, off uint64, data []byte) error {
+	for len(data) > 0 {
+		// find the next run of blocks that are not zero
+		skip := 0
+		for skip < len(data) {
+			n := min(len(data)-skip, importBlockSize)
+			if !isZero(data[skip : skip+n]) {
+				break
+			}
+			skip += n
+		}
+		end := skip
+		for end < len(data) {
+			n := min(len(data)-end, importBlockSize)
+			if isZero(data[end : end+n]) {
+				break
+			}
+			end += n
+		}
+		if end > skip {
+			if _, err := dst.WriteAt(data[skip:end], int64(off)+int64(skip)); err != nil {
+				return err
+			}
+		}
+		off += uint64(end)
+		data = data[end:]
+	}
+	return nil
+}
+
+// importDiff applies the records of a diff to dst. If dst has just been
+// created, zero records and zeros in write records are skipped.
+func importDiff(d *exportDecoder, dst *Image, format ExportFormat, fresh bool) error {
+	for {
+		rec, err := d.diffRecord(format)
+		if err != nil {
+			return err
+		}
+		switch rec.tag {
+		case diffTagEnd:
+			return nil
+		case diffTagZero:
+			if !fresh {
+				if _, err := dst.Discard(rec.offset, rec.length); err != nil {
+					return err
+				}
+			}
+		case diffTagWrite:
+			err = d.diffData(rec, func(off uint64, data []byte) error {
+				if fresh {
+					return writeSparse(dst, off, data)
+				}
+				_, err := dst.WriteAt(data, int64(off))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func importRaw(ioctx *rados.IOContext, name string, d *exportDecoder,
+	opts *ImageOptions) error {
+
+	rio, err := importImageOptions(opts, nil)
+	if err != nil {
+		return err
+	}
+	defer rio.Destroy()
+	if err := CreateImage(ioctx, name, 0, rio); err != nil {
+		return err
+	}
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = img.Close() }()
+
+	// the size of the stream is not known, so grow the image as needed
+	size := uint64(0)
+	buf := make([]byte, maxDiffChunk)
+	off := uint64(0)
+	for {
+		n, err := io.ReadFull(d.r, buf)
+		if n > 0 {
+			if off+uint64(n) > size {
+				size = max(2*size, off+uint64(n))
+				if err := img.Resize(size); err != nil {
+					return err
+				}
+			}
+			if err := writeSparse(img, off, buf[:n]); err != nil {
+				return err
+			}
+			off += uint64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if size != off {
+		return img.Resize(off)
+	}
+	return nil
+}
+
+func importV2(ioctx *rados.IOContext, name string, d *exportDecoder,
+	opts *ImageOptions) error {
+
+	h, err := d.imageHeader()
+	if err != nil {
+		return err
+	}
+	if err := d.banner(imageDiffsBannerV2); err != nil {
+		return err
+	}
+	count, err := d.u64()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: no diffs", ErrInvalidExport)
+	}
+	// the image is created at the size of the first diff
+	dh, err := d.diffHeader(ExportFormatV2)
+	if err != nil {
+		return err
+	}
+	if dh.fromSnap != "" {
+		return fmt.Errorf("%w: first diff starts at snapshot %q",
+			ErrInvalidExport, dh.fromSnap)
+	}
+
+	rio, err := importImageOptions(opts, h)
+	if err != nil {
+		return err
+	}
+	defer rio.Destroy()
+	if err := CreateImage(ioctx, name, dh.size, rio); err != nil {
+		return err
+	}
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = img.Close() }()
+
+	for k, v := range h.metadata {
+		if err := img.SetMetadata(k, v); err != nil {
+			return err
+		}
+	}
+	size := dh.size
+	for i := uint64(0); i < count; i++ {
+		if i > 0 {
+			fromSnap := dh.toSnap
+			if dh, err = d.diffHeader(ExportFormatV2); err != nil {
+				return err
+			}
+			if dh.fromSnap != fromSnap {
+				return fmt.Errorf("%w: diff starts at snapshot %q, expected %q",
+					ErrInvalidExport, dh.fromSnap, fromSnap)
+			}
+		}
+		if dh.toSnap == "" && i != count-1 {
+			return fmt.Errorf("%w: diff of the image is not the last diff",
+				ErrInvalidExport)
+		}
+		if dh.size != size {
+			if err := img.Resize(dh.size); err != nil {
+				return err
+			}
+			size = dh.size
+		}
+		if err := importDiff(d, img, ExportFormatV2, i == 0); err != nil {
+			return err
+		}
+		if dh.toSnap == "" {
+			continue
+		}
+		snap, err := img.CreateSnapshot(dh.toSnap)
+		if err != nil {
+			return err
+		}
+		if dh.protected {
+			if err := snap.Protect(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// ImageFileFormat is the format of an image file imported by ImportFile.
+type ImageFileFormat int
+
+const (
+	// ImageFileRaw is a file containing the raw data of a disk.
+	ImageFileRaw = ImageFileFormat(1)
+	// ImageFileQcow2 is a qcow2 file, as used by QEMU.
+	ImageFileQcow2 = ImageFileFormat(2)
+)
+
+const (
+	// defaultImportFileWorkers is the number of concurrent writes used by
+	// ImportFile if no number is configured.
+	defaultImportFileWorkers = 4
+	// maxImportFileExtent is the largest extent read and written at once.
+	maxImportFileExtent = 4 * 1024 * 1024
+)
+
+// ImportFileOptions control how ImportFile imports an image file.
+type ImportFileOptions struct {
+	// Format of the file. If unset, qcow2 files are detected by their
+	// header and other files are imported as raw files.
+	Format ImageFileFormat
+	// ImageOptions are used to create the image.
+	ImageOptions *ImageOptions
+	// Workers is the number of extents that are written concurrently.
+	// Defaults to 4.
+	Workers int
+	// Progress is called after every extent, if set, with the number of
+	// bytes imported so far and the total number of bytes to import. Calls
+	// are not made concurrently.
+	Progress DiffProgressCallback
+}
+
+// ImportFile creates the image name from the local raw or qcow2 image file
+// at path. The image has the size of the virtual disk of the file.
+//
+// Only the allocated clusters of qcow2 files are read, compressed clusters
+// are decompressed. qcow2 files with a backing file, encryption or other
+// unsupported features are refused with ErrUnsupportedImageFile before the
+// image is created. Internal qcow2 snapshots are not imported. Blocks that
+// only contain zeros are not written to the new image.
+//
+// If the import fails after the image has been created, the partially
+// imported image is left in place.
+func ImportFile(ioctx *rados.IOContext, name, path string, opts *ImportFileOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return importFile(ioctx, name, f, st.Size(), opts)
+}
+
+func importFile(ioctx *rados.IOContext, name string, r io.ReaderAt,
+	fileSize int64, opts *ImportFileOptions) error {
+
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if name == "" {
+		return ErrNoName
+	}
+	if opts == nil {
+		opts = &ImportFileOptions{}
+	}
+	format := opts.Format
+	if format == 0 {
+		format = ImageFileRaw
+		if isQcow2(r) {
+			format = ImageFileQcow2
+		}
+	}
+
+	var (
+		size        uint64
+		clusterSize uint64
+		extents     []fileExtent
+	)
+	switch format {
+	case ImageFileRaw:
+		size = uint64(fileSize)
+		extents = rawFileExtents(size, maxImportFileExtent)
+	case ImageFileQcow2:
+		h, err := readQcow2Header(r, fileSize)
+		if err != nil {
+			return err
+		}
+		size, clusterSize = h.size, h.clusterSize()
+		extents, err = qcow2Extents(r, fileSize, h, maxImportFileExtent)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown format %d", ErrUnsupportedImageFile, format)
+	}
+
+	rio, err := importImageOptions(opts.ImageOptions, nil)
+	if err != nil {
+		return err
+	}
+	defer rio.Destroy()
+	if err := CreateImage(ioctx, name, size, rio); err != nil {
+		return err
+	}
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = img.Close() }()
+
+	total := uint64(0)
+	for _, e := range extents {
+		total += e.length
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultImportFileWorkers
+	}
+	var (
+		mutex sync.Mutex
+		done  uint64
+	)
+	err = runBounded(len(extents), workers, func(i int) error {
+		e := extents[i]
+		data, err := readFileExtent(r, e, clusterSize)
+		if err != nil {
+			return err
+		}
+		if err := writeSparse(img, e.offset, data); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			mutex.Lock()
+			defer mutex.Unlock()
+			done += e.length
+			opts.Progress(done, total)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return img.Flush()
+}
+
+// rawFileExtents splits a raw file into extents of at most maxLength bytes.
+func rawFileExtents(size, maxLength uint64) []fileExtent {
+	var extents []fileExtent
+	for off := uint64(0); off < size; off += maxLength {
+		length := min(maxLength, size-off)
+		extents = append(extents, fileExtent{off, length, int64(off), 0})
+	}
+	return extents
+}
@@ -0,0 +1,160 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFile(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	dir := t.TempDir()
+	writeFile := func(t *testing.T, name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+	// verify checks the content of the image and returns its allocated
+	// extents
+	verify := func(t *testing.T, name string, content []byte) []DiffExtent {
+		img, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img.Close()) }()
+		size, err := img.GetSize()
+		require.NoError(t, err)
+		require.EqualValues(t, len(content), size)
+		data := make([]byte, size)
+		_, err = img.ReadAt(data, 0)
+		assert.NoError(t, err)
+		assert.True(t, bytes.Equal(content, data))
+
+		var extents []DiffExtent
+		err = img.DiffIterate(DiffIterateConfig{
+			Offset: 0,
+			Length: size,
+			Callback: func(o, l uint64, e int, _ interface{}) int {
+				extents = append(extents, DiffExtent{o, l, e != 0})
+				return 0
+			},
+		})
+		assert.NoError(t, err)
+		return extents
+	}
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t, options.SetUint64(ImageOptionOrder, 16))
+
+	t.Run("qcow2", func(t *testing.T) {
+		q := newTestQcow2()
+		path := writeFile(t, "image.qcow2", q.build(t))
+		name := GetUUID()
+		var calls int
+		var done, total uint64
+		err := ImportFile(ioctx, name, path, &ImportFileOptions{
+			ImageOptions: options,
+			Workers:      2,
+			Progress: func(d, t uint64) {
+				calls++
+				done, total = d, t
+			},
+		})
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, total, done)
+		assert.EqualValues(t, 3*(1<<16)+1000, total)
+
+		// only the allocated clusters are written, with 64 KiB objects
+		extents := verify(t, name, q.content())
+		assert.NotEmpty(t, extents)
+		for _, e := range extents {
+			c := e.Offset >> 16
+			assert.Contains(t, []uint64{0, 1, 5, 16}, c)
+		}
+	})
+
+	t.Run("qemuImg", func(t *testing.T) {
+		// files written by qemu-img, uncompressed and compressed, in the
+		// current and the old (version 2) format
+		content := testQcow2Disk(1<<16, 6)
+		raw := writeFile(t, "source.raw", content)
+		for name, args := range map[string][]string{
+			"plain":      {"-o", "cluster_size=65536"},
+			"compressed": {"-c", "-o", "cluster_size=4096"},
+			"compat":     {"-o", "compat=0.10"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(dir, name+".qcow2")
+				qemuImg(t, append(append([]string{
+					"convert", "-f", "raw", "-O", "qcow2"}, args...),
+					raw, path)...)
+				imported := GetUUID()
+				err := ImportFile(ioctx, imported, path,
+					&ImportFileOptions{ImageOptions: options})
+				require.NoError(t, err)
+				defer func() { assert.NoError(t, RemoveImage(ioctx, imported)) }()
+				verify(t, imported, content)
+			})
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		content := make([]byte, 1<<20)
+		copy(content[4096:], bytes.Repeat([]byte("raw"), 100))
+		copy(content[1<<19:], bytes.Repeat([]byte("data"), 100))
+		path := writeFile(t, "image.raw", content)
+		name := GetUUID()
+		err := ImportFile(ioctx, name, path, &ImportFileOptions{ImageOptions: options})
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		// zero blocks are skipped, only two objects are written
+		extents := verify(t, name, content)
+		assert.Len(t, extents, 2)
+	})
+
+	t.Run("rawQcow2", func(t *testing.T) {
+		// forcing the raw format imports a qcow2 file as is
+		file := newTestQcow2().build(t)
+		path := writeFile(t, "forced.qcow2", file)
+		name := GetUUID()
+		err := ImportFile(ioctx, name, path, &ImportFileOptions{Format: ImageFileRaw})
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+		verify(t, name, file)
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		q := newTestQcow2()
+		q.backing = "base.qcow2"
+		path := writeFile(t, "backing.qcow2", q.build(t))
+		name := GetUUID()
+		err := ImportFile(ioctx, name, path, nil)
+		assert.ErrorIs(t, err, ErrUnsupportedImageFile)
+		// the image is not created
+		_, err = OpenImage(ioctx, name, NoSnapshot)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		err = ImportFile(ioctx, name, filepath.Join(dir, "missing"), nil)
+		assert.Error(t, err)
+		err = ImportFile(nil, name, path, nil)
+		assert.ErrorIs(t, err, ErrNoIOContext)
+	})
+}
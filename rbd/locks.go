@@ -33,7 +33,9 @@ func (image *Image) LockAcquire(lockMode LockMode) error {
 		return err
 	}
 
+	t := image.startOp("rbd_lock_acquire")
 	ret := C.rbd_lock_acquire(image.image, C.rbd_lock_mode_t(lockMode))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -52,7 +54,9 @@ func (image *Image) LockBreak(lockMode LockMode, lockOwner string) error {
 	cLockOwner := C.CString(lockOwner)
 	defer C.free(unsafe.Pointer(cLockOwner))
 
+	t := image.startOp("rbd_lock_break")
 	ret := C.rbd_lock_break(image.image, C.rbd_lock_mode_t(lockMode), cLockOwner)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -82,7 +86,9 @@ func (image *Image) LockGetOwners() ([]*LockOwner, error) {
 	)
 
 	for {
+		t := image.startOp("rbd_lock_get_owners")
 		ret := C.rbd_lock_get_owners(image.image, (*C.rbd_lock_mode_t)(&lockMode), &cLockOwners[0], &maxLockOwners)
+		t.Done(0, getErrorIfNegative(ret))
 		if ret >= 0 {
 			break
 		} else if ret == -C.ENOENT {
@@ -116,7 +122,9 @@ func (image *Image) LockIsExclusiveOwner() (bool, error) {
 
 	cIsOwner := C.int(0)
 
+	t := image.startOp("rbd_is_exclusive_lock_owner")
 	ret := C.rbd_is_exclusive_lock_owner(image.image, &cIsOwner)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return false, getError(ret)
 	}
@@ -134,7 +142,9 @@ func (image *Image) LockRelease() error {
 		return err
 	}
 
+	t := image.startOp("rbd_lock_release")
 	ret := C.rbd_lock_release(image.image)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -34,9 +34,10 @@ func (image *Image) GetMetadata(key string) (string, error) {
 		buf = make([]byte, csize)
 		// rbd_metadata_get is a bit quirky and *does not* update the size
 		// value if the size passed in >= the needed size.
+		t := image.startOp("rbd_metadata_get")
 		ret := C.rbd_metadata_get(
 			image.image, cKey, (*C.char)(unsafe.Pointer(&buf[0])), &csize)
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.Size(int(csize)).If(err == errRange)
 	})
 	if err != nil {
@@ -60,7 +61,9 @@ func (image *Image) SetMetadata(key string, value string) error {
 	defer C.free(unsafe.Pointer(cKey))
 	defer C.free(unsafe.Pointer(cValue))
 
+	t := image.startOp("rbd_metadata_set")
 	ret := C.rbd_metadata_set(image.image, cKey, cValue)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return getError(ret)
 	}
@@ -81,7 +84,9 @@ func (image *Image) RemoveMetadata(key string) error {
 	cKey := C.CString(key)
 	defer C.free(unsafe.Pointer(cKey))
 
+	t := image.startOp("rbd_metadata_remove")
 	ret := C.rbd_metadata_remove(image.image, cKey)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return getError(ret)
 	}
@@ -115,6 +120,7 @@ func (image *Image) ListMetadata() (map[string]string, error) {
 		// the rbd_metadata_list function can use a start point and a limit.
 		// we do not use it and prefer our retry helper and just allocating
 		// buffers large enough to take all the keys and values
+		t := image.startOp("rbd_metadata_list")
 		ret := C.rbd_metadata_list(
 			image.image,
 			(*C.char)(unsafe.Pointer(&empty[0])), // always start at the beginning (no paging)
@@ -123,6 +129,7 @@ func (image *Image) ListMetadata() (map[string]string, error) {
 			&keysSize,
 			(*C.char)(unsafe.Pointer(&valsbuf[0])),
 			&valsSize)
+		t.Done(0, getErrorIfNegative(ret))
 
 		err = getError(ret)
 		nextSize := valsSize
@@ -1,20 +1,45 @@
-//go:build !(octopus || nautilus)
-// +build !octopus,!nautilus
+//go:build !nautilus
+// +build !nautilus
 
 package rbd
 
-// #cgo LDFLAGS: -lrbd
-// #include <stdlib.h>
-// #include <rados/librados.h>
-// #include <rbd/librbd.h>
+/*
+#cgo LDFLAGS: -lrbd
+#include <stdlib.h>
+#include <rados/librados.h>
+#include <rbd/librbd.h>
+
+// rbd_migration_prepare_import_fn matches the rbd_migration_prepare_import
+// function signature.
+typedef int(*rbd_migration_prepare_import_fn)(const char *source_spec,
+	rados_ioctx_t dest_ioctx, const char *dest_image_name,
+	rbd_image_options_t opts);
+
+// rbd_migration_prepare_import_dlsym take *fn as
+// rbd_migration_prepare_import_fn and calls the dynamically loaded
+// rbd_migration_prepare_import function passed as 1st argument.
+static inline int rbd_migration_prepare_import_dlsym(void *fn,
+	const char *source_spec, rados_ioctx_t dest_ioctx,
+	const char *dest_image_name, rbd_image_options_t opts) {
+	// cast function pointer fn to rbd_migration_prepare_import and call the
+	// function
+	return ((rbd_migration_prepare_import_fn) fn)(source_spec, dest_ioctx,
+		dest_image_name, opts);
+}
+*/
 import "C"
 
 import (
 	"unsafe"
 
+	"github.com/ceph/go-ceph/internal/dlsym"
 	"github.com/ceph/go-ceph/rados"
 )
 
+// Ceph pacific introduced rbd_migration_prepare_import(). The function is
+// resolved at runtime, so that older versions of librbd can be used.
+var rbdMigrationPrepareImport = dlsym.NewSymbol("rbd_migration_prepare_import")
+
 // MigrationImageState denotes the current migration status of a given image.
 type MigrationImageState int
 
@@ -76,12 +101,14 @@ func MigrationPrepare(ioctx *rados.IOContext, sourceImageName string, destIoctx
 		C.free(unsafe.Pointer(cDestImageName))
 	}()
 
+	t := startOp(ioctx, "rbd_migration_prepare", sourceImageName)
 	ret := C.rbd_migration_prepare(
 		cephIoctx(ioctx),
 		cSourceImageName,
 		cephIoctx(destIoctx),
 		cDestImageName,
 		C.rbd_image_options_t(rio.options))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -96,6 +123,11 @@ func MigrationPrepare(ioctx *rados.IOContext, sourceImageName string, destIoctx
 //	                                 const char *dest_image_name,
 //	                                 rbd_image_options_t opts);
 func MigrationPrepareImport(sourceSpec string, ioctx *rados.IOContext, destImageName string, rio *ImageOptions) error {
+	fn, err := rbdMigrationPrepareImport.Pointer()
+	if err != nil {
+		return err
+	}
+
 	cSourceSpec := C.CString(sourceSpec)
 	cDestImageName := C.CString(destImageName)
 	defer func() {
@@ -103,11 +135,14 @@ func MigrationPrepareImport(sourceSpec string, ioctx *rados.IOContext, destImage
 		C.free(unsafe.Pointer(cDestImageName))
 	}()
 
-	ret := C.rbd_migration_prepare_import(
+	t := startOp(ioctx, "rbd_migration_prepare_import", destImageName)
+	ret := C.rbd_migration_prepare_import_dlsym(
+		fn,
 		cSourceSpec,
 		cephIoctx(ioctx),
 		cDestImageName,
 		C.rbd_image_options_t(rio.options))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -126,10 +161,11 @@ func MigrationExecute(ioctx *rados.IOContext, name string) error {
 		C.free(unsafe.Pointer(cName))
 	}()
 
+	t := startOp(ioctx, "rbd_migration_execute", name)
 	ret := C.rbd_migration_execute(
 		cephIoctx(ioctx),
 		cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MigrationCommit commits a migration after execution
@@ -146,10 +182,11 @@ func MigrationCommit(ioctx *rados.IOContext, name string) error {
 		C.free(unsafe.Pointer(cName))
 	}()
 
+	t := startOp(ioctx, "rbd_migration_commit", name)
 	ret := C.rbd_migration_commit(
 		cephIoctx(ioctx),
 		cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MigrationAbort aborts a migration in progress
@@ -166,10 +203,11 @@ func MigrationAbort(ioctx *rados.IOContext, name string) error {
 		C.free(unsafe.Pointer(cName))
 	}()
 
+	t := startOp(ioctx, "rbd_migration_abort", name)
 	ret := C.rbd_migration_abort(
 		cephIoctx(ioctx),
 		cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MigrationStatus retrieve status of a live migration
@@ -189,11 +227,13 @@ func MigrationStatus(ioctx *rados.IOContext, name string) (*MigrationImageStatus
 	}()
 
 	var status C.rbd_image_migration_status_t
+	t := startOp(ioctx, "rbd_migration_status", name)
 	ret := C.rbd_migration_status(
 		cephIoctx(ioctx),
 		cName,
 		&status,
 		C.sizeof_rbd_image_migration_status_t)
+	t.Done(0, getErrorIfNegative(ret))
 
 	if ret != 0 {
 		return nil, getError(ret)
@@ -0,0 +1,124 @@
+//go:build !nautilus && ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <errno.h>
+#include <stdlib.h>
+#include <rados/librados.h>
+#include <rbd/librbd.h>
+
+extern int progressCallback(uint64_t, uint64_t, uintptr_t);
+
+// inline wrappers to cast uintptr_t to void*
+static inline int wrap_rbd_migration_execute_with_progress(rados_ioctx_t io_ctx,
+		const char *image_name, uintptr_t arg) {
+	return rbd_migration_execute_with_progress(io_ctx, image_name,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+
+static inline int wrap_rbd_migration_commit_with_progress(rados_ioctx_t io_ctx,
+		const char *image_name, uintptr_t arg) {
+	return rbd_migration_commit_with_progress(io_ctx, image_name,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+
+static inline int wrap_rbd_migration_abort_with_progress(rados_ioctx_t io_ctx,
+		const char *image_name, uintptr_t arg) {
+	return rbd_migration_abort_with_progress(io_ctx, image_name,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+type migrationWithProgressFunc func(C.rados_ioctx_t, *C.char, C.uintptr_t) C.int
+
+func migrationWithProgress(opName string, fn migrationWithProgressFunc,
+	ioctx *rados.IOContext, name string, cb ProgressCallback, data interface{}) error {
+
+	// the provided callback must be a real function
+	if cb == nil {
+		return getError(-C.EINVAL)
+	}
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if name == "" {
+		return ErrNoName
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	cbIndex := addProgressCallback(cb, data)
+	defer progressCallbacks.Remove(cbIndex)
+
+	t := startOp(ioctx, opName, name)
+	return t.DoneErr(getError(fn(cephIoctx(ioctx), cName, C.uintptr_t(cbIndex))))
+}
+
+// MigrationExecuteWithProgress starts copying the image blocks from the
+// source image to the target image, like MigrationExecute, calling the
+// callback to report progress. Returning a non-zero value from the callback
+// interrupts the migration, which can be executed again later.
+//
+// Implements:
+//
+//	int rbd_migration_execute_with_progress(rados_ioctx_t io_ctx,
+//	                                        const char *image_name,
+//	                                        librbd_progress_fn_t cb,
+//	                                        void *cbdata);
+func MigrationExecuteWithProgress(ioctx *rados.IOContext, name string,
+	cb ProgressCallback, data interface{}) error {
+
+	return migrationWithProgress("rbd_migration_execute_with_progress",
+		func(io C.rados_ioctx_t, n *C.char, arg C.uintptr_t) C.int {
+			return C.wrap_rbd_migration_execute_with_progress(io, n, arg)
+		},
+		ioctx, name, cb, data)
+}
+
+// MigrationCommitWithProgress commits a migration after execution, like
+// MigrationCommit, calling the callback to report progress.
+//
+// Implements:
+//
+//	int rbd_migration_commit_with_progress(rados_ioctx_t io_ctx,
+//	                                       const char *image_name,
+//	                                       librbd_progress_fn_t cb,
+//	                                       void *cbdata);
+func MigrationCommitWithProgress(ioctx *rados.IOContext, name string,
+	cb ProgressCallback, data interface{}) error {
+
+	return migrationWithProgress("rbd_migration_commit_with_progress",
+		func(io C.rados_ioctx_t, n *C.char, arg C.uintptr_t) C.int {
+			return C.wrap_rbd_migration_commit_with_progress(io, n, arg)
+		},
+		ioctx, name, cb, data)
+}
+
+// MigrationAbortWithProgress aborts a migration in progress, like
+// MigrationAbort, calling the callback to report progress.
+//
+// Implements:
+//
+//	int rbd_migration_abort_with_progress(rados_ioctx_t io_ctx,
+//	                                      const char *image_name,
+//	                                      librbd_progress_fn_t cb,
+//	                                      void *cbdata);
+func MigrationAbortWithProgress(ioctx *rados.IOContext, name string,
+	cb ProgressCallback, data interface{}) error {
+
+	return migrationWithProgress("rbd_migration_abort_with_progress",
+		func(io C.rados_ioctx_t, n *C.char, arg C.uintptr_t) C.int {
+			return C.wrap_rbd_migration_abort_with_progress(io, n, arg)
+		},
+		ioctx, name, cb, data)
+}
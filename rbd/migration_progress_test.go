@@ -0,0 +1,80 @@
+//go:build !(octopus || nautilus) && ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationWithProgress(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	pool := GetUUID()
+	err := conn.MakePool(pool)
+	require.NoError(t, err)
+	defer conn.DeletePool(pool)
+
+	ioctx, err := conn.OpenIOContext(pool)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	calls := 0
+	cb := func(progress, total uint64, data interface{}) int {
+		calls++
+		assert.Equal(t, "data", data)
+		return 0
+	}
+
+	t.Run("executeCommit", func(t *testing.T) {
+		name := createAndWriteDataToImage(t, ioctx)
+		destImage := GetUUID()
+
+		err := MigrationPrepare(ioctx, name, ioctx, destImage, NewRbdImageOptions())
+		require.NoError(t, err)
+
+		calls = 0
+		err = MigrationExecuteWithProgress(ioctx, destImage, cb, "data")
+		require.NoError(t, err)
+		assert.Greater(t, calls, 0)
+
+		status, err := MigrationStatus(ioctx, destImage)
+		require.NoError(t, err)
+		assert.Equal(t, status.State, MigrationImageExecuted)
+
+		err = MigrationCommitWithProgress(ioctx, destImage, cb, "data")
+		require.NoError(t, err)
+
+		img, err := OpenImage(ioctx, destImage, NoSnapshot)
+		assert.NoError(t, err)
+		assert.NoError(t, img.Close())
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		name := createAndWriteDataToImage(t, ioctx)
+		destImage := GetUUID()
+
+		err := MigrationPrepare(ioctx, name, ioctx, destImage, NewRbdImageOptions())
+		require.NoError(t, err)
+
+		err = MigrationAbortWithProgress(ioctx, destImage, cb, "data")
+		require.NoError(t, err)
+
+		// the source image is restored by the abort
+		img, err := OpenImage(ioctx, name, NoSnapshot)
+		assert.NoError(t, err)
+		assert.NoError(t, img.Close())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := MigrationExecuteWithProgress(ioctx, GetUUID(), nil, nil)
+		assert.Error(t, err)
+		err = MigrationExecuteWithProgress(nil, GetUUID(), cb, nil)
+		assert.ErrorIs(t, err, ErrNoIOContext)
+		err = MigrationCommitWithProgress(ioctx, "", cb, nil)
+		assert.ErrorIs(t, err, ErrNoName)
+	})
+}
@@ -0,0 +1,406 @@
+//go:build !nautilus && ceph_preview
+
+package rbd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidMigrationSource is returned if a migration source spec is
+// rejected by validation or can not be parsed.
+var ErrInvalidMigrationSource = errors.New("invalid rbd migration source spec")
+
+const (
+	migrationSourceNative = "native"
+	migrationSourceRaw    = "raw"
+	migrationSourceQcow   = "qcow"
+
+	migrationStreamFile = "file"
+	migrationStreamHTTP = "http"
+	migrationStreamS3   = "s3"
+)
+
+// MigrationSource is implemented by the typed source specs of an import
+// migration: NativeMigrationSource, RawMigrationSource and
+// QcowMigrationSource.
+type MigrationSource interface {
+	// Validate returns an error if the source spec is incomplete or
+	// inconsistent.
+	Validate() error
+
+	migrationSourceType() string
+}
+
+// MigrationStream is implemented by the streams the raw and qcow migration
+// sources read from: FileMigrationStream, HTTPMigrationStream and
+// S3MigrationStream.
+type MigrationStream interface {
+	// Validate returns an error if the stream spec is incomplete.
+	Validate() error
+
+	migrationStreamType() string
+}
+
+// NativeMigrationSource migrates an image from an RBD image, possibly of a
+// different cluster. The pool is identified by PoolName or PoolID and the
+// snapshot, if any, by SnapName or SnapID.
+type NativeMigrationSource struct {
+	ClusterName   string  `json:"cluster_name,omitempty"`
+	ClientName    string  `json:"client_name,omitempty"`
+	PoolName      string  `json:"pool_name,omitempty"`
+	PoolID        *int64  `json:"pool_id,omitempty"`
+	PoolNamespace string  `json:"pool_namespace,omitempty"`
+	ImageName     string  `json:"image_name"`
+	ImageID       string  `json:"image_id,omitempty"`
+	SnapName      string  `json:"snap_name,omitempty"`
+	SnapID        *uint64 `json:"snap_id,omitempty"`
+}
+
+// RawMigrationSource migrates an image from a stream of raw image data.
+// The optional snapshots are streams of raw data of the image at the time
+// of the snapshots, oldest first.
+type RawMigrationSource struct {
+	Stream    MigrationStream
+	Snapshots []RawMigrationSnapshot
+}
+
+// RawMigrationSnapshot is a snapshot of a RawMigrationSource.
+type RawMigrationSnapshot struct {
+	Name   string
+	Stream MigrationStream
+}
+
+// QcowMigrationSource migrates an image from a stream of a QCOW or QCOW2
+// image. Snapshots are taken from the QCOW image itself.
+type QcowMigrationSource struct {
+	Stream MigrationStream
+}
+
+// FileMigrationStream reads the source from a local file. The file has to
+// be accessible to every client running the migration.
+type FileMigrationStream struct {
+	FilePath string `json:"file_path"`
+}
+
+// HTTPMigrationStream reads the source from an HTTP or HTTPS server.
+type HTTPMigrationStream struct {
+	URL string `json:"url"`
+}
+
+// S3MigrationStream reads the source from an object of an S3 server.
+type S3MigrationStream struct {
+	URL       string `json:"url"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+func invalidMigrationSource(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s",
+		ErrInvalidMigrationSource, fmt.Sprintf(format, args...))
+}
+
+func (NativeMigrationSource) migrationSourceType() string {
+	return migrationSourceNative
+}
+
+// Validate returns an error if the source spec is incomplete or
+// inconsistent.
+func (s NativeMigrationSource) Validate() error {
+	if s.PoolName == "" && s.PoolID == nil {
+		return invalidMigrationSource("native source requires a pool name or id")
+	}
+	if s.ImageName == "" {
+		return invalidMigrationSource("native source requires an image name")
+	}
+	return nil
+}
+
+// MarshalJSON encodes the source spec in the format librbd expects.
+func (s NativeMigrationSource) MarshalJSON() ([]byte, error) {
+	type spec NativeMigrationSource
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		spec
+	}{migrationSourceNative, spec(s)})
+}
+
+func (RawMigrationSource) migrationSourceType() string {
+	return migrationSourceRaw
+}
+
+// Validate returns an error if the source spec is incomplete or
+// inconsistent.
+func (s RawMigrationSource) Validate() error {
+	if err := validateMigrationStream(migrationSourceRaw, s.Stream); err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for _, snap := range s.Snapshots {
+		if snap.Name == "" {
+			return invalidMigrationSource("raw snapshot requires a name")
+		}
+		if names[snap.Name] {
+			return invalidMigrationSource("duplicate raw snapshot %q", snap.Name)
+		}
+		names[snap.Name] = true
+		err := validateMigrationStream("raw snapshot "+snap.Name, snap.Stream)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type rawMigrationSnapshotSpec struct {
+	Type   string          `json:"type"`
+	Name   string          `json:"name"`
+	Stream MigrationStream `json:"stream"`
+}
+
+// MarshalJSON encodes the source spec in the format librbd expects.
+func (s RawMigrationSource) MarshalJSON() ([]byte, error) {
+	var snaps []rawMigrationSnapshotSpec
+	for _, snap := range s.Snapshots {
+		snaps = append(snaps, rawMigrationSnapshotSpec{
+			Type:   migrationSourceRaw,
+			Name:   snap.Name,
+			Stream: snap.Stream,
+		})
+	}
+	return json.Marshal(struct {
+		Type      string                     `json:"type"`
+		Stream    MigrationStream            `json:"stream"`
+		Snapshots []rawMigrationSnapshotSpec `json:"snapshots,omitempty"`
+	}{migrationSourceRaw, s.Stream, snaps})
+}
+
+func (QcowMigrationSource) migrationSourceType() string {
+	return migrationSourceQcow
+}
+
+// Validate returns an error if the source spec is incomplete or
+// inconsistent.
+func (s QcowMigrationSource) Validate() error {
+	return validateMigrationStream(migrationSourceQcow, s.Stream)
+}
+
+// MarshalJSON encodes the source spec in the format librbd expects.
+func (s QcowMigrationSource) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type   string          `json:"type"`
+		Stream MigrationStream `json:"stream"`
+	}{migrationSourceQcow, s.Stream})
+}
+
+func validateMigrationStream(owner string, s MigrationStream) error {
+	if s == nil {
+		return invalidMigrationSource("%s source requires a stream", owner)
+	}
+	return s.Validate()
+}
+
+func (FileMigrationStream) migrationStreamType() string {
+	return migrationStreamFile
+}
+
+// Validate returns an error if the stream spec is incomplete.
+func (s FileMigrationStream) Validate() error {
+	if s.FilePath == "" {
+		return invalidMigrationSource("file stream requires a file path")
+	}
+	return nil
+}
+
+// MarshalJSON encodes the stream spec in the format librbd expects.
+func (s FileMigrationStream) MarshalJSON() ([]byte, error) {
+	type spec FileMigrationStream
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		spec
+	}{migrationStreamFile, spec(s)})
+}
+
+func (HTTPMigrationStream) migrationStreamType() string {
+	return migrationStreamHTTP
+}
+
+// Validate returns an error if the stream spec is incomplete.
+func (s HTTPMigrationStream) Validate() error {
+	if s.URL == "" {
+		return invalidMigrationSource("http stream requires a url")
+	}
+	return nil
+}
+
+// MarshalJSON encodes the stream spec in the format librbd expects.
+func (s HTTPMigrationStream) MarshalJSON() ([]byte, error) {
+	type spec HTTPMigrationStream
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		spec
+	}{migrationStreamHTTP, spec(s)})
+}
+
+func (S3MigrationStream) migrationStreamType() string {
+	return migrationStreamS3
+}
+
+// Validate returns an error if the stream spec is incomplete.
+func (s S3MigrationStream) Validate() error {
+	switch {
+	case s.URL == "":
+		return invalidMigrationSource("s3 stream requires a url")
+	case s.AccessKey == "" || s.SecretKey == "":
+		return invalidMigrationSource("s3 stream requires an access and a secret key")
+	}
+	return nil
+}
+
+// MarshalJSON encodes the stream spec in the format librbd expects.
+func (s S3MigrationStream) MarshalJSON() ([]byte, error) {
+	type spec S3MigrationStream
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		spec
+	}{migrationStreamS3, spec(s)})
+}
+
+// MarshalMigrationSource validates the source and returns the source spec
+// JSON expected by MigrationPrepareImport.
+func MarshalMigrationSource(source MigrationSource) (string, error) {
+	if source == nil {
+		return "", invalidMigrationSource("no source")
+	}
+	if err := source.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(source)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+type migrationSpecType struct {
+	Type string `json:"type"`
+}
+
+func specType(data []byte) (string, error) {
+	var t migrationSpecType
+	if err := json.Unmarshal(data, &t); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMigrationSource, err)
+	}
+	return t.Type, nil
+}
+
+func decodeMigrationSpec(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMigrationSource, err)
+	}
+	return nil
+}
+
+// ParseMigrationSource parses a source spec JSON, as accepted by
+// MigrationPrepareImport, into one of the typed source specs. The parsed
+// source is validated.
+func ParseMigrationSource(spec string) (MigrationSource, error) {
+	data := []byte(spec)
+	t, err := specType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var source MigrationSource
+	switch t {
+	case migrationSourceNative:
+		var s NativeMigrationSource
+		if err := decodeMigrationSpec(data, &s); err != nil {
+			return nil, err
+		}
+		source = s
+	case migrationSourceRaw:
+		var s struct {
+			Stream    json.RawMessage `json:"stream"`
+			Snapshots []struct {
+				Type   string          `json:"type"`
+				Name   string          `json:"name"`
+				Stream json.RawMessage `json:"stream"`
+			} `json:"snapshots"`
+		}
+		if err := decodeMigrationSpec(data, &s); err != nil {
+			return nil, err
+		}
+		raw := RawMigrationSource{}
+		if raw.Stream, err = parseMigrationStream(s.Stream); err != nil {
+			return nil, err
+		}
+		for _, snap := range s.Snapshots {
+			if snap.Type != "" && snap.Type != migrationSourceRaw {
+				return nil, invalidMigrationSource(
+					"unsupported snapshot type %q", snap.Type)
+			}
+			stream, err := parseMigrationStream(snap.Stream)
+			if err != nil {
+				return nil, err
+			}
+			raw.Snapshots = append(raw.Snapshots, RawMigrationSnapshot{
+				Name:   snap.Name,
+				Stream: stream,
+			})
+		}
+		source = raw
+	case migrationSourceQcow:
+		var s struct {
+			Stream json.RawMessage `json:"stream"`
+		}
+		if err := decodeMigrationSpec(data, &s); err != nil {
+			return nil, err
+		}
+		qcow := QcowMigrationSource{}
+		if qcow.Stream, err = parseMigrationStream(s.Stream); err != nil {
+			return nil, err
+		}
+		source = qcow
+	default:
+		return nil, invalidMigrationSource("unsupported source type %q", t)
+	}
+
+	if err := source.Validate(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func parseMigrationStream(data json.RawMessage) (MigrationStream, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	t, err := specType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var stream MigrationStream
+	switch t {
+	case migrationStreamFile:
+		var s FileMigrationStream
+		err = decodeMigrationSpec(data, &s)
+		stream = s
+	case migrationStreamHTTP:
+		var s HTTPMigrationStream
+		err = decodeMigrationSpec(data, &s)
+		stream = s
+	case migrationStreamS3:
+		var s S3MigrationStream
+		err = decodeMigrationSpec(data, &s)
+		stream = s
+	default:
+		return nil, invalidMigrationSource("unsupported stream type %q", t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
@@ -0,0 +1,98 @@
+//go:build !nautilus && ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <stdlib.h>
+#include <rados/librados.h>
+#include <rbd/librbd.h>
+
+// rbd_get_migration_source_spec_fn matches the rbd_get_migration_source_spec
+// function signature.
+typedef int(*rbd_get_migration_source_spec_fn)(rbd_image_t image,
+	char *source_spec, size_t *max_len);
+
+// rbd_get_migration_source_spec_dlsym take *fn as
+// rbd_get_migration_source_spec_fn and calls the dynamically loaded
+// rbd_get_migration_source_spec function passed as 1st argument.
+static inline int rbd_get_migration_source_spec_dlsym(void *fn,
+	rbd_image_t image, char *source_spec, size_t *max_len) {
+	// cast function pointer fn to rbd_get_migration_source_spec and call the
+	// function
+	return ((rbd_get_migration_source_spec_fn) fn)(image, source_spec, max_len);
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/dlsym"
+	"github.com/ceph/go-ceph/internal/retry"
+	"github.com/ceph/go-ceph/rados"
+)
+
+// Ceph pacific introduced rbd_get_migration_source_spec(). The function is
+// resolved at runtime, so that older versions of librbd can be used.
+var rbdGetMigrationSourceSpec = dlsym.NewSymbol("rbd_get_migration_source_spec")
+
+// MigrationPrepareImportSource prepares a migration for import from the
+// typed source to a new target image, like MigrationPrepareImport. The
+// source is validated before the migration is prepared.
+func MigrationPrepareImportSource(source MigrationSource, ioctx *rados.IOContext,
+	destImageName string, rio *ImageOptions) error {
+
+	spec, err := MarshalMigrationSource(source)
+	if err != nil {
+		return err
+	}
+	return MigrationPrepareImport(spec, ioctx, destImageName, rio)
+}
+
+// GetMigrationSourceSpec returns the source spec JSON of the migration the
+// image is the target of. For migrations prepared by MigrationPrepare the
+// spec describes the native source image.
+//
+// Implements:
+//
+//	int rbd_get_migration_source_spec(rbd_image_t image, char* source_spec,
+//	                                  size_t* max_len);
+func (image *Image) GetMigrationSourceSpec() (string, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return "", err
+	}
+
+	fn, err := rbdGetMigrationSourceSpec.Pointer()
+	if err != nil {
+		return "", err
+	}
+
+	var buf []byte
+	retry.WithSizes(1024, 1<<20, func(size int) retry.Hint {
+		cSize := C.size_t(size)
+		buf = make([]byte, size)
+		t := image.startOp("rbd_get_migration_source_spec")
+		ret := C.rbd_get_migration_source_spec_dlsym(
+			fn,
+			image.image,
+			(*C.char)(unsafe.Pointer(&buf[0])),
+			&cSize)
+		err = t.DoneErr(getErrorIfNegative(ret))
+		return retry.Size(int(cSize)).If(err == errRange)
+	})
+	if err != nil {
+		return "", err
+	}
+	return C.GoString((*C.char)(unsafe.Pointer(&buf[0]))), nil
+}
+
+// GetMigrationSource returns the typed source of the migration the image is
+// the target of, as parsed by ParseMigrationSource.
+func (image *Image) GetMigrationSource() (MigrationSource, error) {
+	spec, err := image.GetMigrationSourceSpec()
+	if err != nil {
+		return nil, err
+	}
+	return ParseMigrationSource(spec)
+}
@@ -0,0 +1,200 @@
+//go:build !(octopus || nautilus) && ceph_preview
+
+package rbd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalMigrationSource(t *testing.T) {
+	poolID := int64(3)
+	snapID := uint64(4)
+	tests := []struct {
+		name   string
+		source MigrationSource
+		spec   string
+	}{
+		{
+			"native",
+			NativeMigrationSource{
+				PoolName:  "rbd",
+				ImageName: "img",
+				SnapName:  "snap1",
+			},
+			`{"type":"native","pool_name":"rbd","image_name":"img","snap_name":"snap1"}`,
+		},
+		{
+			"nativeIDs",
+			NativeMigrationSource{
+				ClusterName:   "remote",
+				ClientName:    "client.admin",
+				PoolID:        &poolID,
+				PoolNamespace: "ns",
+				ImageName:     "img",
+				ImageID:       "1234",
+				SnapID:        &snapID,
+			},
+			`{"type":"native","cluster_name":"remote","client_name":"client.admin",` +
+				`"pool_id":3,"pool_namespace":"ns","image_name":"img",` +
+				`"image_id":"1234","snap_id":4}`,
+		},
+		{
+			"rawFile",
+			RawMigrationSource{
+				Stream: FileMigrationStream{FilePath: "/tmp/img.raw"},
+			},
+			`{"type":"raw","stream":{"type":"file","file_path":"/tmp/img.raw"}}`,
+		},
+		{
+			"rawSnapshots",
+			RawMigrationSource{
+				Stream: HTTPMigrationStream{URL: "http://host/head"},
+				Snapshots: []RawMigrationSnapshot{
+					{"snap1", HTTPMigrationStream{URL: "http://host/snap1"}},
+				},
+			},
+			`{"type":"raw","stream":{"type":"http","url":"http://host/head"},` +
+				`"snapshots":[{"type":"raw","name":"snap1",` +
+				`"stream":{"type":"http","url":"http://host/snap1"}}]}`,
+		},
+		{
+			"qcowS3",
+			QcowMigrationSource{
+				Stream: S3MigrationStream{
+					URL:       "https://s3/bucket/img.qcow2",
+					AccessKey: "ak",
+					SecretKey: "sk",
+				},
+			},
+			`{"type":"qcow","stream":{"type":"s3","url":"https://s3/bucket/img.qcow2",` +
+				`"access_key":"ak","secret_key":"sk"}}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := MarshalMigrationSource(tc.source)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.spec, spec)
+
+			source, err := ParseMigrationSource(spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.source, source)
+		})
+	}
+}
+
+func TestMigrationSourceInvalid(t *testing.T) {
+	sources := map[string]MigrationSource{
+		"nil":         nil,
+		"noPool":      NativeMigrationSource{ImageName: "img"},
+		"noImage":     NativeMigrationSource{PoolName: "rbd"},
+		"noStream":    RawMigrationSource{},
+		"noFilePath":  QcowMigrationSource{Stream: FileMigrationStream{}},
+		"noURL":       RawMigrationSource{Stream: HTTPMigrationStream{}},
+		"noSecretKey": QcowMigrationSource{Stream: S3MigrationStream{URL: "u", AccessKey: "ak"}},
+		"noSnapName": RawMigrationSource{
+			Stream:    FileMigrationStream{FilePath: "a"},
+			Snapshots: []RawMigrationSnapshot{{Stream: FileMigrationStream{FilePath: "b"}}},
+		},
+		"noSnapStream": RawMigrationSource{
+			Stream:    FileMigrationStream{FilePath: "a"},
+			Snapshots: []RawMigrationSnapshot{{Name: "snap1"}},
+		},
+		"duplicateSnap": RawMigrationSource{
+			Stream: FileMigrationStream{FilePath: "a"},
+			Snapshots: []RawMigrationSnapshot{
+				{"snap1", FileMigrationStream{FilePath: "b"}},
+				{"snap1", FileMigrationStream{FilePath: "c"}},
+			},
+		},
+	}
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			_, err := MarshalMigrationSource(source)
+			assert.ErrorIs(t, err, ErrInvalidMigrationSource)
+		})
+	}
+
+	specs := map[string]string{
+		"notJSON":       `{"type":`,
+		"unknownType":   `{"type":"vmdk","stream":{"type":"file","file_path":"a"}}`,
+		"unknownStream": `{"type":"raw","stream":{"type":"nfs","path":"a"}}`,
+		"noStream":      `{"type":"qcow"}`,
+		"badSnapshot":   `{"type":"raw","stream":{"type":"file","file_path":"a"},"snapshots":[{"type":"qcow","name":"s","stream":{"type":"file","file_path":"b"}}]}`,
+		"noImage":       `{"type":"native","pool_name":"rbd"}`,
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseMigrationSource(spec)
+			assert.ErrorIs(t, err, ErrInvalidMigrationSource)
+		})
+	}
+}
+
+func TestMigrationPrepareImportSource(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	pool := GetUUID()
+	err := conn.MakePool(pool)
+	require.NoError(t, err)
+	defer conn.DeletePool(pool)
+
+	ioctx, err := conn.OpenIOContext(pool)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	data := []byte("sometimes you feel like a nut")
+	path := filepath.Join(t.TempDir(), "source.raw")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(1<<22))
+	require.NoError(t, f.Close())
+
+	source := RawMigrationSource{Stream: FileMigrationStream{FilePath: path}}
+	destImage := GetUUID()
+	err = MigrationPrepareImportSource(source, ioctx, destImage, NewRbdImageOptions())
+	require.NoError(t, err)
+
+	status, err := MigrationStatus(ioctx, destImage)
+	require.NoError(t, err)
+	assert.Equal(t, MigrationImagePrepared, status.State)
+
+	img, err := OpenImage(ioctx, destImage, NoSnapshot)
+	require.NoError(t, err)
+	parsed, err := img.GetMigrationSource()
+	assert.NoError(t, err)
+	assert.Equal(t, source, parsed)
+	assert.NoError(t, img.Close())
+
+	err = MigrationExecute(ioctx, destImage)
+	require.NoError(t, err)
+	err = MigrationCommit(ioctx, destImage)
+	require.NoError(t, err)
+
+	img, err = OpenImage(ioctx, destImage, NoSnapshot)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, img.Close())
+		assert.NoError(t, RemoveImage(ioctx, destImage))
+	}()
+	buf := make([]byte, len(data))
+	_, err = img.ReadAt(buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, data, buf)
+	_, err = img.GetMigrationSourceSpec()
+	assert.Error(t, err)
+
+	t.Run("invalid", func(t *testing.T) {
+		err := MigrationPrepareImportSource(
+			RawMigrationSource{}, ioctx, GetUUID(), NewRbdImageOptions())
+		assert.ErrorIs(t, err, ErrInvalidMigrationSource)
+	})
+}
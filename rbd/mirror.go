@@ -93,11 +93,12 @@ func GetMirrorUUID(ioctx *rados.IOContext) (string, error) {
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		buf = make([]byte, cSize)
+		t := startOp(ioctx, "rbd_mirror_uuid_get", "")
 		ret := C.rbd_mirror_uuid_get(
 			cephIoctx(ioctx),
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 	if err != nil {
@@ -114,10 +115,11 @@ func GetMirrorUUID(ioctx *rados.IOContext) (string, error) {
 //	int rbd_mirror_mode_set(rados_ioctx_t io_ctx,
 //	                        rbd_mirror_mode_t mirror_mode);
 func SetMirrorMode(ioctx *rados.IOContext, mode MirrorMode) error {
+	t := startOp(ioctx, "rbd_mirror_mode_set", "")
 	ret := C.rbd_mirror_mode_set(
 		cephIoctx(ioctx),
 		C.rbd_mirror_mode_t(mode))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GetMirrorMode is used to fetch the current mirroring mode for a pool.
@@ -129,10 +131,11 @@ func SetMirrorMode(ioctx *rados.IOContext, mode MirrorMode) error {
 func GetMirrorMode(ioctx *rados.IOContext) (MirrorMode, error) {
 	var mode C.rbd_mirror_mode_t
 
+	t := startOp(ioctx, "rbd_mirror_mode_get", "")
 	ret := C.rbd_mirror_mode_get(
 		cephIoctx(ioctx),
 		&mode)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return MirrorModeDisabled, err
 	}
 	return MirrorMode(mode), nil
@@ -148,8 +151,9 @@ func (image *Image) MirrorEnable(mode ImageMirrorMode) error {
 	if err := image.validate(imageIsOpen); err != nil {
 		return err
 	}
+	t := image.startOp("rbd_mirror_image_enable2")
 	ret := C.rbd_mirror_image_enable2(image.image, C.rbd_mirror_image_mode_t(mode))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorDisable will disable mirroring for the image.
@@ -161,8 +165,9 @@ func (image *Image) MirrorDisable(force bool) error {
 	if err := image.validate(imageIsOpen); err != nil {
 		return err
 	}
+	t := image.startOp("rbd_mirror_image_disable")
 	ret := C.rbd_mirror_image_disable(image.image, C.bool(force))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorPromote will promote the image to primary status.
@@ -174,8 +179,9 @@ func (image *Image) MirrorPromote(force bool) error {
 	if err := image.validate(imageIsOpen); err != nil {
 		return err
 	}
+	t := image.startOp("rbd_mirror_image_promote")
 	ret := C.rbd_mirror_image_promote(image.image, C.bool(force))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorDemote will demote the image to secondary status.
@@ -187,8 +193,9 @@ func (image *Image) MirrorDemote() error {
 	if err := image.validate(imageIsOpen); err != nil {
 		return err
 	}
+	t := image.startOp("rbd_mirror_image_demote")
 	ret := C.rbd_mirror_image_demote(image.image)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorResync is used to manually resolve split-brain status by triggering
@@ -201,8 +208,9 @@ func (image *Image) MirrorResync() error {
 	if err := image.validate(imageIsOpen); err != nil {
 		return err
 	}
+	t := image.startOp("rbd_mirror_image_resync")
 	ret := C.rbd_mirror_image_resync(image.image)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorInstanceID returns a string naming the instance id for the image.
@@ -224,11 +232,12 @@ func (image *Image) MirrorInstanceID() (string, error) {
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		buf = make([]byte, cSize)
+		t := image.startOp("rbd_mirror_image_get_instance_id")
 		ret := C.rbd_mirror_image_get_instance_id(
 			image.image,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 	if err != nil {
@@ -295,10 +304,12 @@ func (image *Image) GetMirrorImageInfo() (*MirrorImageInfo, error) {
 
 	var cInfo C.rbd_mirror_image_info_t
 
+	t := image.startOp("rbd_mirror_image_get_info")
 	ret := C.rbd_mirror_image_get_info(
 		image.image,
 		&cInfo,
 		C.sizeof_rbd_mirror_image_info_t)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -321,8 +332,9 @@ func (image *Image) GetImageMirrorMode() (ImageMirrorMode, error) {
 		return ImageMirrorMode(mode), err
 	}
 
+	t := image.startOp("rbd_mirror_image_get_mode")
 	ret := C.rbd_mirror_image_get_mode(image.image, &mode)
-	return ImageMirrorMode(mode), getError(ret)
+	return ImageMirrorMode(mode), t.DoneErr(getError(ret))
 }
 
 // MirrorImageStatusState is used to indicate the state of a mirrored image
@@ -426,11 +438,12 @@ func (image *Image) GetGlobalMirrorStatus() (GlobalMirrorImageStatus, error) {
 	}
 
 	s := C.rbd_mirror_image_global_status_t{}
+	t := image.startOp("rbd_mirror_image_get_global_status")
 	ret := C.rbd_mirror_image_get_global_status(
 		image.image,
 		&s,
 		C.sizeof_rbd_mirror_image_global_status_t)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return GlobalMirrorImageStatus{}, err
 	}
 	defer C.rbd_mirror_image_global_status_cleanup(&s)
@@ -470,10 +483,11 @@ func newGlobalMirrorImageStatus(
 //	                                     uint64_t *snap_id);
 func (image *Image) CreateMirrorSnapshot() (uint64, error) {
 	var snapID C.uint64_t
+	t := image.startOp("rbd_mirror_image_create_snapshot")
 	ret := C.rbd_mirror_image_create_snapshot(
 		image.image,
 		&snapID)
-	return uint64(snapID), getError(ret)
+	return uint64(snapID), t.DoneErr(getError(ret))
 }
 
 // MirrorImageStatusSummary returns a map of images statuses and the count
@@ -503,12 +517,13 @@ func MirrorImageStatusSummary(
 		cSize = C.size_t(size)
 		cStates = make([]C.rbd_mirror_image_status_state_t, cSize)
 		cCounts = make([]C.int, cSize)
+		t := startOp(ioctx, "rbd_mirror_image_status_summary", "")
 		ret := C.rbd_mirror_image_status_summary(
 			cioctx,
 			(*C.rbd_mirror_image_status_state_t)(&cStates[0]),
 			(*C.int)(&cCounts[0]),
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 	if err != nil {
@@ -534,10 +549,11 @@ func SetMirrorSiteName(conn *rados.Conn, name string) error {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := startOp(conn, "rbd_mirror_site_name_set", "")
 	ret := C.rbd_mirror_site_name_set(
 		C.rados_t(conn.Cluster()),
 		cName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GetMirrorSiteName gets the site name, used for rbd mirroring, for the ceph
@@ -558,11 +574,12 @@ func GetMirrorSiteName(conn *rados.Conn) (string, error) {
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		buf = make([]byte, cSize)
+		t := startOp(conn, "rbd_mirror_site_name_get", "")
 		ret := C.rbd_mirror_site_name_get(
 			cluster,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 	if err != nil {
@@ -591,11 +608,12 @@ func CreateMirrorPeerBootstrapToken(ioctx *rados.IOContext) (string, error) {
 	retry.WithSizes(1024, 1<<16, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		buf = make([]byte, cSize)
+		t := startOp(ioctx, "rbd_mirror_peer_bootstrap_create", "")
 		ret := C.rbd_mirror_peer_bootstrap_create(
 			cioctx,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 	if err != nil {
@@ -636,11 +654,12 @@ func ImportMirrorPeerBootstrapToken(
 	cToken := C.CString(token)
 	defer C.free(unsafe.Pointer(cToken))
 
+	t := startOp(ioctx, "rbd_mirror_peer_bootstrap_import", "")
 	ret := C.rbd_mirror_peer_bootstrap_import(
 		cephIoctx(ioctx),
 		C.rbd_mirror_peer_direction_t(direction),
 		cToken)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // GlobalMirrorImageIDAndStatus values contain an ID string for a RBD image
@@ -705,6 +724,7 @@ func mirrorImageGlobalStatusList(
 		ids      = make([]*C.char, len(results))
 		images   = make([]C.rbd_mirror_image_global_status_t, len(results))
 	)
+	t := startOp(ioctx, "rbd_mirror_image_global_status_list", "")
 	ret := C.rbd_mirror_image_global_status_list(
 		cephIoctx(ioctx),
 		cStart,
@@ -712,7 +732,7 @@ func mirrorImageGlobalStatusList(
 		&ids[0],
 		&images[0],
 		&length)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return 0, err
 	}
 	for i := 0; i < int(length); i++ {
@@ -849,6 +869,7 @@ func mirrorImageInfoList(ioctx *rados.IOContext, start string,
 		cMode := C.rbd_mirror_image_mode_t(modeFilter.mode())
 		modeFilterPtr = &cMode
 	}
+	t := startOp(ioctx, "rbd_mirror_image_info_list", "")
 	ret := C.rbd_mirror_image_info_list(
 		cephIoctx(ioctx),
 		modeFilterPtr,
@@ -859,7 +880,7 @@ func mirrorImageInfoList(ioctx *rados.IOContext, start string,
 		&infos[0],
 		&length,
 	)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return 0, err
 	}
 	for i := 0; i < int(length); i++ {
@@ -985,6 +1006,7 @@ func mirrorImageInstanceIDList(ioctx *rados.IOContext, start string,
 		ids         = make([]*C.char, len(results))
 		instanceIDs = make([]*C.char, len(results))
 	)
+	t := startOp(ioctx, "rbd_mirror_image_instance_id_list", "")
 	ret := C.rbd_mirror_image_instance_id_list(
 		cephIoctx(ioctx),
 		cStart,
@@ -993,7 +1015,7 @@ func mirrorImageInstanceIDList(ioctx *rados.IOContext, start string,
 		&instanceIDs[0],
 		&length,
 	)
-	if err := getError(ret); err != nil {
+	if err := t.DoneErr(getError(ret)); err != nil {
 		return 0, err
 	}
 	for i := 0; i < int(length); i++ {
@@ -40,7 +40,9 @@ const (
 func MirrorModeGet(ioctx *rados.IOContext) (MirrorMode, error) {
 	var rmm C.rbd_mirror_mode_t
 
+	t := startOp(ioctx, "rbd_mirror_mode_get", "")
 	ret := C.rbd_mirror_mode_get(cephIoctx(ioctx), &rmm)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return -1, getError(ret)
 	}
@@ -59,7 +61,9 @@ func MirrorModeGet(ioctx *rados.IOContext) (MirrorMode, error) {
 func MirrorModeSet(ioctx *rados.IOContext, mode MirrorMode) error {
 	cMode := C.rbd_mirror_mode_t(mode)
 
+	t := startOp(ioctx, "rbd_mirror_mode_set", "")
 	ret := C.rbd_mirror_mode_set(cephIoctx(ioctx), cMode)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -89,8 +93,10 @@ func MirrorPeerAdd(ioctx *rados.IOContext, clusterName, clientName string) (stri
 	cClientName := C.CString(clientName)
 	defer C.free(unsafe.Pointer(cClientName))
 
+	t := startOp(ioctx, "rbd_mirror_peer_add", "")
 	ret := C.rbd_mirror_peer_add(cephIoctx(ioctx), &cUUID[0], cUUIDMaxLen,
 		cClusterName, cClientName)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return C.GoString(&cUUID[0]), getError(ret)
 }
@@ -107,7 +113,9 @@ func MirrorPeerRemove(ioctx *rados.IOContext, uuid string) error {
 	cUUID := C.CString(uuid)
 	defer C.free(unsafe.Pointer(cUUID))
 
+	t := startOp(ioctx, "rbd_mirror_peer_remove", "")
 	ret := C.rbd_mirror_peer_remove(cephIoctx(ioctx), cUUID)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -136,7 +144,9 @@ func MirrorPeerList(ioctx *rados.IOContext) ([]*MirrorPeerInfo, error) {
 	var cPeers []C.rbd_mirror_peer_t
 	for {
 		cPeers = make([]C.rbd_mirror_peer_t, cMaxPeers)
+		t := startOp(ioctx, "rbd_mirror_peer_list", "")
 		ret := C.rbd_mirror_peer_list(cephIoctx(ioctx), &cPeers[0], &cMaxPeers)
+		t.Done(0, getErrorIfNegative(ret))
 		if ret == -C.ERANGE {
 			// There are too many peers to fit in the list, and the number of peers has been
 			// returned in cMaxPeers. Try again with the returned value.
@@ -234,7 +244,9 @@ func (image *Image) MirrorGetImage() (*MirrorImageInfo, error) {
 	}
 
 	var status C.rbd_mirror_image_status_t
+	t := image.startOp("rbd_mirror_image_get_status")
 	ret := C.rbd_mirror_image_get_status(image.image, &status, C.sizeof_rbd_mirror_image_status_t)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return nil, getError(ret)
 	}
@@ -289,8 +301,10 @@ func iterateImageList(ioctx *rados.IOContext, imageInfos *[]*MirrorImageInfo, st
 	done := false
 
 	var cLen C.size_t
+	t := startOp(ioctx, "rbd_mirror_image_status_list", "")
 	ret := C.rbd_mirror_image_status_list(cephIoctx(ioctx), C.CString(*startID),
 		cMaxIter, &cImageIDs[0], &cImageStatus[0], &cLen)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return ret, done
 	}
@@ -342,8 +356,9 @@ func (image *Image) MirrorEnable() error {
 		return err
 	}
 
+	t := image.startOp("rbd_mirror_image_enable")
 	ret := C.rbd_mirror_image_enable(image.image)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorDisable will disable mirroring for an image.
@@ -360,8 +375,9 @@ func (image *Image) MirrorDisable(force bool) error {
 		return err
 	}
 
+	t := image.startOp("rbd_mirror_image_disable")
 	ret := C.rbd_mirror_image_disable(image.image, C.bool(force))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorPromote will promote an image to primary status.
@@ -378,8 +394,9 @@ func (image *Image) MirrorPromote(force bool) error {
 		return err
 	}
 
+	t := image.startOp("rbd_mirror_image_promote")
 	ret := C.rbd_mirror_image_promote(image.image, C.bool(force))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorDemote will demote an image to secondary status.
@@ -396,8 +413,9 @@ func (image *Image) MirrorDemote() error {
 		return err
 	}
 
+	t := image.startOp("rbd_mirror_image_demote")
 	ret := C.rbd_mirror_image_demote(image.image)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // MirrorResync is used to manually resolve split-brain status by triggering
@@ -415,6 +433,7 @@ func (image *Image) MirrorResync() error {
 		return err
 	}
 
+	t := image.startOp("rbd_mirror_image_resync")
 	ret := C.rbd_mirror_image_resync(image.image)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
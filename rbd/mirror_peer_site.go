@@ -42,12 +42,13 @@ func AddMirrorPeerSite(ioctx *rados.IOContext, siteName string, clientName strin
 	retry.WithSizes(512, 1<<16, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		buf = make([]byte, cSize)
+		t := startOp(ioctx, "rbd_mirror_peer_site_add", "")
 		ret := C.rbd_mirror_peer_site_add(
 			cephIoctx(ioctx),
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			cSize, C.rbd_mirror_peer_direction_t(direction),
 			cSiteName, cClientName)
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.Size(int(cSize)).If(err != nil)
 	})
 	if err != nil {
@@ -65,7 +66,9 @@ func RemoveMirrorPeerSite(ioctx *rados.IOContext, uuid string) error {
 	cUUID := C.CString(uuid)
 	defer C.free(unsafe.Pointer(cUUID))
 
+	t := startOp(ioctx, "rbd_mirror_peer_site_remove", "")
 	ret := C.rbd_mirror_peer_site_remove(cephIoctx(ioctx), cUUID)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -95,11 +98,12 @@ func GetAttributesMirrorPeerSite(ioctx *rados.IOContext, uuid string) (map[strin
 		valSize = C.size_t(size)
 		keys = make([]byte, keySize)
 		vals = make([]byte, valSize)
+		t := startOp(ioctx, "rbd_mirror_peer_site_get_attributes", "")
 		ret := C.rbd_mirror_peer_site_get_attributes(
 			cephIoctx(ioctx), cUUID, (*C.char)(unsafe.Pointer(&keys[0])),
 			&keySize, (*C.char)(unsafe.Pointer(&vals[0])), &valSize,
 			&count)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(keySize)).If(err == errRange)
 	})
 	if err != nil {
@@ -143,7 +147,9 @@ func SetAttributesMirrorPeerSite(ioctx *rados.IOContext, uuid string, attributes
 	cVal := C.CString(val)
 	defer C.free(unsafe.Pointer(cVal))
 
+	t := startOp(ioctx, "rbd_mirror_peer_site_set_attributes", "")
 	ret := C.rbd_mirror_peer_site_set_attributes(cephIoctx(ioctx), cUUID, cKey, cVal, count)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -171,8 +177,9 @@ func ListMirrorPeerSite(ioctx *rados.IOContext) ([]*MirrorPeerSite, error) {
 	var cSites []C.rbd_mirror_peer_site_t
 	for {
 		cSites = make([]C.rbd_mirror_peer_site_t, cMaxPeers)
+		t := startOp(ioctx, "rbd_mirror_peer_site_list", "")
 		ret := C.rbd_mirror_peer_site_list(cephIoctx(ioctx), &cSites[0], &cMaxPeers)
-		err := getError(ret)
+		err := t.DoneErr(getError(ret))
 		if err == errRange {
 			// There are too many peer sites to fit in the list, and the number of peer sites has been
 			// returned in cMaxPeers. Try again with the returned value.
@@ -215,7 +222,9 @@ func SetMirrorPeerSiteClientName(ioctx *rados.IOContext, uuid string, clientName
 	cClientName := C.CString(clientName)
 	defer C.free(unsafe.Pointer(cClientName))
 
+	t := startOp(ioctx, "rbd_mirror_peer_site_set_client_name", "")
 	ret := C.rbd_mirror_peer_site_set_client_name(cephIoctx(ioctx), cUUID, cClientName)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -230,8 +239,10 @@ func SetMirrorPeerSiteDirection(ioctx *rados.IOContext, uuid string, direction M
 	cUUID := C.CString(uuid)
 	defer C.free(unsafe.Pointer(cUUID))
 
+	t := startOp(ioctx, "rbd_mirror_peer_site_set_direction", "")
 	ret := C.rbd_mirror_peer_site_set_direction(cephIoctx(ioctx), cUUID,
 		C.rbd_mirror_peer_direction_t(direction))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -249,7 +260,9 @@ func SetMirrorPeerSiteName(ioctx *rados.IOContext, uuid string, siteName string)
 	cSiteName := C.CString(siteName)
 	defer C.free(unsafe.Pointer(cSiteName))
 
+	t := startOp(ioctx, "rbd_mirror_peer_site_set_name", "")
 	ret := C.rbd_mirror_peer_site_set_name(cephIoctx(ioctx), cUUID, cSiteName)
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -34,8 +34,9 @@ func NamespaceCreate(ioctx *rados.IOContext, namespaceName string) error {
 	cNamespaceName := C.CString(namespaceName)
 	defer C.free(unsafe.Pointer(cNamespaceName))
 
+	t := startOp(ioctx, "rbd_namespace_create", "")
 	ret := C.rbd_namespace_create(cephIoctx(ioctx), cNamespaceName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // NamespaceRemove removes a given namespace.
@@ -53,8 +54,9 @@ func NamespaceRemove(ioctx *rados.IOContext, namespaceName string) error {
 	cNamespaceName := C.CString(namespaceName)
 	defer C.free(unsafe.Pointer(cNamespaceName))
 
+	t := startOp(ioctx, "rbd_namespace_remove", "")
 	ret := C.rbd_namespace_remove(cephIoctx(ioctx), cNamespaceName)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // NamespaceExists checks whether a given namespace exists or not.
@@ -73,8 +75,9 @@ func NamespaceExists(ioctx *rados.IOContext, namespaceName string) (bool, error)
 	defer C.free(unsafe.Pointer(cNamespaceName))
 
 	var exists C.bool
+	t := startOp(ioctx, "rbd_namespace_exists", "")
 	ret := C.rbd_namespace_exists(cephIoctx(ioctx), cNamespaceName, &exists)
-	return bool(exists), getErrorIfNegative(ret)
+	return bool(exists), t.DoneErr(getErrorIfNegative(ret))
 }
 
 // NamespaceList returns a slice containing the names of existing rbd namespaces.
@@ -93,10 +96,11 @@ func NamespaceList(ioctx *rados.IOContext) (names []string, err error) {
 	retry.WithSizes(4096, 262144, func(size int) retry.Hint {
 		cSize = C.size_t(size)
 		buf = make([]byte, cSize)
+		t := startOp(ioctx, "rbd_namespace_list", "")
 		ret := C.rbd_namespace_list(cephIoctx(ioctx),
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cSize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 
@@ -0,0 +1,361 @@
+//go:build ceph_preview
+
+package nbd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// socketPair returns the two ends of a connected Unix socket pair.
+func socketPair(t *testing.T) (net.Conn, net.Conn) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	require.NoError(t, err)
+	conns := make([]net.Conn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), fmt.Sprintf("socketpair-%d", i))
+		conns[i], err = net.FileConn(f)
+		f.Close()
+		require.NoError(t, err)
+	}
+	return conns[0], conns[1]
+}
+
+// serveSocketPair serves one end of a socket pair with the server and
+// returns the other end. The error of ServeConn is sent on the channel.
+func serveSocketPair(t *testing.T, s *Server) (net.Conn, <-chan error) {
+	client, server := socketPair(t)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServeConn(server)
+	}()
+	t.Cleanup(func() { client.Close() })
+	return client, done
+}
+
+// testClient is a minimal NBD client for testing the server.
+type testClient struct {
+	conn       net.Conn
+	size       uint64
+	flags      uint16
+	structured bool
+	cookie     uint64
+}
+
+// newTestClient runs the handshake up to the options phase.
+func newTestClient(t *testing.T, conn net.Conn, noZeroes bool) *testClient {
+	var greeting [18]byte
+	_, err := io.ReadFull(conn, greeting[:])
+	require.NoError(t, err)
+	require.Equal(t, nbdMagic, binary.BigEndian.Uint64(greeting[0:]))
+	require.Equal(t, optMagic, binary.BigEndian.Uint64(greeting[8:]))
+	require.Equal(t, flagFixedNewstyle|flagNoZeroes,
+		binary.BigEndian.Uint16(greeting[16:]))
+
+	flags := flagCFixedNewstyle
+	if noZeroes {
+		flags |= flagCNoZeroes
+	}
+	_, err = conn.Write(be32(flags))
+	require.NoError(t, err)
+	return &testClient{conn: conn}
+}
+
+func (c *testClient) sendOption(opt uint32, data []byte) error {
+	b := append(be64(optMagic), be32(opt)...)
+	b = append(b, be32(uint32(len(data)))...)
+	_, err := c.conn.Write(append(b, data...))
+	return err
+}
+
+type optionReply struct {
+	typ  uint32
+	data []byte
+}
+
+func (c *testClient) readOptionReply(opt uint32) (*optionReply, error) {
+	var hdr [20]byte
+	if _, err := io.ReadFull(c.conn, hdr[:]); err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint64(hdr[0:]) != optReplyMagic {
+		return nil, errors.New("bad option reply magic")
+	}
+	if o := binary.BigEndian.Uint32(hdr[8:]); o != opt {
+		return nil, fmt.Errorf("reply for option %d, expected %d", o, opt)
+	}
+	r := &optionReply{
+		typ:  binary.BigEndian.Uint32(hdr[12:]),
+		data: make([]byte, binary.BigEndian.Uint32(hdr[16:])),
+	}
+	_, err := io.ReadFull(c.conn, r.data)
+	return r, err
+}
+
+// option sends an option and returns all replies up to the final one.
+func (c *testClient) option(opt uint32, data []byte) ([]*optionReply, error) {
+	if err := c.sendOption(opt, data); err != nil {
+		return nil, err
+	}
+	var replies []*optionReply
+	for {
+		r, err := c.readOptionReply(opt)
+		if err != nil {
+			return nil, err
+		}
+		replies = append(replies, r)
+		if r.typ == repAck || r.typ&repFlagError != 0 {
+			return replies, nil
+		}
+	}
+}
+
+func (c *testClient) structuredReplies() error {
+	r, err := c.option(optStructuredReply, nil)
+	if err != nil {
+		return err
+	}
+	if r[0].typ != repAck {
+		return fmt.Errorf("structured reply rejected: %#x", r[0].typ)
+	}
+	c.structured = true
+	return nil
+}
+
+func infoRequest(name string) []byte {
+	b := append(be32(uint32(len(name))), name...)
+	return append(b, be16(0)...)
+}
+
+// goExport selects the export with the GO option.
+func (c *testClient) goExport(name string) error {
+	replies, err := c.option(optGo, infoRequest(name))
+	if err != nil {
+		return err
+	}
+	last := replies[len(replies)-1]
+	if last.typ != repAck {
+		return fmt.Errorf("go failed: %#x", last.typ)
+	}
+	for _, r := range replies[:len(replies)-1] {
+		if r.typ == repInfo && binary.BigEndian.Uint16(r.data) == infoExport {
+			c.size = binary.BigEndian.Uint64(r.data[2:])
+			c.flags = binary.BigEndian.Uint16(r.data[10:])
+		}
+	}
+	return nil
+}
+
+// exportName selects the export with the EXPORT_NAME option.
+func (c *testClient) exportName(name string, noZeroes bool) error {
+	if err := c.sendOption(optExportName, []byte(name)); err != nil {
+		return err
+	}
+	size := 10
+	if !noZeroes {
+		size += zeroesPaddingSize
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, b); err != nil {
+		return err
+	}
+	c.size = binary.BigEndian.Uint64(b)
+	c.flags = binary.BigEndian.Uint16(b[8:])
+	return nil
+}
+
+// nbdError is the error of a reply.
+type nbdError uint32
+
+func (e nbdError) Error() string {
+	return fmt.Sprintf("nbd error %d", uint32(e))
+}
+
+func (c *testClient) send(typ, flags uint16, offset uint64, length uint32, data []byte) (uint64, error) {
+	c.cookie++
+	b := append(be32(requestMagic), be16(flags)...)
+	b = append(b, be16(typ)...)
+	b = append(b, be64(c.cookie)...)
+	b = append(b, be64(offset)...)
+	b = append(b, be32(length)...)
+	_, err := c.conn.Write(append(b, data...))
+	return c.cookie, err
+}
+
+// simpleReply reads a simple reply with dataLen bytes of data on success.
+func (c *testClient) simpleReply(cookie uint64, dataLen int) ([]byte, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(c.conn, hdr[:]); err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint32(hdr[0:]) != simpleMagic {
+		return nil, errors.New("bad simple reply magic")
+	}
+	if binary.BigEndian.Uint64(hdr[8:]) != cookie {
+		return nil, errors.New("unexpected cookie")
+	}
+	if errno := binary.BigEndian.Uint32(hdr[4:]); errno != 0 {
+		return nil, nbdError(errno)
+	}
+	data := make([]byte, dataLen)
+	_, err := io.ReadFull(c.conn, data)
+	return data, err
+}
+
+type chunk struct {
+	flags   uint16
+	typ     uint16
+	payload []byte
+}
+
+// structuredReply reads the chunks of a structured reply.
+func (c *testClient) structuredReply(cookie uint64) ([]chunk, error) {
+	var chunks []chunk
+	for {
+		var hdr [20]byte
+		if _, err := io.ReadFull(c.conn, hdr[:]); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint32(hdr[0:]) != structureMagic {
+			return nil, errors.New("bad structured reply magic")
+		}
+		if binary.BigEndian.Uint64(hdr[8:]) != cookie {
+			return nil, errors.New("unexpected cookie")
+		}
+		ch := chunk{
+			flags:   binary.BigEndian.Uint16(hdr[4:]),
+			typ:     binary.BigEndian.Uint16(hdr[6:]),
+			payload: make([]byte, binary.BigEndian.Uint32(hdr[16:])),
+		}
+		if _, err := io.ReadFull(c.conn, ch.payload); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, ch)
+		if ch.flags&replyFlagDone != 0 {
+			return chunks, nil
+		}
+	}
+}
+
+func (c *testClient) read(offset uint64, length uint32) ([]byte, error) {
+	cookie, err := c.send(cmdRead, 0, offset, length, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !c.structured {
+		return c.simpleReply(cookie, int(length))
+	}
+	chunks, err := c.structuredReply(cookie)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	for _, ch := range chunks {
+		switch ch.typ {
+		case replyTypeData:
+			off := binary.BigEndian.Uint64(ch.payload) - offset
+			copy(data[off:], ch.payload[8:])
+		case replyTypeHole:
+		case replyTypeError:
+			return nil, nbdError(binary.BigEndian.Uint32(ch.payload))
+		}
+	}
+	return data, nil
+}
+
+func (c *testClient) command(typ, flags uint16, offset uint64, length uint32, data []byte) error {
+	cookie, err := c.send(typ, flags, offset, length, data)
+	if err != nil {
+		return err
+	}
+	_, err = c.simpleReply(cookie, 0)
+	return err
+}
+
+func (c *testClient) write(offset uint64, data []byte) error {
+	return c.command(cmdWrite, 0, offset, uint32(len(data)), data)
+}
+
+func (c *testClient) disconnect() error {
+	_, err := c.send(cmdDisc, 0, 0, 0, nil)
+	return err
+}
+
+// memDevice is a Device backed by memory.
+type memDevice struct {
+	mutex   sync.Mutex
+	data    []byte
+	flushes int
+	failing error
+}
+
+func newMemDevice(size int) *memDevice {
+	return &memDevice{data: make([]byte, size)}
+}
+
+func (d *memDevice) ReadAt(b []byte, off int64) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.failing != nil {
+		return 0, d.failing
+	}
+	n := copy(b, d.data[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (d *memDevice) WriteAt(b []byte, off int64) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return copy(d.data[off:], b), nil
+}
+
+func (d *memDevice) Discard(ofs, length uint64) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	copy(d.data[ofs:ofs+length], make([]byte, length))
+	return int(length), nil
+}
+
+func (d *memDevice) WriteSame(ofs, n uint64, data []byte, _ rados.OpFlags) (int64, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if n%uint64(len(data)) != 0 {
+		return 0, errors.New("unaligned writesame")
+	}
+	for i := uint64(0); i < n; i += uint64(len(data)) {
+		copy(d.data[ofs+i:], data)
+	}
+	return int64(n), nil
+}
+
+func (d *memDevice) Flush() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.flushes++
+	return nil
+}
+
+func (d *memDevice) GetSize() (uint64, error) {
+	return uint64(len(d.data)), nil
+}
+
+func (d *memDevice) bytes(off, length int) []byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return bytes.Clone(d.data[off : off+length])
+}
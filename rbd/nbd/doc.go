@@ -0,0 +1,12 @@
+/*
+Package nbd serves rbd images over the Network Block Device (NBD) protocol.
+
+The Server implements the fixed newstyle handshake and the transmission
+phase of the NBD protocol, including structured replies, so that the NBD
+client of the Linux kernel or of tools like qemu and nbd-client can attach
+an rbd image without the rbd-nbd daemon. Exports are served on any
+net.Listener, for example a Unix or TCP socket.
+
+This API is not yet stable and is subject to change.
+*/
+package nbd
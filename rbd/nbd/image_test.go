@@ -0,0 +1,71 @@
+//go:build ceph_preview
+
+package nbd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/internal/admintest"
+	"github.com/ceph/go-ceph/rbd"
+)
+
+func TestServeImage(t *testing.T) {
+	conn := admintest.NewConn(t)
+	defer conn.Shutdown()
+
+	poolname := "nbd-" + t.Name()
+	require.NoError(t, conn.MakePool(poolname))
+	defer conn.DeletePool(poolname)
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := "nbd-image"
+	options := rbd.NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t, options.SetUint64(rbd.ImageOptionOrder, 22))
+	require.NoError(t, rbd.CreateImage(ioctx, name, 1<<24, options))
+	defer func() { assert.NoError(t, rbd.RemoveImage(ioctx, name)) }()
+	img, err := rbd.OpenImage(ioctx, name, rbd.NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	s, err := NewServer(Export{Name: name, Device: img})
+	require.NoError(t, err)
+	defer s.Close()
+	client, done := serveSocketPair(t, s)
+	c := newTestClient(t, client, true)
+	require.NoError(t, c.structuredReplies())
+	require.NoError(t, c.goExport(name))
+	assert.EqualValues(t, 1<<24, c.size)
+
+	data := bytes.Repeat([]byte("go-ceph nbd "), 1000)
+	require.NoError(t, c.write(1<<22-100, data))
+	require.NoError(t, c.command(cmdFlush, 0, 0, 0, nil))
+
+	b := make([]byte, len(data))
+	_, err = img.ReadAt(b, 1<<22-100)
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+	b, err = c.read(1<<22-100, uint32(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	require.NoError(t, c.command(cmdWriteZeroes, 0, 1<<22-100, 4096, nil))
+	// a discarded range may still read back its old content, so only the
+	// completion of the trim is checked
+	require.NoError(t, c.command(cmdTrim, 0, 1<<22-100+4096, 4096, nil))
+	b, err = c.read(1<<22-100, 4096)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 4096), b)
+	b, err = c.read(1<<22-100+8192, uint32(len(data)-8192))
+	assert.NoError(t, err)
+	assert.Equal(t, data[8192:], b)
+
+	assert.NoError(t, c.disconnect())
+	assert.NoError(t, <-done)
+}
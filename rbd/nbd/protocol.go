@@ -0,0 +1,206 @@
+//go:build ceph_preview
+
+package nbd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// The constants of the NBD protocol, as documented in proto.md of the NBD
+// project.
+const (
+	nbdMagic       = uint64(0x4e42444d41474943) // "NBDMAGIC"
+	optMagic       = uint64(0x49484156454f5054) // "IHAVEOPT"
+	optReplyMagic  = uint64(0x3e889045565a9)
+	requestMagic   = uint32(0x25609513)
+	simpleMagic    = uint32(0x67446698)
+	structureMagic = uint32(0x668e33ef)
+
+	// handshake flags
+	flagFixedNewstyle = uint16(1 << 0)
+	flagNoZeroes      = uint16(1 << 1)
+
+	// client flags
+	flagCFixedNewstyle = uint32(1 << 0)
+	flagCNoZeroes      = uint32(1 << 1)
+
+	// transmission flags
+	flagHasFlags        = uint16(1 << 0)
+	flagReadOnly        = uint16(1 << 1)
+	flagSendFlush       = uint16(1 << 2)
+	flagSendFUA         = uint16(1 << 3)
+	flagSendTrim        = uint16(1 << 5)
+	flagSendWriteZeroes = uint16(1 << 6)
+	flagSendDF          = uint16(1 << 7)
+	flagCanMultiConn    = uint16(1 << 8)
+
+	// options
+	optExportName      = uint32(1)
+	optAbort           = uint32(2)
+	optList            = uint32(3)
+	optInfo            = uint32(6)
+	optGo              = uint32(7)
+	optStructuredReply = uint32(8)
+
+	// option replies
+	repAck        = uint32(1)
+	repServer     = uint32(2)
+	repInfo       = uint32(3)
+	repFlagError  = uint32(1 << 31)
+	repErrUnsup   = repFlagError | 1
+	repErrInvalid = repFlagError | 3
+	repErrUnknown = repFlagError | 6
+
+	// information types of info replies
+	infoExport    = uint16(0)
+	infoBlockSize = uint16(3)
+
+	// commands
+	cmdRead        = uint16(0)
+	cmdWrite       = uint16(1)
+	cmdDisc        = uint16(2)
+	cmdFlush       = uint16(3)
+	cmdTrim        = uint16(4)
+	cmdWriteZeroes = uint16(6)
+
+	// command flags
+	cmdFlagFUA = uint16(1 << 0)
+
+	// structured reply flags and types
+	replyFlagDone  = uint16(1 << 0)
+	replyTypeNone  = uint16(0)
+	replyTypeData  = uint16(1)
+	replyTypeHole  = uint16(2)
+	replyTypeError = uint16(1<<15 | 1)
+
+	// errors
+	errPerm    = uint32(1)
+	errIO      = uint32(5)
+	errInvalid = uint32(22)
+	errNoSpace = uint32(28)
+	errNotSup  = uint32(95)
+)
+
+// zeroesPaddingSize is the size of the padding after the export
+// information of an EXPORT_NAME option, unless the client set NO_ZEROES.
+const zeroesPaddingSize = 124
+
+// maxNameSize is the maximum size of an export name.
+const maxNameSize = 4096
+
+// maxOptLength is the maximum size of the data of an option.
+const maxOptLength = 64 * 1024
+
+// maxRequestSize is the largest payload of a read or write request the
+// server accepts. It is advertised as the maximum block size.
+const maxRequestSize = 32 * 1024 * 1024
+
+// preferredBlockSize is the block size advertised as preferred to clients.
+const preferredBlockSize = 4096
+
+// option is an option sent by the client during the handshake.
+type option struct {
+	option uint32
+	data   []byte
+}
+
+// request is a request of the transmission phase. The data of write
+// requests follows the request on the wire.
+type request struct {
+	flags  uint16
+	typ    uint16
+	cookie uint64
+	offset uint64
+	length uint32
+}
+
+// requestSize is the size of a request on the wire.
+const requestSize = 28
+
+func readOption(r io.Reader) (*option, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if m := binary.BigEndian.Uint64(hdr[0:]); m != optMagic {
+		return nil, fmt.Errorf("invalid option magic %#x", m)
+	}
+	o := &option{option: binary.BigEndian.Uint32(hdr[8:])}
+	length := binary.BigEndian.Uint32(hdr[12:])
+	if length > maxOptLength {
+		return nil, fmt.Errorf("option %d too long: %d bytes", o.option, length)
+	}
+	o.data = make([]byte, length)
+	if _, err := io.ReadFull(r, o.data); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func writeOptionReply(w io.Writer, opt, typ uint32, data []byte) error {
+	b := make([]byte, 20, 20+len(data))
+	binary.BigEndian.PutUint64(b[0:], optReplyMagic)
+	binary.BigEndian.PutUint32(b[8:], opt)
+	binary.BigEndian.PutUint32(b[12:], typ)
+	binary.BigEndian.PutUint32(b[16:], uint32(len(data)))
+	_, err := w.Write(append(b, data...))
+	return err
+}
+
+func readRequest(r io.Reader) (*request, error) {
+	var b [requestSize]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, err
+	}
+	if m := binary.BigEndian.Uint32(b[0:]); m != requestMagic {
+		return nil, fmt.Errorf("invalid request magic %#x", m)
+	}
+	return &request{
+		flags:  binary.BigEndian.Uint16(b[4:]),
+		typ:    binary.BigEndian.Uint16(b[6:]),
+		cookie: binary.BigEndian.Uint64(b[8:]),
+		offset: binary.BigEndian.Uint64(b[16:]),
+		length: binary.BigEndian.Uint32(b[24:]),
+	}, nil
+}
+
+// simpleReply encodes a simple reply, followed by data.
+func simpleReply(cookie uint64, errno uint32, data []byte) []byte {
+	b := make([]byte, 16, 16+len(data))
+	binary.BigEndian.PutUint32(b[0:], simpleMagic)
+	binary.BigEndian.PutUint32(b[4:], errno)
+	binary.BigEndian.PutUint64(b[8:], cookie)
+	return append(b, data...)
+}
+
+// structuredReply encodes a structured reply chunk with the given payload.
+func structuredReply(cookie uint64, flags, typ uint16, payload ...[]byte) []byte {
+	length := 0
+	for _, p := range payload {
+		length += len(p)
+	}
+	b := make([]byte, 20, 20+length)
+	binary.BigEndian.PutUint32(b[0:], structureMagic)
+	binary.BigEndian.PutUint16(b[4:], flags)
+	binary.BigEndian.PutUint16(b[6:], typ)
+	binary.BigEndian.PutUint64(b[8:], cookie)
+	binary.BigEndian.PutUint32(b[16:], uint32(length))
+	for _, p := range payload {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func be16(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+func be32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func be64(v uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, v)
+}
@@ -0,0 +1,588 @@
+//go:build ceph_preview
+
+package nbd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+
+	"github.com/ceph/go-ceph/internal/log"
+	"github.com/ceph/go-ceph/rados"
+	"github.com/ceph/go-ceph/rbd"
+)
+
+// ErrServerClosed is returned by Serve and ServeConn after the server has
+// been closed.
+var ErrServerClosed = errors.New("nbd server closed")
+
+// ErrInvalidExport is returned by NewServer if an export is invalid.
+var ErrInvalidExport = errors.New("invalid nbd export")
+
+// maxInflight is the number of requests of a connection that are processed
+// concurrently.
+const maxInflight = 16
+
+// zeroBlockSize is the size of the zero block written with WriteSame for
+// write zeroes requests.
+const zeroBlockSize = 512
+
+// Device is the block device served by an export. An open *rbd.Image
+// implements Device. A Device has to support concurrent calls.
+type Device interface {
+	ReadAt(data []byte, off int64) (int, error)
+	WriteAt(data []byte, off int64) (int, error)
+	Discard(ofs, length uint64) (int, error)
+	WriteSame(ofs, n uint64, data []byte, flags rados.OpFlags) (int64, error)
+	Flush() error
+	GetSize() (uint64, error)
+}
+
+var _ Device = (*rbd.Image)(nil)
+
+// Export is a device served under a name. Clients requesting the empty
+// default name are served the only export of a server with a single
+// export.
+type Export struct {
+	Name     string
+	Device   Device
+	ReadOnly bool
+}
+
+// Server serves exports over the NBD protocol. Every connection serves one
+// export and an export may be served over multiple connections at the same
+// time.
+type Server struct {
+	exports []Export
+
+	mutex     sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	// connsWG tracks the served connections, including their requests in
+	// progress, so that Close can wait for them
+	connsWG sync.WaitGroup
+}
+
+// NewServer returns a server for the given exports. The names of the
+// exports have to be unique.
+func NewServer(exports ...Export) (*Server, error) {
+	names := map[string]bool{}
+	for _, e := range exports {
+		if e.Device == nil {
+			return nil, fmt.Errorf("%w: %q has no device", ErrInvalidExport, e.Name)
+		}
+		if len(e.Name) > maxNameSize {
+			return nil, fmt.Errorf("%w: name too long", ErrInvalidExport)
+		}
+		if names[e.Name] {
+			return nil, fmt.Errorf("%w: duplicate name %q", ErrInvalidExport, e.Name)
+		}
+		names[e.Name] = true
+	}
+	return &Server{
+		exports:   exports,
+		listeners: map[net.Listener]struct{}{},
+		conns:     map[net.Conn]struct{}{},
+	}, nil
+}
+
+// Serve accepts connections on the listener, for example a Unix or TCP
+// socket, and serves each of them in its own goroutine. Serve returns
+// ErrServerClosed after Close is called, or the error returned by Accept.
+func (s *Server) Serve(l net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.listeners, l)
+		s.mutex.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go func() {
+			err := s.ServeConn(conn)
+			if err != nil && err != ErrServerClosed {
+				log.Warn("nbd connection failed",
+					"remote", conn.RemoteAddr().String(), "error", err)
+			}
+		}()
+	}
+}
+
+// ServeConn serves a single connection until the client disconnects. The
+// connection is closed when ServeConn returns.
+func (s *Server) ServeConn(conn net.Conn) error {
+	if err := s.trackConn(conn); err != nil {
+		conn.Close()
+		return err
+	}
+	defer s.untrackConn(conn)
+
+	c := &connection{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		server: s,
+	}
+	err := c.serve()
+	if err != nil && s.isClosed() {
+		err = ErrServerClosed
+	}
+	return err
+}
+
+// Close closes all listeners and connections of the server and waits until
+// the requests in progress are done. Once Close returns, the devices of the
+// exports are no longer used by the server and may be closed. Close must not
+// be called from a method of a Device.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mutex.Unlock()
+	s.connsWG.Wait()
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closed
+}
+
+func (s *Server) trackConn(conn net.Conn) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return ErrServerClosed
+	}
+	s.conns[conn] = struct{}{}
+	s.connsWG.Add(1)
+	return nil
+}
+
+func (s *Server) untrackConn(conn net.Conn) {
+	s.mutex.Lock()
+	delete(s.conns, conn)
+	s.mutex.Unlock()
+	conn.Close()
+	s.connsWG.Done()
+}
+
+// lookup returns the export of the given name, if any.
+func (s *Server) lookup(name string) *Export {
+	if name == "" && len(s.exports) == 1 {
+		return &s.exports[0]
+	}
+	for i := range s.exports {
+		if s.exports[i].Name == name {
+			return &s.exports[i]
+		}
+	}
+	return nil
+}
+
+// connection is the state of a single client connection.
+type connection struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	server *Server
+
+	export     *Export
+	size       uint64
+	structured bool
+	noZeroes   bool
+
+	// writeMutex serializes the replies of concurrent requests
+	writeMutex sync.Mutex
+}
+
+func (c *connection) serve() error {
+	ok, err := c.handshake()
+	if err != nil || !ok {
+		return err
+	}
+	return c.transmission()
+}
+
+// handshake runs the fixed newstyle handshake. It returns false if the
+// client ended the connection without selecting an export.
+func (c *connection) handshake() (bool, error) {
+	greeting := append(be64(nbdMagic), be64(optMagic)...)
+	greeting = append(greeting, be16(flagFixedNewstyle|flagNoZeroes)...)
+	if _, err := c.conn.Write(greeting); err != nil {
+		return false, err
+	}
+
+	var b [4]byte
+	if _, err := io.ReadFull(c.reader, b[:]); err != nil {
+		return false, err
+	}
+	clientFlags := binary.BigEndian.Uint32(b[:])
+	if clientFlags&flagCFixedNewstyle == 0 {
+		return false, errors.New("client does not support fixed newstyle")
+	}
+	if clientFlags&^(flagCFixedNewstyle|flagCNoZeroes) != 0 {
+		return false, fmt.Errorf("unknown client flags %#x", clientFlags)
+	}
+	c.noZeroes = clientFlags&flagCNoZeroes != 0
+
+	for {
+		opt, err := readOption(c.reader)
+		if err != nil {
+			return false, err
+		}
+		done, err := c.handleOption(opt)
+		if err != nil {
+			return false, err
+		}
+		if done {
+			return c.export != nil, nil
+		}
+	}
+}
+
+// handleOption handles a single option. It returns true if the handshake is
+// done, either because an export has been selected or because the client
+// aborted.
+func (c *connection) handleOption(opt *option) (bool, error) {
+	reply := func(typ uint32, data []byte) error {
+		return writeOptionReply(c.conn, opt.option, typ, data)
+	}
+
+	switch opt.option {
+	case optExportName:
+		e := c.server.lookup(string(opt.data))
+		if e == nil {
+			return false, fmt.Errorf("unknown export %q", opt.data)
+		}
+		if err := c.selectExport(e); err != nil {
+			return false, err
+		}
+		b := append(be64(c.size), be16(c.exportFlags(c.export))...)
+		if !c.noZeroes {
+			b = append(b, make([]byte, zeroesPaddingSize)...)
+		}
+		_, err := c.conn.Write(b)
+		return true, err
+
+	case optAbort:
+		// the client may not wait for the reply
+		_ = reply(repAck, nil)
+		return true, nil
+
+	case optList:
+		if len(opt.data) != 0 {
+			return false, reply(repErrInvalid, nil)
+		}
+		for _, e := range c.server.exports {
+			data := append(be32(uint32(len(e.Name))), e.Name...)
+			if err := reply(repServer, data); err != nil {
+				return false, err
+			}
+		}
+		return false, reply(repAck, nil)
+
+	case optInfo, optGo:
+		name, ok := parseInfoRequest(opt.data)
+		if !ok {
+			return false, reply(repErrInvalid, nil)
+		}
+		e := c.server.lookup(name)
+		if e == nil {
+			return false, reply(repErrUnknown, nil)
+		}
+		size, err := e.Device.GetSize()
+		if err != nil {
+			return false, err
+		}
+		info := append(be16(infoExport), be64(size)...)
+		info = append(info, be16(c.exportFlags(e))...)
+		if err := reply(repInfo, info); err != nil {
+			return false, err
+		}
+		info = append(be16(infoBlockSize), be32(1)...)
+		info = append(info, be32(preferredBlockSize)...)
+		info = append(info, be32(maxRequestSize)...)
+		if err := reply(repInfo, info); err != nil {
+			return false, err
+		}
+		if opt.option == optInfo {
+			return false, reply(repAck, nil)
+		}
+		if err := c.selectExport(e); err != nil {
+			return false, err
+		}
+		return true, reply(repAck, nil)
+
+	case optStructuredReply:
+		if len(opt.data) != 0 {
+			return false, reply(repErrInvalid, nil)
+		}
+		c.structured = true
+		return false, reply(repAck, nil)
+
+	default:
+		return false, reply(repErrUnsup, nil)
+	}
+}
+
+// parseInfoRequest returns the export name of the data of an INFO or GO
+// option. The requested information items are ignored, the server always
+// sends the export and the block size information.
+func parseInfoRequest(data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	nameLen := int(binary.BigEndian.Uint32(data))
+	if nameLen > len(data)-4-2 {
+		return "", false
+	}
+	name := string(data[4 : 4+nameLen])
+	data = data[4+nameLen:]
+	nInfos := int(binary.BigEndian.Uint16(data))
+	if len(data) != 2+2*nInfos {
+		return "", false
+	}
+	return name, true
+}
+
+func (c *connection) selectExport(e *Export) error {
+	size, err := e.Device.GetSize()
+	if err != nil {
+		return err
+	}
+	c.export = e
+	c.size = size
+	return nil
+}
+
+func (c *connection) exportFlags(e *Export) uint16 {
+	flags := flagHasFlags | flagSendFlush | flagSendFUA | flagSendTrim |
+		flagSendWriteZeroes | flagCanMultiConn
+	if e.ReadOnly {
+		flags |= flagReadOnly
+	}
+	if c.structured {
+		flags |= flagSendDF
+	}
+	return flags
+}
+
+// transmission processes requests until the client disconnects. Requests
+// are processed concurrently, the replies may be sent out of order.
+func (c *connection) transmission() error {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxInflight)
+	)
+	defer wg.Wait()
+
+	for {
+		req, err := readRequest(c.reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		switch req.typ {
+		case cmdDisc:
+			return nil
+		case cmdWrite:
+			// a write that is too large can not be skipped safely
+			if req.length > maxRequestSize {
+				return fmt.Errorf("write request too large: %d bytes", req.length)
+			}
+			data = make([]byte, req.length)
+			if _, err := io.ReadFull(c.reader, data); err != nil {
+				return err
+			}
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			c.handleRequest(req, data)
+		}()
+	}
+}
+
+func (c *connection) handleRequest(req *request, data []byte) {
+	if req.typ == cmdRead {
+		c.handleRead(req)
+		return
+	}
+
+	var errno uint32
+	switch req.typ {
+	case cmdWrite:
+		errno = c.modify(req, func(dev Device) error {
+			_, err := dev.WriteAt(data, int64(req.offset))
+			return err
+		})
+	case cmdTrim:
+		errno = c.modify(req, func(dev Device) error {
+			_, err := dev.Discard(req.offset, uint64(req.length))
+			return err
+		})
+	case cmdWriteZeroes:
+		errno = c.modify(req, func(dev Device) error {
+			return writeZeroes(dev, req.offset, uint64(req.length))
+		})
+	case cmdFlush:
+		errno = toErrno(c.export.Device.Flush())
+	default:
+		errno = errInvalid
+	}
+	c.reply(simpleReply(req.cookie, errno, nil))
+}
+
+// modify runs a request modifying the device, after checking that the
+// export is writable and the request within the bounds of the device.
+func (c *connection) modify(req *request, fn func(Device) error) uint32 {
+	if c.export.ReadOnly {
+		return errPerm
+	}
+	if !c.inBounds(req) {
+		return errNoSpace
+	}
+	if err := fn(c.export.Device); err != nil {
+		return toErrno(err)
+	}
+	if req.flags&cmdFlagFUA != 0 {
+		return toErrno(c.export.Device.Flush())
+	}
+	return 0
+}
+
+func (c *connection) handleRead(req *request) {
+	var errno uint32
+	if req.length > maxRequestSize || !c.inBounds(req) {
+		errno = errInvalid
+	}
+
+	var data []byte
+	if errno == 0 && req.length > 0 {
+		data = make([]byte, req.length)
+		n, err := c.export.Device.ReadAt(data, int64(req.offset))
+		if n < len(data) {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			errno = toErrno(err)
+		}
+	}
+
+	if !c.structured {
+		if errno != 0 {
+			data = nil
+		}
+		c.reply(simpleReply(req.cookie, errno, data))
+		return
+	}
+
+	// a single chunk always satisfies the don't fragment flag
+	switch {
+	case errno != 0:
+		c.reply(structuredReply(req.cookie, replyFlagDone, replyTypeError,
+			be32(errno), be16(0)))
+	case len(data) == 0:
+		c.reply(structuredReply(req.cookie, replyFlagDone, replyTypeNone))
+	case isZero(data):
+		c.reply(structuredReply(req.cookie, replyFlagDone, replyTypeHole,
+			be64(req.offset), be32(req.length)))
+	default:
+		c.reply(structuredReply(req.cookie, replyFlagDone, replyTypeData,
+			be64(req.offset), data))
+	}
+}
+
+func (c *connection) inBounds(req *request) bool {
+	end := req.offset + uint64(req.length)
+	return end >= req.offset && end <= c.size
+}
+
+// reply sends a reply. Errors are not returned, a failing connection is
+// detected by the request loop.
+func (c *connection) reply(b []byte) {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	_, _ = c.conn.Write(b)
+}
+
+// writeZeroes zeroes the range using WriteSame, writing the unaligned tail
+// with WriteAt.
+func writeZeroes(dev Device, offset, length uint64) error {
+	zeroes := make([]byte, zeroBlockSize)
+	aligned := length - length%zeroBlockSize
+	if aligned > 0 {
+		if _, err := dev.WriteSame(offset, aligned, zeroes, 0); err != nil {
+			return err
+		}
+	}
+	if tail := length - aligned; tail > 0 {
+		if _, err := dev.WriteAt(zeroes[:tail], int64(offset+aligned)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// toErrno maps an error of a device to the error of a reply.
+func toErrno(err error) uint32 {
+	if err == nil {
+		return 0
+	}
+	var ec interface{ ErrorCode() int }
+	if errors.As(err, &ec) {
+		switch syscall.Errno(-ec.ErrorCode()) {
+		case syscall.EPERM, syscall.EROFS:
+			return errPerm
+		case syscall.ENOSPC, syscall.EDQUOT:
+			return errNoSpace
+		case syscall.EINVAL:
+			return errInvalid
+		case syscall.EOPNOTSUPP:
+			return errNotSup
+		}
+	}
+	return errIO
+}
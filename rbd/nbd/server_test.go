@@ -0,0 +1,340 @@
+//go:build ceph_preview
+
+package nbd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDeviceSize = 1 << 20
+
+func TestNewServer(t *testing.T) {
+	dev := newMemDevice(testDeviceSize)
+
+	_, err := NewServer(Export{Name: "a", Device: dev}, Export{Name: "b", Device: dev})
+	assert.NoError(t, err)
+	_, err = NewServer(Export{Name: "a"})
+	assert.ErrorIs(t, err, ErrInvalidExport)
+	_, err = NewServer(Export{Name: "a", Device: dev}, Export{Name: "a", Device: dev})
+	assert.ErrorIs(t, err, ErrInvalidExport)
+}
+
+func TestHandshake(t *testing.T) {
+	s, err := NewServer(
+		Export{Name: "one", Device: newMemDevice(testDeviceSize)},
+		Export{Name: "two", Device: newMemDevice(2 * testDeviceSize), ReadOnly: true})
+	require.NoError(t, err)
+
+	t.Run("list", func(t *testing.T) {
+		conn, done := serveSocketPair(t, s)
+		c := newTestClient(t, conn, true)
+		replies, err := c.option(optList, nil)
+		require.NoError(t, err)
+		require.Len(t, replies, 3)
+		var names []string
+		for _, r := range replies[:2] {
+			assert.Equal(t, repServer, r.typ)
+			names = append(names, string(r.data[4:]))
+		}
+		assert.Equal(t, []string{"one", "two"}, names)
+		assert.Equal(t, repAck, replies[2].typ)
+
+		replies, err = c.option(optAbort, nil)
+		require.NoError(t, err)
+		assert.Equal(t, repAck, replies[0].typ)
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("info", func(t *testing.T) {
+		conn, _ := serveSocketPair(t, s)
+		c := newTestClient(t, conn, true)
+		replies, err := c.option(optInfo, infoRequest("two"))
+		require.NoError(t, err)
+		require.Len(t, replies, 3)
+		export, blockSize := replies[0].data, replies[1].data
+		assert.Equal(t, infoExport, binary.BigEndian.Uint16(export))
+		assert.EqualValues(t, 2*testDeviceSize, binary.BigEndian.Uint64(export[2:]))
+		assert.NotZero(t, binary.BigEndian.Uint16(export[10:])&flagReadOnly)
+		assert.Equal(t, infoBlockSize, binary.BigEndian.Uint16(blockSize))
+		assert.EqualValues(t, maxRequestSize, binary.BigEndian.Uint32(blockSize[10:]))
+
+		replies, err = c.option(optInfo, infoRequest("three"))
+		require.NoError(t, err)
+		assert.Equal(t, repErrUnknown, replies[0].typ)
+		replies, err = c.option(optInfo, []byte{0, 0})
+		require.NoError(t, err)
+		assert.Equal(t, repErrInvalid, replies[0].typ)
+		replies, err = c.option(99, nil)
+		require.NoError(t, err)
+		assert.Equal(t, repErrUnsup, replies[0].typ)
+
+		// the connection is still usable after failed options
+		require.NoError(t, c.goExport("one"))
+		assert.EqualValues(t, testDeviceSize, c.size)
+		assert.Zero(t, c.flags&flagReadOnly)
+		assert.NotZero(t, c.flags&flagCanMultiConn)
+		assert.NoError(t, c.disconnect())
+	})
+
+	t.Run("exportName", func(t *testing.T) {
+		for _, noZeroes := range []bool{false, true} {
+			conn, done := serveSocketPair(t, s)
+			c := newTestClient(t, conn, noZeroes)
+			require.NoError(t, c.exportName("two", noZeroes))
+			assert.EqualValues(t, 2*testDeviceSize, c.size)
+			assert.NotZero(t, c.flags&flagReadOnly)
+			assert.NoError(t, c.disconnect())
+			assert.NoError(t, <-done)
+		}
+
+		conn, done := serveSocketPair(t, s)
+		c := newTestClient(t, conn, true)
+		require.NoError(t, c.sendOption(optExportName, []byte("three")))
+		assert.Error(t, <-done)
+	})
+
+	t.Run("defaultExport", func(t *testing.T) {
+		single, err := NewServer(Export{Name: "only", Device: newMemDevice(testDeviceSize)})
+		require.NoError(t, err)
+		conn, _ := serveSocketPair(t, single)
+		c := newTestClient(t, conn, true)
+		require.NoError(t, c.goExport(""))
+		assert.EqualValues(t, testDeviceSize, c.size)
+
+		conn, _ = serveSocketPair(t, s)
+		c = newTestClient(t, conn, true)
+		assert.Error(t, c.goExport(""))
+	})
+
+	t.Run("oldClient", func(t *testing.T) {
+		conn, done := serveSocketPair(t, s)
+		var greeting [18]byte
+		_, err := conn.Read(greeting[:])
+		require.NoError(t, err)
+		_, err = conn.Write(be32(0))
+		require.NoError(t, err)
+		assert.Error(t, <-done)
+	})
+}
+
+func TestTransmission(t *testing.T) {
+	for _, structured := range []bool{false, true} {
+		name := "simple"
+		if structured {
+			name = "structured"
+		}
+		t.Run(name, func(t *testing.T) {
+			testTransmission(t, structured)
+		})
+	}
+}
+
+func testTransmission(t *testing.T, structured bool) {
+	dev := newMemDevice(testDeviceSize)
+	s, err := NewServer(Export{Name: "mem", Device: dev})
+	require.NoError(t, err)
+	conn, done := serveSocketPair(t, s)
+	c := newTestClient(t, conn, true)
+	if structured {
+		require.NoError(t, c.structuredReplies())
+	}
+	require.NoError(t, c.goExport("mem"))
+	assert.Equal(t, structured, c.flags&flagSendDF != 0)
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 512)
+
+	t.Run("readWrite", func(t *testing.T) {
+		err := c.write(4096, data)
+		assert.NoError(t, err)
+		assert.Equal(t, data, dev.bytes(4096, len(data)))
+
+		b, err := c.read(4096, uint32(len(data)))
+		assert.NoError(t, err)
+		assert.Equal(t, data, b)
+
+		// reads of unwritten ranges are holes in structured replies
+		b, err = c.read(512*1024, 4096)
+		assert.NoError(t, err)
+		assert.Equal(t, make([]byte, 4096), b)
+
+		b, err = c.read(0, 0)
+		assert.NoError(t, err)
+		assert.Len(t, b, 0)
+	})
+
+	t.Run("trim", func(t *testing.T) {
+		err := c.command(cmdTrim, 0, 4096, 1024, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, make([]byte, 1024), dev.bytes(4096, 1024))
+		assert.Equal(t, data[1024:2048], dev.bytes(4096+1024, 1024))
+	})
+
+	t.Run("writeZeroes", func(t *testing.T) {
+		// unaligned length, the tail is written with WriteAt
+		err := c.command(cmdWriteZeroes, 0, 4096+1024, 1500, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, make([]byte, 2524), dev.bytes(4096, 2524))
+		assert.Equal(t, data[2524:2600], dev.bytes(4096+2524, 76))
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		flushes := dev.flushes
+		err := c.command(cmdFlush, 0, 0, 0, nil)
+		assert.NoError(t, err)
+		err = c.command(cmdWrite, cmdFlagFUA, 0, 4, []byte("fua!"))
+		assert.NoError(t, err)
+		assert.Equal(t, flushes+2, dev.flushes)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := c.read(testDeviceSize-10, 20)
+		assert.Equal(t, nbdError(errInvalid), err)
+		err = c.write(testDeviceSize-2, []byte("four"))
+		assert.Equal(t, nbdError(errNoSpace), err)
+		err = c.command(cmdTrim, 0, testDeviceSize, 1, nil)
+		assert.Equal(t, nbdError(errNoSpace), err)
+		err = c.command(42, 0, 0, 0, nil)
+		assert.Equal(t, nbdError(errInvalid), err)
+
+		dev.mutex.Lock()
+		dev.failing = errors.New("broken")
+		dev.mutex.Unlock()
+		_, err = c.read(0, 16)
+		assert.Equal(t, nbdError(errIO), err)
+		dev.mutex.Lock()
+		dev.failing = nil
+		dev.mutex.Unlock()
+	})
+
+	assert.NoError(t, c.disconnect())
+	assert.NoError(t, <-done)
+}
+
+func TestReadOnly(t *testing.T) {
+	dev := newMemDevice(testDeviceSize)
+	s, err := NewServer(Export{Name: "ro", Device: dev, ReadOnly: true})
+	require.NoError(t, err)
+	conn, _ := serveSocketPair(t, s)
+	c := newTestClient(t, conn, true)
+	require.NoError(t, c.structuredReplies())
+	require.NoError(t, c.goExport("ro"))
+	assert.NotZero(t, c.flags&flagReadOnly)
+
+	err = c.write(0, []byte("data"))
+	assert.Equal(t, nbdError(errPerm), err)
+	err = c.command(cmdTrim, 0, 0, 4096, nil)
+	assert.Equal(t, nbdError(errPerm), err)
+	err = c.command(cmdWriteZeroes, 0, 0, 4096, nil)
+	assert.Equal(t, nbdError(errPerm), err)
+	_, err = c.read(0, 4096)
+	assert.NoError(t, err)
+	assert.NoError(t, c.command(cmdFlush, 0, 0, 0, nil))
+}
+
+func TestMultiConn(t *testing.T) {
+	dev := newMemDevice(testDeviceSize)
+	s, err := NewServer(Export{Name: "mem", Device: dev})
+	require.NoError(t, err)
+
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "nbd.sock"))
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+
+	const nconns = 4
+	var wg sync.WaitGroup
+	for i := 0; i < nconns; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := net.Dial("unix", l.Addr().String())
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			c := newTestClient(t, conn, true)
+			if !assert.NoError(t, c.goExport("mem")) {
+				return
+			}
+			data := bytes.Repeat([]byte{byte('a' + i)}, 8192)
+			assert.NoError(t, c.write(uint64(i)*8192, data))
+			assert.NoError(t, c.command(cmdFlush, 0, 0, 0, nil))
+			assert.NoError(t, c.disconnect())
+		}(i)
+	}
+	wg.Wait()
+
+	// every connection sees the writes of the others
+	conn, err := net.Dial("unix", l.Addr().String())
+	require.NoError(t, err)
+	c := newTestClient(t, conn, true)
+	require.NoError(t, c.goExport("mem"))
+	for i := 0; i < nconns; i++ {
+		b, err := c.read(uint64(i)*8192, 8192)
+		assert.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte{byte('a' + i)}, 8192), b)
+	}
+
+	assert.NoError(t, s.Close())
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+	// the open connection is closed by the server
+	_, err = c.read(0, 1)
+	assert.Error(t, err)
+	assert.ErrorIs(t, s.Serve(l), ErrServerClosed)
+}
+
+// blockingDevice is a memDevice whose Flush blocks until release is closed.
+type blockingDevice struct {
+	*memDevice
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (d *blockingDevice) Flush() error {
+	close(d.entered)
+	<-d.release
+	return d.memDevice.Flush()
+}
+
+func TestCloseWaitsForRequests(t *testing.T) {
+	dev := &blockingDevice{
+		memDevice: newMemDevice(testDeviceSize),
+		entered:   make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+	s, err := NewServer(Export{Name: "mem", Device: dev})
+	require.NoError(t, err)
+	conn, done := serveSocketPair(t, s)
+	c := newTestClient(t, conn, true)
+	require.NoError(t, c.goExport("mem"))
+	_, err = c.send(cmdFlush, 0, 0, 0, nil)
+	require.NoError(t, err)
+	<-dev.entered
+
+	closed := make(chan struct{})
+	go func() {
+		assert.NoError(t, s.Close())
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a request was in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(dev.release)
+	<-closed
+	assert.Equal(t, 1, dev.flushes)
+	assert.ErrorIs(t, <-done, ErrServerClosed)
+}
@@ -37,11 +37,12 @@ func GetPoolMetadata(ioctx *rados.IOContext, key string) (string, error) {
 	retry.WithSizes(4096, 262144, func(size int) retry.Hint {
 		cSize := C.size_t(size)
 		buf = make([]byte, cSize)
+		t := startOp(ioctx, "rbd_pool_metadata_get", "")
 		ret := C.rbd_pool_metadata_get(cephIoctx(ioctx),
 			cKey,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cSize)
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.Size(int(cSize)).If(err == errRange)
 	})
 
@@ -66,8 +67,9 @@ func SetPoolMetadata(ioctx *rados.IOContext, key, value string) error {
 	cValue := C.CString(value)
 	defer C.free(unsafe.Pointer(cValue))
 
+	t := startOp(ioctx, "rbd_pool_metadata_set", "")
 	ret := C.rbd_pool_metadata_set(cephIoctx(ioctx), cKey, cValue)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // RemovePoolMetadata removes the pool metadata value for a given pool metadata key.
@@ -83,8 +85,9 @@ func RemovePoolMetadata(ioctx *rados.IOContext, key string) error {
 	cKey := C.CString(key)
 	defer C.free(unsafe.Pointer(cKey))
 
+	t := startOp(ioctx, "rbd_pool_metadata_remove", "")
 	ret := C.rbd_pool_metadata_remove(cephIoctx(ioctx), cKey)
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // PoolInit initializes a pool for use by rbd.
@@ -99,8 +102,9 @@ func PoolInit(ioctx *rados.IOContext, force bool) error {
 		return ErrNoIOContext
 	}
 
+	t := startOp(ioctx, "rbd_pool_init", "")
 	ret := C.rbd_pool_init(cephIoctx(ioctx), C.bool(force))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // poolStats represents RBD pool stats variable.
@@ -212,7 +216,9 @@ func GetAllPoolStats(ioctx *rados.IOContext) (map[PoolStatOption]uint64, error)
 		}
 	}
 
+	t := startOp(ioctx, "rbd_pool_stats_get", "")
 	ret := C.rbd_pool_stats_get(cephIoctx(ioctx), poolstats.stats)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return omap, getError(ret)
 	}
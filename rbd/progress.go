@@ -0,0 +1,244 @@
+//go:build ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <errno.h>
+#include <stdlib.h>
+#include <rados/librados.h>
+#include <rbd/librbd.h>
+
+extern int progressCallback(uint64_t, uint64_t, uintptr_t);
+
+// inline wrappers to cast uintptr_t to void*
+static inline int wrap_rbd_flatten_with_progress(rbd_image_t image,
+		uintptr_t arg) {
+	return rbd_flatten_with_progress(image,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+
+static inline int wrap_rbd_copy_with_progress2(rbd_image_t src,
+		rbd_image_t dest, uintptr_t arg) {
+	return rbd_copy_with_progress2(src, dest,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+
+static inline int wrap_rbd_deep_copy_with_progress(rbd_image_t src,
+		rados_ioctx_t dest_io_ctx, const char *destname,
+		rbd_image_options_t dest_opts, uintptr_t arg) {
+	return rbd_deep_copy_with_progress(src, dest_io_ctx, destname, dest_opts,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+
+static inline int wrap_rbd_snap_rollback_with_progress(rbd_image_t image,
+		const char *snapname, uintptr_t arg) {
+	return rbd_snap_rollback_with_progress(image, snapname,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+
+static inline int wrap_rbd_remove_with_progress(rados_ioctx_t io,
+		const char *name, uintptr_t arg) {
+	return rbd_remove_with_progress(io, name,
+		(librbd_progress_fn_t)progressCallback, (void*)arg);
+};
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/callbacks"
+	"github.com/ceph/go-ceph/rados"
+)
+
+// ProgressCallback defines the function signature needed for the callbacks
+// of the WithProgress variants of long running image operations.
+//
+// The callback will be called with the progress made so far, the total
+// amount of work and the data argument passed along with the callback. The
+// operation is aborted if the callback returns a non-zero value, if librbd
+// supports aborting the operation.
+type ProgressCallback func(uint64, uint64, interface{}) int
+
+var progressCallbacks = callbacks.New()
+
+type progressCallbackCtx struct {
+	callback ProgressCallback
+	data     interface{}
+}
+
+// addProgressCallback registers the callback. The returned index has to be
+// removed from progressCallbacks once the operation is done.
+func addProgressCallback(cb ProgressCallback, data interface{}) uintptr {
+	return progressCallbacks.Add(progressCallbackCtx{
+		callback: cb,
+		data:     data,
+	})
+}
+
+//export progressCallback
+func progressCallback(
+	offset, total C.uint64_t, index uintptr) C.int {
+
+	v := progressCallbacks.Lookup(index)
+	ctx := v.(progressCallbackCtx)
+	return C.int(ctx.callback(uint64(offset), uint64(total), ctx.data))
+}
+
+// FlattenWithProgress removes snapshot references from the image, like
+// Flatten, calling the callback to report progress. Returning a non-zero
+// value from the callback aborts the flatten.
+//
+// Implements:
+//
+//	int rbd_flatten_with_progress(rbd_image_t image,
+//	                              librbd_progress_fn_t cb, void *cbdata);
+func (image *Image) FlattenWithProgress(cb ProgressCallback, data interface{}) error {
+	// the provided callback must be a real function
+	if cb == nil {
+		return getError(-C.EINVAL)
+	}
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+
+	cbIndex := addProgressCallback(cb, data)
+	defer progressCallbacks.Remove(cbIndex)
+
+	t := image.startOp("rbd_flatten_with_progress")
+	ret := C.wrap_rbd_flatten_with_progress(image.image, C.uintptr_t(cbIndex))
+	return t.DoneErr(getError(ret))
+}
+
+// Copy2WithProgress copies one rbd image to another, like Copy2, calling
+// the callback to report progress. Returning a non-zero value from the
+// callback aborts the copy.
+//
+// Implements:
+//
+//	int rbd_copy_with_progress2(rbd_image_t src, rbd_image_t dest,
+//	                            librbd_progress_fn_t cb, void *cbdata);
+func (image *Image) Copy2WithProgress(dest *Image, cb ProgressCallback, data interface{}) error {
+	// the provided callback must be a real function
+	if cb == nil {
+		return getError(-C.EINVAL)
+	}
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	} else if err := dest.validate(imageIsOpen); err != nil {
+		return err
+	}
+
+	cbIndex := addProgressCallback(cb, data)
+	defer progressCallbacks.Remove(cbIndex)
+
+	t := image.startOp("rbd_copy_with_progress2")
+	ret := C.wrap_rbd_copy_with_progress2(
+		image.image, dest.image, C.uintptr_t(cbIndex))
+	return t.DoneErr(getError(ret))
+}
+
+// DeepCopyWithProgress copies an rbd image to a new image, like DeepCopy,
+// calling the callback to report progress. Returning a non-zero value from
+// the callback aborts the copy.
+//
+// Implements:
+//
+//	int rbd_deep_copy_with_progress(rbd_image_t image,
+//	                                rados_ioctx_t dest_io_ctx,
+//	                                const char *destname,
+//	                                rbd_image_options_t dest_opts,
+//	                                librbd_progress_fn_t cb, void *cbdata);
+func (image *Image) DeepCopyWithProgress(ioctx *rados.IOContext, destname string,
+	rio *ImageOptions, cb ProgressCallback, data interface{}) error {
+
+	// the provided callback must be a real function
+	if cb == nil {
+		return getError(-C.EINVAL)
+	}
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if destname == "" {
+		return ErrNoName
+	}
+	if rio == nil {
+		return getError(-C.EINVAL)
+	}
+
+	cDestname := C.CString(destname)
+	defer C.free(unsafe.Pointer(cDestname))
+
+	cbIndex := addProgressCallback(cb, data)
+	defer progressCallbacks.Remove(cbIndex)
+
+	t := image.startOp("rbd_deep_copy_with_progress")
+	ret := C.wrap_rbd_deep_copy_with_progress(image.image, cephIoctx(ioctx),
+		cDestname, C.rbd_image_options_t(rio.options), C.uintptr_t(cbIndex))
+	return t.DoneErr(getError(ret))
+}
+
+// RollbackWithProgress rolls back the image to the snapshot, like Rollback,
+// calling the callback to report progress. Returning a non-zero value from
+// the callback aborts the rollback, leaving the image partially rolled
+// back.
+//
+// Implements:
+//
+//	int rbd_snap_rollback_with_progress(rbd_image_t image,
+//	                                    const char *snapname,
+//	                                    librbd_progress_fn_t cb,
+//	                                    void *cbdata);
+func (snapshot *Snapshot) RollbackWithProgress(cb ProgressCallback, data interface{}) error {
+	// the provided callback must be a real function
+	if cb == nil {
+		return getError(-C.EINVAL)
+	}
+	if err := snapshot.validate(snapshotNeedsName | imageIsOpen); err != nil {
+		return err
+	}
+
+	cSnapName := C.CString(snapshot.name)
+	defer C.free(unsafe.Pointer(cSnapName))
+
+	cbIndex := addProgressCallback(cb, data)
+	defer progressCallbacks.Remove(cbIndex)
+
+	t := snapshot.image.startOp("rbd_snap_rollback_with_progress")
+	ret := C.wrap_rbd_snap_rollback_with_progress(
+		snapshot.image.image, cSnapName, C.uintptr_t(cbIndex))
+	return t.DoneErr(getError(ret))
+}
+
+// RemoveWithProgress removes the image, like Remove, calling the callback to
+// report progress. Returning a non-zero value from the callback aborts the
+// removal, possibly leaving a partially removed image behind.
+//
+// Implements:
+//
+//	int rbd_remove_with_progress(rados_ioctx_t io, const char *name,
+//	                             librbd_progress_fn_t cb, void *cbdata);
+func (image *Image) RemoveWithProgress(cb ProgressCallback, data interface{}) error {
+	// the provided callback must be a real function
+	if cb == nil {
+		return getError(-C.EINVAL)
+	}
+	if err := image.validate(imageNeedsIOContext | imageNeedsName | imageIsNotOpen); err != nil {
+		return err
+	}
+
+	cName := C.CString(image.name)
+	defer C.free(unsafe.Pointer(cName))
+
+	cbIndex := addProgressCallback(cb, data)
+	defer progressCallbacks.Remove(cbIndex)
+
+	t := image.startOp("rbd_remove_with_progress")
+	ret := C.wrap_rbd_remove_with_progress(
+		cephIoctx(image.ioctx), cName, C.uintptr_t(cbIndex))
+	return t.DoneErr(getError(ret))
+}
@@ -0,0 +1,144 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithProgress(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t, options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	require.NoError(t, options.SetUint64(ImageOptionFeatures, FeatureLayering))
+
+	name := GetUUID()
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, img.Close())
+		assert.NoError(t, RemoveImage(ioctx, name))
+	}()
+	_, err = img.WriteAt([]byte("progress"), 0)
+	require.NoError(t, err)
+
+	// counter returns a callback counting its calls and checking the data
+	counter := func(t *testing.T, calls *int) ProgressCallback {
+		return func(progress, total uint64, data interface{}) int {
+			*calls++
+			assert.Equal(t, "data", data)
+			assert.LessOrEqual(t, progress, total)
+			return 0
+		}
+	}
+	abort := func(uint64, uint64, interface{}) int { return -1 }
+
+	t.Run("copy2", func(t *testing.T) {
+		dstName := GetUUID()
+		err := CreateImage(ioctx, dstName, testImageSize, options)
+		require.NoError(t, err)
+		dst, err := OpenImage(ioctx, dstName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, dst.Close())
+			assert.NoError(t, RemoveImage(ioctx, dstName))
+		}()
+
+		calls := 0
+		err = img.Copy2WithProgress(dst, counter(t, &calls), "data")
+		assert.NoError(t, err)
+		assert.Greater(t, calls, 0)
+		buf := make([]byte, 8)
+		_, err = dst.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "progress", string(buf))
+
+		err = img.Copy2WithProgress(dst, abort, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("deepCopy", func(t *testing.T) {
+		dstName := GetUUID()
+		calls := 0
+		err := img.DeepCopyWithProgress(
+			ioctx, dstName, options, counter(t, &calls), "data")
+		assert.NoError(t, err)
+		assert.Greater(t, calls, 0)
+
+		// removing reports progress as well
+		calls = 0
+		err = GetImage(ioctx, dstName).RemoveWithProgress(counter(t, &calls), "data")
+		assert.NoError(t, err)
+		assert.Greater(t, calls, 0)
+
+		err = img.DeepCopyWithProgress(ioctx, GetUUID(), options, abort, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		snap, err := img.CreateSnapshot("rollback")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, snap.Remove()) }()
+		_, err = img.WriteAt([]byte("changed!"), 0)
+		require.NoError(t, err)
+
+		calls := 0
+		err = snap.RollbackWithProgress(counter(t, &calls), "data")
+		assert.NoError(t, err)
+		assert.Greater(t, calls, 0)
+		buf := make([]byte, 8)
+		_, err = img.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "progress", string(buf))
+	})
+
+	t.Run("flatten", func(t *testing.T) {
+		snap, err := img.CreateSnapshot("parent")
+		require.NoError(t, err)
+		require.NoError(t, snap.Protect())
+		defer func() {
+			assert.NoError(t, snap.Unprotect())
+			assert.NoError(t, snap.Remove())
+		}()
+		cloneName := GetUUID()
+		err = CloneImage(ioctx, name, "parent", ioctx, cloneName, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, cloneName)) }()
+		clone, err := OpenImage(ioctx, cloneName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, clone.Close()) }()
+
+		calls := 0
+		err = clone.FlattenWithProgress(counter(t, &calls), "data")
+		assert.NoError(t, err)
+		assert.Greater(t, calls, 0)
+		_, err = clone.GetParent()
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := img.FlattenWithProgress(nil, nil)
+		assert.Error(t, err)
+		err = GetImage(ioctx, name).FlattenWithProgress(abort, nil)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		err = img.RemoveWithProgress(abort, nil)
+		assert.ErrorIs(t, err, ErrImageIsOpen)
+	})
+}
@@ -0,0 +1,347 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrInvalidImageFile is returned if an image file is corrupt.
+	ErrInvalidImageFile = errors.New("invalid image file")
+	// ErrUnsupportedImageFile is returned if an image file uses a feature
+	// that is not supported, like a qcow2 backing file or encryption.
+	ErrUnsupportedImageFile = errors.New("unsupported image file")
+)
+
+// The layout of qcow2 images, as documented in docs/interop/qcow2.txt of
+// the QEMU project.
+const (
+	qcow2Magic = uint32('Q'<<24 | 'F'<<16 | 'I'<<8 | 0xfb)
+
+	qcow2HeaderSizeV2 = 72
+	qcow2HeaderSizeV3 = 104
+
+	qcow2MinClusterBits = 9
+	qcow2MaxClusterBits = 21
+
+	// incompatible features
+	qcow2IncompatDirty         = uint64(1 << 0)
+	qcow2IncompatCorrupt       = uint64(1 << 1)
+	qcow2IncompatExternalData  = uint64(1 << 2)
+	qcow2IncompatCompression   = uint64(1 << 3)
+	qcow2IncompatExtendedL2    = uint64(1 << 4)
+	qcow2IncompatKnownFeatures = qcow2IncompatDirty | qcow2IncompatCorrupt |
+		qcow2IncompatExternalData | qcow2IncompatCompression |
+		qcow2IncompatExtendedL2
+
+	// L1 and L2 table entries
+	qcow2OffsetMask     = uint64(0x00fffffffffffe00)
+	qcow2CopiedFlag     = uint64(1 << 63)
+	qcow2CompressedFlag = uint64(1 << 62)
+	qcow2ZeroFlag       = uint64(1 << 0)
+
+	// maxQcow2L1Size limits the size of the L1 table read from a file.
+	maxQcow2L1Size = 32 * 1024 * 1024
+)
+
+// qcow2Header contains the fields of a qcow2 header used by the importer
+// and the exporter.
+type qcow2Header struct {
+	version             uint32
+	backingFileOffset   uint64
+	backingFileSize     uint32
+	clusterBits         uint32
+	size                uint64
+	cryptMethod         uint32
+	l1Size              uint32
+	l1TableOffset       uint64
+	refcountTableOffset uint64
+	refcountClusters    uint32
+	nbSnapshots         uint32
+	snapshotsOffset     uint64
+	incompatible        uint64
+	compatible          uint64
+	autoclear           uint64
+	refcountOrder       uint32
+	headerLength        uint32
+	compressionType     uint8
+}
+
+func (h *qcow2Header) clusterSize() uint64 {
+	return 1 << h.clusterBits
+}
+
+// l2Entries returns the number of entries of an L2 table.
+func (h *qcow2Header) l2Entries() uint64 {
+	return h.clusterSize() / 8
+}
+
+// isQcow2 returns true if the data starts with the qcow2 magic.
+func isQcow2(r io.ReaderAt) bool {
+	var b [4]byte
+	_, err := r.ReadAt(b[:], 0)
+	return err == nil && binary.BigEndian.Uint32(b[:]) == qcow2Magic
+}
+
+func invalidImageFile(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidImageFile, fmt.Sprintf(format, args...))
+}
+
+func unsupportedImageFile(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedImageFile, fmt.Sprintf(format, args...))
+}
+
+// readAtFull reads len(b) bytes at off, treating a short read as an
+// invalid file.
+func readAtFull(r io.ReaderAt, b []byte, off int64) error {
+	n, err := r.ReadAt(b, off)
+	if n == len(b) {
+		return nil
+	}
+	if err == io.EOF || err == nil {
+		return invalidImageFile("%d bytes at offset %d beyond end of file",
+			len(b), off)
+	}
+	return err
+}
+
+// readQcow2Header reads and validates the header of a qcow2 image. Images
+// with a backing file, encryption, an external data file, extended L2
+// entries or a compression type other than zlib are refused.
+func readQcow2Header(r io.ReaderAt, fileSize int64) (*qcow2Header, error) {
+	b := make([]byte, qcow2HeaderSizeV3+8)
+	n, err := r.ReadAt(b, 0)
+	if n < qcow2HeaderSizeV2 {
+		if err == nil || err == io.EOF {
+			return nil, invalidImageFile("qcow2 header truncated")
+		}
+		return nil, err
+	}
+	b = b[:n]
+	be := binary.BigEndian
+	if be.Uint32(b[0:]) != qcow2Magic {
+		return nil, invalidImageFile("no qcow2 magic")
+	}
+	h := &qcow2Header{
+		version:             be.Uint32(b[4:]),
+		backingFileOffset:   be.Uint64(b[8:]),
+		backingFileSize:     be.Uint32(b[16:]),
+		clusterBits:         be.Uint32(b[20:]),
+		size:                be.Uint64(b[24:]),
+		cryptMethod:         be.Uint32(b[32:]),
+		l1Size:              be.Uint32(b[36:]),
+		l1TableOffset:       be.Uint64(b[40:]),
+		refcountTableOffset: be.Uint64(b[48:]),
+		refcountClusters:    be.Uint32(b[56:]),
+		nbSnapshots:         be.Uint32(b[60:]),
+		snapshotsOffset:     be.Uint64(b[64:]),
+		refcountOrder:       4,
+		headerLength:        qcow2HeaderSizeV2,
+	}
+	switch h.version {
+	case 2:
+	case 3:
+		if len(b) < qcow2HeaderSizeV3 {
+			return nil, invalidImageFile("qcow2 v3 header truncated")
+		}
+		h.incompatible = be.Uint64(b[72:])
+		h.compatible = be.Uint64(b[80:])
+		h.autoclear = be.Uint64(b[88:])
+		h.refcountOrder = be.Uint32(b[96:])
+		h.headerLength = be.Uint32(b[100:])
+		if h.headerLength > qcow2HeaderSizeV3 && len(b) > qcow2HeaderSizeV3 {
+			h.compressionType = b[qcow2HeaderSizeV3]
+		}
+	default:
+		return nil, unsupportedImageFile("qcow version %d", h.version)
+	}
+
+	if h.backingFileOffset != 0 {
+		name := make([]byte, min(h.backingFileSize, 1023))
+		if err := readAtFull(r, name, int64(h.backingFileOffset)); err != nil {
+			return nil, err
+		}
+		return nil, unsupportedImageFile("qcow2 image has backing file %q", name)
+	}
+	if h.cryptMethod != 0 {
+		return nil, unsupportedImageFile("qcow2 image is encrypted")
+	}
+	if unknown := h.incompatible &^ qcow2IncompatKnownFeatures; unknown != 0 {
+		return nil, unsupportedImageFile("qcow2 incompatible features %#x", unknown)
+	}
+	switch {
+	case h.incompatible&qcow2IncompatCorrupt != 0:
+		return nil, invalidImageFile("qcow2 image is marked corrupt")
+	case h.incompatible&qcow2IncompatExternalData != 0:
+		return nil, unsupportedImageFile("qcow2 image has an external data file")
+	case h.incompatible&qcow2IncompatExtendedL2 != 0:
+		return nil, unsupportedImageFile("qcow2 image has extended L2 entries")
+	case h.compressionType != 0:
+		return nil, unsupportedImageFile("qcow2 compression type %d", h.compressionType)
+	}
+	if h.clusterBits < qcow2MinClusterBits || h.clusterBits > qcow2MaxClusterBits {
+		return nil, invalidImageFile("qcow2 cluster bits %d", h.clusterBits)
+	}
+
+	// every cluster of the image has to be covered by the L1 table
+	l2Coverage := h.clusterSize() * h.l2Entries()
+	needed := (h.size + l2Coverage - 1) / l2Coverage
+	if uint64(h.l1Size) < needed {
+		return nil, invalidImageFile("qcow2 L1 table too small: %d entries", h.l1Size)
+	}
+	if uint64(h.l1Size)*8 > maxQcow2L1Size {
+		return nil, invalidImageFile("qcow2 L1 table too large: %d entries", h.l1Size)
+	}
+	if h.l1TableOffset%h.clusterSize() != 0 ||
+		h.l1TableOffset+uint64(h.l1Size)*8 > uint64(fileSize) {
+		return nil, invalidImageFile("qcow2 L1 table at invalid offset %d",
+			h.l1TableOffset)
+	}
+	return h, nil
+}
+
+// fileExtent is a range of the virtual disk of an image file that is
+// backed by data of the file.
+type fileExtent struct {
+	offset     uint64
+	length     uint64
+	hostOffset int64
+	// compressedSize is set for compressed qcow2 clusters, whose data is
+	// deflate compressed, starting at hostOffset.
+	compressedSize int64
+}
+
+// walkQcow2 calls fn for every L2 entry of the active image of a qcow2 file
+// that is not unallocated, in order of offset, with the offset and the
+// length of its cluster in the virtual disk. If l2Fn is set, it is called for
+// every L1 entry that points to an L2 table before the table is read.
+func walkQcow2(r io.ReaderAt, fileSize int64, h *qcow2Header,
+	l2Fn func(l1Entry uint64) error, fn func(offset, length, entry uint64) error) error {
+
+	clusterSize := h.clusterSize()
+	l1 := make([]byte, uint64(h.l1Size)*8)
+	if err := readAtFull(r, l1, int64(h.l1TableOffset)); err != nil {
+		return err
+	}
+	l2 := make([]byte, clusterSize)
+	for l1Index := uint64(0); l1Index < uint64(h.l1Size); l1Index++ {
+		l1Entry := binary.BigEndian.Uint64(l1[l1Index*8:])
+		l2Offset := l1Entry & qcow2OffsetMask
+		if l2Offset == 0 {
+			continue
+		}
+		if l2Offset%clusterSize != 0 || l2Offset+clusterSize > uint64(fileSize) {
+			return invalidImageFile("qcow2 L2 table at invalid offset %d", l2Offset)
+		}
+		if l2Fn != nil {
+			if err := l2Fn(l1Entry); err != nil {
+				return err
+			}
+		}
+		if err := readAtFull(r, l2, int64(l2Offset)); err != nil {
+			return err
+		}
+		for l2Index := uint64(0); l2Index < h.l2Entries(); l2Index++ {
+			offset := (l1Index*h.l2Entries() + l2Index) * clusterSize
+			if offset >= h.size {
+				break
+			}
+			entry := binary.BigEndian.Uint64(l2[l2Index*8:])
+			if entry == 0 {
+				continue
+			}
+			if err := fn(offset, min(clusterSize, h.size-offset), entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// qcow2Extents returns the allocated extents of the active image of a qcow2
+// file, sorted by offset. Clusters that are unallocated or read as zeros are
+// not included. Adjacent clusters are merged into extents of at most
+// maxLength bytes.
+func qcow2Extents(r io.ReaderAt, fileSize int64, h *qcow2Header,
+	maxLength uint64) ([]fileExtent, error) {
+
+	var extents []fileExtent
+	add := func(e fileExtent) {
+		if n := len(extents); n > 0 && e.compressedSize == 0 {
+			last := &extents[n-1]
+			if last.compressedSize == 0 &&
+				last.offset+last.length == e.offset &&
+				last.hostOffset+int64(last.length) == e.hostOffset &&
+				last.length+e.length <= maxLength {
+				last.length += e.length
+				return
+			}
+		}
+		extents = append(extents, e)
+	}
+
+	err := walkQcow2(r, fileSize, h, nil, func(offset, length, entry uint64) error {
+		if entry&qcow2CompressedFlag != 0 {
+			hostOffset, size := h.compressedCluster(entry)
+			if hostOffset >= uint64(fileSize) {
+				return invalidImageFile(
+					"qcow2 compressed cluster at invalid offset %d", hostOffset)
+			}
+			// the compressed size is rounded up to sectors and may
+			// extend past the end of the file
+			size = min(size, uint64(fileSize)-hostOffset)
+			add(fileExtent{offset, length, int64(hostOffset), int64(size)})
+			return nil
+		}
+		hostOffset := entry & qcow2OffsetMask
+		if entry&qcow2ZeroFlag != 0 || hostOffset == 0 {
+			return nil
+		}
+		if hostOffset%h.clusterSize() != 0 || hostOffset+length > uint64(fileSize) {
+			return invalidImageFile(
+				"qcow2 data cluster at invalid offset %d", hostOffset)
+		}
+		add(fileExtent{offset, length, int64(hostOffset), 0})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return extents, nil
+}
+
+// compressedCluster returns the host offset and the maximum size of the
+// compressed data of an L2 entry of a compressed cluster.
+func (h *qcow2Header) compressedCluster(entry uint64) (uint64, uint64) {
+	x := 62 - (h.clusterBits - 8)
+	hostOffset := entry & (1<<x - 1)
+	sectors := (entry>>x)&(1<<(h.clusterBits-8)-1) + 1
+	return hostOffset, sectors*512 - hostOffset%512
+}
+
+// readFileExtent reads the data of an extent, decompressing compressed
+// clusters.
+func readFileExtent(r io.ReaderAt, e fileExtent, clusterSize uint64) ([]byte, error) {
+	if e.compressedSize == 0 {
+		data := make([]byte, e.length)
+		return data, readAtFull(r, data, e.hostOffset)
+	}
+	compressed := make([]byte, e.compressedSize)
+	if err := readAtFull(r, compressed, e.hostOffset); err != nil {
+		return nil, err
+	}
+	data := make([]byte, clusterSize)
+	zr := flate.NewReader(bytes.NewReader(compressed))
+	defer zr.Close()
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, invalidImageFile(
+			"qcow2 compressed cluster at offset %d: %v", e.hostOffset, err)
+	}
+	return data[:e.length], nil
+}
@@ -0,0 +1,175 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// ValidateQcow2 checks the consistency of the qcow2 file read from r, of
+// fileSize bytes. Besides the header, it checks that the L1 and L2 tables
+// and all data clusters are inside the file, that every compressed cluster
+// can be decompressed, and that the refcounts and the copied flags of the
+// L1 and L2 entries match the references to every cluster, like
+// "qemu-img check" does.
+//
+// Files that cannot be imported by ImportFile, and files with internal
+// snapshots, are refused with ErrUnsupportedImageFile. Any inconsistency is
+// reported with an error wrapping ErrInvalidImageFile.
+func ValidateQcow2(r io.ReaderAt, fileSize int64) error {
+	h, err := readQcow2Header(r, fileSize)
+	if err != nil {
+		return err
+	}
+	switch {
+	case h.incompatible&qcow2IncompatDirty != 0:
+		return invalidImageFile("qcow2 image is marked dirty")
+	case h.nbSnapshots != 0:
+		return unsupportedImageFile("qcow2 image has %d internal snapshots",
+			h.nbSnapshots)
+	case h.refcountOrder < 3 || h.refcountOrder > 6:
+		return unsupportedImageFile("qcow2 refcount order %d", h.refcountOrder)
+	}
+	cs := h.clusterSize()
+	clusters := (uint64(fileSize) + cs - 1) / cs
+
+	// count the references to every cluster of the file
+	refs := make([]uint64, clusters)
+	ref := func(what string, off, length uint64) error {
+		if length == 0 {
+			return nil
+		}
+		if off+length > uint64(fileSize) {
+			return invalidImageFile("qcow2 %s at offset %d beyond end of file",
+				what, off)
+		}
+		for c := off / cs; c <= (off+length-1)/cs; c++ {
+			refs[c]++
+		}
+		return nil
+	}
+	// copied flags are checked once the refcounts are known
+	type copiedFlag struct {
+		what    string
+		offset  uint64
+		entry   uint64
+		cluster uint64
+	}
+	var flags []copiedFlag
+
+	if err := ref("header", 0, cs); err != nil {
+		return err
+	}
+	if err := ref("L1 table", h.l1TableOffset, uint64(h.l1Size)*8); err != nil {
+		return err
+	}
+	if h.refcountTableOffset%cs != 0 || h.refcountClusters == 0 {
+		return invalidImageFile("qcow2 refcount table at invalid offset %d",
+			h.refcountTableOffset)
+	}
+	tableSize := uint64(h.refcountClusters) * cs
+	if err := ref("refcount table", h.refcountTableOffset, tableSize); err != nil {
+		return err
+	}
+	table := make([]byte, tableSize)
+	if err := readAtFull(r, table, int64(h.refcountTableOffset)); err != nil {
+		return err
+	}
+	var blocks []uint64
+	for i := uint64(0); i < tableSize/8; i++ {
+		off := binary.BigEndian.Uint64(table[i*8:]) & qcow2OffsetMask
+		if off%cs != 0 {
+			return invalidImageFile("qcow2 refcount block at invalid offset %d", off)
+		}
+		if off != 0 {
+			if err := ref("refcount block", off, cs); err != nil {
+				return err
+			}
+		}
+		blocks = append(blocks, off)
+	}
+
+	err = walkQcow2(r, fileSize, h,
+		func(l1Entry uint64) error {
+			off := l1Entry & qcow2OffsetMask
+			flags = append(flags, copiedFlag{"L2 table", off, l1Entry, off / cs})
+			return ref("L2 table", off, cs)
+		},
+		func(offset, length, entry uint64) error {
+			if entry&qcow2CompressedFlag != 0 {
+				if entry&qcow2CopiedFlag != 0 {
+					return invalidImageFile(
+						"qcow2 compressed cluster at %d has the copied flag", offset)
+				}
+				hostOffset, size := h.compressedCluster(entry)
+				if hostOffset < uint64(fileSize) {
+					// the size is rounded up to sectors, like in
+					// qcow2Extents
+					size = min(size, uint64(fileSize)-hostOffset)
+				}
+				if err := ref("compressed cluster", hostOffset, size); err != nil {
+					return err
+				}
+				_, err := readFileExtent(r,
+					fileExtent{offset, length, int64(hostOffset), int64(size)}, cs)
+				return err
+			}
+			hostOffset := entry & qcow2OffsetMask
+			if hostOffset == 0 {
+				return nil
+			}
+			if hostOffset%cs != 0 {
+				return invalidImageFile(
+					"qcow2 data cluster at invalid offset %d", hostOffset)
+			}
+			flags = append(flags,
+				copiedFlag{"data cluster", offset, entry, hostOffset / cs})
+			return ref("data cluster", hostOffset, cs)
+		})
+	if err != nil {
+		return err
+	}
+
+	// compare the references to the refcounts of the file
+	width := uint64(1) << h.refcountOrder / 8
+	perBlock := cs / width
+	block := make([]byte, cs)
+	loaded := -1
+	refcount := func(c uint64) (uint64, error) {
+		b := c / perBlock
+		if b >= uint64(len(blocks)) || blocks[b] == 0 {
+			return 0, nil
+		}
+		if int(b) != loaded {
+			if err := readAtFull(r, block, int64(blocks[b])); err != nil {
+				return 0, err
+			}
+			loaded = int(b)
+		}
+		var v uint64
+		for _, x := range block[c%perBlock*width:][:width] {
+			v = v<<8 | uint64(x)
+		}
+		return v, nil
+	}
+	counts := make([]uint64, clusters)
+	for c := uint64(0); c < clusters; c++ {
+		if counts[c], err = refcount(c); err != nil {
+			return err
+		}
+		if counts[c] != refs[c] {
+			return invalidImageFile("qcow2 cluster %d has refcount %d but %d references",
+				c, counts[c], refs[c])
+		}
+	}
+	for _, f := range flags {
+		copied := f.entry&qcow2CopiedFlag != 0
+		if copied != (counts[f.cluster] == 1) {
+			return invalidImageFile(
+				"qcow2 %s at %d has copied flag %t with refcount %d",
+				f.what, f.offset, copied, counts[f.cluster])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testQcow2 describes a small qcow2 image generated for tests.
+type testQcow2 struct {
+	clusterBits uint32
+	size        uint64
+	// data of the allocated clusters, by cluster index
+	data map[uint64][]byte
+	// compressed clusters, a subset of the clusters of data
+	compressed map[uint64]bool
+	// clusters with the zero flag set
+	zero []uint64
+
+	version         uint32
+	backing         string
+	cryptMethod     uint32
+	incompatible    uint64
+	compressionType uint8
+}
+
+// build returns the content of the qcow2 file. Refcounts are not written.
+func (q *testQcow2) build(t *testing.T) []byte {
+	cs := uint64(1) << q.clusterBits
+	l2n := cs / 8
+	l1Size := (q.size + cs*l2n - 1) / (cs * l2n)
+	require.LessOrEqual(t, l1Size*8, cs)
+	version := q.version
+	if version == 0 {
+		version = 3
+	}
+
+	// the header is in cluster 0, the L1 table in cluster 1
+	file := make([]byte, 2*cs)
+	be := binary.BigEndian
+	be.PutUint32(file[0:], qcow2Magic)
+	be.PutUint32(file[4:], version)
+	if q.backing != "" {
+		be.PutUint64(file[8:], 512)
+		be.PutUint32(file[16:], uint32(len(q.backing)))
+		copy(file[512:], q.backing)
+	}
+	be.PutUint32(file[20:], q.clusterBits)
+	be.PutUint64(file[24:], q.size)
+	be.PutUint32(file[32:], q.cryptMethod)
+	be.PutUint32(file[36:], uint32(l1Size))
+	be.PutUint64(file[40:], cs)
+	if version == 3 {
+		be.PutUint64(file[72:], q.incompatible)
+		be.PutUint32(file[96:], 4)
+		be.PutUint32(file[100:], qcow2HeaderSizeV3)
+		if q.compressionType != 0 {
+			be.PutUint32(file[100:], qcow2HeaderSizeV3+8)
+			file[qcow2HeaderSizeV3] = q.compressionType
+		}
+	}
+
+	alloc := func(n uint64) uint64 {
+		off := uint64(len(file))
+		n = (n + cs - 1) / cs * cs
+		file = append(file, make([]byte, n)...)
+		return off
+	}
+	// the L2 tables are allocated before the data clusters, so that
+	// adjacent clusters are adjacent in the file
+	l2Tables := make([]uint64, l1Size)
+	for i := range l2Tables {
+		l2Tables[i] = alloc(cs)
+		be.PutUint64(file[cs+uint64(i)*8:], l2Tables[i]|qcow2CopiedFlag)
+	}
+	setL2 := func(cluster, entry uint64) {
+		be.PutUint64(file[l2Tables[cluster/l2n]+(cluster%l2n)*8:], entry)
+	}
+
+	var clusters []uint64
+	for c := range q.data {
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i] < clusters[j] })
+	for _, c := range clusters {
+		data := q.data[c]
+		if !q.compressed[c] {
+			off := alloc(cs)
+			copy(file[off:], data)
+			setL2(c, off|qcow2CopiedFlag)
+			continue
+		}
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.BestCompression)
+		require.NoError(t, err)
+		padded := make([]byte, cs)
+		copy(padded, data)
+		_, err = w.Write(padded)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		off := alloc(uint64(buf.Len()))
+		copy(file[off:], buf.Bytes())
+		x := 62 - (q.clusterBits - 8)
+		sectors := (uint64(buf.Len()) + 511) / 512
+		setL2(c, off|(sectors-1)<<x|qcow2CompressedFlag)
+	}
+	for _, c := range q.zero {
+		setL2(c, qcow2ZeroFlag)
+	}
+	return file
+}
+
+// content returns the expected content of the virtual disk of q.
+func (q *testQcow2) content() []byte {
+	cs := uint64(1) << q.clusterBits
+	b := make([]byte, q.size)
+	for c, data := range q.data {
+		copy(b[c*cs:], data)
+	}
+	return b
+}
+
+func newTestQcow2() *testQcow2 {
+	const cs = 1 << 16
+	return &testQcow2{
+		clusterBits: 16,
+		size:        16*cs + 1000,
+		data: map[uint64][]byte{
+			0:  bytes.Repeat([]byte{1}, cs),
+			1:  bytes.Repeat([]byte("two"), 1000),
+			5:  bytes.Repeat([]byte("compressed"), 3000),
+			16: bytes.Repeat([]byte{7}, 1000),
+		},
+		compressed: map[uint64]bool{5: true},
+		zero:       []uint64{3},
+	}
+}
+
+func TestQcow2Extents(t *testing.T) {
+	q := newTestQcow2()
+	file := q.build(t)
+	r := bytes.NewReader(file)
+	require.True(t, isQcow2(r))
+
+	h, err := readQcow2Header(r, int64(len(file)))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, h.version)
+	assert.Equal(t, q.size, h.size)
+	cs := h.clusterSize()
+	assert.EqualValues(t, 1<<16, cs)
+
+	extents, err := qcow2Extents(r, int64(len(file)), h, 4*1024*1024)
+	require.NoError(t, err)
+	require.Len(t, extents, 3)
+	// clusters 0 and 1 are adjacent on disk and merged
+	assert.Equal(t, uint64(0), extents[0].offset)
+	assert.Equal(t, 2*cs, extents[0].length)
+	assert.Zero(t, extents[0].compressedSize)
+	assert.Equal(t, 5*cs, extents[1].offset)
+	assert.Equal(t, cs, extents[1].length)
+	assert.NotZero(t, extents[1].compressedSize)
+	assert.Equal(t, 16*cs, extents[2].offset)
+	assert.Equal(t, uint64(1000), extents[2].length)
+
+	content := q.content()
+	for _, e := range extents {
+		data, err := readFileExtent(r, e, cs)
+		require.NoError(t, err)
+		assert.Equal(t, content[e.offset:e.offset+e.length], data)
+	}
+
+	// merging is limited by the maximum length
+	extents, err = qcow2Extents(r, int64(len(file)), h, cs)
+	require.NoError(t, err)
+	assert.Len(t, extents, 4)
+
+	t.Run("v2", func(t *testing.T) {
+		q := newTestQcow2()
+		q.version = 2
+		q.zero = nil
+		file := q.build(t)
+		h, err := readQcow2Header(bytes.NewReader(file), int64(len(file)))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, h.version)
+		extents, err := qcow2Extents(bytes.NewReader(file), int64(len(file)), h, 1<<22)
+		assert.NoError(t, err)
+		assert.Len(t, extents, 3)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		short := file[:uint64(len(file))-cs]
+		h, err := readQcow2Header(bytes.NewReader(short), int64(len(short)))
+		require.NoError(t, err)
+		_, err = qcow2Extents(bytes.NewReader(short), int64(len(short)), h, 1<<22)
+		assert.ErrorIs(t, err, ErrInvalidImageFile)
+	})
+}
+
+func TestReadQcow2HeaderRefused(t *testing.T) {
+	unsupported := map[string]func(q *testQcow2){
+		"backingFile":    func(q *testQcow2) { q.backing = "base.qcow2" },
+		"encrypted":      func(q *testQcow2) { q.cryptMethod = 1 },
+		"version1":       func(q *testQcow2) { q.version = 1 },
+		"externalData":   func(q *testQcow2) { q.incompatible = qcow2IncompatExternalData },
+		"extendedL2":     func(q *testQcow2) { q.incompatible = qcow2IncompatExtendedL2 },
+		"unknownFeature": func(q *testQcow2) { q.incompatible = 1 << 20 },
+		"zstd": func(q *testQcow2) {
+			q.incompatible = qcow2IncompatCompression
+			q.compressionType = 1
+		},
+	}
+	for name, modify := range unsupported {
+		t.Run(name, func(t *testing.T) {
+			q := newTestQcow2()
+			modify(q)
+			file := q.build(t)
+			_, err := readQcow2Header(bytes.NewReader(file), int64(len(file)))
+			assert.ErrorIs(t, err, ErrUnsupportedImageFile)
+			if q.backing != "" {
+				assert.Contains(t, err.Error(), q.backing)
+			}
+		})
+	}
+
+	invalid := map[string]func(file []byte){
+		"corrupt": func(file []byte) {
+			binary.BigEndian.PutUint64(file[72:], qcow2IncompatCorrupt)
+		},
+		"clusterBits": func(file []byte) {
+			binary.BigEndian.PutUint32(file[20:], 30)
+		},
+		"smallL1": func(file []byte) {
+			binary.BigEndian.PutUint64(file[24:], 1<<40)
+		},
+		"l1Offset": func(file []byte) {
+			binary.BigEndian.PutUint64(file[40:], 1<<40)
+		},
+	}
+	for name, modify := range invalid {
+		t.Run(name, func(t *testing.T) {
+			file := newTestQcow2().build(t)
+			modify(file)
+			_, err := readQcow2Header(bytes.NewReader(file), int64(len(file)))
+			assert.ErrorIs(t, err, ErrInvalidImageFile)
+		})
+	}
+
+	t.Run("truncatedHeader", func(t *testing.T) {
+		file := newTestQcow2().build(t)[:50]
+		_, err := readQcow2Header(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrInvalidImageFile)
+	})
+	t.Run("notQcow2", func(t *testing.T) {
+		file := make([]byte, 4096)
+		assert.False(t, isQcow2(bytes.NewReader(file)))
+		_, err := readQcow2Header(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrInvalidImageFile)
+	})
+}
+
+func TestRawFileExtents(t *testing.T) {
+	assert.Len(t, rawFileExtents(0, 4096), 0)
+	assert.Equal(t, []fileExtent{
+		{0, 4096, 0, 0},
+		{4096, 4096, 4096, 0},
+		{8192, 10, 8192, 0},
+	}, rawFileExtents(8202, 4096))
+}
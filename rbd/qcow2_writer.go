@@ -0,0 +1,273 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+	"sort"
+	"sync"
+)
+
+const (
+	// qcow2DefaultClusterBits matches the default cluster size of qemu-img.
+	qcow2DefaultClusterBits = 16
+	// qcow2RefcountOrder is the refcount width written, 16 bits as used by
+	// QEMU.
+	qcow2RefcountOrder = 4
+	// qcow2DeflateWindow is the window size QEMU inflates compressed
+	// clusters with.
+	qcow2DeflateWindow = 4096
+)
+
+// qcow2FinalBlock is an empty, final, stored deflate block.
+var qcow2FinalBlock = []byte{1, 0, 0, 0xff, 0xff}
+
+var qcow2Deflaters = sync.Pool{
+	New: func() interface{} {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	},
+}
+
+// qcow2Compress returns the raw deflate data of a cluster. QEMU inflates
+// compressed clusters with a window of 4 KiB, so that every 4 KiB of the
+// cluster are compressed independently. The resulting streams are joined
+// by their sync flushes and terminated by an empty final block.
+func qcow2Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw := qcow2Deflaters.Get().(*flate.Writer)
+	defer qcow2Deflaters.Put(fw)
+	for off := 0; off < len(data); off += qcow2DeflateWindow {
+		fw.Reset(&buf)
+		if _, err := fw.Write(data[off:min(off+qcow2DeflateWindow, len(data))]); err != nil {
+			return nil, err
+		}
+		if err := fw.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	buf.Write(qcow2FinalBlock)
+	return buf.Bytes(), nil
+}
+
+// marshal returns a version 3 header for h.
+func (h *qcow2Header) marshal() []byte {
+	b := make([]byte, qcow2HeaderSizeV3)
+	be := binary.BigEndian
+	be.PutUint32(b[0:], qcow2Magic)
+	be.PutUint32(b[4:], 3)
+	be.PutUint32(b[20:], h.clusterBits)
+	be.PutUint64(b[24:], h.size)
+	be.PutUint32(b[36:], h.l1Size)
+	be.PutUint64(b[40:], h.l1TableOffset)
+	be.PutUint64(b[48:], h.refcountTableOffset)
+	be.PutUint32(b[56:], h.refcountClusters)
+	be.PutUint32(b[96:], h.refcountOrder)
+	be.PutUint32(b[100:], qcow2HeaderSizeV3)
+	return b
+}
+
+// qcow2Writer writes a qcow2 file without backing file and snapshots.
+// Clusters may be written concurrently and in any order. The header, the
+// L1 and L2 tables and the refcounts are written by finish.
+//
+// The header is stored in the first cluster, followed by the L1 table.
+// Data clusters are allocated after it, in the order they are written. The
+// L2 tables and the refcount structures are stored at the end of the file.
+type qcow2Writer struct {
+	w        io.WriterAt
+	h        qcow2Header
+	compress bool
+
+	mutex sync.Mutex
+	// next is the offset of the next free cluster of the file
+	next uint64
+	// compressed data is packed into the range from cpos to cend
+	cpos, cend uint64
+	l2         map[uint64][]uint64
+	refcounts  []uint16
+}
+
+func newQcow2Writer(w io.WriterAt, size uint64, clusterBits uint32,
+	compress bool) *qcow2Writer {
+
+	q := &qcow2Writer{
+		w: w,
+		h: qcow2Header{
+			version:       3,
+			clusterBits:   clusterBits,
+			size:          size,
+			refcountOrder: qcow2RefcountOrder,
+		},
+		compress: compress,
+		l2:       map[uint64][]uint64{},
+	}
+	cs := q.h.clusterSize()
+	l2Coverage := cs * q.h.l2Entries()
+	q.h.l1Size = uint32((size + l2Coverage - 1) / l2Coverage)
+	q.h.l1TableOffset = cs
+	// the header cluster and the L1 table
+	q.allocClusters(1 + (uint64(q.h.l1Size)*8+cs-1)/cs)
+	return q
+}
+
+// allocClusters allocates n clusters at the end of the file, returning the
+// offset of the first one.
+func (q *qcow2Writer) allocClusters(n uint64) uint64 {
+	off := q.next
+	for i := uint64(0); i < n; i++ {
+		q.refcounts = append(q.refcounts, 1)
+	}
+	q.next = uint64(len(q.refcounts)) * q.h.clusterSize()
+	return off
+}
+
+// allocCompressed allocates length bytes for compressed data, returning the
+// offset. Compressed data is aligned to sectors, and may span clusters.
+// Every cluster holding compressed data of a guest cluster is referenced
+// once by it.
+func (q *qcow2Writer) allocCompressed(length uint64) uint64 {
+	cs := q.h.clusterSize()
+	if q.cpos+length > q.cend {
+		if q.cend != q.next {
+			// the last allocated cluster is not used for compressed data
+			q.cpos = q.next
+		}
+		for q.next < q.cpos+length {
+			q.refcounts = append(q.refcounts, 0)
+			q.next += cs
+		}
+		q.cend = q.next
+	}
+	off := q.cpos
+	q.cpos = (off + length + 511) &^ 511
+	for c := off / cs; c <= (q.cpos-1)/cs; c++ {
+		q.refcounts[c]++
+	}
+	return off
+}
+
+// setL2 sets the L2 entry of the cluster at the given offset of the virtual
+// disk.
+func (q *qcow2Writer) setL2(offset, entry uint64) {
+	cluster := offset >> q.h.clusterBits
+	l1Index := cluster / q.h.l2Entries()
+	table := q.l2[l1Index]
+	if table == nil {
+		table = make([]uint64, q.h.l2Entries())
+		q.l2[l1Index] = table
+	}
+	table[cluster%q.h.l2Entries()] = entry
+}
+
+// writeCluster writes the data of the cluster at the given offset of the
+// virtual disk. The data of the last cluster of the disk may be shorter
+// than a cluster. Clusters that only contain zeros are not written.
+func (q *qcow2Writer) writeCluster(offset uint64, data []byte) error {
+	if isZero(data) {
+		return nil
+	}
+	cs := q.h.clusterSize()
+	if uint64(len(data)) < cs {
+		padded := make([]byte, cs)
+		copy(padded, data)
+		data = padded
+	}
+	if q.compress {
+		compressed, err := qcow2Compress(data)
+		if err != nil {
+			return err
+		}
+		// clusters that do not shrink are stored uncompressed
+		if uint64(len(compressed)) < cs {
+			q.mutex.Lock()
+			hostOffset := q.allocCompressed(uint64(len(compressed)))
+			x := 62 - (q.h.clusterBits - 8)
+			sectors := (uint64(len(compressed)) + 511) / 512
+			q.setL2(offset, hostOffset|(sectors-1)<<x|qcow2CompressedFlag)
+			q.mutex.Unlock()
+			_, err = q.w.WriteAt(compressed, int64(hostOffset))
+			return err
+		}
+	}
+	q.mutex.Lock()
+	hostOffset := q.allocClusters(1)
+	q.setL2(offset, hostOffset|qcow2CopiedFlag)
+	q.mutex.Unlock()
+	_, err := q.w.WriteAt(data, int64(hostOffset))
+	return err
+}
+
+// finish writes the L2 tables, the refcounts, the L1 table and the header.
+// No clusters may be written concurrently.
+func (q *qcow2Writer) finish() error {
+	cs := q.h.clusterSize()
+	be := binary.BigEndian
+
+	l1 := make([]byte, (uint64(q.h.l1Size)*8+cs-1)/cs*cs)
+	indexes := make([]uint64, 0, len(q.l2))
+	for i := range q.l2 {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	buf := make([]byte, cs)
+	for _, i := range indexes {
+		for j, entry := range q.l2[i] {
+			be.PutUint64(buf[j*8:], entry)
+		}
+		off := q.allocClusters(1)
+		if _, err := q.w.WriteAt(buf, int64(off)); err != nil {
+			return err
+		}
+		be.PutUint64(l1[i*8:], off|qcow2CopiedFlag)
+	}
+
+	// the refcount table and blocks are allocated at the end and have to
+	// cover themselves
+	perBlock := cs * 8 >> qcow2RefcountOrder
+	used := uint64(len(q.refcounts))
+	var tableClusters, blocks uint64
+	for {
+		total := used + tableClusters + blocks
+		b := (total + perBlock - 1) / perBlock
+		t := (b*8 + cs - 1) / cs
+		if b == blocks && t == tableClusters {
+			break
+		}
+		blocks, tableClusters = b, t
+	}
+	q.h.refcountTableOffset = q.allocClusters(tableClusters)
+	q.h.refcountClusters = uint32(tableClusters)
+	blocksOffset := q.allocClusters(blocks)
+	table := make([]byte, tableClusters*cs)
+	for b := uint64(0); b < blocks; b++ {
+		for i := range buf {
+			buf[i] = 0
+		}
+		for i := uint64(0); i < perBlock; i++ {
+			c := b*perBlock + i
+			if c >= uint64(len(q.refcounts)) {
+				break
+			}
+			be.PutUint16(buf[i*2:], q.refcounts[c])
+		}
+		off := blocksOffset + b*cs
+		if _, err := q.w.WriteAt(buf, int64(off)); err != nil {
+			return err
+		}
+		be.PutUint64(table[b*8:], off)
+	}
+	if _, err := q.w.WriteAt(table, int64(q.h.refcountTableOffset)); err != nil {
+		return err
+	}
+	if _, err := q.w.WriteAt(l1, int64(q.h.l1TableOffset)); err != nil {
+		return err
+	}
+	header := make([]byte, cs)
+	copy(header, q.h.marshal())
+	_, err := q.w.WriteAt(header, 0)
+	return err
+}
@@ -0,0 +1,276 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFile is an in-memory io.WriterAt and io.ReaderAt.
+type memFile struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (f *memFile) WriteAt(b []byte, off int64) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if end := int(off) + len(b); end > len(f.data) {
+		f.data = append(f.data, make([]byte, end-len(f.data))...)
+	}
+	return copy(f.data[off:], b), nil
+}
+
+func (f *memFile) ReadAt(b []byte, off int64) (int, error) {
+	return bytes.NewReader(f.data).ReadAt(b, off)
+}
+
+// testQcow2Disk returns the content of a virtual disk of the given number
+// of clusters, plus a partial cluster, with compressible, incompressible and
+// zero clusters.
+func testQcow2Disk(cs, clusters int) []byte {
+	disk := make([]byte, cs*clusters+cs/2)
+	random := make([]byte, len(disk))
+	seed := uint32(1)
+	for i := range random {
+		seed = seed*1103515245 + 12345
+		random[i] = byte(seed >> 16)
+	}
+	for c := 0; c*cs < len(disk); c++ {
+		chunk := disk[c*cs : min((c+1)*cs, len(disk))]
+		switch c % 4 {
+		case 0:
+			copy(chunk, bytes.Repeat([]byte("compressible"), cs))
+		case 1:
+			copy(chunk, random[c*cs:])
+		case 2:
+			// zeros
+		case 3:
+			chunk[len(chunk)-1] = byte(c)
+		}
+	}
+	return disk
+}
+
+func writeTestQcow2(t *testing.T, disk []byte, clusterBits uint32, compress bool) *memFile {
+	f := &memFile{}
+	q := newQcow2Writer(f, uint64(len(disk)), clusterBits, compress)
+	cs := uint64(1) << clusterBits
+	var wg sync.WaitGroup
+	// write the clusters concurrently, in reverse order
+	for off := (uint64(len(disk)) - 1) / cs * cs; ; off -= cs {
+		wg.Add(1)
+		go func(off uint64) {
+			defer wg.Done()
+			assert.NoError(t, q.writeCluster(off, disk[off:min(off+cs, uint64(len(disk)))]))
+		}(off)
+		if off == 0 {
+			break
+		}
+	}
+	wg.Wait()
+	require.NoError(t, q.finish())
+	return f
+}
+
+// readTestQcow2 returns the virtual disk of a qcow2 file.
+func readTestQcow2(t *testing.T, file []byte) []byte {
+	r := bytes.NewReader(file)
+	h, err := readQcow2Header(r, int64(len(file)))
+	require.NoError(t, err)
+	extents, err := qcow2Extents(r, int64(len(file)), h, 1<<22)
+	require.NoError(t, err)
+	disk := make([]byte, h.size)
+	for _, e := range extents {
+		data, err := readFileExtent(r, e, h.clusterSize())
+		require.NoError(t, err)
+		copy(disk[e.offset:], data)
+	}
+	return disk
+}
+
+// qemuImg runs qemu-img with the given arguments and returns its output,
+// skipping the test if qemu-img is not installed.
+func qemuImg(t *testing.T, args ...string) []byte {
+	path, err := exec.LookPath("qemu-img")
+	if err != nil {
+		t.Skip("qemu-img not found")
+	}
+	out, err := exec.Command(path, args...).CombinedOutput()
+	require.NoError(t, err, "qemu-img %v: %s", args, out)
+	return out
+}
+
+// qemuImgVerify checks the qcow2 file at path with qemu-img and compares its
+// virtual disk to the expected content.
+func qemuImgVerify(t *testing.T, path string, expected []byte) {
+	qemuImg(t, "check", "-f", "qcow2", path)
+	var info struct {
+		VirtualSize int64 `json:"virtual-size"`
+	}
+	out := qemuImg(t, "info", "-f", "qcow2", "--output=json", path)
+	require.NoError(t, json.Unmarshal(out, &info))
+	assert.EqualValues(t, len(expected), info.VirtualSize)
+	raw := filepath.Join(t.TempDir(), "expected.raw")
+	require.NoError(t, os.WriteFile(raw, expected, 0600))
+	qemuImg(t, "compare", "-f", "qcow2", "-F", "raw", path, raw)
+}
+
+func TestQcow2Writer(t *testing.T) {
+	for _, clusterBits := range []uint32{9, 12, 16} {
+		for _, compress := range []bool{false, true} {
+			cs := 1 << clusterBits
+			disk := testQcow2Disk(cs, 20)
+			f := writeTestQcow2(t, disk, clusterBits, compress)
+			assert.NoError(t, ValidateQcow2(f, int64(len(f.data))))
+			assert.Zero(t, len(f.data)%cs)
+			assert.Equal(t, disk, readTestQcow2(t, f.data))
+
+			h, err := readQcow2Header(f, int64(len(f.data)))
+			require.NoError(t, err)
+			extents, err := qcow2Extents(f, int64(len(f.data)), h, uint64(cs))
+			require.NoError(t, err)
+			// the zero clusters are not written
+			assert.Len(t, extents, 16)
+			var compressed int
+			for _, e := range extents {
+				if e.compressedSize != 0 {
+					compressed++
+				}
+			}
+			if compress {
+				// the random clusters are stored uncompressed
+				assert.Equal(t, 11, compressed)
+				// compressed data is stored in sectors, which saves
+				// no space with 512 byte clusters
+				if clusterBits > 9 {
+					assert.Less(t, len(f.data), len(
+						writeTestQcow2(t, disk, clusterBits, false).data))
+				}
+			} else {
+				assert.Zero(t, compressed)
+			}
+		}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		f := writeTestQcow2(t, make([]byte, 1<<20), 16, true)
+		assert.NoError(t, ValidateQcow2(f, int64(len(f.data))))
+		// header, L1 table, refcount table and block
+		assert.Len(t, f.data, 4<<16)
+	})
+}
+
+func TestQcow2WriterQemuImg(t *testing.T) {
+	dir := t.TempDir()
+	for _, clusterBits := range []uint32{9, 16} {
+		for _, compress := range []bool{false, true} {
+			name := fmt.Sprintf("bits%d-compress%v", clusterBits, compress)
+			t.Run(name, func(t *testing.T) {
+				// qemu handles disks in sectors of 512 bytes
+				disk := testQcow2Disk(1<<clusterBits, 20)
+				disk = disk[:len(disk)/512*512]
+				f := writeTestQcow2(t, disk, clusterBits, compress)
+				path := filepath.Join(dir, name+".qcow2")
+				require.NoError(t, os.WriteFile(path, f.data, 0600))
+				qemuImgVerify(t, path, disk)
+			})
+		}
+	}
+}
+
+func TestQcow2Compress(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 6554)[:1<<16]
+	compressed, err := qcow2Compress(data)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(data)/10)
+	assert.Equal(t, qcow2FinalBlock, compressed[len(compressed)-5:])
+	out, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	assert.NoError(t, err)
+	assert.Equal(t, data, out)
+}
+
+func TestValidateQcow2(t *testing.T) {
+	const clusterBits = 12
+	const cs = 1 << clusterBits
+	disk := testQcow2Disk(cs, 8)
+	build := func(t *testing.T) (*qcow2Header, []byte) {
+		f := writeTestQcow2(t, disk, clusterBits, true)
+		h, err := readQcow2Header(f, int64(len(f.data)))
+		require.NoError(t, err)
+		return h, f.data
+	}
+	be := binary.BigEndian
+	// l2Entry returns the offset of the L2 entry of a cluster of the disk
+	l2Entry := func(h *qcow2Header, file []byte, cluster uint64) uint64 {
+		l2 := be.Uint64(file[h.l1TableOffset:]) & qcow2OffsetMask
+		return l2 + cluster*8
+	}
+	refcount := func(h *qcow2Header, file []byte, cluster uint64) uint64 {
+		block := be.Uint64(file[h.refcountTableOffset:])
+		return block + cluster*2
+	}
+
+	tests := map[string]func(h *qcow2Header, file []byte){
+		"leak": func(h *qcow2Header, file []byte) {
+			// the header cluster is referenced once
+			be.PutUint16(file[refcount(h, file, 0):], 2)
+		},
+		"missingRefcount": func(h *qcow2Header, file []byte) {
+			host := be.Uint64(file[l2Entry(h, file, 1):]) & qcow2OffsetMask
+			be.PutUint16(file[refcount(h, file, host/cs):], 0)
+		},
+		"copiedFlag": func(h *qcow2Header, file []byte) {
+			off := l2Entry(h, file, 1)
+			be.PutUint64(file[off:], be.Uint64(file[off:])&^qcow2CopiedFlag)
+		},
+		"sharedCluster": func(h *qcow2Header, file []byte) {
+			be.PutUint64(file[l2Entry(h, file, 3):],
+				be.Uint64(file[l2Entry(h, file, 1):]))
+		},
+		"corruptCompressed": func(h *qcow2Header, file []byte) {
+			entry := be.Uint64(file[l2Entry(h, file, 0):])
+			hostOffset, _ := h.compressedCluster(entry)
+			copy(file[hostOffset:], bytes.Repeat([]byte{0xff}, 16))
+		},
+		"dataBeyondEnd": func(h *qcow2Header, file []byte) {
+			be.PutUint64(file[l2Entry(h, file, 1):], 1<<30|qcow2CopiedFlag)
+		},
+		"dirty": func(h *qcow2Header, file []byte) {
+			be.PutUint64(file[72:], qcow2IncompatDirty)
+		},
+	}
+	for name, corrupt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h, file := build(t)
+			corrupt(h, file)
+			err := ValidateQcow2(bytes.NewReader(file), int64(len(file)))
+			assert.ErrorIs(t, err, ErrInvalidImageFile)
+		})
+	}
+
+	t.Run("snapshots", func(t *testing.T) {
+		_, file := build(t)
+		be.PutUint32(file[60:], 1)
+		err := ValidateQcow2(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrUnsupportedImageFile)
+	})
+	t.Run("noRefcounts", func(t *testing.T) {
+		file := newTestQcow2().build(t)
+		err := ValidateQcow2(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrInvalidImageFile)
+	})
+}
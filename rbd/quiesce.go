@@ -0,0 +1,152 @@
+//go:build !octopus && !nautilus && ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <errno.h>
+#include <rbd/librbd.h>
+
+extern void quiesceCallback(uintptr_t);
+extern void unquiesceCallback(uintptr_t);
+
+// inline wrapper to cast uintptr_t to void*
+static inline int wrap_rbd_quiesce_watch(rbd_image_t image, uintptr_t arg,
+	uint64_t *handle) {
+		return rbd_quiesce_watch(image, (rbd_update_callback_t)quiesceCallback,
+			(rbd_update_callback_t)unquiesceCallback, (void*)arg, handle);
+};
+*/
+import "C"
+
+import (
+	"errors"
+
+	"github.com/ceph/go-ceph/internal/callbacks"
+)
+
+// quiesceCallbacks tracks the active quiesce watches
+var quiesceCallbacks = callbacks.New()
+
+// QuiesceCallback is called when a snapshot of the image is about to be
+// created. The application has to call Complete on the watch once its
+// writes to the image are quiesced, either from within the callback or
+// later. The snapshot is not created before Complete is called.
+type QuiesceCallback func(w *QuiesceWatch, data interface{})
+
+// UnquiesceCallback is called once the snapshot of the image has been
+// created, or its creation has failed, and writes may resume.
+type UnquiesceCallback func(w *QuiesceWatch, data interface{})
+
+type quiesceCallbackCtx struct {
+	watch     *QuiesceWatch
+	quiesce   QuiesceCallback
+	unquiesce UnquiesceCallback
+	data      interface{}
+}
+
+// QuiesceWatch represents a registered quiesce watch of an image.
+type QuiesceWatch struct {
+	image   *Image
+	handle  C.uint64_t
+	cbIndex uintptr
+}
+
+// QuiesceWatch registers callbacks that are called before and after a
+// snapshot of the image is created by any client, allowing the application
+// that writes to the image to make the snapshot consistent, for example by
+// freezing a file system or flushing a database.
+//
+// Implements:
+//
+//	int rbd_quiesce_watch(rbd_image_t image,
+//	                      rbd_update_callback_t quiesce_cb,
+//	                      rbd_update_callback_t unquiesce_cb,
+//	                      void *arg, uint64_t *handle);
+func (image *Image) QuiesceWatch(
+	quiesce QuiesceCallback, unquiesce UnquiesceCallback,
+	data interface{}) (*QuiesceWatch, error) {
+
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+	if quiesce == nil || unquiesce == nil {
+		return nil, getError(-C.EINVAL)
+	}
+	w := &QuiesceWatch{image: image}
+	w.cbIndex = quiesceCallbacks.Add(quiesceCallbackCtx{
+		watch:     w,
+		quiesce:   quiesce,
+		unquiesce: unquiesce,
+		data:      data,
+	})
+
+	t := image.startOp("rbd_quiesce_watch")
+	ret := C.wrap_rbd_quiesce_watch(
+		image.image, C.uintptr_t(w.cbIndex), &w.handle)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret != 0 {
+		quiesceCallbacks.Remove(w.cbIndex)
+		return nil, getError(ret)
+	}
+	return w, nil
+}
+
+// Complete reports that the application has quiesced its writes, or failed
+// to do so if err is not nil. Unless the snapshot is created with
+// SnapCreateIgnoreQuiesceError, an error makes the snapshot creation fail.
+//
+// Implements:
+//
+//	void rbd_quiesce_complete(rbd_image_t image, uint64_t handle, int r);
+func (w *QuiesceWatch) Complete(err error) error {
+	if w.image == nil {
+		return ErrImageNotOpen
+	}
+	if verr := w.image.validate(imageIsOpen); verr != nil {
+		return verr
+	}
+	r := C.int(0)
+	if err != nil {
+		r = -C.EIO
+		var ec interface{ ErrorCode() int }
+		if errors.As(err, &ec) && ec.ErrorCode() < 0 {
+			r = C.int(ec.ErrorCode())
+		}
+	}
+	t := w.image.startOp("rbd_quiesce_complete")
+	C.rbd_quiesce_complete(w.image.image, w.handle, r)
+	t.Done(0, nil)
+	return nil
+}
+
+// Unwatch un-registers the quiesce watch.
+//
+// Implements:
+//
+//	int rbd_quiesce_unwatch(rbd_image_t image, uint64_t handle);
+func (w *QuiesceWatch) Unwatch() error {
+	if w.image == nil {
+		return ErrImageNotOpen
+	}
+	if err := w.image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	t := w.image.startOp("rbd_quiesce_unwatch")
+	ret := C.rbd_quiesce_unwatch(w.image.image, w.handle)
+	t.Done(0, getErrorIfNegative(ret))
+	quiesceCallbacks.Remove(w.cbIndex)
+	return getError(ret)
+}
+
+//export quiesceCallback
+func quiesceCallback(index uintptr) {
+	ctx := quiesceCallbacks.Lookup(index).(quiesceCallbackCtx)
+	ctx.quiesce(ctx.watch, ctx.data)
+}
+
+//export unquiesceCallback
+func unquiesceCallback(index uintptr) {
+	ctx := quiesceCallbacks.Lookup(index).(quiesceCallbackCtx)
+	ctx.unquiesce(ctx.watch, ctx.data)
+}
@@ -0,0 +1,100 @@
+//go:build !octopus && !nautilus && ceph_preview
+
+package rbd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuiesceSnapshot(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	// the application holds one handle, the backup another one
+	app, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, app.Close()) }()
+	backup, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, backup.Close()) }()
+
+	var (
+		quiesceErr error
+		events     []string
+	)
+	w, err := app.QuiesceWatch(
+		func(w *QuiesceWatch, data interface{}) {
+			events = append(events, "quiesce:"+data.(string))
+			assert.NoError(t, w.Complete(quiesceErr))
+		},
+		func(w *QuiesceWatch, data interface{}) {
+			events = append(events, "unquiesce:"+data.(string))
+		},
+		"app")
+	require.NoError(t, err)
+
+	t.Run("consistent", func(t *testing.T) {
+		events = nil
+		snap, err := backup.CreateSnapshot2("consistent", 0)
+		require.NoError(t, err)
+		assert.NoError(t, snap.Remove())
+		assert.Equal(t, []string{"quiesce:app", "unquiesce:app"}, events)
+	})
+
+	t.Run("skipQuiesce", func(t *testing.T) {
+		events = nil
+		snap, err := backup.CreateSnapshot2("skipped", SnapCreateSkipQuiesce)
+		require.NoError(t, err)
+		assert.NoError(t, snap.Remove())
+		assert.Len(t, events, 0)
+	})
+
+	t.Run("quiesceError", func(t *testing.T) {
+		quiesceErr = errors.New("fsfreeze failed")
+		defer func() { quiesceErr = nil }()
+		_, err := backup.CreateSnapshot2("failed", 0)
+		assert.Error(t, err)
+
+		snap, err := backup.CreateSnapshot2("ignored", SnapCreateIgnoreQuiesceError)
+		require.NoError(t, err)
+		assert.NoError(t, snap.Remove())
+	})
+
+	t.Run("unwatch", func(t *testing.T) {
+		err := w.Unwatch()
+		require.NoError(t, err)
+		events = nil
+		snap, err := backup.CreateSnapshot2("unwatched", 0)
+		require.NoError(t, err)
+		assert.NoError(t, snap.Remove())
+		assert.Len(t, events, 0)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		closed := GetImage(ioctx, name)
+		_, err := closed.CreateSnapshot2("closed", 0)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		_, err = closed.QuiesceWatch(
+			func(*QuiesceWatch, interface{}) {},
+			func(*QuiesceWatch, interface{}) {}, nil)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
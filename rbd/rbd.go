@@ -13,6 +13,7 @@ import "C"
 import (
 	"errors"
 	"io"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -87,6 +88,10 @@ type Image struct {
 	offset int64
 	ioctx  *rados.IOContext
 	image  C.rbd_image_t
+	// snapName is the snapshot the image is opened at, if any
+	snapName string
+	// notify is set once completions are queued for rbd_poll_io_events
+	notify atomic.Bool
 }
 
 // TrashInfo contains information about trashed RBDs.
@@ -171,8 +176,10 @@ func Create(ioctx *rados.IOContext, name string, size uint64, order int,
 
 		defer C.free(unsafe.Pointer(cName))
 
+		t := startOp(ioctx, "rbd_create", name)
 		ret = C.rbd_create(cephIoctx(ioctx),
 			cName, C.uint64_t(size), &cOrder)
+		t.Done(0, getErrorIfNegative(ret))
 	default:
 		return nil, errors.New("Wrong number of argument")
 	}
@@ -202,8 +209,10 @@ func Create2(ioctx *rados.IOContext, name string, size uint64, features uint64,
 
 	defer C.free(unsafe.Pointer(cName))
 
+	t := startOp(ioctx, "rbd_create2", name)
 	ret = C.rbd_create2(cephIoctx(ioctx), cName,
 		C.uint64_t(size), C.uint64_t(features), &cOrder)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -231,9 +240,11 @@ func Create3(ioctx *rados.IOContext, name string, size uint64, features uint64,
 
 	defer C.free(unsafe.Pointer(cName))
 
+	t := startOp(ioctx, "rbd_create3", name)
 	ret = C.rbd_create3(cephIoctx(ioctx), cName,
 		C.uint64_t(size), C.uint64_t(features), &cOrder,
 		C.uint64_t(stripeUnit), C.uint64_t(stripeCount))
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -265,6 +276,7 @@ func (image *Image) Clone(snapname string, cIoctx *rados.IOContext, cName string
 	defer C.free(unsafe.Pointer(cParentSnapName))
 	defer C.free(unsafe.Pointer(cCloneName))
 
+	t := image.startOp("rbd_clone")
 	ret := C.rbd_clone(
 		cephIoctx(image.ioctx),
 		cParentName,
@@ -273,6 +285,7 @@ func (image *Image) Clone(snapname string, cIoctx *rados.IOContext, cName string
 		cCloneName,
 		C.uint64_t(features),
 		&cOrder)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -305,8 +318,9 @@ func (image *Image) Trash(delay time.Duration) error {
 	cName := C.CString(image.name)
 	defer C.free(unsafe.Pointer(cName))
 
-	return getError(C.rbd_trash_move(cephIoctx(image.ioctx), cName,
-		C.uint64_t(delay.Seconds())))
+	t := image.startOp("rbd_trash_move")
+	return t.DoneErr(getError(C.rbd_trash_move(cephIoctx(image.ioctx), cName,
+		C.uint64_t(delay.Seconds()))))
 }
 
 // Rename an rbd image.
@@ -325,8 +339,9 @@ func (image *Image) Rename(destname string) error {
 	defer C.free(unsafe.Pointer(cSrcName))
 	defer C.free(unsafe.Pointer(cDestName))
 
-	err := getError(C.rbd_rename(cephIoctx(image.ioctx),
-		cSrcName, cDestName))
+	t := image.startOp("rbd_rename")
+	err := t.DoneErr(getError(C.rbd_rename(cephIoctx(image.ioctx),
+		cSrcName, cDestName)))
 	if err == nil {
 		image.name = destname
 		return nil
@@ -371,6 +386,7 @@ func (image *Image) Open(args ...interface{}) error {
 	}
 
 	image.image = tmp.image
+	image.snapName = snapName
 	return nil
 }
 
@@ -384,11 +400,15 @@ func (image *Image) Close() error {
 		return err
 	}
 
-	if ret := C.rbd_close(image.image); ret != 0 {
+	t := image.startOp("rbd_close")
+	ret := C.rbd_close(image.image)
+	t.Done(0, getError(ret))
+	if ret != 0 {
 		return getError(ret)
 	}
 
 	image.image = nil
+	image.snapName = NoSnapshot
 	return nil
 }
 
@@ -402,7 +422,8 @@ func (image *Image) Resize(size uint64) error {
 		return err
 	}
 
-	return getError(C.rbd_resize(image.image, C.uint64_t(size)))
+	t := image.startOp("rbd_resize")
+	return t.DoneErr(getError(C.rbd_resize(image.image, C.uint64_t(size))))
 }
 
 // Stat an rbd image.
@@ -417,7 +438,10 @@ func (image *Image) Stat() (info *ImageInfo, err error) {
 
 	var cStat C.rbd_image_info_t
 
-	if ret := C.rbd_stat(image.image, &cStat, C.size_t(unsafe.Sizeof(info))); ret < 0 {
+	t := image.startOp("rbd_stat")
+	ret := C.rbd_stat(image.image, &cStat, C.size_t(unsafe.Sizeof(info)))
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return info, getError(ret)
 	}
 
@@ -440,8 +464,10 @@ func (image *Image) IsOldFormat() (bool, error) {
 	}
 
 	var cOldFormat C.uint8_t
+	t := image.startOp("rbd_get_old_format")
 	ret := C.rbd_get_old_format(image.image,
 		&cOldFormat)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return false, getError(ret)
 	}
@@ -459,7 +485,10 @@ func (image *Image) GetSize() (size uint64, err error) {
 		return 0, err
 	}
 
-	if ret := C.rbd_get_size(image.image, (*C.uint64_t)(&size)); ret < 0 {
+	t := image.startOp("rbd_get_size")
+	ret := C.rbd_get_size(image.image, (*C.uint64_t)(&size))
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return 0, getError(ret)
 	}
 
@@ -477,7 +506,10 @@ func (image *Image) GetStripeUnit() (uint64, error) {
 	}
 
 	var stripeUnit uint64
-	if ret := C.rbd_get_stripe_unit(image.image, (*C.uint64_t)(&stripeUnit)); ret < 0 {
+	t := image.startOp("rbd_get_stripe_unit")
+	ret := C.rbd_get_stripe_unit(image.image, (*C.uint64_t)(&stripeUnit))
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return 0, getError(ret)
 	}
 
@@ -495,7 +527,10 @@ func (image *Image) GetStripeCount() (uint64, error) {
 	}
 
 	var stripeCount uint64
-	if ret := C.rbd_get_stripe_count(image.image, (*C.uint64_t)(&stripeCount)); ret < 0 {
+	t := image.startOp("rbd_get_stripe_count")
+	ret := C.rbd_get_stripe_count(image.image, (*C.uint64_t)(&stripeCount))
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return 0, getError(ret)
 	}
 
@@ -513,7 +548,10 @@ func (image *Image) GetOverlap() (overlap uint64, err error) {
 		return 0, err
 	}
 
-	if ret := C.rbd_get_overlap(image.image, (*C.uint64_t)(&overlap)); ret < 0 {
+	t := image.startOp("rbd_get_overlap")
+	ret := C.rbd_get_overlap(image.image, (*C.uint64_t)(&overlap))
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return overlap, getError(ret)
 	}
 
@@ -537,8 +575,9 @@ func (image *Image) Copy(ioctx *rados.IOContext, destname string) error {
 	cDestName := C.CString(destname)
 	defer C.free(unsafe.Pointer(cDestName))
 
-	return getError(C.rbd_copy(image.image,
-		cephIoctx(ioctx), cDestName))
+	t := image.startOp("rbd_copy")
+	return t.DoneErr(getError(C.rbd_copy(image.image,
+		cephIoctx(ioctx), cDestName)))
 }
 
 // Copy2 copies one rbd image to another, using an image handle.
@@ -553,7 +592,8 @@ func (image *Image) Copy2(dest *Image) error {
 		return err
 	}
 
-	return getError(C.rbd_copy2(image.image, dest.image))
+	t := image.startOp("rbd_copy2")
+	return t.DoneErr(getError(C.rbd_copy2(image.image, dest.image)))
 }
 
 // DeepCopy an rbd image to a new image with specific options.
@@ -579,9 +619,10 @@ func (image *Image) DeepCopy(ioctx *rados.IOContext, destname string, rio *Image
 	cDestname := C.CString(destname)
 	defer C.free(unsafe.Pointer(cDestname))
 
+	t := image.startOp("rbd_deep_copy")
 	ret := C.rbd_deep_copy(image.image, cephIoctx(ioctx), cDestname,
 		C.rbd_image_options_t(rio.options))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // Flatten removes snapshot references from the image.
@@ -594,7 +635,8 @@ func (image *Image) Flatten() error {
 		return err
 	}
 
-	return getError(C.rbd_flatten(image.image))
+	t := image.startOp("rbd_flatten")
+	return t.DoneErr(getError(C.rbd_flatten(image.image)))
 }
 
 // ListLockers returns a list of clients that have locks on the image.
@@ -615,11 +657,13 @@ func (image *Image) ListLockers() (tag string, lockers []Locker, err error) {
 	var cTagLen, cClientsLen, cCookiesLen, cAddrsLen C.size_t
 	var cLockerCount C.ssize_t
 
-	C.rbd_list_lockers(image.image, &cExclusive,
+	t := image.startOp("rbd_list_lockers")
+	cLockerCount = C.rbd_list_lockers(image.image, &cExclusive,
 		nil, (*C.size_t)(&cTagLen),
 		nil, (*C.size_t)(&cClientsLen),
 		nil, (*C.size_t)(&cCookiesLen),
 		nil, (*C.size_t)(&cAddrsLen))
+	t.Done(0, getErrorIfNegative(C.int(cLockerCount)))
 
 	// no locker held on rbd image when either c_clients_len,
 	// c_cookies_len or c_addrs_len is *0*, so just quickly returned
@@ -634,11 +678,13 @@ func (image *Image) ListLockers() (tag string, lockers []Locker, err error) {
 	cookiesBuf := make([]byte, cCookiesLen)
 	addrsBuf := make([]byte, cAddrsLen)
 
+	t = image.startOp("rbd_list_lockers")
 	cLockerCount = C.rbd_list_lockers(image.image, &cExclusive,
 		(*C.char)(unsafe.Pointer(&tagBuf[0])), (*C.size_t)(&cTagLen),
 		(*C.char)(unsafe.Pointer(&clientsBuf[0])), (*C.size_t)(&cClientsLen),
 		(*C.char)(unsafe.Pointer(&cookiesBuf[0])), (*C.size_t)(&cCookiesLen),
 		(*C.char)(unsafe.Pointer(&addrsBuf[0])), (*C.size_t)(&cAddrsLen))
+	t.Done(0, getErrorIfNegative(C.int(cLockerCount)))
 
 	// rbd_list_lockers returns negative value for errors
 	// and *0* means no locker held on rbd image.
@@ -675,7 +721,8 @@ func (image *Image) LockExclusive(cookie string) error {
 	cCookie := C.CString(cookie)
 	defer C.free(unsafe.Pointer(cCookie))
 
-	return getError(C.rbd_lock_exclusive(image.image, cCookie))
+	t := image.startOp("rbd_lock_exclusive")
+	return t.DoneErr(getError(C.rbd_lock_exclusive(image.image, cCookie)))
 }
 
 // LockShared acquires a shared lock on the rbd image.
@@ -693,7 +740,8 @@ func (image *Image) LockShared(cookie string, tag string) error {
 	defer C.free(unsafe.Pointer(cCookie))
 	defer C.free(unsafe.Pointer(cTag))
 
-	return getError(C.rbd_lock_shared(image.image, cCookie, cTag))
+	t := image.startOp("rbd_lock_shared")
+	return t.DoneErr(getError(C.rbd_lock_shared(image.image, cCookie, cTag)))
 }
 
 // Unlock releases a lock on the image.
@@ -709,7 +757,8 @@ func (image *Image) Unlock(cookie string) error {
 	cCookie := C.CString(cookie)
 	defer C.free(unsafe.Pointer(cCookie))
 
-	return getError(C.rbd_unlock(image.image, cCookie))
+	t := image.startOp("rbd_unlock")
+	return t.DoneErr(getError(C.rbd_unlock(image.image, cCookie)))
 }
 
 // BreakLock forces the release of a lock held by another client.
@@ -727,7 +776,8 @@ func (image *Image) BreakLock(client string, cookie string) error {
 	defer C.free(unsafe.Pointer(cClient))
 	defer C.free(unsafe.Pointer(cCookie))
 
-	return getError(C.rbd_break_lock(image.image, cClient, cCookie))
+	t := image.startOp("rbd_break_lock")
+	return t.DoneErr(getError(C.rbd_break_lock(image.image, cClient, cCookie)))
 }
 
 // Read data from the image. The length of the read is determined by the length
@@ -747,6 +797,7 @@ func (image *Image) Read(data []byte) (int, error) {
 		return 0, nil
 	}
 
+	t := image.startOp("rbd_read")
 	ret := int(C.rbd_read(
 		image.image,
 		(C.uint64_t)(image.offset),
@@ -754,8 +805,11 @@ func (image *Image) Read(data []byte) (int, error) {
 		(*C.char)(unsafe.Pointer(&data[0]))))
 
 	if ret < 0 {
-		return 0, getError(C.int(ret))
+		err := getError(C.int(ret))
+		t.Done(0, err)
+		return 0, err
 	}
+	t.Done(int64(ret), nil)
 
 	image.offset += int64(ret)
 	if ret < len(data) {
@@ -778,6 +832,7 @@ func (image *Image) Write(data []byte) (n int, err error) {
 		return 0, err
 	}
 
+	t := image.startOp("rbd_write")
 	ret := int(C.rbd_write(image.image, C.uint64_t(image.offset),
 		C.size_t(len(data)), (*C.char)(unsafe.Pointer(&data[0]))))
 
@@ -788,6 +843,7 @@ func (image *Image) Write(data []byte) (n int, err error) {
 	if ret != len(data) {
 		err = getError(-C.EPERM)
 	}
+	t.Done(int64(max(ret, 0)), err)
 
 	return ret, err
 }
@@ -823,10 +879,14 @@ func (image *Image) Discard(ofs uint64, length uint64) (int, error) {
 		return 0, err
 	}
 
+	t := image.startOp("rbd_discard")
 	ret := C.rbd_discard(image.image, C.uint64_t(ofs), C.uint64_t(length))
 	if ret < 0 {
-		return 0, getError(ret)
+		err := getError(ret)
+		t.Done(0, err)
+		return 0, err
 	}
+	t.Done(int64(ret), nil)
 
 	return int(ret), nil
 }
@@ -841,6 +901,7 @@ func (image *Image) ReadAt(data []byte, off int64) (int, error) {
 		return 0, nil
 	}
 
+	t := image.startOp("rbd_read")
 	ret := int(C.rbd_read(
 		image.image,
 		(C.uint64_t)(off),
@@ -848,8 +909,11 @@ func (image *Image) ReadAt(data []byte, off int64) (int, error) {
 		(*C.char)(unsafe.Pointer(&data[0]))))
 
 	if ret < 0 {
-		return 0, getError(C.int(ret))
+		err := getError(C.int(ret))
+		t.Done(0, err)
+		return 0, err
 	}
+	t.Done(int64(ret), nil)
 
 	if ret < len(data) {
 		return ret, io.EOF
@@ -868,12 +932,14 @@ func (image *Image) WriteAt(data []byte, off int64) (n int, err error) {
 		return 0, nil
 	}
 
+	t := image.startOp("rbd_write")
 	ret := int(C.rbd_write(image.image, C.uint64_t(off),
 		C.size_t(len(data)), (*C.char)(unsafe.Pointer(&data[0]))))
 
 	if ret != len(data) {
 		err = getError(-C.EPERM)
 	}
+	t.Done(int64(max(ret, 0)), err)
 
 	return ret, err
 }
@@ -896,6 +962,7 @@ func (image *Image) WriteSame(ofs, n uint64, data []byte, flags rados.OpFlags) (
 		return 0, nil
 	}
 
+	t := image.startOp("rbd_writesame")
 	ret := C.rbd_writesame(image.image,
 		C.uint64_t(ofs),
 		C.size_t(n),
@@ -905,6 +972,7 @@ func (image *Image) WriteSame(ofs, n uint64, data []byte, flags rados.OpFlags) (
 	if ret < 0 {
 		err = getError(C.int(ret))
 	}
+	t.Done(int64(max(ret, 0)), err)
 
 	return int64(ret), err
 }
@@ -919,7 +987,10 @@ func (image *Image) Flush() error {
 		return err
 	}
 
-	return getError(C.rbd_flush(image.image))
+	t := image.startOp("rbd_flush")
+	err := getError(C.rbd_flush(image.image))
+	t.Done(0, err)
+	return err
 }
 
 // GetSnapshotNames returns more than just the names of snapshots
@@ -935,7 +1006,9 @@ func (image *Image) GetSnapshotNames() (snaps []SnapInfo, err error) {
 
 	var cMaxSnaps C.int
 
+	t := image.startOp("rbd_snap_list")
 	ret := C.rbd_snap_list(image.image, nil, &cMaxSnaps)
+	t.Done(0, getErrorIfNegative(ret))
 	// bugfix index out of range(&cSnaps[0])
 	if cMaxSnaps < 1 {
 		return nil, getError(ret)
@@ -943,8 +1016,10 @@ func (image *Image) GetSnapshotNames() (snaps []SnapInfo, err error) {
 	cSnaps := make([]C.rbd_snap_info_t, cMaxSnaps)
 	snaps = make([]SnapInfo, cMaxSnaps)
 
+	t = image.startOp("rbd_snap_list")
 	ret = C.rbd_snap_list(image.image,
 		&cSnaps[0], &cMaxSnaps)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -974,11 +1049,12 @@ func (image *Image) GetId() (string, error) {
 	)
 	retry.WithSizes(1, 8192, func(size int) retry.Hint {
 		buf = make([]byte, size)
+		t := image.startOp("rbd_get_id")
 		ret := C.rbd_get_id(
 			image.image,
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			C.size_t(size))
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.DoubleSize.If(err == errRange)
 	})
 	if err != nil {
@@ -1008,7 +1084,13 @@ func (image *Image) SetSnapshot(snapname string) error {
 	cSnapName := C.CString(snapname)
 	defer C.free(unsafe.Pointer(cSnapName))
 
-	return getError(C.rbd_snap_set(image.image, cSnapName))
+	t := image.startOp("rbd_snap_set")
+	ret := C.rbd_snap_set(image.image, cSnapName)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret == 0 {
+		image.snapName = snapname
+	}
+	return getError(ret)
 }
 
 // GetTrashList returns a slice of TrashInfo structs, containing information about all RBD images
@@ -1022,8 +1104,9 @@ func GetTrashList(ioctx *rados.IOContext) ([]TrashInfo, error) {
 	retry.WithSizes(32, 10240, func(size int) retry.Hint {
 		count = C.size_t(size)
 		entries = make([]C.rbd_trash_image_info_t, count)
+		t := startOp(ioctx, "rbd_trash_list", "")
 		ret := C.rbd_trash_list(cephIoctx(ioctx), &entries[0], &count)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(count)).If(err == errRange)
 	})
 	if err != nil {
@@ -1049,7 +1132,8 @@ func TrashRemove(ioctx *rados.IOContext, id string, force bool) error {
 	cid := C.CString(id)
 	defer C.free(unsafe.Pointer(cid))
 
-	return getError(C.rbd_trash_remove(cephIoctx(ioctx), cid, C.bool(force)))
+	t := startOp(ioctx, "rbd_trash_remove", "")
+	return t.DoneErr(getError(C.rbd_trash_remove(cephIoctx(ioctx), cid, C.bool(force))))
 }
 
 // TrashRestore restores the trashed RBD with the specified id back to the pool from whence it
@@ -1060,7 +1144,8 @@ func TrashRestore(ioctx *rados.IOContext, id, name string) error {
 	defer C.free(unsafe.Pointer(cid))
 	defer C.free(unsafe.Pointer(cName))
 
-	return getError(C.rbd_trash_restore(cephIoctx(ioctx), cid, cName))
+	t := startOp(ioctx, "rbd_trash_restore", name)
+	return t.DoneErr(getError(C.rbd_trash_restore(cephIoctx(ioctx), cid, cName)))
 }
 
 // OpenImage will open an existing rbd image by name and snapshot name,
@@ -1089,20 +1174,23 @@ func OpenImage(ioctx *rados.IOContext, name, snapName string) (*Image, error) {
 	}
 
 	var cImage C.rbd_image_t
+	t := startOp(ioctx, "rbd_open", name)
 	ret := C.rbd_open(
 		cephIoctx(ioctx),
 		cName,
 		&cImage,
 		cSnapName)
+	t.Done(0, getErrorIfNegative(ret))
 
 	if ret != 0 {
 		return nil, getError(ret)
 	}
 
 	return &Image{
-		ioctx: ioctx,
-		name:  name,
-		image: cImage,
+		ioctx:    ioctx,
+		name:     name,
+		image:    cImage,
+		snapName: snapName,
 	}, nil
 }
 
@@ -1133,20 +1221,23 @@ func OpenImageReadOnly(ioctx *rados.IOContext, name, snapName string) (*Image, e
 	}
 
 	var cImage C.rbd_image_t
+	t := startOp(ioctx, "rbd_open_read_only", name)
 	ret := C.rbd_open_read_only(
 		cephIoctx(ioctx),
 		cName,
 		&cImage,
 		cSnapName)
+	t.Done(0, getErrorIfNegative(ret))
 
 	if ret != 0 {
 		return nil, getError(ret)
 	}
 
 	return &Image{
-		ioctx: ioctx,
-		name:  name,
-		image: cImage,
+		ioctx:    ioctx,
+		name:     name,
+		image:    cImage,
+		snapName: snapName,
 	}, nil
 }
 
@@ -1178,19 +1269,22 @@ func OpenImageById(ioctx *rados.IOContext, id, snapName string) (*Image, error)
 	}
 
 	var cImage C.rbd_image_t
+	t := startOp(ioctx, "rbd_open_by_id", "")
 	ret := C.rbd_open_by_id(
 		cephIoctx(ioctx),
 		cid,
 		&cImage,
 		cSnapName)
+	t.Done(0, getErrorIfNegative(ret))
 
 	if ret != 0 {
 		return nil, getError(ret)
 	}
 
 	return &Image{
-		ioctx: ioctx,
-		image: cImage,
+		ioctx:    ioctx,
+		image:    cImage,
+		snapName: snapName,
 	}, nil
 }
 
@@ -1223,19 +1317,22 @@ func OpenImageByIdReadOnly(ioctx *rados.IOContext, id, snapName string) (*Image,
 	}
 
 	var cImage C.rbd_image_t
+	t := startOp(ioctx, "rbd_open_by_id_read_only", "")
 	ret := C.rbd_open_by_id_read_only(
 		cephIoctx(ioctx),
 		cid,
 		&cImage,
 		cSnapName)
+	t.Done(0, getErrorIfNegative(ret))
 
 	if ret != 0 {
 		return nil, getError(ret)
 	}
 
 	return &Image{
-		ioctx: ioctx,
-		image: cImage,
+		ioctx:    ioctx,
+		image:    cImage,
+		snapName: snapName,
 	}, nil
 }
 
@@ -1259,9 +1356,10 @@ func CreateImage(ioctx *rados.IOContext, name string, size uint64, rio *ImageOpt
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
+	t := startOp(ioctx, "rbd_create4", name)
 	ret := C.rbd_create4(cephIoctx(ioctx), cName,
 		C.uint64_t(size), C.rbd_image_options_t(rio.options))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // RemoveImage removes the specified rbd image.
@@ -1279,7 +1377,8 @@ func RemoveImage(ioctx *rados.IOContext, name string) error {
 
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
-	return getError(C.rbd_remove(cephIoctx(ioctx), cName))
+	t := startOp(ioctx, "rbd_remove", name)
+	return t.DoneErr(getError(C.rbd_remove(cephIoctx(ioctx), cName)))
 }
 
 // CloneImage creates a clone of the image from the named snapshot in the
@@ -1304,6 +1403,7 @@ func CloneImage(ioctx *rados.IOContext, parentName, snapName string,
 	cCloneName := C.CString(name)
 	defer C.free(unsafe.Pointer(cCloneName))
 
+	t := startOp(destctx, "rbd_clone3", name)
 	ret := C.rbd_clone3(
 		cephIoctx(ioctx),
 		cParentName,
@@ -1311,7 +1411,7 @@ func CloneImage(ioctx *rados.IOContext, parentName, snapName string,
 		cephIoctx(destctx),
 		cCloneName,
 		C.rbd_image_options_t(rio.options))
-	return getError(ret)
+	return t.DoneErr(getError(ret))
 }
 
 // CloneFromImage creates a clone of the image from the named snapshot in the
@@ -23,11 +23,12 @@ func GetImageNames(ioctx *rados.IOContext) ([]string, error) {
 	size := C.size_t(4096)
 	for {
 		images = make([]C.rbd_image_spec_t, size)
+		t := startOp(ioctx, "rbd_list2", "")
 		ret := C.rbd_list2(
 			cephIoctx(ioctx),
 			(*C.rbd_image_spec_t)(unsafe.Pointer(&images[0])),
 			&size)
-		err := getErrorIfNegative(ret)
+		err := t.DoneErr(getErrorIfNegative(ret))
 		if err != nil {
 			if err == errRange {
 				continue
@@ -57,7 +58,10 @@ func (image *Image) GetCreateTimestamp() (Timespec, error) {
 
 	var cts C.struct_timespec
 
-	if ret := C.rbd_get_create_timestamp(image.image, &cts); ret < 0 {
+	t := image.startOp("rbd_get_create_timestamp")
+	ret := C.rbd_get_create_timestamp(image.image, &cts)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return Timespec{}, getError(ret)
 	}
 
@@ -76,7 +80,10 @@ func (image *Image) GetAccessTimestamp() (Timespec, error) {
 
 	var cts C.struct_timespec
 
-	if ret := C.rbd_get_access_timestamp(image.image, &cts); ret < 0 {
+	t := image.startOp("rbd_get_access_timestamp")
+	ret := C.rbd_get_access_timestamp(image.image, &cts)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return Timespec{}, getError(ret)
 	}
 
@@ -95,7 +102,10 @@ func (image *Image) GetModifyTimestamp() (Timespec, error) {
 
 	var cts C.struct_timespec
 
-	if ret := C.rbd_get_modify_timestamp(image.image, &cts); ret < 0 {
+	t := image.startOp("rbd_get_modify_timestamp")
+	ret := C.rbd_get_modify_timestamp(image.image, &cts)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
 		return Timespec{}, getError(ret)
 	}
 
@@ -114,5 +124,6 @@ func (image *Image) Sparsify(sparseSize uint) error {
 		return err
 	}
 
-	return getError(C.rbd_sparsify(image.image, C.size_t(sparseSize)))
+	t := image.startOp("rbd_sparsify")
+	return t.DoneErr(getError(C.rbd_sparsify(image.image, C.size_t(sparseSize))))
 }
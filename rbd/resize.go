@@ -71,7 +71,9 @@ func (image *Image) Resize2(size uint64, allowShrink bool, cb Resize2ProgressCal
 	cbIndex := resizeCallbacks.Add(ctx)
 	defer resizeCallbacks.Remove(cbIndex)
 
+	t := image.startOp("rbd_resize2")
 	ret := C.wrap_rbd_resize2(image.image, C.uint64_t(size), C.bool(allowShrink), C.uintptr_t(cbIndex))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 
@@ -32,11 +32,12 @@ func (image *Image) GetSnapGroupNamespace(snapID uint64) (*SnapGroupNamespace, e
 		sgn C.rbd_snap_group_namespace_t
 	)
 
+	t := image.startOp("rbd_snap_get_group_namespace")
 	ret := C.rbd_snap_get_group_namespace(image.image,
 		C.uint64_t(snapID),
 		&sgn,
 		C.sizeof_rbd_snap_group_namespace_t)
-	err = getError(ret)
+	err = t.DoneErr(getError(ret))
 	if err != nil {
 		return nil, err
 	}
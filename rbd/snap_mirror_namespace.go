@@ -0,0 +1,112 @@
+//go:build !nautilus && ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"unsafe"
+)
+
+// SnapNamespaceTypeMirror indicates that the snapshot belongs to the mirror
+// namespace. Such snapshots are created for snapshot based mirroring and
+// have associated mirror information.
+const SnapNamespaceTypeMirror = SnapNamespaceType(C.RBD_SNAP_NAMESPACE_TYPE_MIRROR)
+
+// SnapMirrorState is the state of a mirror snapshot.
+type SnapMirrorState C.rbd_snap_mirror_state_t
+
+const (
+	// SnapMirrorStatePrimary is the state of a snapshot of a primary image.
+	SnapMirrorStatePrimary = SnapMirrorState(C.RBD_SNAP_MIRROR_STATE_PRIMARY)
+	// SnapMirrorStatePrimaryDemoted is the state of the snapshot created
+	// when a primary image is demoted.
+	SnapMirrorStatePrimaryDemoted = SnapMirrorState(C.RBD_SNAP_MIRROR_STATE_PRIMARY_DEMOTED)
+	// SnapMirrorStateNonPrimary is the state of a snapshot of a non-primary
+	// image, copied from the primary image.
+	SnapMirrorStateNonPrimary = SnapMirrorState(C.RBD_SNAP_MIRROR_STATE_NON_PRIMARY)
+	// SnapMirrorStateNonPrimaryDemoted is the state of the snapshot of a
+	// non-primary image that was copied from the snapshot of a demoted
+	// primary image.
+	SnapMirrorStateNonPrimaryDemoted = SnapMirrorState(C.RBD_SNAP_MIRROR_STATE_NON_PRIMARY_DEMOTED)
+)
+
+// String representation of SnapMirrorState.
+func (s SnapMirrorState) String() string {
+	switch s {
+	case SnapMirrorStatePrimary:
+		return "primary"
+	case SnapMirrorStatePrimaryDemoted:
+		return "primary (demoted)"
+	case SnapMirrorStateNonPrimary:
+		return "non-primary"
+	case SnapMirrorStateNonPrimaryDemoted:
+		return "non-primary (demoted)"
+	default:
+		return "<unknown>"
+	}
+}
+
+// SnapMirrorNamespace provides details about a snapshot created for
+// snapshot based mirroring.
+type SnapMirrorNamespace struct {
+	State SnapMirrorState
+	// MirrorPeerUUIDs are the UUIDs of the peers the snapshot is mirrored
+	// to.
+	MirrorPeerUUIDs []string
+	// Complete is false while the snapshot of a non-primary image is still
+	// being copied from the primary image.
+	Complete bool
+	// PrimaryMirrorUUID and PrimarySnapID identify the snapshot of the
+	// primary image a non-primary snapshot is copied from.
+	PrimaryMirrorUUID string
+	PrimarySnapID     uint64
+	// LastCopiedObjectNumber is the number of the last object copied to an
+	// incomplete non-primary snapshot.
+	LastCopiedObjectNumber uint64
+}
+
+// GetSnapMirrorNamespace returns the SnapMirrorNamespace of a mirror
+// snapshot. The caller should make sure that the snapshot ID passed in this
+// function belongs to a snapshot in the mirror namespace.
+//
+// Implements:
+//
+//	int rbd_snap_get_mirror_namespace(rbd_image_t image, uint64_t snap_id,
+//	                                  rbd_snap_mirror_namespace_t *mirror_snap,
+//	                                  size_t mirror_snap_size)
+func (image *Image) GetSnapMirrorNamespace(snapID uint64) (*SnapMirrorNamespace, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+
+	var smn C.rbd_snap_mirror_namespace_t
+	t := image.startOp("rbd_snap_get_mirror_namespace")
+	ret := C.rbd_snap_get_mirror_namespace(image.image,
+		C.uint64_t(snapID),
+		&smn,
+		C.sizeof_rbd_snap_mirror_namespace_t)
+	if err := t.DoneErr(getError(ret)); err != nil {
+		return nil, err
+	}
+	defer C.rbd_snap_mirror_namespace_cleanup(&smn, C.sizeof_rbd_snap_mirror_namespace_t)
+
+	// the peer UUIDs are stored as consecutive null terminated strings
+	uuids := make([]string, 0, int(smn.mirror_peer_uuids_count))
+	p := smn.mirror_peer_uuids
+	for i := 0; i < int(smn.mirror_peer_uuids_count); i++ {
+		uuid := C.GoString(p)
+		uuids = append(uuids, uuid)
+		p = (*C.char)(unsafe.Add(unsafe.Pointer(p), len(uuid)+1))
+	}
+	return &SnapMirrorNamespace{
+		State:                  SnapMirrorState(smn.state),
+		MirrorPeerUUIDs:        uuids,
+		Complete:               bool(smn.complete),
+		PrimaryMirrorUUID:      C.GoString(smn.primary_mirror_uuid),
+		PrimarySnapID:          uint64(smn.primary_snap_id),
+		LastCopiedObjectNumber: uint64(smn.last_copied_object_number),
+	}, nil
+}
@@ -0,0 +1,81 @@
+//go:build !nautilus && ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSnapMirrorNamespace(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	require.NoError(t, SetMirrorMode(ioctx, MirrorModeImage))
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t, options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	require.NoError(t, CreateImage(ioctx, name, testImageSize, options))
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	require.NoError(t, img.MirrorEnable(ImageMirrorModeSnapshot))
+	defer func() { assert.NoError(t, img.MirrorDisable(false)) }()
+
+	snapID, err := img.CreateMirrorSnapshot()
+	require.NoError(t, err)
+
+	nsType, err := img.GetSnapNamespaceType(snapID)
+	assert.NoError(t, err)
+	assert.Equal(t, SnapNamespaceTypeMirror, nsType)
+
+	ns, err := img.GetSnapMirrorNamespace(snapID)
+	require.NoError(t, err)
+	assert.Equal(t, SnapMirrorStatePrimary, ns.State)
+	assert.Equal(t, "primary", ns.State.String())
+	assert.True(t, ns.Complete)
+	// no peers are configured
+	assert.Empty(t, ns.MirrorPeerUUIDs)
+	assert.Empty(t, ns.PrimaryMirrorUUID)
+
+	t.Run("userSnapshot", func(t *testing.T) {
+		snap, err := img.CreateSnapshot("user")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, snap.Remove()) }()
+		id, err := img.GetSnapID("user")
+		require.NoError(t, err)
+		nsType, err := img.GetSnapNamespaceType(id)
+		assert.NoError(t, err)
+		assert.Equal(t, SnapNamespaceTypeUser, nsType)
+		_, err = img.GetSnapMirrorNamespace(id)
+		assert.Error(t, err)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		_, err := GetImage(ioctx, name).GetSnapMirrorNamespace(snapID)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
+
+func TestSnapMirrorStateString(t *testing.T) {
+	assert.Equal(t, "primary (demoted)", SnapMirrorStatePrimaryDemoted.String())
+	assert.Equal(t, "non-primary", SnapMirrorStateNonPrimary.String())
+	assert.Equal(t, "non-primary (demoted)", SnapMirrorStateNonPrimaryDemoted.String())
+	assert.Equal(t, "<unknown>", SnapMirrorState(99).String())
+}
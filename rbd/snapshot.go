@@ -31,7 +31,9 @@ func (image *Image) CreateSnapshot(snapname string) (*Snapshot, error) {
 	cSnapName := C.CString(snapname)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := image.startOp("rbd_snap_create")
 	ret := C.rbd_snap_create(image.image, cSnapName)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return nil, getError(ret)
 	}
@@ -77,7 +79,8 @@ func (snapshot *Snapshot) Remove() error {
 	cSnapName := C.CString(snapshot.name)
 	defer C.free(unsafe.Pointer(cSnapName))
 
-	return getError(C.rbd_snap_remove(snapshot.image.image, cSnapName))
+	t := snapshot.image.startOp("rbd_snap_remove")
+	return t.DoneErr(getError(C.rbd_snap_remove(snapshot.image.image, cSnapName)))
 }
 
 // Rollback the image to the snapshot.
@@ -93,7 +96,8 @@ func (snapshot *Snapshot) Rollback() error {
 	cSnapName := C.CString(snapshot.name)
 	defer C.free(unsafe.Pointer(cSnapName))
 
-	return getError(C.rbd_snap_rollback(snapshot.image.image, cSnapName))
+	t := snapshot.image.startOp("rbd_snap_rollback")
+	return t.DoneErr(getError(C.rbd_snap_rollback(snapshot.image.image, cSnapName)))
 }
 
 // Protect a snapshot from unwanted deletion.
@@ -109,7 +113,8 @@ func (snapshot *Snapshot) Protect() error {
 	cSnapName := C.CString(snapshot.name)
 	defer C.free(unsafe.Pointer(cSnapName))
 
-	return getError(C.rbd_snap_protect(snapshot.image.image, cSnapName))
+	t := snapshot.image.startOp("rbd_snap_protect")
+	return t.DoneErr(getError(C.rbd_snap_protect(snapshot.image.image, cSnapName)))
 }
 
 // Unprotect stops protecting the snapshot.
@@ -125,7 +130,8 @@ func (snapshot *Snapshot) Unprotect() error {
 	cSnapName := C.CString(snapshot.name)
 	defer C.free(unsafe.Pointer(cSnapName))
 
-	return getError(C.rbd_snap_unprotect(snapshot.image.image, cSnapName))
+	t := snapshot.image.startOp("rbd_snap_unprotect")
+	return t.DoneErr(getError(C.rbd_snap_unprotect(snapshot.image.image, cSnapName)))
 }
 
 // IsProtected returns true if the snapshot is currently protected.
@@ -144,8 +150,10 @@ func (snapshot *Snapshot) IsProtected() (bool, error) {
 	cSnapName := C.CString(snapshot.name)
 	defer C.free(unsafe.Pointer(cSnapName))
 
+	t := snapshot.image.startOp("rbd_snap_is_protected")
 	ret := C.rbd_snap_is_protected(snapshot.image.image, cSnapName,
 		&cIsProtected)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return false, getError(ret)
 	}
@@ -184,7 +192,9 @@ func (image *Image) GetSnapTimestamp(snapID uint64) (Timespec, error) {
 
 	var cts C.struct_timespec
 
+	t := image.startOp("rbd_snap_get_timestamp")
 	ret := C.rbd_snap_get_timestamp(image.image, C.uint64_t(snapID), &cts)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret < 0 {
 		return Timespec{}, getError(ret)
 	}
@@ -0,0 +1,68 @@
+//go:build !octopus && !nautilus && ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <stdlib.h>
+#include <rbd/librbd.h>
+
+// librbd calls the progress function unconditionally
+static int snap_create2_no_progress(uint64_t offset, uint64_t total, void *arg) {
+	return 0;
+}
+
+static inline int wrap_rbd_snap_create2(rbd_image_t image,
+	const char *snap_name, uint32_t flags) {
+		return rbd_snap_create2(image, snap_name, flags,
+			snap_create2_no_progress, NULL);
+};
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// SnapCreateFlags control the behavior of CreateSnapshot2.
+type SnapCreateFlags uint32
+
+const (
+	// SnapCreateSkipQuiesce creates the snapshot without notifying the
+	// quiesce watchers of the image.
+	SnapCreateSkipQuiesce = SnapCreateFlags(C.RBD_SNAP_CREATE_SKIP_QUIESCE)
+	// SnapCreateIgnoreQuiesceError creates the snapshot even if a quiesce
+	// watcher reports an error.
+	SnapCreateIgnoreQuiesceError = SnapCreateFlags(C.RBD_SNAP_CREATE_IGNORE_QUIESCE_ERROR)
+)
+
+// CreateSnapshot2 returns a new Snapshot object after creating a snapshot of
+// the rbd image. Unless SnapCreateSkipQuiesce is set, the quiesce watchers of
+// the image, see QuiesceWatch, are notified before the snapshot is created
+// and after it has been created.
+//
+// Implements:
+//
+//	int rbd_snap_create2(rbd_image_t image, const char *snap_name,
+//	                     uint32_t flags, librbd_progress_fn_t cb,
+//	                     void *cbdata);
+func (image *Image) CreateSnapshot2(snapname string, flags SnapCreateFlags) (*Snapshot, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+
+	cSnapName := C.CString(snapname)
+	defer C.free(unsafe.Pointer(cSnapName))
+
+	t := image.startOp("rbd_snap_create2")
+	ret := C.wrap_rbd_snap_create2(image.image, cSnapName, C.uint32_t(flags))
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+
+	return &Snapshot{
+		image: image,
+		name:  snapname,
+	}, nil
+}
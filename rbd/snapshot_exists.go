@@ -0,0 +1,62 @@
+//go:build ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <stdlib.h>
+#include <stdbool.h>
+#include <rbd/librbd.h>
+
+// rbd_snap_exists_fn matches the rbd_snap_exists function signature.
+typedef int(*rbd_snap_exists_fn)(rbd_image_t image, const char *snapname,
+                                 bool *exists);
+
+// rbd_snap_exists_dlsym take *fn as rbd_snap_exists_fn and calls the
+// dynamically loaded rbd_snap_exists function passed as 1st argument.
+static inline int rbd_snap_exists_dlsym(void *fn, rbd_image_t image,
+                                        const char *snapname, bool *exists) {
+  // cast function pointer fn to rbd_snap_exists and call the function
+  return ((rbd_snap_exists_fn) fn)(image, snapname, exists);
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/dlsym"
+)
+
+// Ceph pacific introduced rbd_snap_exists(). The function is resolved at
+// runtime, so that older versions of librbd can be used.
+var rbdSnapExists = dlsym.NewSymbol("rbd_snap_exists")
+
+// Exists returns true if a snapshot with the name of the snapshot exists in
+// the image.
+//
+// Implements:
+//
+//	int rbd_snap_exists(rbd_image_t image, const char *snapname, bool *exists);
+func (snapshot *Snapshot) Exists() (bool, error) {
+	if err := snapshot.validate(snapshotNeedsName | imageIsOpen); err != nil {
+		return false, err
+	}
+
+	fn, err := rbdSnapExists.Pointer()
+	if err != nil {
+		return false, err
+	}
+
+	cSnapName := C.CString(snapshot.name)
+	defer C.free(unsafe.Pointer(cSnapName))
+
+	var cExists C.bool
+	t := snapshot.image.startOp("rbd_snap_exists")
+	ret := C.rbd_snap_exists_dlsym(fn, snapshot.image.image, cSnapName, &cExists)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
+		return false, getError(ret)
+	}
+	return bool(cExists), nil
+}
@@ -0,0 +1,55 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotExists(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t, options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	require.NoError(t, CreateImage(ioctx, name, testImageSize, options))
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	snap, err := img.CreateSnapshot("snap")
+	require.NoError(t, err)
+	exists, err := snap.Exists()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = img.GetSnapshot("missing").Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, snap.Remove())
+	exists, err = snap.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = img.GetSnapshot("").Exists()
+	assert.ErrorIs(t, err, ErrSnapshotNoName)
+	_, err = GetImage(ioctx, name).GetSnapshot("snap").Exists()
+	assert.ErrorIs(t, err, ErrImageNotOpen)
+}
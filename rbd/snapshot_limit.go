@@ -0,0 +1,52 @@
+//go:build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"math"
+)
+
+// NoSnapLimit is the snapshot limit of images without a limit. Passing it to
+// SetSnapLimit removes the limit of an image.
+const NoSnapLimit = uint64(math.MaxUint64)
+
+// SetSnapLimit sets the maximum number of snapshots of the image. Creating a
+// snapshot fails if the image already has that many snapshots.
+//
+// Implements:
+//
+//	int rbd_snap_set_limit(rbd_image_t image, uint64_t limit);
+func (image *Image) SetSnapLimit(limit uint64) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+
+	t := image.startOp("rbd_snap_set_limit")
+	ret := C.rbd_snap_set_limit(image.image, C.uint64_t(limit))
+	return t.DoneErr(getError(ret))
+}
+
+// GetSnapLimit returns the maximum number of snapshots of the image, or
+// NoSnapLimit if the number of snapshots is not limited.
+//
+// Implements:
+//
+//	int rbd_snap_get_limit(rbd_image_t image, uint64_t *limit);
+func (image *Image) GetSnapLimit() (uint64, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return 0, err
+	}
+
+	var cLimit C.uint64_t
+	t := image.startOp("rbd_snap_get_limit")
+	ret := C.rbd_snap_get_limit(image.image, &cLimit)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret < 0 {
+		return 0, getError(ret)
+	}
+	return uint64(cLimit), nil
+}
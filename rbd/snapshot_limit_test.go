@@ -0,0 +1,66 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapLimit(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t, options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	require.NoError(t, CreateImage(ioctx, name, testImageSize, options))
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	limit, err := img.GetSnapLimit()
+	assert.NoError(t, err)
+	assert.Equal(t, NoSnapLimit, limit)
+
+	require.NoError(t, img.SetSnapLimit(1))
+	limit, err = img.GetSnapLimit()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, limit)
+
+	snap, err := img.CreateSnapshot("snap1")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snap.Remove()) }()
+	_, err = img.CreateSnapshot("snap2")
+	assert.Error(t, err)
+
+	require.NoError(t, img.SetSnapLimit(NoSnapLimit))
+	limit, err = img.GetSnapLimit()
+	assert.NoError(t, err)
+	assert.Equal(t, NoSnapLimit, limit)
+	snap2, err := img.CreateSnapshot("snap2")
+	assert.NoError(t, err)
+	assert.NoError(t, snap2.Remove())
+
+	t.Run("closed", func(t *testing.T) {
+		closed := GetImage(ioctx, name)
+		err := closed.SetSnapLimit(1)
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+		_, err = closed.GetSnapLimit()
+		assert.ErrorIs(t, err, ErrImageNotOpen)
+	})
+}
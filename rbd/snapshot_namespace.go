@@ -41,10 +41,11 @@ func (image *Image) GetSnapNamespaceType(snapID uint64) (SnapNamespaceType, erro
 		return nsType, err
 	}
 
+	t := image.startOp("rbd_snap_get_namespace_type")
 	ret := C.rbd_snap_get_namespace_type(image.image,
 		C.uint64_t(snapID),
 		(*C.rbd_snap_namespace_type_t)(&nsType))
-	return nsType, getError(ret)
+	return nsType, t.DoneErr(getError(ret))
 }
 
 // GetSnapTrashNamespace returns the original name of the snapshot which was
@@ -66,11 +67,12 @@ func (image *Image) GetSnapTrashNamespace(snapID uint64) (string, error) {
 	retry.WithSizes(4096, 262144, func(length int) retry.Hint {
 		cLength := C.size_t(length)
 		buf = make([]byte, cLength)
+		t := image.startOp("rbd_snap_get_trash_namespace")
 		ret := C.rbd_snap_get_trash_namespace(image.image,
 			C.uint64_t(snapID),
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			cLength)
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.Size(int(cLength)).If(err == errRange)
 	})
 
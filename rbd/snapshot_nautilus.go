@@ -30,7 +30,9 @@ func (image *Image) GetParentInfo(pool, name, snapname []byte) error {
 
 	parentImage := C.rbd_linked_image_spec_t{}
 	parentSnap := C.rbd_snap_spec_t{}
+	t := image.startOp("rbd_get_parent")
 	ret := C.rbd_get_parent(image.image, &parentImage, &parentSnap)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return getError(ret)
 	}
@@ -100,7 +102,9 @@ func (image *Image) GetParent() (*ParentInfo, error) {
 
 	parentImage := C.rbd_linked_image_spec_t{}
 	parentSnap := C.rbd_snap_spec_t{}
+	t := image.startOp("rbd_get_parent")
 	ret := C.rbd_get_parent(image.image, &parentImage, &parentSnap)
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return nil, getError(ret)
 	}
@@ -147,11 +151,12 @@ func (image *Image) ListChildren() (pools []string, images []string, err error)
 	retry.WithSizes(16, 4096, func(size int) retry.Hint {
 		csize = C.size_t(size)
 		children = make([]C.rbd_linked_image_spec_t, csize)
+		t := image.startOp("rbd_list_children3")
 		ret := C.rbd_list_children3(
 			image.image,
 			(*C.rbd_linked_image_spec_t)(unsafe.Pointer(&children[0])),
 			&csize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(csize)).If(err == errRange)
 	})
 	if err != nil {
@@ -186,11 +191,12 @@ func (image *Image) ListChildrenAttributes() (imgSpec []ImageSpec, err error) {
 	retry.WithSizes(16, 4096, func(size int) retry.Hint {
 		csize = C.size_t(size)
 		children = make([]C.rbd_linked_image_spec_t, csize)
+		t := image.startOp("rbd_list_children3")
 		ret := C.rbd_list_children3(
 			image.image,
 			(*C.rbd_linked_image_spec_t)(unsafe.Pointer(&children[0])),
 			&csize)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(csize)).If(err == errRange)
 	})
 	if err != nil {
@@ -223,6 +229,21 @@ func (image *Image) SetSnapByID(snapID uint64) error {
 		return err
 	}
 
+	t := image.startOp("rbd_snap_set_by_id")
 	ret := C.rbd_snap_set_by_id(image.image, C.uint64_t(snapID))
-	return getError(ret)
+	t.Done(0, getErrorIfNegative(ret))
+	if ret != 0 {
+		return getError(ret)
+	}
+	// the name stays unknown for snapshots that are not listed, such as
+	// those of other namespaces
+	image.snapName = NoSnapshot
+	if snaps, err := image.GetSnapshotNames(); err == nil {
+		for _, snap := range snaps {
+			if snap.Id == snapID {
+				image.snapName = snap.Name
+			}
+		}
+	}
+	return nil
 }
@@ -1,19 +1,53 @@
-//go:build !nautilus
-// +build !nautilus
-
 package rbd
 
-// #cgo LDFLAGS: -lrbd
-// #include <stdlib.h>
-// #include <rbd/librbd.h>
+/*
+#cgo LDFLAGS: -lrbd
+#include <stdlib.h>
+#include <rbd/librbd.h>
+
+// rbd_snap_get_id_fn matches the rbd_snap_get_id function signature.
+typedef int(*rbd_snap_get_id_fn)(rbd_image_t image, const char *snapname,
+                                 uint64_t *snap_id);
+
+// rbd_snap_get_id_dlsym take *fn as rbd_snap_get_id_fn and calls the
+// dynamically loaded rbd_snap_get_id function passed as 1st argument.
+static inline int rbd_snap_get_id_dlsym(void *fn, rbd_image_t image,
+                                        const char *snapname,
+                                        uint64_t *snap_id) {
+  // cast function pointer fn to rbd_snap_get_id and call the function
+  return ((rbd_snap_get_id_fn) fn)(image, snapname, snap_id);
+}
+
+// rbd_snap_get_name_fn matches the rbd_snap_get_name function signature.
+typedef int(*rbd_snap_get_name_fn)(rbd_image_t image, uint64_t snap_id,
+                                   char *snapname, size_t *name_len);
+
+// rbd_snap_get_name_dlsym take *fn as rbd_snap_get_name_fn and calls the
+// dynamically loaded rbd_snap_get_name function passed as 1st argument.
+static inline int rbd_snap_get_name_dlsym(void *fn, rbd_image_t image,
+                                          uint64_t snap_id, char *snapname,
+                                          size_t *name_len) {
+  // cast function pointer fn to rbd_snap_get_name and call the function
+  return ((rbd_snap_get_name_fn) fn)(image, snap_id, snapname, name_len);
+}
+*/
 import "C"
 
 import (
 	"unsafe"
 
+	"github.com/ceph/go-ceph/internal/dlsym"
 	"github.com/ceph/go-ceph/internal/retry"
 )
 
+// Ceph octopus introduced rbd_snap_get_id() and rbd_snap_get_name(). The
+// functions are resolved at runtime, so that older versions of librbd can be
+// used.
+var (
+	rbdSnapGetID   = dlsym.NewSymbol("rbd_snap_get_id")
+	rbdSnapGetName = dlsym.NewSymbol("rbd_snap_get_name")
+)
+
 // GetSnapID returns the snapshot ID for the given snapshot name.
 //
 // Implements:
@@ -28,11 +62,17 @@ func (image *Image) GetSnapID(snapName string) (uint64, error) {
 		return uint64(snapID), ErrSnapshotNoName
 	}
 
+	fn, err := rbdSnapGetID.Pointer()
+	if err != nil {
+		return uint64(snapID), err
+	}
+
 	cSnapName := C.CString(snapName)
 	defer C.free(unsafe.Pointer(cSnapName))
 
-	ret := C.rbd_snap_get_id(image.image, cSnapName, &snapID)
-	return uint64(snapID), getError(ret)
+	t := image.startOp("rbd_snap_get_id")
+	ret := C.rbd_snap_get_id_dlsym(fn, image.image, cSnapName, &snapID)
+	return uint64(snapID), t.DoneErr(getError(ret))
 }
 
 // GetSnapByID returns the snapshot name for the given snapshot ID.
@@ -45,20 +85,24 @@ func (image *Image) GetSnapByID(snapID uint64) (string, error) {
 		return "", err
 	}
 
-	var (
-		buf []byte
-		err error
-	)
+	fn, err := rbdSnapGetName.Pointer()
+	if err != nil {
+		return "", err
+	}
+
+	var buf []byte
 	// range from 1k to 64KiB
 	retry.WithSizes(1024, 1<<16, func(length int) retry.Hint {
 		cLen := C.size_t(length)
 		buf = make([]byte, cLen)
-		ret := C.rbd_snap_get_name(
+		t := image.startOp("rbd_snap_get_name")
+		ret := C.rbd_snap_get_name_dlsym(
+			fn,
 			image.image,
 			(C.uint64_t)(snapID),
 			(*C.char)(unsafe.Pointer(&buf[0])),
 			&cLen)
-		err = getError(ret)
+		err = t.DoneErr(getError(ret))
 		return retry.Size(int(cLen)).If(err == errRange)
 	})
 
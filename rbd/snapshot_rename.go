@@ -25,7 +25,9 @@ func (snapshot *Snapshot) Rename(destName string) error {
 	defer C.free(unsafe.Pointer(cSrcName))
 	defer C.free(unsafe.Pointer(cDestName))
 
+	t := snapshot.image.startOp("rbd_snap_rename")
 	err := C.rbd_snap_rename(snapshot.image.image, cSrcName, cDestName)
+	t.Done(0, getErrorIfNegative(err))
 	if err != 0 {
 		return getError(err)
 	}
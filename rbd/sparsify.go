@@ -1,6 +1,3 @@
-//go:build !nautilus
-// +build !nautilus
-
 package rbd
 
 /*
@@ -11,10 +8,19 @@ package rbd
 
 extern int sparsifyCallback(uint64_t, uint64_t, uintptr_t);
 
-// inline wrapper to cast uintptr_t to void*
-static inline int wrap_rbd_sparsify_with_progress(
+// rbd_sparsify_with_progress_fn matches the rbd_sparsify_with_progress
+// function signature.
+typedef int(*rbd_sparsify_with_progress_fn)(rbd_image_t image,
+	size_t sparse_size, librbd_progress_fn_t cb, void *cbdata);
+
+// rbd_sparsify_with_progress_dlsym take *fn as rbd_sparsify_with_progress_fn
+// and calls the dynamically loaded rbd_sparsify_with_progress function passed
+// as 1st argument, casting uintptr_t to void*.
+static inline int rbd_sparsify_with_progress_dlsym(void *fn,
 		rbd_image_t image, size_t sparse_size, uintptr_t arg) {
-	return rbd_sparsify_with_progress(
+	// cast function pointer fn to rbd_sparsify_with_progress and call the
+	// function
+	return ((rbd_sparsify_with_progress_fn) fn)(
 		image, sparse_size, (librbd_progress_fn_t)sparsifyCallback, (void*)arg);
 };
 */
@@ -22,8 +28,13 @@ import "C"
 
 import (
 	"github.com/ceph/go-ceph/internal/callbacks"
+	"github.com/ceph/go-ceph/internal/dlsym"
 )
 
+// Ceph octopus introduced rbd_sparsify_with_progress(). The function is
+// resolved at runtime, so that older versions of librbd can be used.
+var rbdSparsifyWithProgress = dlsym.NewSymbol("rbd_sparsify_with_progress")
+
 // SparsifyCallback defines the function signature needed for the
 // SparsifyWithProgress callback.
 //
@@ -67,6 +78,11 @@ func (image *Image) SparsifyWithProgress(
 		return err
 	}
 
+	fn, err := rbdSparsifyWithProgress.Pointer()
+	if err != nil {
+		return err
+	}
+
 	ctx := sparsifyCallbackCtx{
 		callback: cb,
 		data:     data,
@@ -74,7 +90,9 @@ func (image *Image) SparsifyWithProgress(
 	cbIndex := sparsifyCallbacks.Add(ctx)
 	defer sparsifyCallbacks.Remove(cbIndex)
 
-	ret := C.wrap_rbd_sparsify_with_progress(image.image, C.size_t(sparseSize), C.uintptr_t(cbIndex))
+	t := image.startOp("rbd_sparsify_with_progress")
+	ret := C.rbd_sparsify_with_progress_dlsym(fn, image.image, C.size_t(sparseSize), C.uintptr_t(cbIndex))
+	t.Done(0, getErrorIfNegative(ret))
 
 	return getError(ret)
 }
@@ -0,0 +1,58 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"github.com/ceph/go-ceph/internal/dlsym"
+)
+
+// NotImplementedError is returned by functions that require a function that
+// is not provided by the version of librbd loaded at runtime. It matches
+// ErrNotImplemented when compared with errors.Is.
+type NotImplementedError = dlsym.NotImplementedError
+
+// APIFeature identifies a part of the go-ceph rbd API that depends on
+// functions that are not provided by all versions of librbd.
+type APIFeature string
+
+const (
+	// APICloneImageByID is required by CloneImageByID.
+	APICloneImageByID = APIFeature("CloneImageByID")
+	// APIDiffIterateByID is required by Image.DiffIterateByID.
+	APIDiffIterateByID = APIFeature("DiffIterateByID")
+	// APIGroupSnapGetInfo is required by GroupSnapGetInfo.
+	APIGroupSnapGetInfo = APIFeature("GroupSnapGetInfo")
+	// APISnapID is required by Image.GetSnapID and Image.GetSnapByID.
+	APISnapID = APIFeature("SnapID")
+	// APISnapExists is required by Snapshot.Exists.
+	APISnapExists = APIFeature("SnapExists")
+	// APISparsifyWithProgress is required by Image.SparsifyWithProgress.
+	APISparsifyWithProgress = APIFeature("SparsifyWithProgress")
+	// APIMigrationPrepareImport is required by MigrationPrepareImport and
+	// MigrationPrepareImportSource.
+	APIMigrationPrepareImport = APIFeature("MigrationPrepareImport")
+	// APIMigrationSourceSpec is required by Image.GetMigrationSourceSpec and
+	// Image.GetMigrationSource.
+	APIMigrationSourceSpec = APIFeature("MigrationSourceSpec")
+)
+
+// apiFeatureSymbols maps the features to the symbols they require. Features
+// of functions that are not built for the selected ceph version are added by
+// the files implementing them.
+var apiFeatureSymbols = map[APIFeature][]*dlsym.Symbol{
+	APICloneImageByID:       {rbdClone4},
+	APIDiffIterateByID:      {rbdDiffIterate3},
+	APIGroupSnapGetInfo:     {rbdGroupSnapGetInfo, rbdGroupSnapGetInfoCleanup},
+	APISnapID:               {rbdSnapGetID, rbdSnapGetName},
+	APISnapExists:           {rbdSnapExists},
+	APISparsifyWithProgress: {rbdSparsifyWithProgress},
+}
+
+// Supports returns true if the librbd library loaded at runtime provides
+// everything the given APIFeature requires. Functions of unsupported
+// features return a NotImplementedError. Supports returns false for unknown
+// features.
+func Supports(f APIFeature) bool {
+	symbols, ok := apiFeatureSymbols[f]
+	return ok && dlsym.Available(symbols...)
+}
@@ -0,0 +1,12 @@
+//go:build !nautilus && ceph_preview
+
+package rbd
+
+import (
+	"github.com/ceph/go-ceph/internal/dlsym"
+)
+
+func init() {
+	apiFeatureSymbols[APIMigrationPrepareImport] = []*dlsym.Symbol{rbdMigrationPrepareImport}
+	apiFeatureSymbols[APIMigrationSourceSpec] = []*dlsym.Symbol{rbdGetMigrationSourceSpec}
+}
@@ -0,0 +1,34 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ceph/go-ceph/internal/dlsym"
+)
+
+func TestSupports(t *testing.T) {
+	assert.False(t, Supports(APIFeature("NoSuchFeature")))
+
+	_, err := dlsym.LookupSymbol("rbd_clone4")
+	assert.Equal(t, err == nil, Supports(APICloneImageByID))
+	_, err = dlsym.LookupSymbol("rbd_snap_get_id")
+	assert.Equal(t, err == nil, Supports(APISnapID))
+	_, err = dlsym.LookupSymbol("rbd_sparsify_with_progress")
+	assert.Equal(t, err == nil, Supports(APISparsifyWithProgress))
+	_, err = dlsym.LookupSymbol("rbd_snap_exists")
+	assert.Equal(t, err == nil, Supports(APISnapExists))
+	_, err = dlsym.LookupSymbol("rbd_get_migration_source_spec")
+	assert.Equal(t, err == nil, Supports(APIMigrationSourceSpec))
+
+	if !Supports(APIGroupSnapGetInfo) {
+		_, err := GroupSnapGetInfo(nil, "group", "snap")
+		var nie NotImplementedError
+		assert.True(t, errors.As(err, &nie))
+		assert.ErrorIs(t, err, ErrNotImplemented)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <errno.h>
+#include <stdlib.h>
+#include <rados/librados.h>
+#include <rbd/librbd.h>
+
+extern int trashPurgeCallback(uint64_t, uint64_t, uintptr_t);
+
+// inline wrapper to cast uintptr_t to void*
+static inline int wrap_rbd_trash_purge_with_progress(rados_ioctx_t io,
+		time_t expire_ts, float threshold, uintptr_t arg) {
+	return rbd_trash_purge_with_progress(io, expire_ts, threshold,
+		(librbd_progress_fn_t)trashPurgeCallback, (void*)arg);
+};
+*/
+import "C"
+
+import (
+	"time"
+
+	"github.com/ceph/go-ceph/internal/callbacks"
+	"github.com/ceph/go-ceph/rados"
+)
+
+// NoTrashPurgeThreshold can be passed as the threshold to TrashPurge to
+// purge images regardless of the usage of the pool.
+const NoTrashPurgeThreshold = float32(-1)
+
+// TrashPurgeCallback defines the function signature needed for the
+// TrashPurgeWithProgress callback.
+//
+// The callback will be called with the number of images processed so far,
+// the total number of images to process and the data argument passed to
+// TrashPurgeWithProgress. The purge will be aborted if the callback returns a
+// non-zero value.
+type TrashPurgeCallback func(uint64, uint64, interface{}) int
+
+var trashPurgeCallbacks = callbacks.New()
+
+type trashPurgeCallbackCtx struct {
+	callback TrashPurgeCallback
+	data     interface{}
+}
+
+// TrashPurge permanently removes the images in the trash of the pool whose
+// deferment ended before expiredBefore. If threshold is not
+// NoTrashPurgeThreshold, images are only removed while the usage of the pool
+// is above the given ratio, between 0 and 1, starting with the oldest
+// images.
+//
+// Implements:
+//
+//	int rbd_trash_purge(rados_ioctx_t io, time_t expire_ts, float threshold);
+func TrashPurge(ioctx *rados.IOContext, expiredBefore time.Time, threshold float32) error {
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+
+	t := startOp(ioctx, "rbd_trash_purge", "")
+	ret := C.rbd_trash_purge(cephIoctx(ioctx),
+		C.time_t(expiredBefore.Unix()), C.float(threshold))
+	return t.DoneErr(getError(ret))
+}
+
+// TrashPurgeWithProgress works like TrashPurge, calling the callback to
+// report the progress of the purge.
+//
+// Implements:
+//
+//	int rbd_trash_purge_with_progress(rados_ioctx_t io, time_t expire_ts,
+//	                                  float threshold,
+//	                                  librbd_progress_fn_t cb, void* cbdata);
+func TrashPurgeWithProgress(ioctx *rados.IOContext, expiredBefore time.Time,
+	threshold float32, cb TrashPurgeCallback, data interface{}) error {
+
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	// the provided callback must be a real function
+	if cb == nil {
+		return getError(-C.EINVAL)
+	}
+
+	cbIndex := trashPurgeCallbacks.Add(trashPurgeCallbackCtx{
+		callback: cb,
+		data:     data,
+	})
+	defer trashPurgeCallbacks.Remove(cbIndex)
+
+	t := startOp(ioctx, "rbd_trash_purge_with_progress", "")
+	ret := C.wrap_rbd_trash_purge_with_progress(cephIoctx(ioctx),
+		C.time_t(expiredBefore.Unix()), C.float(threshold), C.uintptr_t(cbIndex))
+	return t.DoneErr(getError(ret))
+}
+
+//export trashPurgeCallback
+func trashPurgeCallback(
+	progress, total C.uint64_t, index uintptr) C.int {
+
+	v := trashPurgeCallbacks.Lookup(index)
+	ctx := v.(trashPurgeCallbackCtx)
+	return C.int(ctx.callback(uint64(progress), uint64(total), ctx.data))
+}
@@ -0,0 +1,89 @@
+//go:build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashPurge(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	trash := func(t *testing.T, delay time.Duration) {
+		t.Helper()
+		name := GetUUID()
+		err := quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+		err = GetImage(ioctx, name).Trash(delay)
+		require.NoError(t, err)
+	}
+	trashCount := func(t *testing.T) int {
+		t.Helper()
+		entries, err := GetTrashList(ioctx)
+		require.NoError(t, err)
+		return len(entries)
+	}
+
+	// this image is kept by all purges below
+	trash(t, time.Hour)
+	defer func() {
+		entries, err := GetTrashList(ioctx)
+		assert.NoError(t, err)
+		for _, e := range entries {
+			assert.NoError(t, TrashRemove(ioctx, e.Id, true))
+		}
+	}()
+
+	t.Run("purge", func(t *testing.T) {
+		trash(t, 0)
+		trash(t, 0)
+		require.Equal(t, 3, trashCount(t))
+		err := TrashPurge(ioctx, time.Now().Add(time.Minute), NoTrashPurgeThreshold)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, trashCount(t))
+	})
+
+	t.Run("withProgress", func(t *testing.T) {
+		trash(t, 0)
+		calls := 0
+		err := TrashPurgeWithProgress(ioctx, time.Now().Add(time.Minute),
+			NoTrashPurgeThreshold,
+			func(progress, total uint64, data interface{}) int {
+				calls++
+				assert.Equal(t, "purge", data)
+				assert.LessOrEqual(t, progress, total)
+				return 0
+			}, "purge")
+		assert.NoError(t, err)
+		assert.Greater(t, calls, 0)
+		assert.Equal(t, 1, trashCount(t))
+	})
+
+	t.Run("notExpired", func(t *testing.T) {
+		trash(t, 0)
+		err := TrashPurge(ioctx, time.Now().Add(-time.Minute), NoTrashPurgeThreshold)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, trashCount(t))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := TrashPurge(nil, time.Now(), NoTrashPurgeThreshold)
+		assert.ErrorIs(t, err, ErrNoIOContext)
+		err = TrashPurgeWithProgress(ioctx, time.Now(), NoTrashPurgeThreshold, nil, nil)
+		assert.Error(t, err)
+	})
+}
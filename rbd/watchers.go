@@ -51,8 +51,9 @@ func (image *Image) ListWatchers() ([]ImageWatcher, error) {
 	retry.WithSizes(16, 4096, func(size int) retry.Hint {
 		count = C.size_t(size)
 		watchers = make([]C.rbd_image_watcher_t, count)
+		t := image.startOp("rbd_watchers_list")
 		ret := C.rbd_watchers_list(image.image, &watchers[0], &count)
-		err = getErrorIfNegative(ret)
+		err = t.DoneErr(getErrorIfNegative(ret))
 		return retry.Size(int(count)).If(err == errRange)
 	})
 	if err != nil {
@@ -110,10 +111,12 @@ func (image *Image) UpdateWatch(cb WatchCallback, data interface{}) (*Watch, err
 		cbIndex: watchCallbacks.Add(wcc),
 	}
 
+	t := image.startOp("rbd_update_watch")
 	ret := C.wrap_rbd_update_watch(
 		image.image,
 		&w.handle,
 		C.uintptr_t(w.cbIndex))
+	t.Done(0, getErrorIfNegative(ret))
 	if ret != 0 {
 		return nil, getError(ret)
 	}
@@ -132,7 +135,9 @@ func (w *Watch) Unwatch() error {
 	if err := w.image.validate(imageIsOpen); err != nil {
 		return err
 	}
+	t := w.image.startOp("rbd_update_unwatch")
 	ret := C.rbd_update_unwatch(w.image.image, w.handle)
+	t.Done(0, getErrorIfNegative(ret))
 	watchCallbacks.Remove(w.cbIndex)
 	return getError(ret)
 }